@@ -0,0 +1,92 @@
+// Command import-users creates users in bulk from the CSV or NDJSON export of
+// another identity system, keeping their password hashes:
+//
+//	import-users [-tenant slug] [-format csv|ndjson] [-dry-run] [file]
+//
+// Without a file the users are read from standard input. The format defaults
+// to the file extension.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/sanchey92/sso/internal/app"
+	"github.com/sanchey92/sso/internal/config"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func main() {
+	tenant := flag.String("tenant", model.DefaultTenantSlug, "slug of the tenant to import into")
+	format := flag.String("format", "", "file format: csv or ndjson (default: from the file extension)")
+	dryRun := flag.Bool("dry-run", false, "check the file without creating users")
+	flag.Parse()
+
+	if err := godotenv.Load(".env"); err != nil {
+		log.Fatalf("failed to load .env file: %v", err)
+	}
+	cfg := config.MustLoad(os.Getenv("CONFIG_PATH"))
+
+	if err := run(cfg, *tenant, *format, flag.Arg(0), *dryRun); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cfg *config.Config, tenant, format, path string, dryRun bool) error {
+	var in io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path) //nolint:gosec // the path is given by the operator
+		if err != nil {
+			return fmt.Errorf("open file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+		if format == "" {
+			format = formatOf(path)
+		}
+	}
+	if format == "" {
+		return errors.New("-format is required when reading from standard input")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := app.ImportUsers(ctx, cfg, tenant, model.ImportFormat(format), in, dryRun)
+	if err != nil {
+		return fmt.Errorf("import users: %w", err)
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "line %d: %s: %s\n", e.Line, e.Email, e.Error)
+	}
+	if result.Failed > len(result.Errors) {
+		fmt.Fprintf(os.Stderr, "... and %d more rejected lines\n", result.Failed-len(result.Errors))
+	}
+	verb := "created"
+	if result.DryRun {
+		verb = "would create"
+	}
+	fmt.Fprintf(os.Stdout, "%s %d users, rejected %d lines\n", verb, result.Created, result.Failed)
+	return nil
+}
+
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return string(model.ImportFormatCSV)
+	case ".ndjson", ".jsonl":
+		return string(model.ImportFormatNDJSON)
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+// Package breach screens passwords against a local copy of a breached-password
+// corpus, so that no password or hash of one leaves the service.
+package breach
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" //nolint:gosec // the corpus is keyed by SHA-1
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// prefixLen is the length of the hash prefix that names a range file.
+const prefixLen = 5
+
+type Config struct {
+	// Dir holds the corpus in the Have I Been Pwned range format: one file
+	// per 5-character prefix of the uppercase hex SHA-1 of a password, named
+	// after the prefix with an optional .txt extension, with one
+	// "SUFFIX:COUNT" line per breached password. An empty Dir disables the
+	// check.
+	Dir string
+	// MinCount is how many breaches a password must appear in to count.
+	MinCount int
+}
+
+func (c *Config) Validate() error {
+	if c.Dir == "" {
+		return nil
+	}
+	if c.MinCount < 1 {
+		return errors.New("min count must be at least 1")
+	}
+	info, err := os.Stat(c.Dir)
+	if err != nil {
+		return fmt.Errorf("corpus directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("corpus directory: %s is not a directory", c.Dir)
+	}
+	return nil
+}
+
+// Checker looks passwords up in the corpus. Only the range file of the
+// password's hash prefix is read, so a lookup costs one small file read.
+type Checker struct {
+	cfg *Config
+}
+
+func New(cfg *Config) *Checker {
+	return &Checker{cfg: cfg}
+}
+
+// Breached reports whether password appears in the corpus at least MinCount
+// times.
+func (c *Checker) Breached(ctx context.Context, password string) (bool, error) {
+	if c.cfg.Dir == "" {
+		return false, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("check breach corpus: %w", err)
+	}
+
+	sum := sha1.Sum([]byte(password)) //nolint:gosec // the corpus is keyed by SHA-1
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+
+	f, err := c.openRange(prefix)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineSuffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return false, fmt.Errorf("range %s: invalid count %q", prefix, count)
+		}
+		return n >= c.cfg.MinCount, nil
+	}
+	if err = scanner.Err(); err != nil {
+		return false, fmt.Errorf("read range %s: %w", prefix, err)
+	}
+	return false, nil
+}
+
+// openRange opens the range file of prefix. A corpus is complete, so a missing
+// file is an error rather than a clean result.
+func (c *Checker) openRange(prefix string) (*os.File, error) {
+	for _, name := range []string{prefix + ".txt", prefix} {
+		f, err := os.OpenInRoot(c.cfg.Dir, name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("open range %s: %w", prefix, err)
+		}
+	}
+	return nil, fmt.Errorf("open range %s: %w", prefix, fs.ErrNotExist)
+}
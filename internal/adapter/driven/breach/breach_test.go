@@ -0,0 +1,105 @@
+package breach
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8, of
+// "hunter2" F3BBBD66A63D4BF1747940578EC3D0103530E21D and of "Password"
+// 8BE3C943B1609FFFBFC51AAD666D0A04ADF83C9D.
+func testCorpus(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(
+		"003D68EB55068C33ACE09247EE4C639306B:3\r\n"+
+			"1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"+
+			"1E4C9B93F3F0682250B6CF8331B7EE68FD9:0\r\n",
+	), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "F3BBB"), []byte(
+		"d66a63d4bf1747940578ec3d0103530e21d:2\n",
+	), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "8BE3C.txt"), []byte(
+		"0018A45C4D1DEF81644B54AB7F969B88D65:1\n",
+	), 0o600))
+	return dir
+}
+
+func TestChecker_Breached(t *testing.T) {
+	dir := testCorpus(t)
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		password string
+		want     bool
+		wantErr  string
+	}{
+		{name: "found", cfg: &Config{Dir: dir, MinCount: 1}, password: "password", want: true},
+		{name: "lowercase range without extension", cfg: &Config{Dir: dir, MinCount: 1}, password: "hunter2", want: true},
+		{name: "below min count", cfg: &Config{Dir: dir, MinCount: 3}, password: "hunter2"},
+		{name: "not in range", cfg: &Config{Dir: dir, MinCount: 1}, password: "Password"},
+		{name: "disabled", cfg: &Config{}, password: "password"},
+		{
+			name:     "missing range",
+			cfg:      &Config{Dir: dir, MinCount: 1},
+			password: "correct horse battery staple",
+			wantErr:  "open range ABF7A: file does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.cfg).Breached(t.Context(), tt.password)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChecker_Breached_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := New(&Config{Dir: testCorpus(t), MinCount: 1}).Breached(ctx, "password")
+
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	dir := testCorpus(t)
+
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{name: "disabled", cfg: &Config{}},
+		{name: "valid", cfg: &Config{Dir: dir, MinCount: 1}},
+		{name: "zero min count", cfg: &Config{Dir: dir}, wantErr: true},
+		{name: "missing directory", cfg: &Config{Dir: filepath.Join(dir, "missing"), MinCount: 1}, wantErr: true},
+		{name: "not a directory", cfg: &Config{Dir: filepath.Join(dir, "5BAA6.txt"), MinCount: 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package email
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -33,3 +35,71 @@ func (s *LogSender) SendPasswordResetEmail(_ context.Context, toEmail, token str
 	)
 	return nil
 }
+
+func (s *LogSender) SendAccountExistsEmail(_ context.Context, toEmail string) error {
+	s.log.Info("account exists email",
+		zap.String("to", toEmail),
+		zap.String("reset_url", s.baseURL+"/api/v1/auth/password/reset-request"),
+	)
+	return nil
+}
+
+func (s *LogSender) SendPasswordChangedEmail(_ context.Context, toEmail string) error {
+	s.log.Info("password changed email",
+		zap.String("to", toEmail),
+		zap.String("reset_url", s.baseURL+"/api/v1/auth/password/reset-request"),
+	)
+	return nil
+}
+
+func (s *LogSender) SendEmailChangeVerificationEmail(_ context.Context, toEmail, token string) error {
+	s.log.Info("email change verification email",
+		zap.String("to", toEmail),
+		zap.String("confirm_url", s.baseURL+"/api/v1/auth/email/change/confirm?token="+token),
+	)
+	return nil
+}
+
+func (s *LogSender) SendEmailChangeNoticeEmail(_ context.Context, toEmail, newEmail, revertToken string) error {
+	s.log.Info("email change notice email",
+		zap.String("to", toEmail),
+		zap.String("new_email", newEmail),
+		zap.String("revert_url", s.baseURL+"/api/v1/auth/email/change/revert?token="+revertToken),
+	)
+	return nil
+}
+
+func (s *LogSender) SendDataExportEmail(_ context.Context, toEmail, token string, expiresAt time.Time) error {
+	s.log.Info("data export email",
+		zap.String("to", toEmail),
+		zap.String("download_url", s.baseURL+"/api/v1/exports/download?token="+token),
+		zap.Time("expires_at", expiresAt),
+	)
+	return nil
+}
+
+func (s *LogSender) SendMagicLinkEmail(_ context.Context, toEmail, linkBase, token string) error {
+	s.log.Info("magic link email",
+		zap.String("to", toEmail),
+		zap.String("magic_link_url", s.resolve(linkBase)+"/api/v1/auth/magic-link/consume?token="+token),
+	)
+	return nil
+}
+
+// resolve returns the URL linkBase stands for: a path is taken below the
+// base URL, an absolute URL as it is, and "" is the base URL.
+func (s *LogSender) resolve(linkBase string) string {
+	if linkBase == "" || strings.HasPrefix(linkBase, "/") {
+		return s.baseURL + linkBase
+	}
+	return linkBase
+}
+
+func (s *LogSender) SendLockoutAlertEmail(_ context.Context, toEmail string, lockedUntil time.Time) error {
+	s.log.Info("account lockout alert email",
+		zap.String("to", toEmail),
+		zap.Time("locked_until", lockedUntil),
+		zap.String("reset_url", s.baseURL+"/api/v1/auth/password/reset-request"),
+	)
+	return nil
+}
@@ -0,0 +1,33 @@
+package hasher
+
+import "time"
+
+// calibrationRuns is how many single-iteration hashes Calibrate times; the
+// fastest is used, as the others are slowed down by the rest of the process.
+const calibrationRuns = 3
+
+// Calibrate returns a copy of cfg with as many iterations as fit in target on
+// this machine, keeping the memory and parallelism of cfg. It never returns
+// fewer iterations than cfg has, so calibration cannot weaken the configured
+// parameters.
+func Calibrate(cfg *Config, target time.Duration) *Config {
+	probe := *cfg
+	probe.Iterations = 1
+	h := New(&probe)
+
+	var perIteration time.Duration
+	for i := range calibrationRuns {
+		start := time.Now()
+		_, _ = h.Hash("calibration")
+		if d := time.Since(start); i == 0 || d < perIteration {
+			perIteration = d
+		}
+	}
+
+	calibrated := *cfg
+	if perIteration > 0 {
+		n := uint32(min(target/perIteration, maxArgon2Iterations)) //nolint:gosec // bounded above
+		calibrated.Iterations = max(calibrated.Iterations, n)
+	}
+	return &calibrated
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
@@ -11,6 +12,17 @@ import (
 	"golang.org/x/crypto/argon2"
 )
 
+const argon2idPrefix = "$argon2id$"
+
+// Limits on argon2id parameters. They hold for the configured parameters and
+// for every hash verified, so that a crafted or imported hash cannot exhaust
+// memory or make a login take minutes.
+const (
+	maxArgon2Memory      = 1 << 20 // KiB, 1 GiB
+	maxArgon2Iterations  = 1 << 10
+	maxArgon2Parallelism = 16
+)
+
 type Config struct {
 	Memory      uint32 // kb
 	Iterations  uint32
@@ -33,6 +45,37 @@ func DefaultConfig() *Config {
 	}
 }
 
+// Validate checks that cfg produces hashes argon2 and Verify accept and that
+// are not trivially weak.
+func (c *Config) Validate() error {
+	if err := c.checkLimits(); err != nil {
+		return err
+	}
+	switch {
+	case c.Memory < 8*uint32(c.Parallelism):
+		return errors.New("memory must be at least 8 KiB per lane")
+	case c.SaltLen < 16:
+		return errors.New("salt length must be at least 16 bytes")
+	}
+	return nil
+}
+
+// checkLimits checks the parameters that bound the cost of a hash and the
+// key length, both for the configuration and for decoded hashes.
+func (c *Config) checkLimits() error {
+	switch {
+	case c.Iterations < 1 || c.Iterations > maxArgon2Iterations:
+		return fmt.Errorf("iterations must be between 1 and %d", maxArgon2Iterations)
+	case c.Parallelism < 1 || c.Parallelism > maxArgon2Parallelism:
+		return fmt.Errorf("parallelism must be between 1 and %d", maxArgon2Parallelism)
+	case c.Memory > maxArgon2Memory:
+		return fmt.Errorf("memory must not exceed %d KiB", maxArgon2Memory)
+	case c.KeyLen < minKeyLen || c.KeyLen > maxKeyLen:
+		return fmt.Errorf("key length must be between %d and %d bytes", minKeyLen, maxKeyLen)
+	}
+	return nil
+}
+
 func New(cfg *Config) *Hasher {
 	return &Hasher{cfg: cfg}
 }
@@ -68,7 +111,49 @@ func (h *Hasher) Hash(password string) (string, error) {
 	return result, nil
 }
 
+// Verify checks password against an argon2id hash or a hash imported from
+// another system in one of the formats parseLegacy accepts.
 func (h *Hasher) Verify(password, encodedHash string) (bool, error) {
+	if !strings.HasPrefix(encodedHash, argon2idPrefix) {
+		legacy, err := parseLegacy(encodedHash)
+		if err != nil {
+			return false, fmt.Errorf("decode hash: %w", err)
+		}
+		return legacy.verify(password)
+	}
+	return verifyArgon2id(password, encodedHash)
+}
+
+// Supports reports whether Verify understands the format of encodedHash.
+func (h *Hasher) Supports(encodedHash string) bool {
+	if strings.HasPrefix(encodedHash, argon2idPrefix) {
+		_, _, _, err := decodeHash(encodedHash)
+		return err == nil
+	}
+	_, err := parseLegacy(encodedHash)
+	return err == nil
+}
+
+// NeedsRehash reports whether encodedHash should be replaced with a fresh hash
+// once the password is known, i.e. after a successful login: hashes in another
+// format, and argon2id hashes whose memory, iterations, key or salt length are
+// below the current parameters. A different parallelism alone does not
+// weaken a hash and is kept.
+func (h *Hasher) NeedsRehash(encodedHash string) bool {
+	if !strings.HasPrefix(encodedHash, argon2idPrefix) {
+		return true
+	}
+	cfg, _, _, err := decodeHash(encodedHash)
+	if err != nil {
+		return true
+	}
+	return cfg.Memory < h.cfg.Memory ||
+		cfg.Iterations < h.cfg.Iterations ||
+		cfg.KeyLen < h.cfg.KeyLen ||
+		cfg.SaltLen < h.cfg.SaltLen
+}
+
+func verifyArgon2id(password, encodedHash string) (bool, error) {
 	cfg, salt, hash, err := decodeHash(encodedHash)
 	if err != nil {
 		return false, fmt.Errorf("decode hash: %w", err)
@@ -127,5 +212,8 @@ func decodeHash(encodedHash string) (*Config, []byte, []byte, error) {
 	}
 	cfg.KeyLen = uint32(len(hash)) //nolint:gosec // length checked above
 
+	if err = cfg.checkLimits(); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid hash params: %w", err)
+	}
 	return cfg, salt, hash, nil
 }
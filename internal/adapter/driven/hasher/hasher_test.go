@@ -96,3 +96,65 @@ func TestHasher_Verify_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestHasher_NeedsRehash(t *testing.T) {
+	hash, err := New(testParams()).Hash("secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		policy func(cfg *Config)
+		want   bool
+	}{
+		{name: "same parameters", policy: func(_ *Config) {}, want: false},
+		{name: "more memory", policy: func(cfg *Config) { cfg.Memory *= 2 }, want: true},
+		{name: "more iterations", policy: func(cfg *Config) { cfg.Iterations++ }, want: true},
+		{name: "longer key", policy: func(cfg *Config) { cfg.KeyLen = 64 }, want: true},
+		{name: "longer salt", policy: func(cfg *Config) { cfg.SaltLen = 32 }, want: true},
+		{name: "less memory", policy: func(cfg *Config) { cfg.Memory /= 2 }, want: false},
+		{name: "other parallelism", policy: func(cfg *Config) { cfg.Parallelism = 4 }, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testParams()
+			tt.policy(cfg)
+
+			assert.Equal(t, tt.want, New(cfg).NeedsRehash(hash))
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{name: "default", modify: func(_ *Config) {}},
+		{name: "no iterations", modify: func(cfg *Config) { cfg.Iterations = 0 }, wantErr: "iterations must be between 1 and 1024"},
+		{name: "too many iterations", modify: func(cfg *Config) { cfg.Iterations = 2000 }, wantErr: "iterations must be between 1 and 1024"},
+		{name: "no lanes", modify: func(cfg *Config) { cfg.Parallelism = 0 }, wantErr: "parallelism must be between 1 and 16"},
+		{name: "too many lanes", modify: func(cfg *Config) { cfg.Parallelism = 64 }, wantErr: "parallelism must be between 1 and 16"},
+		{name: "too little memory", modify: func(cfg *Config) { cfg.Memory = 16 }, wantErr: "memory must be at least 8 KiB per lane"},
+		{name: "too much memory", modify: func(cfg *Config) { cfg.Memory = 4 << 20 }, wantErr: "memory must not exceed 1048576 KiB"},
+		{name: "short key", modify: func(cfg *Config) { cfg.KeyLen = 8 }, wantErr: "key length must be between 16 and 128 bytes"},
+		{name: "long key", modify: func(cfg *Config) { cfg.KeyLen = 256 }, wantErr: "key length must be between 16 and 128 bytes"},
+		{name: "short salt", modify: func(cfg *Config) { cfg.SaltLen = 8 }, wantErr: "salt length must be at least 16 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package hasher
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha1" //nolint:gosec // PBKDF2-SHA1 hashes are only verified, never created
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Limits on the cost parameters of imported hashes, so that a crafted hash
+// cannot make a login take minutes.
+const (
+	maxBcryptCost       = 16
+	maxPBKDF2Iterations = 10_000_000
+	maxScryptN          = 1 << 20
+	maxScryptR          = 32
+	maxScryptP          = 16
+	minKeyLen           = 16
+	maxKeyLen           = 128
+)
+
+// legacyHash is a password hash created by another system.
+type legacyHash interface {
+	verify(password string) (bool, error)
+}
+
+// parseLegacy parses a hash imported from another system:
+//
+//   - bcrypt: $2a$, $2b$ and $2y$ (Rails, Devise, PHP), Django's bcrypt$ and
+//     bcrypt_sha256$
+//   - PBKDF2: Django's pbkdf2_sha256$<iterations>$<salt>$<hash> and
+//     $pbkdf2-sha256$i=<iterations>$<salt>$<hash> with base64 salt and hash,
+//     also as -sha512 and plain $pbkdf2$ (SHA-1); Keycloak credentials are
+//     imported in this form
+//   - scrypt: Django's scrypt$<salt>$<n>$<r>$<p>$<hash>
+//   - argon2id: Django's argon2$argon2id$...
+func parseLegacy(encoded string) (legacyHash, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return parseBcrypt(encoded, false)
+	case strings.HasPrefix(encoded, "bcrypt$"):
+		return parseBcrypt(strings.TrimPrefix(encoded, "bcrypt$"), false)
+	case strings.HasPrefix(encoded, "bcrypt_sha256$"):
+		return parseBcrypt(strings.TrimPrefix(encoded, "bcrypt_sha256$"), true)
+	case strings.HasPrefix(encoded, "pbkdf2_sha256$"):
+		return parseDjangoPBKDF2(encoded)
+	case strings.HasPrefix(encoded, "$pbkdf2"):
+		return parsePBKDF2(encoded)
+	case strings.HasPrefix(encoded, "scrypt$"):
+		return parseDjangoScrypt(encoded)
+	case strings.HasPrefix(encoded, "argon2"+argon2idPrefix):
+		encoded = strings.TrimPrefix(encoded, "argon2")
+		if _, _, _, err := decodeHash(encoded); err != nil {
+			return nil, err
+		}
+		return djangoArgon2id(encoded), nil
+	}
+	return nil, errors.New("unsupported hash format")
+}
+
+type djangoArgon2id string
+
+func (h djangoArgon2id) verify(password string) (bool, error) {
+	return verifyArgon2id(password, string(h))
+}
+
+// bcryptHash is a bcrypt hash. Django's bcrypt_sha256 hashes the hex SHA-256
+// of the password, which lifts bcrypt's 72-byte limit.
+type bcryptHash struct {
+	hash    []byte
+	prehash bool
+}
+
+func parseBcrypt(encoded string, prehash bool) (*bcryptHash, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bcrypt hash: %w", err)
+	}
+	if cost > maxBcryptCost {
+		return nil, fmt.Errorf("bcrypt cost %d exceeds %d", cost, maxBcryptCost)
+	}
+	return &bcryptHash{hash: []byte(encoded), prehash: prehash}, nil
+}
+
+func (h *bcryptHash) verify(password string) (bool, error) {
+	secret := []byte(password)
+	if h.prehash {
+		sum := sha256.Sum256(secret)
+		secret = []byte(hex.EncodeToString(sum[:]))
+	}
+	err := bcrypt.CompareHashAndPassword(h.hash, secret)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, fmt.Errorf("compare bcrypt hash: %w", err)
+	}
+}
+
+type pbkdf2Hash struct {
+	hash       func() hash.Hash
+	iterations int
+	salt       []byte
+	key        []byte
+}
+
+func parseDjangoPBKDF2(encoded string) (*pbkdf2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 {
+		return nil, errors.New("invalid pbkdf2_sha256 hash")
+	}
+	key, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pbkdf2_sha256 hash: %w", err)
+	}
+	return newPBKDF2(sha256.New, parts[1], []byte(parts[2]), key)
+}
+
+func parsePBKDF2(encoded string) (*pbkdf2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return nil, errors.New("invalid pbkdf2 hash")
+	}
+
+	var h func() hash.Hash
+	switch parts[1] {
+	case "pbkdf2":
+		h = sha1.New
+	case "pbkdf2-sha256":
+		h = sha256.New
+	case "pbkdf2-sha512":
+		h = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", parts[1])
+	}
+
+	salt, err := decodeBase64(parts[3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pbkdf2 salt: %w", err)
+	}
+	key, err := decodeBase64(parts[4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pbkdf2 hash: %w", err)
+	}
+	return newPBKDF2(h, strings.TrimPrefix(parts[2], "i="), salt, key)
+}
+
+func newPBKDF2(h func() hash.Hash, iterations string, salt, key []byte) (*pbkdf2Hash, error) {
+	n, err := strconv.Atoi(iterations)
+	if err != nil || n < 1 || n > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("invalid pbkdf2 iterations: %s", iterations)
+	}
+	if len(key) < minKeyLen || len(key) > maxKeyLen {
+		return nil, fmt.Errorf("invalid pbkdf2 key length: %d", len(key))
+	}
+	return &pbkdf2Hash{hash: h, iterations: n, salt: salt, key: key}, nil
+}
+
+func (h *pbkdf2Hash) verify(password string) (bool, error) {
+	key, err := pbkdf2.Key(h.hash, password, h.salt, h.iterations, len(h.key))
+	if err != nil {
+		return false, fmt.Errorf("derive pbkdf2 key: %w", err)
+	}
+	return subtle.ConstantTimeCompare(h.key, key) == 1, nil
+}
+
+type scryptHash struct {
+	salt    []byte
+	n, r, p int
+	key     []byte
+}
+
+func parseDjangoScrypt(encoded string) (*scryptHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid scrypt hash")
+	}
+
+	var params [3]int
+	for i, v := range parts[2:5] {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid scrypt parameter: %s", v)
+		}
+		params[i] = n
+	}
+	n, r, p := params[0], params[1], params[2]
+	if n < 2 || n > maxScryptN || n&(n-1) != 0 || r > maxScryptR || p > maxScryptP {
+		return nil, fmt.Errorf("unsupported scrypt parameters: n=%d, r=%d, p=%d", n, r, p)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrypt hash: %w", err)
+	}
+	if len(key) < minKeyLen || len(key) > maxKeyLen {
+		return nil, fmt.Errorf("invalid scrypt key length: %d", len(key))
+	}
+	return &scryptHash{salt: []byte(parts[1]), n: n, r: r, p: p, key: key}, nil
+}
+
+func (h *scryptHash) verify(password string) (bool, error) {
+	key, err := scrypt.Key([]byte(password), h.salt, h.n, h.r, h.p, len(h.key))
+	if err != nil {
+		return false, fmt.Errorf("derive scrypt key: %w", err)
+	}
+	return subtle.ConstantTimeCompare(h.key, key) == 1, nil
+}
+
+// decodeBase64 decodes standard base64 with or without padding, and the
+// adapted alphabet passlib uses, which has "." in place of "+".
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ReplaceAll(s, ".", "+"), "=")
+	b, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,104 @@
+package hasher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasher_Verify_Legacy(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{
+			name: "bcrypt",
+			hash: "$2a$04$dWgtPPizGLgM/OwJfejfzuzC64BsEROLZajHqfgV8CVl9kH3fsrxC",
+		},
+		{
+			name: "django bcrypt_sha256",
+			hash: "bcrypt_sha256$$2a$04$RunpryhW1Kv2WcFsE49e3uj1ckrFJ0ocjVdjzn608Z424t6yJx1IS",
+		},
+		{
+			name: "django pbkdf2_sha256",
+			hash: "pbkdf2_sha256$1000$seasalt$+hs9qSCcGyNSGDNojIEbomuX9WI/mzTF5yfwAXqyKOo=",
+		},
+		{
+			name: "pbkdf2-sha512",
+			hash: "$pbkdf2-sha512$i=1000$MDEyMzQ1Njc4OWFiY2RlZg$" +
+				"vgFvU7zWIDgDAUi7d8ayt+cfRiPWVVWfv8iQRsGZaZviWzsSNgWYXEE5PmvI/VELMsOmEbqLz0PKuePNjOk41A",
+		},
+		{
+			name: "django scrypt",
+			hash: "scrypt$seasalt$1024$8$1$" +
+				"mBdsVxZCf6G+Bs1aXMjSdR6I0Vt8atpmM4Gvd0hAgnod0ZyMUPDbZ9Z0534FznWrdtrH3Ifd/FCn7Akjoe9Wrg==",
+		},
+	}
+
+	h := New(testParams())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, h.Supports(tt.hash))
+			assert.True(t, h.NeedsRehash(tt.hash))
+
+			match, err := h.Verify("secret", tt.hash)
+			require.NoError(t, err)
+			assert.True(t, match)
+
+			match, err = h.Verify("wrong", tt.hash)
+			require.NoError(t, err)
+			assert.False(t, match)
+		})
+	}
+}
+
+func TestHasher_Verify_DjangoArgon2(t *testing.T) {
+	h := New(testParams())
+
+	hash, err := h.Hash("secret")
+	require.NoError(t, err)
+
+	match, err := h.Verify("secret", "argon2"+hash)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, h.NeedsRehash("argon2"+hash))
+	assert.False(t, h.NeedsRehash(hash))
+}
+
+// argon2Salt and argon2Key are a well-formed 16-byte salt and 32-byte key for
+// argon2id hashes whose parameters are out of bounds.
+const (
+	argon2Salt = "AAAAAAAAAAAAAAAAAAAAAA"
+	argon2Key  = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+)
+
+func TestHasher_Supports_Rejected(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "md5", hash: "5ebe2294ecd0e0f08eab7690d2a6ee69"},
+		{name: "bcrypt cost too high", hash: "$2a$20$dWgtPPizGLgM/OwJfejfzuzC64BsEROLZajHqfgV8CVl9kH3fsrxC"},
+		{name: "pbkdf2 too many iterations", hash: "pbkdf2_sha256$99999999$seasalt$+hs9qSCcGyNSGDNojIEbomuX9WI/mzTF5yfwAXqyKOo="},
+		{name: "pbkdf2 unknown digest", hash: "$pbkdf2-md5$i=1000$c2FsdA$c2FsdA"},
+		{name: "scrypt n not a power of two", hash: "scrypt$seasalt$1000$8$1$mBdsVxZCf6G+Bs1aXMjSdR6I0Vt8atpmM4Gvd0hAgno="},
+		{name: "malformed argon2id", hash: "$argon2id$v=19$garbage"},
+		{name: "argon2id memory too high", hash: "$argon2id$v=19$m=4194304,t=1,p=1$" + argon2Salt + "$" + argon2Key},
+		{name: "argon2id too many iterations", hash: "$argon2id$v=19$m=65536,t=100000,p=1$" + argon2Salt + "$" + argon2Key},
+		{name: "argon2id too many lanes", hash: "$argon2id$v=19$m=65536,t=1,p=255$" + argon2Salt + "$" + argon2Key},
+		{name: "argon2id no iterations", hash: "$argon2id$v=19$m=65536,t=0,p=1$" + argon2Salt + "$" + argon2Key},
+		{name: "argon2id empty key", hash: "$argon2id$v=19$m=65536,t=1,p=1$" + argon2Salt + "$"},
+		{name: "django argon2id memory too high", hash: "argon2$argon2id$v=19$m=4194304,t=1,p=1$" + argon2Salt + "$" + argon2Key},
+	}
+
+	h := New(testParams())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.False(t, h.Supports(tt.hash))
+
+			_, err := h.Verify("secret", tt.hash)
+			assert.Error(t, err)
+		})
+	}
+}
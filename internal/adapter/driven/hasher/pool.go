@@ -0,0 +1,170 @@
+package hasher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the hash latency
+// histogram.
+var durationBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// PoolConfig bounds the memory hashing may take: each running hash holds
+// Config.Memory.
+type PoolConfig struct {
+	// Workers is how many hashes run at once.
+	Workers int
+	// MaxQueue is how many hashes may wait for a worker; more are rejected
+	// at once.
+	MaxQueue int
+	// MaxWait is how long a hash waits for a worker before it is rejected.
+	MaxWait time.Duration
+}
+
+// Pool runs the hashes of a Hasher on a bounded number of workers. Hashes that
+// find all workers busy queue up; when the queue is full or the wait exceeds
+// MaxWait they fail with domainerrors.ErrOverloaded, so that a burst of logins
+// is shed instead of exhausting memory.
+type Pool struct {
+	h     *Hasher
+	cfg   *PoolConfig
+	slots chan struct{}
+
+	queued   atomic.Int64
+	rejected atomic.Uint64
+
+	mu      sync.Mutex
+	counts  []uint64
+	count   uint64
+	sumSecs float64
+}
+
+func NewPool(h *Hasher, cfg *PoolConfig) *Pool {
+	return &Pool{
+		h:      h,
+		cfg:    cfg,
+		slots:  make(chan struct{}, cfg.Workers),
+		counts: make([]uint64, len(durationBuckets)),
+	}
+}
+
+// Hash hashes password with the current parameters on a worker.
+func (p *Pool) Hash(ctx context.Context, password string) (string, error) {
+	var hash string
+	err := p.run(ctx, func() error {
+		var err error
+		hash, err = p.h.Hash(password)
+		return err
+	})
+	return hash, err
+}
+
+// Verify checks password against encodedHash on a worker.
+func (p *Pool) Verify(ctx context.Context, password, encodedHash string) (bool, error) {
+	var match bool
+	err := p.run(ctx, func() error {
+		var err error
+		match, err = p.h.Verify(password, encodedHash)
+		return err
+	})
+	return match, err
+}
+
+// NeedsRehash reports whether encodedHash falls short of the current
+// parameters; see Hasher.NeedsRehash.
+func (p *Pool) NeedsRehash(encodedHash string) bool {
+	return p.h.NeedsRehash(encodedHash)
+}
+
+// Supports reports whether Verify understands the format of encodedHash.
+func (p *Pool) Supports(encodedHash string) bool {
+	return p.h.Supports(encodedHash)
+}
+
+// Stats returns the current load of the pool and the latency of the hashes
+// run so far.
+func (p *Pool) Stats() model.HashPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cumulative := make([]uint64, len(p.counts))
+	var total uint64
+	for i, c := range p.counts {
+		total += c
+		cumulative[i] = total
+	}
+	return model.HashPoolStats{
+		Workers:  p.cfg.Workers,
+		InFlight: len(p.slots),
+		Queued:   int(p.queued.Load()),
+		Rejected: p.rejected.Load(),
+		Duration: model.Histogram{
+			Bounds: durationBuckets,
+			Counts: cumulative,
+			Count:  p.count,
+			Sum:    p.sumSecs,
+		},
+	}
+}
+
+func (p *Pool) run(ctx context.Context, fn func() error) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() { <-p.slots }()
+
+	start := time.Now()
+	err := fn()
+	p.observe(time.Since(start))
+	return err
+}
+
+func (p *Pool) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if p.queued.Add(1) > int64(p.cfg.MaxQueue) {
+		p.queued.Add(-1)
+		p.rejected.Add(1)
+		return domainerrors.ErrOverloaded
+	}
+	defer p.queued.Add(-1)
+
+	timer := time.NewTimer(p.cfg.MaxWait)
+	defer timer.Stop()
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		p.rejected.Add(1)
+		return domainerrors.ErrOverloaded
+	case <-ctx.Done():
+		return fmt.Errorf("wait for hash worker: %w", ctx.Err())
+	}
+}
+
+func (p *Pool) observe(d time.Duration) {
+	secs := d.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, bound := range durationBuckets {
+		if secs <= bound {
+			p.counts[i]++
+			break
+		}
+	}
+	p.count++
+	p.sumSecs += secs
+}
@@ -0,0 +1,111 @@
+package hasher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+)
+
+func TestPool_HashVerify(t *testing.T) {
+	p := NewPool(New(testParams()), &PoolConfig{Workers: 1, MaxQueue: 1, MaxWait: time.Second})
+
+	hash, err := p.Hash(t.Context(), "password123")
+	require.NoError(t, err)
+	match, err := p.Verify(t.Context(), "password123", hash)
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	stats := p.Stats()
+	assert.Equal(t, 1, stats.Workers)
+	assert.Zero(t, stats.InFlight)
+	assert.Zero(t, stats.Queued)
+	assert.Equal(t, uint64(2), stats.Duration.Count)
+	assert.Equal(t, uint64(2), stats.Duration.Counts[len(stats.Duration.Counts)-1])
+	assert.Positive(t, stats.Duration.Sum)
+}
+
+func TestPool_Busy(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          *PoolConfig
+		ctx          func(t *testing.T) context.Context
+		wantErr      error
+		wantRejected uint64
+	}{
+		{
+			name:         "queue full",
+			cfg:          &PoolConfig{Workers: 1, MaxQueue: 0, MaxWait: time.Second},
+			ctx:          func(t *testing.T) context.Context { return t.Context() },
+			wantErr:      domainerrors.ErrOverloaded,
+			wantRejected: 1,
+		},
+		{
+			name:         "wait exceeded",
+			cfg:          &PoolConfig{Workers: 1, MaxQueue: 1, MaxWait: 10 * time.Millisecond},
+			ctx:          func(t *testing.T) context.Context { return t.Context() },
+			wantErr:      domainerrors.ErrOverloaded,
+			wantRejected: 1,
+		},
+		{
+			name: "context canceled",
+			cfg:  &PoolConfig{Workers: 1, MaxQueue: 1, MaxWait: time.Minute},
+			ctx: func(t *testing.T) context.Context {
+				ctx, cancel := context.WithCancel(t.Context())
+				cancel()
+				return ctx
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPool(New(testParams()), tt.cfg)
+			p.slots <- struct{}{}
+
+			_, err := p.Hash(tt.ctx(t), "password123")
+
+			require.ErrorIs(t, err, tt.wantErr)
+			stats := p.Stats()
+			assert.Equal(t, 1, stats.InFlight)
+			assert.Zero(t, stats.Queued)
+			assert.Equal(t, tt.wantRejected, stats.Rejected)
+			assert.Zero(t, stats.Duration.Count)
+		})
+	}
+}
+
+func TestPool_QueuedHashRunsWhenWorkerFrees(t *testing.T) {
+	p := NewPool(New(testParams()), &PoolConfig{Workers: 1, MaxQueue: 1, MaxWait: time.Minute})
+	p.slots <- struct{}{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Hash(t.Context(), "password123")
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return p.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	<-p.slots
+	require.NoError(t, <-done)
+	assert.Zero(t, p.Stats().Rejected)
+}
+
+func TestCalibrate(t *testing.T) {
+	cfg := testParams()
+
+	calibrated := Calibrate(cfg, time.Nanosecond)
+	assert.Equal(t, cfg.Iterations, calibrated.Iterations)
+	assert.Equal(t, cfg.Memory, calibrated.Memory)
+
+	cfg.Iterations = 5
+	assert.Equal(t, uint32(5), Calibrate(cfg, time.Nanosecond).Iterations)
+
+	calibrated = Calibrate(testParams(), time.Hour)
+	assert.Equal(t, uint32(maxArgon2Iterations), calibrated.Iterations)
+}
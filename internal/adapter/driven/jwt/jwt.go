@@ -4,66 +4,109 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 
 	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
 	"github.com/sanchey92/sso/pkg/crypto"
 )
 
-type Claims struct {
-	Subject  string
-	Issuer   string
-	Audience string
-}
-
 type Config struct {
 	Issuer          string
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
 }
 
+// Service signs access tokens with a separate key per tenant. Keys are
+// created on first use.
 type Service struct {
-	cfg        *Config
-	currentKey *KeyPair
-	allKeys    map[string]*KeyPair
+	cfg *Config
+
+	mu          sync.RWMutex
+	currentKeys map[string]*KeyPair // by tenant slug
+	allKeys     map[string]*KeyPair // by kid
+}
+
+// accessClaims are the claims of an access token. The authorization claims
+// are omitted when empty.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	Tenant        string   `json:"tenant"`
+	SessionID     string   `json:"sid,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+	GroupsOverage bool     `json:"groups_overage,omitempty"`
 }
 
 func NewService(cfg *Config) (*Service, error) {
-	kp, err := GenerateKeyPair()
-	if err != nil {
-		return nil, fmt.Errorf("generate key pair: %w", err)
+	s := &Service{
+		cfg:         cfg,
+		currentKeys: make(map[string]*KeyPair),
+		allKeys:     make(map[string]*KeyPair),
+	}
+	if _, err := s.keyFor(model.DefaultTenantSlug); err != nil {
+		return nil, err
 	}
-	return &Service{
-		cfg:        cfg,
-		currentKey: kp,
-		allKeys:    map[string]*KeyPair{kp.KID: kp},
-	}, nil
+	return s, nil
 }
 
-func (s *Service) GenerateToken(userID, audience string) (string, error) {
+// Issuer returns the issuer URL of tenant. The default tenant keeps the
+// configured issuer; other tenants are issued under /t/{tenant} below it.
+func (s *Service) Issuer(tenant string) string {
+	tenant = tenantOrDefault(tenant)
+	if tenant == model.DefaultTenantSlug {
+		return s.cfg.Issuer
+	}
+	return strings.TrimSuffix(s.cfg.Issuer, "/") + "/t/" + tenant
+}
+
+// GenerateToken signs an access token with the key of authz.Tenant. sessionID
+// becomes the sid claim.
+func (s *Service) GenerateToken(userID, sessionID, audience string, authz *model.Authorization) (string, error) {
 	now := time.Now()
+	tenant := tenantOrDefault(authz.Tenant)
+
+	key, err := s.keyFor(tenant)
+	if err != nil {
+		return "", err
+	}
 
-	claims := jwt.RegisteredClaims{
-		Issuer:    s.cfg.Issuer,
-		Subject:   userID,
-		Audience:  jwt.ClaimStrings{audience},
-		ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTokenTTL)),
-		IssuedAt:  jwt.NewNumericDate(now),
+	claims := accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.Issuer(tenant),
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Tenant:        tenant,
+		SessionID:     sessionID,
+		Roles:         authz.Roles,
+		Permissions:   authz.Permissions,
+		Groups:        authz.Groups,
+		GroupsOverage: authz.GroupsOverage,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
-	token.Header["kid"] = s.currentKey.KID
-	signed, err := token.SignedString(s.currentKey.PrivateKey)
+	token.Header["kid"] = key.KID
+	signed, err := token.SignedString(key.PrivateKey)
 	if err != nil {
 		return "", fmt.Errorf("sign token: %w", err)
 	}
 	return signed, nil
 }
 
-func (s *Service) ValidateToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &jwt.RegisteredClaims{}, func(t *jwt.Token) (any, error) {
+// ValidateToken verifies a token of any tenant. The token must name the
+// tenant and issuer its signing key belongs to, so one tenant's key cannot
+// vouch for another tenant's tokens.
+func (s *Service) ValidateToken(tokenStr string) (*model.AccessTokenClaims, error) {
+	var key *KeyPair
+	token, err := jwt.ParseWithClaims(tokenStr, &accessClaims{}, func(t *jwt.Token) (any, error) {
 		if t.Method != jwt.SigningMethodEdDSA {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
@@ -71,8 +114,10 @@ func (s *Service) ValidateToken(tokenStr string) (*Claims, error) {
 		if !ok {
 			return nil, fmt.Errorf("missing kid in token header")
 		}
-		key, exists := s.allKeys[kid]
-		if !exists {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		var exists bool
+		if key, exists = s.allKeys[kid]; !exists {
 			return nil, fmt.Errorf("unknown kid: %s", kid)
 		}
 		return key.PublicKey, nil
@@ -81,21 +126,37 @@ func (s *Service) ValidateToken(tokenStr string) (*Claims, error) {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, domainerrors.ErrTokenExpired
 		}
-		return nil, fmt.Errorf("parse token: %w", err)
+		return nil, fmt.Errorf("parse token: %w: %w", domainerrors.ErrInvalidToken, err)
 	}
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*accessClaims)
 	if !ok {
-		return nil, fmt.Errorf("invalid token claims")
+		return nil, fmt.Errorf("unexpected claims type: %w", domainerrors.ErrInvalidToken)
+	}
+	if claims.Tenant != key.Tenant || claims.Issuer != s.Issuer(key.Tenant) {
+		return nil, fmt.Errorf("token does not match the tenant of its key: %w", domainerrors.ErrInvalidToken)
 	}
 	var aud string
 	if len(claims.Audience) > 0 {
 		aud = claims.Audience[0]
 	}
-	return &Claims{
-		Subject:  claims.Subject,
-		Issuer:   claims.Issuer,
-		Audience: aud,
-	}, nil
+	result := &model.AccessTokenClaims{
+		Subject:       claims.Subject,
+		Issuer:        claims.Issuer,
+		Tenant:        claims.Tenant,
+		SessionID:     claims.SessionID,
+		Audience:      aud,
+		Roles:         claims.Roles,
+		Permissions:   claims.Permissions,
+		Groups:        claims.Groups,
+		GroupsOverage: claims.GroupsOverage,
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return result, nil
 }
 
 func (s *Service) GenerateRefreshToken() (string, string, error) {
@@ -107,10 +168,21 @@ func (s *Service) GenerateRefreshToken() (string, string, error) {
 	return raw, hash, nil
 }
 
-func (s *Service) GetJWKS() *JWKS {
-	keys := make([]JWK, 0, len(s.allKeys))
+// GetJWKS returns the public keys of tenant.
+func (s *Service) GetJWKS(tenant string) (*model.JWKS, error) {
+	tenant = tenantOrDefault(tenant)
+	if _, err := s.keyFor(tenant); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]model.JWK, 0, 1)
 	for _, kp := range s.allKeys {
-		keys = append(keys, JWK{
+		if kp.Tenant != tenant {
+			continue
+		}
+		keys = append(keys, model.JWK{
 			KTY: "OKP",
 			CRV: "Ed25519",
 			KID: kp.KID,
@@ -119,5 +191,36 @@ func (s *Service) GetJWKS() *JWKS {
 		})
 	}
 
-	return &JWKS{Keys: keys}
+	return &model.JWKS{Keys: keys}, nil
+}
+
+// keyFor returns the current signing key of tenant, creating it on first use.
+func (s *Service) keyFor(tenant string) (*KeyPair, error) {
+	s.mu.RLock()
+	kp, ok := s.currentKeys[tenant]
+	s.mu.RUnlock()
+	if ok {
+		return kp, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kp, ok = s.currentKeys[tenant]; ok {
+		return kp, nil
+	}
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("generate key pair: %w", err)
+	}
+	kp.Tenant = tenant
+	s.currentKeys[tenant] = kp
+	s.allKeys[kp.KID] = kp
+	return kp, nil
+}
+
+func tenantOrDefault(tenant string) string {
+	if tenant == "" {
+		return model.DefaultTenantSlug
+	}
+	return tenant
 }
@@ -5,10 +5,12 @@ import (
 	"testing"
 	"time"
 
+	jwtlib "github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
 )
 
 func testConfig() *Config {
@@ -23,7 +25,7 @@ func TestNewService(t *testing.T) {
 	svc, err := NewService(testConfig())
 
 	require.NoError(t, err)
-	assert.NotNil(t, svc.currentKey)
+	assert.NotNil(t, svc.currentKeys[model.DefaultTenantSlug])
 	assert.Len(t, svc.allKeys, 1)
 }
 
@@ -31,7 +33,7 @@ func TestService_GenerateToken(t *testing.T) {
 	svc, err := NewService(testConfig())
 	require.NoError(t, err)
 
-	token, err := svc.GenerateToken("user-123", "my-usecase")
+	token, err := svc.GenerateToken("user-123", "session-1", "my-usecase", &model.Authorization{})
 
 	require.NoError(t, err)
 	assert.Equal(t, 3, len(strings.Split(token, ".")))
@@ -41,7 +43,7 @@ func TestService_ValidateToken_OK(t *testing.T) {
 	svc, err := NewService(testConfig())
 	require.NoError(t, err)
 
-	token, err := svc.GenerateToken("user-123", "my-usecase")
+	token, err := svc.GenerateToken("user-123", "session-1", "my-usecase", &model.Authorization{})
 	require.NoError(t, err)
 
 	claims, err := svc.ValidateToken(token)
@@ -49,20 +51,60 @@ func TestService_ValidateToken_OK(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "user-123", claims.Subject)
 	assert.Equal(t, "test-issuer", claims.Issuer)
+	assert.Equal(t, model.DefaultTenantSlug, claims.Tenant)
+	assert.Equal(t, "session-1", claims.SessionID)
 	assert.Equal(t, "my-usecase", claims.Audience)
+	assert.WithinDuration(t, claims.IssuedAt.Add(testConfig().AccessTokenTTL), claims.ExpiresAt, time.Second)
+	assert.Empty(t, claims.Roles)
+}
+
+func TestService_ValidateToken_Authorization(t *testing.T) {
+	svc, err := NewService(testConfig())
+	require.NoError(t, err)
+
+	token, err := svc.GenerateToken("user-123", "session-1", "sso", &model.Authorization{
+		Roles:       []string{"admin"},
+		Permissions: []string{"users:read"},
+		Groups:      []string{"engineering"},
+	})
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(token)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	assert.True(t, claims.HasRole("admin"))
+	assert.Equal(t, []string{"users:read"}, claims.Permissions)
+	assert.True(t, claims.HasPermission("users:read"))
+	assert.Equal(t, []string{"engineering"}, claims.Groups)
+	assert.False(t, claims.GroupsOverage)
+}
+
+func TestService_ValidateToken_GroupsOverage(t *testing.T) {
+	svc, err := NewService(testConfig())
+	require.NoError(t, err)
+
+	token, err := svc.GenerateToken("user-123", "session-1", "sso", &model.Authorization{GroupsOverage: true})
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(token)
+
+	require.NoError(t, err)
+	assert.Empty(t, claims.Groups)
+	assert.True(t, claims.GroupsOverage)
 }
 
 func TestService_ValidateToken_Tampered(t *testing.T) {
 	svc, err := NewService(testConfig())
 	require.NoError(t, err)
 
-	token, err := svc.GenerateToken("user-123", "my-usecase")
+	token, err := svc.GenerateToken("user-123", "session-1", "my-usecase", &model.Authorization{})
 	require.NoError(t, err)
 
 	tampered := token[:len(token)-4] + "XXXX"
 
 	_, err = svc.ValidateToken(tampered)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, domainerrors.ErrInvalidToken)
 }
 
 func TestService_ValidateToken_Expired(t *testing.T) {
@@ -72,7 +114,7 @@ func TestService_ValidateToken_Expired(t *testing.T) {
 	svc, err := NewService(cfg)
 	require.NoError(t, err)
 
-	token, err := svc.GenerateToken("user-123", "my-usecase")
+	token, err := svc.GenerateToken("user-123", "session-1", "my-usecase", &model.Authorization{})
 	require.NoError(t, err)
 
 	_, err = svc.ValidateToken(token)
@@ -91,7 +133,7 @@ func TestService_ValidateToken_TableDriven(t *testing.T) {
 	svc, err := NewService(testConfig())
 	require.NoError(t, err)
 
-	validToken, err := svc.GenerateToken("user-456", "usecase-2")
+	validToken, err := svc.GenerateToken("user-456", "session-1", "usecase-2", &model.Authorization{})
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -118,17 +160,60 @@ func TestService_ValidateToken_TableDriven(t *testing.T) {
 	}
 }
 
+func TestService_ValidateToken_Tenant(t *testing.T) {
+	cfg := testConfig()
+	cfg.Issuer = "https://sso.example.com/"
+	svc, err := NewService(cfg)
+	require.NoError(t, err)
+
+	token, err := svc.GenerateToken("user-123", "session-1", "sso", &model.Authorization{Tenant: "acme"})
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(token)
+
+	require.NoError(t, err)
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.Equal(t, "https://sso.example.com/t/acme", claims.Issuer)
+	assert.NotEqual(t, svc.currentKeys["acme"].KID, svc.currentKeys[model.DefaultTenantSlug].KID)
+}
+
+func TestService_ValidateToken_ForeignTenantKey(t *testing.T) {
+	svc, err := NewService(testConfig())
+	require.NoError(t, err)
+	acmeKey, err := svc.keyFor("acme")
+	require.NoError(t, err)
+
+	// A token claiming the default tenant but signed with acme's key.
+	forged := jwtlib.NewWithClaims(jwtlib.SigningMethodEdDSA, accessClaims{
+		RegisteredClaims: jwtlib.RegisteredClaims{
+			Issuer:    svc.Issuer(model.DefaultTenantSlug),
+			Subject:   "user-123",
+			ExpiresAt: jwtlib.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Tenant: model.DefaultTenantSlug,
+	})
+	forged.Header["kid"] = acmeKey.KID
+	token, err := forged.SignedString(acmeKey.PrivateKey)
+	require.NoError(t, err)
+
+	_, err = svc.ValidateToken(token)
+	assert.ErrorIs(t, err, domainerrors.ErrInvalidToken)
+}
+
 func TestService_GetJWKS(t *testing.T) {
 	svc, err := NewService(testConfig())
 	require.NoError(t, err)
+	_, err = svc.keyFor("acme")
+	require.NoError(t, err)
 
-	jwks := svc.GetJWKS()
+	jwks, err := svc.GetJWKS(model.DefaultTenantSlug)
 
+	require.NoError(t, err)
 	require.Len(t, jwks.Keys, 1)
 	assert.Equal(t, "OKP", jwks.Keys[0].KTY)
 	assert.Equal(t, "Ed25519", jwks.Keys[0].CRV)
 	assert.Equal(t, "sig", jwks.Keys[0].Use)
-	assert.Equal(t, svc.currentKey.KID, jwks.Keys[0].KID)
+	assert.Equal(t, svc.currentKeys[model.DefaultTenantSlug].KID, jwks.Keys[0].KID)
 	assert.NotEmpty(t, jwks.Keys[0].X)
 }
 
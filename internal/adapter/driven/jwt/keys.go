@@ -8,8 +8,10 @@ import (
 	"fmt"
 )
 
+// KeyPair is a signing key of one tenant.
 type KeyPair struct {
 	KID        string
+	Tenant     string
 	PrivateKey ed25519.PrivateKey
 	PublicKey  ed25519.PublicKey
 }
@@ -30,15 +32,3 @@ func GenerateKeyPair() (*KeyPair, error) {
 		PublicKey:  pub,
 	}, nil
 }
-
-type JWK struct {
-	KTY string `json:"kty"`
-	CRV string `json:"crv"`
-	KID string `json:"kid"`
-	Use string `json:"use"`
-	X   string `json:"x"`
-}
-
-type JWKS struct {
-	Keys []JWK `json:"keys"`
-}
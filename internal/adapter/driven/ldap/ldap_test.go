@@ -0,0 +1,235 @@
+package ldap
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+)
+
+const (
+	testServiceDN       = "CN=sso,OU=Service,DC=corp,DC=example"
+	testServicePassword = "service-secret"
+)
+
+type directoryEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// fakeDirectory is an in-process LDAP stand-in that understands just enough
+// of the protocol for simple binds and equality searches on mail.
+type fakeDirectory struct {
+	ln      net.Listener
+	entries []directoryEntry
+}
+
+func startFakeDirectory(t *testing.T, entries ...directoryEntry) *fakeDirectory {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	d := &fakeDirectory{ln: ln, entries: entries}
+	go d.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+
+	return d
+}
+
+func (d *fakeDirectory) url() string {
+	return "ldap://" + d.ln.Addr().String()
+}
+
+func (d *fakeDirectory) serve() {
+	for {
+		conn, err := d.ln.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *fakeDirectory) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		msgID, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			name, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if d.checkPassword(name, password) {
+				code = ldap.LDAPResultSuccess
+			}
+			d.write(conn, msgID, ldap.ApplicationBindResponse, ldapResult(code)...)
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(op.Children[6])
+			for _, e := range d.entries {
+				for _, mail := range e.attrs["mail"] {
+					if strings.Contains(strings.ToLower(filter), "(mail="+strings.ToLower(mail)+")") {
+						d.write(conn, msgID, ldap.ApplicationSearchResultEntry, searchEntry(e)...)
+					}
+				}
+			}
+			d.write(conn, msgID, ldap.ApplicationSearchResultDone, ldapResult(ldap.LDAPResultSuccess)...)
+		default:
+			return
+		}
+	}
+}
+
+func (d *fakeDirectory) checkPassword(dn, password string) bool {
+	if dn == testServiceDN {
+		return password == testServicePassword
+	}
+	for _, e := range d.entries {
+		if e.dn == dn {
+			return password == e.password
+		}
+	}
+	return false
+}
+
+func (d *fakeDirectory) write(conn net.Conn, msgID int64, tag ber.Tag, children ...*ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, "MessageID"))
+
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	for _, c := range children {
+		op.AppendChild(c)
+	}
+	envelope.AppendChild(op)
+
+	_, _ = conn.Write(envelope.Bytes())
+}
+
+func ldapResult(code uint16) []*ber.Packet {
+	return []*ber.Packet{
+		ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"),
+	}
+}
+
+func searchEntry(e directoryEntry) []*ber.Packet {
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range e.attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "val"))
+		}
+		attr.AppendChild(vals)
+		attrs.AppendChild(attr)
+	}
+	return []*ber.Packet{
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "objectName"),
+		attrs,
+	}
+}
+
+func newTestBackend(url string) *Backend {
+	return New(&Config{
+		Name:         "corp-ad",
+		URL:          url,
+		BindDN:       testServiceDN,
+		BindPassword: testServicePassword,
+		BaseDN:       "DC=corp,DC=example",
+		Attributes:   []string{"department"},
+		Timeout:      2 * time.Second,
+	}, zap.NewNop())
+}
+
+var jane = directoryEntry{
+	dn:       "CN=Jane Doe,OU=Staff,DC=corp,DC=example",
+	password: "correct-horse",
+	attrs: map[string][]string{
+		"mail":        {"Jane@corp.example"},
+		"displayName": {"Jane Doe"},
+		"department":  {"Engineering"},
+		"memberOf": {
+			"CN=Engineering,OU=Groups,DC=corp,DC=example",
+			"CN=VPN Users,OU=Groups,DC=corp,DC=example",
+		},
+	},
+}
+
+func TestBackend_Authenticate(t *testing.T) {
+	dir := startFakeDirectory(t, jane)
+	b := newTestBackend(dir.url())
+
+	identity, err := b.Authenticate(t.Context(), "jane@corp.example", "correct-horse")
+	require.NoError(t, err)
+
+	assert.Equal(t, "corp-ad", identity.Provider)
+	assert.Equal(t, jane.dn, identity.Subject)
+	assert.Equal(t, "jane@corp.example", identity.Email)
+	assert.Equal(t, "Jane Doe", identity.DisplayName)
+	assert.ElementsMatch(t, jane.attrs["memberOf"], identity.Groups)
+	assert.Equal(t, map[string]string{"department": "Engineering"}, identity.Attributes)
+}
+
+func TestBackend_Authenticate_Rejected(t *testing.T) {
+	dir := startFakeDirectory(t, jane)
+	b := newTestBackend(dir.url())
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+	}{
+		{name: "wrong password", email: "jane@corp.example", password: "wrong"},
+		{name: "unknown user", email: "nobody@corp.example", password: "correct-horse"},
+		{name: "empty password", email: "jane@corp.example", password: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := b.Authenticate(t.Context(), tt.email, tt.password)
+
+			require.ErrorIs(t, err, domainerrors.ErrInvalidCredentials)
+			assert.Nil(t, identity)
+		})
+	}
+}
+
+func TestBackend_Authenticate_ServiceBindFails(t *testing.T) {
+	dir := startFakeDirectory(t, jane)
+	b := newTestBackend(dir.url())
+	b.cfg.BindPassword = "stale"
+
+	_, err := b.Authenticate(t.Context(), "jane@corp.example", "correct-horse")
+
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, domainerrors.ErrInvalidCredentials))
+	assert.Contains(t, err.Error(), "ldap service bind")
+}
+
+func TestBackend_Authenticate_Unreachable(t *testing.T) {
+	b := newTestBackend("ldap://127.0.0.1:1")
+
+	_, err := b.Authenticate(t.Context(), "jane@corp.example", "correct-horse")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ldap dial")
+}
@@ -0,0 +1,163 @@
+// Package passwordpolicy decides whether a new password is acceptable: long
+// enough, hard enough to guess, and unrelated to the account it protects.
+package passwordpolicy
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+)
+
+// minEmailPartLen is the shortest part of an email address a password is
+// checked for; shorter ones occur in too many passwords by chance.
+const minEmailPartLen = 3
+
+//go:embed common.txt
+var commonList string
+
+type Config struct {
+	// MinLength and MaxLength bound the length in characters, counted after
+	// normalization.
+	MinLength int
+	MaxLength int
+	// MinScore is the lowest accepted strength from 0 to 4; zero accepts
+	// any.
+	MinScore int
+	// RejectEmail rejects passwords containing the account's email address
+	// or its parts.
+	RejectEmail bool
+	// RejectCommon rejects passwords from the bundled list of common ones.
+	RejectCommon bool
+}
+
+func (c *Config) Validate() error {
+	if c.MinLength < 1 {
+		return errors.New("min length must be at least 1")
+	}
+	if c.MaxLength < c.MinLength {
+		return errors.New("max length must not be less than min length")
+	}
+	if c.MinScore < 0 || c.MinScore > len(scoreThresholds) {
+		return fmt.Errorf("min score must be between 0 and %d", len(scoreThresholds))
+	}
+	return nil
+}
+
+type Policy struct {
+	cfg    *Config
+	common map[string]int
+}
+
+func New(cfg *Config) *Policy {
+	common := make(map[string]int)
+	for line := range strings.Lines(commonList) {
+		word := strings.ToLower(strings.TrimSpace(line))
+		if _, ok := common[word]; word != "" && !ok {
+			common[word] = len(common) + 1
+		}
+	}
+	return &Policy{cfg: cfg, common: common}
+}
+
+// Normalize returns the NFKC form of password, so that a password typed on a
+// different keyboard or input method hashes the same. Passwords are
+// normalized before they are checked and hashed.
+func (p *Policy) Normalize(password string) string {
+	return norm.NFKC.String(password)
+}
+
+// Check returns a *domainerrors.PasswordPolicyError listing every rule
+// password breaks, or nil. email, if given, is the address of the account.
+func (p *Policy) Check(password, email string) error {
+	password = p.Normalize(password)
+	var violations []domainerrors.PasswordViolation
+	add := func(code, format string, args ...any) {
+		violations = append(violations, domainerrors.PasswordViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		add("too_short", "must be at least %d characters", p.cfg.MinLength)
+	}
+	if length > p.cfg.MaxLength {
+		// Longer passwords are not estimated, which costs quadratic time.
+		add("too_long", "must be at most %d characters", p.cfg.MaxLength)
+		return &domainerrors.PasswordPolicyError{Violations: violations}
+	}
+
+	lower := strings.ToLower(password)
+	emailParts := emailParts(email)
+	if p.cfg.RejectEmail {
+		for _, part := range emailParts {
+			if strings.Contains(lower, part) {
+				add("contains_email", "must not contain your email address")
+				break
+			}
+		}
+	}
+
+	common := p.isCommon(lower)
+	if common && p.cfg.RejectCommon {
+		add("common", "is too common")
+	}
+	if p.cfg.MinScore > 0 && !common && p.score(password, emailParts) < p.cfg.MinScore {
+		add("too_weak", "is too easy to guess")
+	}
+
+	if len(violations) > 0 {
+		return &domainerrors.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (p *Policy) isCommon(lower string) bool {
+	if _, ok := p.common[lower]; ok {
+		return true
+	}
+	unleeted, subs := unleet([]rune(lower))
+	if subs == 0 {
+		return false
+	}
+	_, ok := p.common[unleeted]
+	return ok
+}
+
+// score estimates the strength of password. The parts of the email address
+// rank above every common password, as an attacker targeting the account
+// tries them first.
+func (p *Policy) score(password string, emailParts []string) int {
+	ranked := p.common
+	if len(emailParts) > 0 {
+		ranked = make(map[string]int, len(p.common)+len(emailParts))
+		for word, rank := range p.common {
+			ranked[word] = rank + len(emailParts)
+		}
+		for i, part := range emailParts {
+			ranked[part] = i + 1
+		}
+	}
+	return score(estimateGuesses(password, ranked))
+}
+
+// emailParts returns the lowercase local part of email and the words in it,
+// e.g. "john.smith", "john" and "smith".
+func emailParts(email string) []string {
+	local, _, found := strings.Cut(strings.ToLower(email), "@")
+	if !found || utf8.RuneCountInString(local) < minEmailPartLen {
+		return nil
+	}
+	parts := []string{local}
+	for _, word := range strings.FieldsFunc(local, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if utf8.RuneCountInString(word) >= minEmailPartLen && word != local {
+			parts = append(parts, word)
+		}
+	}
+	return parts
+}
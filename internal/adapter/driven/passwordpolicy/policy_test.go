@@ -0,0 +1,174 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+)
+
+func testConfig() *Config {
+	return &Config{
+		MinLength:    8,
+		MaxLength:    64,
+		MinScore:     2,
+		RejectEmail:  true,
+		RejectCommon: true,
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name      string
+		password  string
+		email     string
+		wantCodes []string
+	}{
+		{
+			name:     "strong password",
+			password: "vivid-Otter-57-Lantern",
+			email:    "jane.doe@example.com",
+		},
+		{
+			name:      "too short in characters",
+			password:  "пароль1",
+			wantCodes: []string{"too_short"},
+		},
+		{
+			name:     "multibyte characters count once",
+			password: "ключ-Ёжик-7полёт",
+		},
+		{
+			name:      "too long",
+			password:  "a-very-long-password-that-goes-on-and-on-well-past-the-limit-set-here",
+			wantCodes: []string{"too_long"},
+		},
+		{
+			name:      "common password",
+			password:  "Password123",
+			wantCodes: []string{"common"},
+		},
+		{
+			name:      "common password with substitutions",
+			password:  "p4ssw0rd",
+			wantCodes: []string{"common"},
+		},
+		{
+			name:      "contains email local part",
+			password:  "Jane.Doe-Secure-2024",
+			email:     "jane.doe@example.com",
+			wantCodes: []string{"contains_email"},
+		},
+		{
+			name:      "contains word of email",
+			password:  "xq-smithers-91-zp",
+			email:     "al.smithers@example.com",
+			wantCodes: []string{"contains_email"},
+		},
+		{
+			name:      "keyboard walk and year",
+			password:  "qwertyuiop1987",
+			wantCodes: []string{"too_weak"},
+		},
+		{
+			name:      "sequence and repeat",
+			password:  "abcdefgh11111",
+			wantCodes: []string{"too_weak"},
+		},
+		{
+			name:      "word and year",
+			password:  "Summer2024!",
+			wantCodes: []string{"too_weak"},
+		},
+		{
+			name:      "several violations",
+			password:  "jdoe",
+			email:     "jdoe@example.com",
+			wantCodes: []string{"too_short", "contains_email", "too_weak"},
+		},
+	}
+
+	p := New(testConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.password, tt.email)
+
+			if tt.wantCodes == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, domainerrors.ErrWeakPassword)
+			policyErr, ok := errors.AsType[*domainerrors.PasswordPolicyError](err)
+			require.True(t, ok)
+			codes := make([]string, 0, len(policyErr.Violations))
+			for _, v := range policyErr.Violations {
+				codes = append(codes, v.Code)
+			}
+			assert.Equal(t, tt.wantCodes, codes)
+		})
+	}
+}
+
+func TestPolicy_Check_Disabled(t *testing.T) {
+	p := New(&Config{MinLength: 4, MaxLength: 64})
+
+	assert.NoError(t, p.Check("password", "password@example.com"))
+}
+
+func TestPolicy_Normalize(t *testing.T) {
+	p := New(testConfig())
+
+	assert.Equal(t, "Password1", p.Normalize("Ｐａｓｓｗｏｒｄ１"))
+	assert.Equal(t, "café", p.Normalize("café"))
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{password: "password", want: 0},
+		{password: "aaaaaaaa", want: 0},
+		{password: "12345678", want: 0},
+		{password: "Tr0ub4dour&3", want: 4},
+		{password: "correct horse battery staple", want: 4},
+	}
+
+	p := New(testConfig())
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.score(tt.password, nil))
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{name: "valid", modify: func(*Config) {}},
+		{name: "zero min length", modify: func(c *Config) { c.MinLength = 0 }, wantErr: "min length must be at least 1"},
+		{name: "max below min", modify: func(c *Config) { c.MaxLength = 4 }, wantErr: "max length must not be less than min length"},
+		{name: "score out of range", modify: func(c *Config) { c.MinScore = 5 }, wantErr: "min score must be between 0 and 4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package passwordpolicy
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The estimate follows zxcvbn: a password is split into the sequence of
+// guessable patterns, such as dictionary words, keyboard walks, sequences,
+// repeats and years, that is cheapest to guess, with brute force for the
+// characters between them. The guesses of the patterns multiply.
+
+const (
+	// bruteforceCardinality is the guesses per character not covered by a
+	// pattern.
+	bruteforceCardinality = 10
+	// minSubmatchGuesses is the least a pattern of more than one character
+	// is worth, so that no split makes a password look cheaper than it is.
+	minSubmatchGuesses = 50
+	minYearSpace       = 20
+)
+
+// scoreThresholds are the guesses a password must exceed for scores 1 to 4.
+var scoreThresholds = [...]float64{1e3, 1e6, 1e8, 1e10}
+
+var keyboardRows = []string{
+	"1234567890-=",
+	"qwertyuiop[]",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"!@#$%^&*()_+",
+}
+
+// leet maps the common substitutions back to the letters they stand for.
+var leet = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// score maps an estimate of guesses to a score from 0 to 4.
+func score(guesses float64) int {
+	for i, threshold := range scoreThresholds {
+		if guesses <= threshold {
+			return i
+		}
+	}
+	return len(scoreThresholds)
+}
+
+// estimateGuesses returns how many guesses an attacker who knows the common
+// patterns needs for password. ranked holds dictionary words by popularity,
+// starting at 1.
+func estimateGuesses(password string, ranked map[string]int) float64 {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return 1
+	}
+	lower := []rune(strings.ToLower(password))
+
+	// best[k] is the fewest guesses for the first k characters.
+	best := make([]float64, n+1)
+	best[0] = 1
+	for k := 1; k <= n; k++ {
+		best[k] = best[k-1] * bruteforceCardinality
+		for i := range k {
+			if g := patternGuesses(runes[i:k], lower[i:k], ranked); g > 0 {
+				best[k] = math.Min(best[k], best[i]*g)
+			}
+		}
+	}
+	return best[n]
+}
+
+// patternGuesses returns the guesses for token if it matches a pattern, or
+// zero.
+func patternGuesses(token, lower []rune, ranked map[string]int) float64 {
+	guesses := math.Inf(1)
+	if g := dictionaryGuesses(token, lower, ranked); g > 0 {
+		guesses = g
+	}
+	if len(token) >= 3 {
+		if g := repeatGuesses(lower); g > 0 {
+			guesses = math.Min(guesses, g)
+		}
+		if g := sequenceGuesses(lower); g > 0 {
+			guesses = math.Min(guesses, g)
+		}
+	}
+	if len(token) >= 4 {
+		if g := keyboardGuesses(lower); g > 0 {
+			guesses = math.Min(guesses, g)
+		}
+		if g := yearGuesses(lower); g > 0 {
+			guesses = math.Min(guesses, g)
+		}
+	}
+	if math.IsInf(guesses, 1) {
+		return 0
+	}
+	if len(token) > 1 {
+		return math.Max(guesses, minSubmatchGuesses)
+	}
+	return math.Max(guesses, bruteforceCardinality)
+}
+
+func dictionaryGuesses(token, lower []rune, ranked map[string]int) float64 {
+	if len(token) < 3 {
+		return 0
+	}
+	word := string(lower)
+	if rank, ok := ranked[word]; ok {
+		return float64(rank) * uppercaseVariations(token)
+	}
+	if rank, ok := ranked[reverse(word)]; ok {
+		return float64(rank) * uppercaseVariations(token) * 2
+	}
+	unleeted, subs := unleet(lower)
+	if subs == 0 {
+		return 0
+	}
+	if rank, ok := ranked[unleeted]; ok {
+		return float64(rank) * uppercaseVariations(token) * float64(2*subs)
+	}
+	return 0
+}
+
+// uppercaseVariations is how many ways of capitalizing token an attacker
+// tries before the one used.
+func uppercaseVariations(token []rune) float64 {
+	var upper, lower int
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1]))) {
+		return 2
+	}
+	var variations float64
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+func unleet(lower []rune) (string, int) {
+	out := make([]rune, len(lower))
+	var subs int
+	for i, r := range lower {
+		if l, ok := leet[r]; ok {
+			out[i] = l
+			subs++
+			continue
+		}
+		out[i] = r
+	}
+	return string(out), subs
+}
+
+// repeatGuesses matches a character repeated, e.g. "aaaa".
+func repeatGuesses(lower []rune) float64 {
+	for _, r := range lower[1:] {
+		if r != lower[0] {
+			return 0
+		}
+	}
+	return bruteforceCardinality * float64(len(lower))
+}
+
+// sequenceGuesses matches characters one code point apart, e.g. "abcd" or
+// "9876".
+func sequenceGuesses(lower []rune) float64 {
+	delta := lower[1] - lower[0]
+	if delta != 1 && delta != -1 {
+		return 0
+	}
+	for i := 2; i < len(lower); i++ {
+		if lower[i]-lower[i-1] != delta {
+			return 0
+		}
+	}
+
+	var base float64
+	switch first := lower[0]; {
+	case strings.ContainsRune("az019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if delta < 0 {
+		base *= 2
+	}
+	return base * float64(len(lower))
+}
+
+// keyboardGuesses matches a run along a keyboard row, e.g. "asdf" or "poiu".
+func keyboardGuesses(lower []rune) float64 {
+	token := string(lower)
+	for _, row := range keyboardRows {
+		if strings.Contains(row, token) {
+			return 10 * float64(len(lower))
+		}
+		if strings.Contains(row, reverse(token)) {
+			return 20 * float64(len(lower))
+		}
+	}
+	return 0
+}
+
+// yearGuesses matches a recent year, e.g. "1987".
+func yearGuesses(lower []rune) float64 {
+	if len(lower) != 4 {
+		return 0
+	}
+	year := 0
+	for _, r := range lower {
+		if r < '0' || r > '9' {
+			return 0
+		}
+		year = year*10 + int(r-'0')
+	}
+	if year < 1900 || year > 2099 {
+		return 0
+	}
+	span := year - time.Now().Year()
+	if span < 0 {
+		span = -span
+	}
+	return float64(max(span, minYearSpace))
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// eventsLockKey serializes event inserts. Holding it while the id is drawn
+// and until commit makes ids become visible in order, so a reader that has
+// seen id N will never later find a committed event below N.
+const eventsLockKey = 0x55534f4556 // "USOEV"
+
+func (s *Storage) AppendEvent(ctx context.Context, event *model.Event) (err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, eventsLockKey); err != nil {
+		return fmt.Errorf("lock events: %w", err)
+	}
+
+	query := `INSERT INTO identity_events (type, tenant_id, user_id, data)
+              VALUES ($1, $2, $3, $4)
+              RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query, string(event.Type), event.TenantID, event.UserID, event.Data).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert event: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit event: %w", err)
+	}
+	return nil
+}
+
+// ListEventsAfter returns up to limit events with an id above afterID, oldest
+// first. An empty tenantID matches every tenant and an empty types every type.
+func (s *Storage) ListEventsAfter(
+	ctx context.Context,
+	afterID int64,
+	tenantID string,
+	types []model.EventType,
+	limit int,
+) ([]*model.Event, error) {
+	query := `SELECT id, type, COALESCE(tenant_id::text, ''), user_id, data, created_at
+              FROM identity_events
+              WHERE id > $1
+                AND ($2 = '' OR tenant_id = NULLIF($2, '')::uuid)
+                AND (cardinality($3::text[]) = 0 OR type = ANY($3::text[]))
+              ORDER BY id
+              LIMIT $4`
+
+	typeNames := make([]string, len(types))
+	for i, t := range types {
+		typeNames[i] = string(t)
+	}
+
+	rows, err := s.pool.Query(ctx, query, afterID, tenantID, typeNames, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*model.Event, 0, limit)
+	for rows.Next() {
+		var e model.Event
+		var eventType string
+		if err = rows.Scan(&e.ID, &eventType, &e.TenantID, &e.UserID, &e.Data, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		e.Type = model.EventType(eventType)
+		events = append(events, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate events: %w", err)
+	}
+	return events, nil
+}
+
+// ListEventsByUserID returns every event about the user, oldest first.
+func (s *Storage) ListEventsByUserID(ctx context.Context, userID string) ([]*model.Event, error) {
+	query := `SELECT id, type, COALESCE(tenant_id::text, ''), user_id, data, created_at
+              FROM identity_events
+              WHERE user_id = $1
+              ORDER BY id`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select events by user id: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var e model.Event
+		var eventType string
+		if err = rows.Scan(&e.ID, &eventType, &e.TenantID, &e.UserID, &e.Data, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		e.Type = model.EventType(eventType)
+		events = append(events, &e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *Storage) LatestEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.pool.QueryRow(ctx, `SELECT id FROM identity_events ORDER BY id DESC LIMIT 1`).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("select latest event id: %w", err)
+	}
+	return id, nil
+}
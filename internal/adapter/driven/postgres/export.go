@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const selectExportColumns = `SELECT id, user_id, status, requested_by, archive, token_hash,
+              expires_at, created_at, completed_at
+              FROM data_exports`
+
+func (s *Storage) CreateExport(ctx context.Context, export *model.DataExport) error {
+	query := `INSERT INTO data_exports (user_id, status, requested_by)
+              VALUES ($1, $2, $3)
+              RETURNING id, created_at`
+
+	err := s.pool.QueryRow(ctx, query, export.UserID, string(export.Status), string(export.RequestedBy)).
+		Scan(&export.ID, &export.CreatedAt)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrExportInProgress
+		}
+		return fmt.Errorf("insert data export: %w", err)
+	}
+	return nil
+}
+
+// ClaimPendingExport marks the oldest pending export as processing and
+// returns it. Exports left processing since before staleBefore, by a worker
+// that died, are claimed again.
+func (s *Storage) ClaimPendingExport(ctx context.Context, staleBefore time.Time) (*model.DataExport, error) {
+	query := `UPDATE data_exports
+              SET status = 'processing', claimed_at = now()
+              WHERE id = (
+                  SELECT id FROM data_exports
+                  WHERE status = 'pending' OR (status = 'processing' AND claimed_at < $1)
+                  ORDER BY created_at
+                  LIMIT 1
+                  FOR UPDATE SKIP LOCKED
+              )
+              RETURNING id, user_id, status, requested_by, archive, token_hash,
+                        expires_at, created_at, completed_at`
+
+	export, err := scanExport(s.pool.QueryRow(ctx, query, staleBefore))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("claim data export: %w", err)
+	}
+	return export, nil
+}
+
+func (s *Storage) CompleteExport(ctx context.Context, id string, archive []byte, tokenHash string, expiresAt time.Time) error {
+	query := `UPDATE data_exports
+              SET status = 'ready', archive = $2, token_hash = $3, expires_at = $4, completed_at = now()
+              WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, id, archive, tokenHash, expiresAt); err != nil {
+		return fmt.Errorf("complete data export: %w", err)
+	}
+	return nil
+}
+
+// FailExport gives up on an export. It expires at once, so it is cleaned up
+// with the next expired batch and does not block a new request.
+func (s *Storage) FailExport(ctx context.Context, id string) error {
+	query := `UPDATE data_exports
+              SET status = 'failed', expires_at = now(), completed_at = now()
+              WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("fail data export: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetExportByTokenHash(ctx context.Context, hash string) (*model.DataExport, error) {
+	export, err := scanExport(s.pool.QueryRow(ctx, selectExportColumns+` WHERE token_hash = $1`, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrExportNotFound
+		}
+		return nil, fmt.Errorf("select data export by token: %w", err)
+	}
+	return export, nil
+}
+
+func (s *Storage) DeleteExpiredExports(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.pool.Exec(ctx, `DELETE FROM data_exports WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired data exports: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+func scanExport(row pgx.Row) (*model.DataExport, error) {
+	var export model.DataExport
+	var status, requestedBy string
+	var tokenHash *string
+	var expiresAt, completedAt *time.Time
+
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&status,
+		&requestedBy,
+		&export.Archive,
+		&tokenHash,
+		&expiresAt,
+		&export.CreatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+
+	export.Status = model.DataExportStatus(status)
+	export.RequestedBy = model.DataExportRequester(requestedBy)
+	if tokenHash != nil {
+		export.TokenHash = *tokenHash
+	}
+	if expiresAt != nil {
+		export.ExpiresAt = *expiresAt
+	}
+	if completedAt != nil {
+		export.CompletedAt = *completedAt
+	}
+	return &export, nil
+}
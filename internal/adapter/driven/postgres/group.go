@@ -0,0 +1,379 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const groupColumns = `g.id, g.tenant_id, g.name, g.description, g.source, g.external_id, g.created_at, g.updated_at`
+
+// userGroupsCTE resolves the groups of user $1: the groups the user is a
+// direct member of and, transitively, every group containing one of them.
+// UNION drops rows already seen, so the recursion ends even if the nesting
+// has a cycle.
+const userGroupsCTE = `WITH RECURSIVE member_of (id, direct) AS (
+                  SELECT group_id, true FROM group_members WHERE user_id = $1
+                  UNION
+                  SELECT gs.parent_id, false
+                  FROM group_subgroups gs
+                  JOIN member_of m ON m.id = gs.child_id
+              )`
+
+func (s *Storage) CreateGroup(ctx context.Context, group *model.Group) error {
+	query := `INSERT INTO groups (tenant_id, name, description)
+              VALUES ($1, $2, $3)
+              RETURNING id, created_at, updated_at`
+
+	err := s.pool.QueryRow(ctx, query, group.TenantID, group.Name, group.Description).
+		Scan(&group.ID, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrGroupAlreadyExists
+		}
+		return fmt.Errorf("insert group: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListGroups(ctx context.Context, tenantID string) ([]*model.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups g WHERE g.tenant_id = $1 ORDER BY g.name, g.id`
+	rows, err := s.pool.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("select groups: %w", err)
+	}
+	return collectGroups(rows)
+}
+
+// GetGroup returns the group with id in the tenant. Groups of other tenants
+// are reported as not found.
+func (s *Storage) GetGroup(ctx context.Context, tenantID, id string) (*model.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups g WHERE g.id = $1 AND g.tenant_id = $2`
+	group, err := scanGroup(s.pool.QueryRow(ctx, query, id, tenantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("select group: %w", err)
+	}
+	return group, nil
+}
+
+// UpdateGroup saves the name and description of group.
+func (s *Storage) UpdateGroup(ctx context.Context, group *model.Group) error {
+	query := `UPDATE groups
+              SET name = $2, description = $3, updated_at = now()
+              WHERE id = $1
+              RETURNING updated_at`
+
+	err := s.pool.QueryRow(ctx, query, group.ID, group.Name, group.Description).Scan(&group.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domainerrors.ErrGroupNotFound
+		}
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrGroupAlreadyExists
+		}
+		return fmt.Errorf("update group: %w", err)
+	}
+	return nil
+}
+
+// DeleteGroup removes the group with its memberships and nesting.
+func (s *Storage) DeleteGroup(ctx context.Context, tenantID, id string) error {
+	result, err := s.pool.Exec(ctx, `DELETE FROM groups WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrGroupNotFound
+	}
+	return nil
+}
+
+// ListGroupMembers returns the users and groups directly in the group.
+func (s *Storage) ListGroupMembers(ctx context.Context, groupID string) (*model.GroupMembers, error) {
+	members := &model.GroupMembers{}
+
+	rows, err := s.pool.Query(ctx,
+		`SELECT user_id FROM group_members WHERE group_id = $1 ORDER BY created_at, user_id`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("select group members: %w", err)
+	}
+	members.UserIDs, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("collect group members: %w", err)
+	}
+
+	query := `SELECT ` + groupColumns + `
+              FROM group_subgroups gs
+              JOIN groups g ON g.id = gs.child_id
+              WHERE gs.parent_id = $1
+              ORDER BY g.name, g.id`
+
+	rows, err = s.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("select subgroups: %w", err)
+	}
+	if members.Subgroups, err = collectGroups(rows); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddGroupMember adds the user to the group. Adding a member twice is a
+// no-op. Users of another tenant than the group's are reported as not found.
+func (s *Storage) AddGroupMember(ctx context.Context, groupID, userID string) error {
+	query := `INSERT INTO group_members (group_id, user_id)
+              SELECT g.id, u.id
+              FROM groups g
+              JOIN users u ON u.tenant_id = g.tenant_id
+              WHERE g.id = $1 AND u.id = $2
+              ON CONFLICT (group_id, user_id) DO NOTHING`
+
+	result, err := s.pool.Exec(ctx, query, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("add group member: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		// Either the user is already a member or not in the group's tenant.
+		var member bool
+		err = s.pool.QueryRow(ctx, `SELECT EXISTS (
+                                        SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2
+                                    )`, groupID, userID).Scan(&member)
+		if err != nil {
+			return fmt.Errorf("select group member: %w", err)
+		}
+		if !member {
+			return domainerrors.ErrUserNotFound
+		}
+	}
+	return nil
+}
+
+// RemoveGroupMember takes the user out of the group. Removing a user who is
+// not a member is a no-op.
+func (s *Storage) RemoveGroupMember(ctx context.Context, groupID, userID string) error {
+	query := `DELETE FROM group_members
+              WHERE group_id = $1 AND user_id = $2`
+
+	if _, err := s.pool.Exec(ctx, query, groupID, userID); err != nil {
+		return fmt.Errorf("remove group member: %w", err)
+	}
+	return nil
+}
+
+// AddSubgroup nests child in parent. It fails with ErrGroupCycle when parent
+// is already reachable from child. The table lock serialises concurrent
+// nesting changes, which could otherwise each pass the check and together
+// close a cycle.
+func (s *Storage) AddSubgroup(ctx context.Context, parentID, childID string) (err error) {
+	if parentID == childID {
+		return domainerrors.ErrGroupCycle
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, `LOCK TABLE group_subgroups IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("lock group nesting: %w", err)
+	}
+
+	query := `WITH RECURSIVE descendants (id) AS (
+                  SELECT child_id FROM group_subgroups WHERE parent_id = $1
+                  UNION
+                  SELECT gs.child_id
+                  FROM group_subgroups gs
+                  JOIN descendants d ON d.id = gs.parent_id
+              )
+              SELECT EXISTS (SELECT 1 FROM descendants WHERE id = $2)`
+
+	var cycle bool
+	if err = tx.QueryRow(ctx, query, childID, parentID).Scan(&cycle); err != nil {
+		return fmt.Errorf("check group cycle: %w", err)
+	}
+	if cycle {
+		return domainerrors.ErrGroupCycle
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO group_subgroups (parent_id, child_id)
+                           VALUES ($1, $2)
+                           ON CONFLICT (parent_id, child_id) DO NOTHING`, parentID, childID)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23503" {
+			return domainerrors.ErrGroupNotFound
+		}
+		return fmt.Errorf("insert subgroup: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit subgroup: %w", err)
+	}
+	return nil
+}
+
+// RemoveSubgroup un-nests child from parent. Removing a missing nesting is a
+// no-op.
+func (s *Storage) RemoveSubgroup(ctx context.Context, parentID, childID string) error {
+	query := `DELETE FROM group_subgroups
+              WHERE parent_id = $1 AND child_id = $2`
+
+	if _, err := s.pool.Exec(ctx, query, parentID, childID); err != nil {
+		return fmt.Errorf("remove subgroup: %w", err)
+	}
+	return nil
+}
+
+// ListUserGroups returns every group the user belongs to, directly or
+// through nesting.
+func (s *Storage) ListUserGroups(ctx context.Context, userID string) ([]*model.UserGroup, error) {
+	query := userGroupsCTE + `
+              SELECT ` + groupColumns + `, bool_or(m.direct)
+              FROM member_of m
+              JOIN groups g ON g.id = m.id
+              GROUP BY g.id
+              ORDER BY g.name, g.id`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select user groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*model.UserGroup
+	for rows.Next() {
+		var ug model.UserGroup
+		var source, externalID *string
+		err = rows.Scan(&ug.ID, &ug.TenantID, &ug.Name, &ug.Description, &source, &externalID,
+			&ug.CreatedAt, &ug.UpdatedAt, &ug.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("scan user group: %w", err)
+		}
+		ug.Source, ug.ExternalID = deref(source), deref(externalID)
+		groups = append(groups, &ug)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user groups: %w", err)
+	}
+	return groups, nil
+}
+
+// ListGroupNamesByUserID returns the distinct names of the user's groups,
+// including nested ones, for the groups claim.
+func (s *Storage) ListGroupNamesByUserID(ctx context.Context, userID string) ([]string, error) {
+	query := userGroupsCTE + `
+              SELECT DISTINCT g.name
+              FROM member_of m
+              JOIN groups g ON g.id = m.id
+              ORDER BY g.name`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select user group names: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("collect user group names: %w", err)
+	}
+	return names, nil
+}
+
+// SyncExternalGroups makes the user's direct membership in groups of source
+// exactly groups, creating missing groups and refreshing their names. Groups
+// are matched on TenantID and ExternalID; local groups are not touched.
+func (s *Storage) SyncExternalGroups(ctx context.Context, userID, source string, groups []*model.Group) (err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	upsert := `INSERT INTO groups (tenant_id, name, source, external_id)
+               VALUES ($1, $2, $3, $4)
+               ON CONFLICT (tenant_id, source, external_id) WHERE source IS NOT NULL
+               DO UPDATE SET name = EXCLUDED.name,
+                             updated_at = CASE WHEN groups.name = EXCLUDED.name THEN groups.updated_at ELSE now() END
+               RETURNING id`
+
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		var id string
+		if err = tx.QueryRow(ctx, upsert, g.TenantID, g.Name, source, g.ExternalID).Scan(&id); err != nil {
+			return fmt.Errorf("upsert external group: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	query := `DELETE FROM group_members gm
+              USING groups g
+              WHERE g.id = gm.group_id AND gm.user_id = $1
+                AND g.source = $2 AND g.id <> ALL($3::uuid[])`
+	if _, err = tx.Exec(ctx, query, userID, source, ids); err != nil {
+		return fmt.Errorf("delete stale memberships: %w", err)
+	}
+
+	query = `INSERT INTO group_members (group_id, user_id)
+             SELECT id, $1 FROM unnest($2::uuid[]) AS id
+             ON CONFLICT (group_id, user_id) DO NOTHING`
+	if _, err = tx.Exec(ctx, query, userID, ids); err != nil {
+		return fmt.Errorf("insert memberships: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit group sync: %w", err)
+	}
+	return nil
+}
+
+func collectGroups(rows pgx.Rows) ([]*model.Group, error) {
+	defer rows.Close()
+
+	var groups []*model.Group
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate groups: %w", err)
+	}
+	return groups, nil
+}
+
+func scanGroup(row pgx.Row) (*model.Group, error) {
+	var group model.Group
+	var source, externalID *string
+	err := row.Scan(&group.ID, &group.TenantID, &group.Name, &group.Description, &source, &externalID,
+		&group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+	group.Source, group.ExternalID = deref(source), deref(externalID)
+	return &group, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
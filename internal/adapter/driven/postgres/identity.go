@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func (s *Storage) UpsertIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email, display_name, groups, attributes)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)
+              ON CONFLICT (provider, subject) DO UPDATE
+              SET email = EXCLUDED.email,
+                  display_name = EXCLUDED.display_name,
+                  groups = EXCLUDED.groups,
+                  attributes = EXCLUDED.attributes,
+                  updated_at = now()
+              RETURNING id, user_id, created_at, updated_at`
+
+	groups := identity.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+	attributes := identity.Attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	err := s.pool.QueryRow(ctx, query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		identity.DisplayName,
+		groups,
+		attributes,
+	).Scan(&identity.ID, &identity.UserID, &identity.CreatedAt, &identity.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert user identity: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListIdentitiesByUserID(ctx context.Context, userID string) ([]*model.UserIdentity, error) {
+	query := `SELECT id, user_id, provider, subject, email, display_name, groups, attributes, created_at, updated_at
+              FROM user_identities
+              WHERE user_id = $1
+              ORDER BY created_at`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select user identities: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []*model.UserIdentity
+	for rows.Next() {
+		var identity model.UserIdentity
+		var displayName *string
+		err = rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+			&displayName, &identity.Groups, &identity.Attributes, &identity.CreatedAt, &identity.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan user identity: %w", err)
+		}
+		if displayName != nil {
+			identity.DisplayName = *displayName
+		}
+		identities = append(identities, &identity)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate user identities: %w", err)
+	}
+	return identities, nil
+}
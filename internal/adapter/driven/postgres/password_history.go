@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecentPasswordHashes returns up to limit hashes of the user's replaced
+// passwords stored after since, newest first.
+func (s *Storage) RecentPasswordHashes(ctx context.Context, userID string, limit int, since time.Time) ([]string, error) {
+	query := `SELECT password_hash
+              FROM password_history
+              WHERE user_id = $1 AND created_at > $2
+              ORDER BY created_at DESC
+              LIMIT $3`
+
+	rows, err := s.pool.Query(ctx, query, userID, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err = rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("scan password history: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate password history: %w", err)
+	}
+	return hashes, nil
+}
+
+// PrunePasswordHistory deletes the user's replaced passwords beyond the keep
+// newest ones, and those stored before before.
+func (s *Storage) PrunePasswordHistory(ctx context.Context, userID string, keep int, before time.Time) error {
+	query := `DELETE FROM password_history
+              WHERE user_id = $1
+                AND (created_at < $2 OR id NOT IN (
+                    SELECT id FROM password_history
+                    WHERE user_id = $1
+                    ORDER BY created_at DESC
+                    LIMIT $3))`
+
+	if _, err := s.pool.Exec(ctx, query, userID, before, keep); err != nil {
+		return fmt.Errorf("prune password history: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 
@@ -34,15 +35,118 @@ func (s *Storage) SaveToken(ctx context.Context, token *model.RefreshToken) erro
 	return nil
 }
 
+const refreshTokenColumns = `id, token_hash, user_id, client_id, family_id, scopes, revoked, expires_at, created_at,
+              rotated_at, sealed_successor`
+
 func (s *Storage) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
-	query := `SELECT id, token_hash, user_id, client_id, family_id, scopes, revoked, expires_at, created_at
+	query := `SELECT ` + refreshTokenColumns + `
               FROM refresh_tokens
               WHERE token_hash = $1`
 
+	rt, err := scanRefreshToken(s.pool.QueryRow(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("select refresh token by hash: %w", err)
+	}
+	return rt, nil
+}
+
+// ListByUserID returns every stored refresh token of the user, newest first.
+func (s *Storage) ListByUserID(ctx context.Context, userID string) ([]*model.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + `
+              FROM refresh_tokens
+              WHERE user_id = $1
+              ORDER BY created_at DESC`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select refresh tokens by user id: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.RefreshToken
+	for rows.Next() {
+		rt, err := scanRefreshToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan refresh token: %w", err)
+		}
+		tokens = append(tokens, rt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate refresh tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RotateToken exchanges the refresh token with oldHash for next, which joins
+// its family, in one transaction. The old token stays locked until then, so
+// concurrent rotations of one token run one after the other and only the
+// first succeeds. sealedNext is kept with the old token for re-presentation
+// within a grace window.
+//
+// It returns the old token as it was before the exchange and whether it was
+// exchanged; a revoked or expired old token is left as it is.
+func (s *Storage) RotateToken(
+	ctx context.Context, oldHash string, next *model.RefreshToken, sealedNext []byte,
+) (_ *model.RefreshToken, _ bool, err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	query := `SELECT ` + refreshTokenColumns + `
+              FROM refresh_tokens
+              WHERE token_hash = $1
+              FOR UPDATE`
+
+	old, err := scanRefreshToken(tx.QueryRow(ctx, query, oldHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, domainerrors.ErrInvalidToken
+		}
+		return nil, false, fmt.Errorf("select refresh token for update: %w", err)
+	}
+	if old.Revoked || !time.Now().Before(old.ExpiresAt) {
+		if err = tx.Rollback(ctx); err != nil {
+			return nil, false, fmt.Errorf("rollback tx: %w", err)
+		}
+		return old, false, nil
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE refresh_tokens
+              SET revoked = true, rotated_at = now(), sealed_successor = $2
+              WHERE id = $1`, old.ID, sealedNext)
+	if err != nil {
+		return nil, false, fmt.Errorf("revoke rotated refresh token: %w", err)
+	}
+
+	next.UserID, next.ClientID, next.FamilyID, next.Scopes = old.UserID, old.ClientID, old.FamilyID, old.Scopes
+	err = tx.QueryRow(ctx, `INSERT INTO refresh_tokens (token_hash, user_id, client_id, family_id, scopes, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6)
+              RETURNING id, created_at`,
+		next.TokenHash, next.UserID, nullIfEmpty(next.ClientID), next.FamilyID, next.Scopes, next.ExpiresAt,
+	).Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("insert refresh token: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("commit rotation: %w", err)
+	}
+	return old, true, nil
+}
+
+func scanRefreshToken(row pgx.Row) (*model.RefreshToken, error) {
 	var rt model.RefreshToken
 	var clientID *string
-
-	err := s.pool.QueryRow(ctx, query, hash).Scan(
+	err := row.Scan(
 		&rt.ID,
 		&rt.TokenHash,
 		&rt.UserID,
@@ -52,18 +156,14 @@ func (s *Storage) GetByHash(ctx context.Context, hash string) (*model.RefreshTok
 		&rt.Revoked,
 		&rt.ExpiresAt,
 		&rt.CreatedAt,
+		&rt.RotatedAt,
+		&rt.SealedSuccessor,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domainerrors.ErrInvalidToken
-		}
-		return nil, fmt.Errorf("select refresh token by hash: %w", err)
-	}
-
-	if clientID != nil {
-		rt.ClientID = *clientID
+		return nil, err //nolint:wrapcheck // callers wrap with query context
 	}
 
+	rt.ClientID = deref(clientID)
 	return &rt, nil
 }
 
@@ -105,3 +205,17 @@ func (s *Storage) RevokeByUserID(ctx context.Context, userID string) error {
 	}
 	return nil
 }
+
+// RevokeFamiliesExcept revokes the user's refresh tokens outside the family
+// keepFamilyID.
+func (s *Storage) RevokeFamiliesExcept(ctx context.Context, userID, keepFamilyID string) error {
+	query := `UPDATE refresh_tokens
+              SET revoked = true
+              WHERE user_id = $1 AND revoked = false AND family_id <> $2`
+
+	_, err := s.pool.Exec(ctx, query, userID, keepFamilyID)
+	if err != nil {
+		return fmt.Errorf("revoke refresh token families: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// roleColumns selects a role with its permission names. It expects roles
+// aliased as r.
+const roleColumns = `r.id, r.name, r.description, r.client_id, r.created_at,
+              COALESCE((SELECT array_agg(p.name ORDER BY p.name)
+                        FROM role_permissions rp
+                        JOIN permissions p ON p.id = rp.permission_id
+                        WHERE rp.role_id = r.id), '{}')`
+
+// CreateRole stores role together with its permissions, which must exist.
+func (s *Storage) CreateRole(ctx context.Context, role *model.Role) (err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	query := `INSERT INTO roles (name, description, client_id)
+              VALUES ($1, $2, $3)
+              RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query, role.Name, role.Description, nullIfEmpty(role.ClientID)).
+		Scan(&role.ID, &role.CreatedAt)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok {
+			switch pgErr.Code {
+			case "23505":
+				return domainerrors.ErrRoleAlreadyExists
+			case "23503":
+				return domainerrors.ErrClientNotFound
+			}
+		}
+		return fmt.Errorf("insert role: %w", err)
+	}
+
+	if err = setRolePermissions(ctx, tx, role.ID, role.Permissions); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit role: %w", err)
+	}
+	return nil
+}
+
+// ListRoles returns every role, global roles first.
+func (s *Storage) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	query := `SELECT ` + roleColumns + `
+              FROM roles r
+              ORDER BY r.client_id NULLS FIRST, r.name`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("select roles: %w", err)
+	}
+	return collectRoles(rows)
+}
+
+func (s *Storage) GetRole(ctx context.Context, id string) (*model.Role, error) {
+	role, err := scanRole(s.pool.QueryRow(ctx, `SELECT `+roleColumns+` FROM roles r WHERE r.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("select role: %w", err)
+	}
+	return role, nil
+}
+
+// DeleteRole removes the role and, through ON DELETE CASCADE, its
+// assignments.
+func (s *Storage) DeleteRole(ctx context.Context, id string) error {
+	result, err := s.pool.Exec(ctx, `DELETE FROM roles WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrRoleNotFound
+	}
+	return nil
+}
+
+// SetRolePermissions replaces the permissions of the role.
+func (s *Storage) SetRolePermissions(ctx context.Context, roleID string, permissions []string) (err error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var id string
+	err = tx.QueryRow(ctx, `SELECT id FROM roles WHERE id = $1 FOR UPDATE`, roleID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domainerrors.ErrRoleNotFound
+		}
+		return fmt.Errorf("lock role: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("delete role permissions: %w", err)
+	}
+	if err = setRolePermissions(ctx, tx, roleID, permissions); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit role permissions: %w", err)
+	}
+	return nil
+}
+
+// setRolePermissions adds the named permissions to the role. The caller
+// passes names without duplicates.
+func setRolePermissions(ctx context.Context, tx pgx.Tx, roleID string, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO role_permissions (role_id, permission_id)
+              SELECT $1, id FROM permissions WHERE name = ANY($2)`
+
+	result, err := tx.Exec(ctx, query, roleID, permissions)
+	if err != nil {
+		return fmt.Errorf("insert role permissions: %w", err)
+	}
+	if result.RowsAffected() != int64(len(permissions)) {
+		return domainerrors.ErrPermissionNotFound
+	}
+	return nil
+}
+
+func (s *Storage) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	query := `INSERT INTO permissions (name, description)
+              VALUES ($1, $2)
+              RETURNING id, created_at`
+
+	err := s.pool.QueryRow(ctx, query, permission.Name, permission.Description).
+		Scan(&permission.ID, &permission.CreatedAt)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrPermissionAlreadyExists
+		}
+		return fmt.Errorf("insert permission: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	rows, err := s.pool.Query(ctx, `SELECT id, name, description, created_at FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("select permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []*model.Permission
+	for rows.Next() {
+		var p model.Permission
+		if err = rows.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan permission: %w", err)
+		}
+		permissions = append(permissions, &p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// DeletePermission removes the permission from every role that has it.
+func (s *Storage) DeletePermission(ctx context.Context, id string) error {
+	result, err := s.pool.Exec(ctx, `DELETE FROM permissions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete permission: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrPermissionNotFound
+	}
+	return nil
+}
+
+// ListRolesByUserID returns the roles assigned to the user.
+func (s *Storage) ListRolesByUserID(ctx context.Context, userID string) ([]*model.Role, error) {
+	query := `SELECT ` + roleColumns + `
+              FROM user_roles ur
+              JOIN roles r ON r.id = ur.role_id
+              WHERE ur.user_id = $1
+              ORDER BY r.client_id NULLS FIRST, r.name`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select user roles: %w", err)
+	}
+	return collectRoles(rows)
+}
+
+// AssignRole gives the user the role. Assigning a role twice is a no-op.
+func (s *Storage) AssignRole(ctx context.Context, userID, roleID string) error {
+	query := `INSERT INTO user_roles (user_id, role_id)
+              VALUES ($1, $2)
+              ON CONFLICT (user_id, role_id) DO NOTHING`
+
+	if _, err := s.pool.Exec(ctx, query, userID, roleID); err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23503" {
+			if pgErr.ConstraintName == "user_roles_user_id_fkey" {
+				return domainerrors.ErrUserNotFound
+			}
+			return domainerrors.ErrRoleNotFound
+		}
+		return fmt.Errorf("assign role: %w", err)
+	}
+	return nil
+}
+
+// UnassignRole takes the role away from the user. Removing a role the user
+// does not have is a no-op.
+func (s *Storage) UnassignRole(ctx context.Context, userID, roleID string) error {
+	query := `DELETE FROM user_roles
+              WHERE user_id = $1 AND role_id = $2`
+
+	if _, err := s.pool.Exec(ctx, query, userID, roleID); err != nil {
+		return fmt.Errorf("unassign role: %w", err)
+	}
+	return nil
+}
+
+// GetAuthorization returns the user's tenant and the names of the user's
+// roles and permissions that apply to clientID: global roles plus roles of
+// that client. An empty clientID selects global roles only.
+func (s *Storage) GetAuthorization(ctx context.Context, userID, clientID string) (*model.Authorization, error) {
+	query := `SELECT
+                  t.slug,
+                  COALESCE(array_agg(DISTINCT r.name) FILTER (WHERE r.name IS NOT NULL), '{}'),
+                  COALESCE(array_agg(DISTINCT p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+              FROM users u
+              JOIN tenants t ON t.id = u.tenant_id
+              LEFT JOIN user_roles ur ON ur.user_id = u.id
+              LEFT JOIN roles r ON r.id = ur.role_id
+                  AND (r.client_id IS NULL OR r.client_id = $2::uuid)
+              LEFT JOIN role_permissions rp ON rp.role_id = r.id
+              LEFT JOIN permissions p ON p.id = rp.permission_id
+              WHERE u.id = $1
+              GROUP BY t.slug`
+
+	var authz model.Authorization
+	err := s.pool.QueryRow(ctx, query, userID, nullIfEmpty(clientID)).
+		Scan(&authz.Tenant, &authz.Roles, &authz.Permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("select authorization: %w", err)
+	}
+	return &authz, nil
+}
+
+// HasPermission reports whether an active user holds permission for
+// clientID, following the same rules as GetAuthorization.
+func (s *Storage) HasPermission(ctx context.Context, userID, clientID, permission string) (bool, error) {
+	query := `SELECT EXISTS (
+                  SELECT 1
+                  FROM users u
+                  JOIN user_roles ur ON ur.user_id = u.id
+                  JOIN roles r ON r.id = ur.role_id
+                  JOIN role_permissions rp ON rp.role_id = r.id
+                  JOIN permissions p ON p.id = rp.permission_id
+                  WHERE u.id = $1 AND u.status = 'active'
+                    AND (r.client_id IS NULL OR r.client_id = $2::uuid)
+                    AND p.name = $3
+              )`
+
+	var allowed bool
+	if err := s.pool.QueryRow(ctx, query, userID, nullIfEmpty(clientID), permission).Scan(&allowed); err != nil {
+		return false, fmt.Errorf("check permission: %w", err)
+	}
+	return allowed, nil
+}
+
+// GrantRoleByEmail assigns the global role to the user with email in the
+// tenant with tenantSlug. Granting a role the user already has is a no-op.
+func (s *Storage) GrantRoleByEmail(ctx context.Context, tenantSlug, email, role string) error {
+	query := `INSERT INTO user_roles (user_id, role_id)
+              SELECT u.id, r.id
+              FROM users u
+              JOIN tenants t ON t.id = u.tenant_id
+              CROSS JOIN roles r
+              WHERE t.slug = $1 AND u.email = $2 AND r.name = $3 AND r.client_id IS NULL
+              ON CONFLICT (user_id, role_id) DO NOTHING`
+
+	result, err := s.pool.Exec(ctx, query, tenantSlug, email, role)
+	if err != nil {
+		return fmt.Errorf("grant role: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		// Either the user does not exist or already has the role.
+		var exists bool
+		err = s.pool.QueryRow(ctx, `SELECT EXISTS (
+                                        SELECT 1 FROM users u JOIN tenants t ON t.id = u.tenant_id
+                                        WHERE t.slug = $1 AND u.email = $2
+                                    )`, tenantSlug, email).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("select user by email: %w", err)
+		}
+		if !exists {
+			return domainerrors.ErrUserNotFound
+		}
+	}
+	return nil
+}
+
+func collectRoles(rows pgx.Rows) ([]*model.Role, error) {
+	defer rows.Close()
+
+	var roles []*model.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate roles: %w", err)
+	}
+	return roles, nil
+}
+
+func scanRole(row pgx.Row) (*model.Role, error) {
+	var role model.Role
+	var clientID *string
+	err := row.Scan(&role.ID, &role.Name, &role.Description, &clientID, &role.CreatedAt, &role.Permissions)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+	if clientID != nil {
+		role.ClientID = *clientID
+	}
+	return &role, nil
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const scimTokenColumns = `id, tenant_id, name, token_hash, created_at, last_used_at`
+
+func (s *Storage) CreateSCIMToken(ctx context.Context, token *model.SCIMToken) error {
+	query := `INSERT INTO scim_tokens (tenant_id, name, token_hash)
+              VALUES ($1, $2, $3)
+              RETURNING id, created_at`
+
+	err := s.pool.QueryRow(ctx, query, token.TenantID, token.Name, token.TokenHash).
+		Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert scim token: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListSCIMTokens(ctx context.Context, tenantID string) ([]*model.SCIMToken, error) {
+	query := `SELECT ` + scimTokenColumns + ` FROM scim_tokens WHERE tenant_id = $1 ORDER BY created_at`
+
+	rows, err := s.pool.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("select scim tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.SCIMToken
+	for rows.Next() {
+		token, err := scanSCIMToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan scim token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate scim tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *Storage) DeleteSCIMToken(ctx context.Context, tenantID, id string) error {
+	result, err := s.pool.Exec(ctx, `DELETE FROM scim_tokens WHERE id = $1 AND tenant_id = $2`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("delete scim token: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrSCIMTokenNotFound
+	}
+	return nil
+}
+
+// UseSCIMToken returns the token with tokenHash and records that it was used.
+func (s *Storage) UseSCIMToken(ctx context.Context, tokenHash string) (*model.SCIMToken, error) {
+	query := `UPDATE scim_tokens SET last_used_at = now()
+              WHERE token_hash = $1
+              RETURNING ` + scimTokenColumns
+
+	token, err := scanSCIMToken(s.pool.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrSCIMTokenNotFound
+		}
+		return nil, fmt.Errorf("use scim token: %w", err)
+	}
+	return token, nil
+}
+
+func scanSCIMToken(row pgx.Row) (*model.SCIMToken, error) {
+	var token model.SCIMToken
+	err := row.Scan(&token.ID, &token.TenantID, &token.Name, &token.TokenHash, &token.CreatedAt, &token.LastUsedAt)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+	return &token, nil
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const sessionColumns = `s.id, s.user_id, s.client_id, COALESCE(c.name, ''), s.user_agent, s.ip, s.created_at, s.last_refreshed_at`
+
+func (s *Storage) CreateSession(ctx context.Context, session *model.Session) error {
+	query := `INSERT INTO sessions (id, user_id, client_id, user_agent, ip)
+              VALUES ($1, $2, $3, $4, $5)
+              RETURNING created_at, last_refreshed_at`
+
+	err := s.pool.QueryRow(ctx, query,
+		session.ID,
+		session.UserID,
+		nullIfEmpty(session.ClientID),
+		session.UserAgent,
+		session.IP,
+	).Scan(&session.CreatedAt, &session.LastRefreshedAt)
+	if err != nil {
+		return fmt.Errorf("insert session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession records that the session rotated its refresh token.
+func (s *Storage) TouchSession(ctx context.Context, id string) error {
+	query := `UPDATE sessions SET last_refreshed_at = now() WHERE id = $1`
+
+	if _, err := s.pool.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("touch session: %w", err)
+	}
+	return nil
+}
+
+// GetSession returns the session with id, whether or not it is still active.
+func (s *Storage) GetSession(ctx context.Context, id string) (*model.Session, error) {
+	query := `SELECT ` + sessionColumns + `
+              FROM sessions s
+              LEFT JOIN oauth_clients c ON c.id = s.client_id
+              WHERE s.id = $1`
+
+	session, err := scanSession(s.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("select session: %w", err)
+	}
+	return session, nil
+}
+
+// ListActiveSessions returns the user's sessions that still hold a usable
+// refresh token, most recently refreshed first.
+func (s *Storage) ListActiveSessions(ctx context.Context, userID string) ([]*model.Session, error) {
+	query := `SELECT ` + sessionColumns + `
+              FROM sessions s
+              LEFT JOIN oauth_clients c ON c.id = s.client_id
+              WHERE s.user_id = $1
+                AND EXISTS (
+                    SELECT 1 FROM refresh_tokens t
+                    WHERE t.family_id = s.id AND t.revoked = false AND t.expires_at > now()
+                )
+              ORDER BY s.last_refreshed_at DESC`
+
+	rows, err := s.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("select sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+func scanSession(row pgx.Row) (*model.Session, error) {
+	var session model.Session
+	var clientID *string
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&clientID,
+		&session.ClientName,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastRefreshedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+
+	session.ClientID = deref(clientID)
+	return &session, nil
+}
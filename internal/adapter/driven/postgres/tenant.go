@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const selectTenantColumns = `SELECT id, slug, name, host, display_name, logo_url, primary_color,
+              created_at, updated_at FROM tenants`
+
+func (s *Storage) CreateTenant(ctx context.Context, tenant *model.Tenant) error {
+	query := `INSERT INTO tenants (slug, name, host, display_name, logo_url, primary_color)
+              VALUES ($1, $2, $3, $4, $5, $6)
+              RETURNING id, created_at, updated_at`
+
+	err := s.pool.QueryRow(ctx, query,
+		tenant.Slug,
+		tenant.Name,
+		nullIfEmpty(tenant.Host),
+		tenant.Branding.DisplayName,
+		tenant.Branding.LogoURL,
+		tenant.Branding.PrimaryColor,
+	).Scan(&tenant.ID, &tenant.CreatedAt, &tenant.UpdatedAt)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrTenantAlreadyExists
+		}
+		return fmt.Errorf("insert tenant: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListTenants(ctx context.Context) ([]*model.Tenant, error) {
+	rows, err := s.pool.Query(ctx, selectTenantColumns+` ORDER BY slug`)
+	if err != nil {
+		return nil, fmt.Errorf("select tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*model.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+func (s *Storage) GetTenant(ctx context.Context, id string) (*model.Tenant, error) {
+	return s.getTenant(ctx, `id`, id)
+}
+
+func (s *Storage) GetTenantBySlug(ctx context.Context, slug string) (*model.Tenant, error) {
+	return s.getTenant(ctx, `slug`, slug)
+}
+
+func (s *Storage) GetTenantByHost(ctx context.Context, host string) (*model.Tenant, error) {
+	return s.getTenant(ctx, `host`, host)
+}
+
+func (s *Storage) getTenant(ctx context.Context, column, value string) (*model.Tenant, error) {
+	tenant, err := scanTenant(s.pool.QueryRow(ctx, selectTenantColumns+` WHERE `+column+` = $1`, value))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrTenantNotFound
+		}
+		return nil, fmt.Errorf("select tenant by %s: %w", column, err)
+	}
+	return tenant, nil
+}
+
+// UpdateTenant saves the name, host and branding of tenant.
+func (s *Storage) UpdateTenant(ctx context.Context, tenant *model.Tenant) error {
+	query := `UPDATE tenants
+              SET name = $2, host = $3, display_name = $4, logo_url = $5, primary_color = $6,
+                  updated_at = now()
+              WHERE id = $1
+              RETURNING updated_at`
+
+	err := s.pool.QueryRow(ctx, query,
+		tenant.ID,
+		tenant.Name,
+		nullIfEmpty(tenant.Host),
+		tenant.Branding.DisplayName,
+		tenant.Branding.LogoURL,
+		tenant.Branding.PrimaryColor,
+	).Scan(&tenant.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domainerrors.ErrTenantNotFound
+		}
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrTenantAlreadyExists
+		}
+		return fmt.Errorf("update tenant: %w", err)
+	}
+	return nil
+}
+
+func scanTenant(row pgx.Row) (*model.Tenant, error) {
+	var tenant model.Tenant
+	var host *string
+	err := row.Scan(
+		&tenant.ID,
+		&tenant.Slug,
+		&tenant.Name,
+		&host,
+		&tenant.Branding.DisplayName,
+		&tenant.Branding.LogoURL,
+		&tenant.Branding.PrimaryColor,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+	tenant.Host = deref(host)
+	return &tenant, nil
+}
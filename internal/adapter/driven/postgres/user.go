@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -13,16 +15,26 @@ import (
 )
 
 func (s *Storage) Create(ctx context.Context, user *model.User) error {
-	query := `INSERT INTO users(email, password_hash, email_verified, mfa_enabled, status)
-              VALUES ($1, $2, $3, $4, $5)
+	query := `INSERT INTO users(tenant_id, email, external_id, password_hash, email_verified, mfa_enabled, status,
+                                  display_name, locale, timezone)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
               RETURNING id, created_at, updated_at`
 
+	var passwordHash any
+	if user.PasswordHash != "" {
+		passwordHash = user.PasswordHash
+	}
 	err := s.pool.QueryRow(ctx, query,
+		user.TenantID,
 		user.Email,
-		user.PasswordHash,
+		nullIfEmpty(user.ExternalID),
+		passwordHash,
 		user.EmailVerified,
 		user.MFAEnabled,
 		string(user.Status),
+		user.Profile.DisplayName,
+		user.Profile.Locale,
+		user.Profile.Timezone,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
@@ -33,33 +45,88 @@ func (s *Storage) Create(ctx context.Context, user *model.User) error {
 	return nil
 }
 
-func (s *Storage) GetByEmail(ctx context.Context, email string) (*model.User, error) {
-	query := `SELECT id, email, password_hash, email_verified, mfa_enabled, 
-              mfa_secret_enc, status, created_at, updated_at
-              FROM users
-              WHERE email = $1`
+const userColumns = `id, tenant_id, email, external_id, password_hash, email_verified, mfa_enabled,
+              mfa_secret_enc, status, display_name, locale, timezone, avatar_url,
+              password_reset_required, deleted_at, created_at, updated_at`
+
+const selectUserColumns = `SELECT ` + userColumns + ` FROM users`
+
+// GetByEmail returns the user with email in the tenant. The same email may
+// belong to different users in other tenants.
+func (s *Storage) GetByEmail(ctx context.Context, tenantID, email string) (*model.User, error) {
+	query := selectUserColumns + ` WHERE tenant_id = $1 AND email = $2`
+	user, err := scanUser(s.pool.QueryRow(ctx, query, tenantID, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("select user by email: %w", err)
+	}
+	return user, nil
+}
 
+func (s *Storage) GetByID(ctx context.Context, id string) (*model.User, error) {
+	user, err := scanUser(s.pool.QueryRow(ctx, selectUserColumns+` WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domainerrors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("select user by id: %w", err)
+	}
+	return user, nil
+}
+
+func (s *Storage) GetByIDs(ctx context.Context, ids []string) ([]*model.User, error) {
+	rows, err := s.pool.Query(ctx, selectUserColumns+` WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("select users by ids: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*model.User, 0, len(ids))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, nil
+}
+
+func scanUser(row pgx.Row) (*model.User, error) {
 	var user model.User
+	var externalID, passwordHash *string
 	var status string
 
-	err := s.pool.QueryRow(ctx, query, email).Scan(
+	err := row.Scan(
 		&user.ID,
+		&user.TenantID,
 		&user.Email,
-		&user.PasswordHash,
+		&externalID,
+		&passwordHash,
 		&user.EmailVerified,
 		&user.MFAEnabled,
 		&user.MFASecretEnc,
 		&status,
+		&user.Profile.DisplayName,
+		&user.Profile.Locale,
+		&user.Profile.Timezone,
+		&user.Profile.AvatarURL,
+		&user.PasswordResetRequired,
+		&user.DeletedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, domainerrors.ErrUserNotFound
-		}
-		return nil, fmt.Errorf("select user by email: %w", err)
+		return nil, err //nolint:wrapcheck // callers wrap with query context
 	}
 
+	user.ExternalID = deref(externalID)
+	user.PasswordHash = deref(passwordHash)
 	user.Status = model.UserStatus(status)
 	return &user, nil
 }
@@ -79,9 +146,17 @@ func (s *Storage) UpdateEmailVerified(ctx context.Context, userID string, verifi
 	return nil
 }
 
+// UpdatePassword stores a new password hash, which lifts a required password
+// reset. The replaced hash, if any, is kept in the password history.
 func (s *Storage) UpdatePassword(ctx context.Context, userID, passwordHash string) error {
-	query := `UPDATE users
-              SET password_hash = $2, updated_at = now()
+	query := `WITH old AS (
+                  SELECT id, password_hash FROM users WHERE id = $1 FOR UPDATE
+              ), archived AS (
+                  INSERT INTO password_history(user_id, password_hash)
+                  SELECT id, password_hash FROM old WHERE password_hash IS NOT NULL
+              )
+              UPDATE users
+              SET password_hash = $2, password_reset_required = FALSE, updated_at = now()
               WHERE id = $1`
 
 	result, err := s.pool.Exec(ctx, query, userID, passwordHash)
@@ -93,3 +168,393 @@ func (s *Storage) UpdatePassword(ctx context.Context, userID, passwordHash strin
 	}
 	return nil
 }
+
+// ReplacePasswordHash swaps oldHash for newHash, a new hash of the same
+// password, leaving the password history alone. It does nothing if the
+// password changed since oldHash was read.
+func (s *Storage) ReplacePasswordHash(ctx context.Context, userID, oldHash, newHash string) error {
+	query := `UPDATE users
+              SET password_hash = $3, updated_at = now()
+              WHERE id = $1 AND password_hash = $2`
+
+	if _, err := s.pool.Exec(ctx, query, userID, oldHash, newHash); err != nil {
+		return fmt.Errorf("replace password hash: %w", err)
+	}
+	return nil
+}
+
+// RequirePasswordReset blocks password logins of the user until the password
+// is reset.
+func (s *Storage) RequirePasswordReset(ctx context.Context, userID string) error {
+	query := `UPDATE users
+              SET password_reset_required = TRUE, updated_at = now()
+              WHERE id = $1`
+
+	result, err := s.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("require password reset: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrUserNotFound
+	}
+	return nil
+}
+
+// GroupPasswordHashes groups the stored password hashes by everything but
+// their last two "$"-separated fields, which for argon2id are the salt and the
+// hash, leaving the algorithm and cost parameters.
+func (s *Storage) GroupPasswordHashes(ctx context.Context) ([]*model.PasswordHashGroup, error) {
+	query := `SELECT min(password_hash), count(*)
+              FROM users
+              WHERE password_hash IS NOT NULL
+              GROUP BY regexp_replace(password_hash, '\$[^$]*\$[^$]*$', '')`
+
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query password hashes: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*model.PasswordHashGroup
+	for rows.Next() {
+		var g model.PasswordHashGroup
+		if err = rows.Scan(&g.Sample, &g.Count); err != nil {
+			return nil, fmt.Errorf("scan password hash group: %w", err)
+		}
+		groups = append(groups, &g)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate password hash groups: %w", err)
+	}
+	return groups, nil
+}
+
+// UpdateEmail moves the user to email, which the caller has verified.
+func (s *Storage) UpdateEmail(ctx context.Context, userID, email string) error {
+	query := `UPDATE users
+              SET email = $2, email_verified = true, updated_at = now()
+              WHERE id = $1`
+
+	result, err := s.pool.Exec(ctx, query, userID, email)
+	if err != nil {
+		if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+			return domainerrors.ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("update email: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateProfile stores profile for the user, provided the row has not changed
+// since unmodifiedSince. The new updated_at always moves forward, so it can
+// serve as a version for optimistic concurrency.
+func (s *Storage) UpdateProfile(
+	ctx context.Context,
+	userID string,
+	profile model.Profile,
+	unmodifiedSince time.Time,
+) (*model.User, error) {
+	query := `UPDATE users
+              SET display_name = $2, locale = $3, timezone = $4, avatar_url = $5,
+                  updated_at = greatest(now(), updated_at + interval '1 microsecond')
+              WHERE id = $1 AND updated_at = $6
+              RETURNING ` + userColumns
+
+	user, err := scanUser(s.pool.QueryRow(ctx, query,
+		userID,
+		profile.DisplayName,
+		profile.Locale,
+		profile.Timezone,
+		profile.AvatarURL,
+		unmodifiedSince,
+	))
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("update profile: %w", err)
+	}
+
+	// Nothing matched: tell a missing user apart from a stale version.
+	if _, err = s.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return nil, domainerrors.ErrPreconditionFailed
+}
+
+// ReplaceUser stores the email, external id and profile of user, provided the
+// row has not changed since unmodifiedSince. A changed email counts as
+// verified: the caller vouches for it.
+func (s *Storage) ReplaceUser(ctx context.Context, user *model.User, unmodifiedSince time.Time) (*model.User, error) {
+	query := `UPDATE users
+              SET email_verified = email_verified OR email <> $2,
+                  email = $2, external_id = $3,
+                  display_name = $4, locale = $5, timezone = $6, avatar_url = $7,
+                  updated_at = greatest(now(), updated_at + interval '1 microsecond')
+              WHERE id = $1 AND updated_at = $8
+              RETURNING ` + userColumns
+
+	updated, err := scanUser(s.pool.QueryRow(ctx, query,
+		user.ID,
+		user.Email,
+		nullIfEmpty(user.ExternalID),
+		user.Profile.DisplayName,
+		user.Profile.Locale,
+		user.Profile.Timezone,
+		user.Profile.AvatarURL,
+		unmodifiedSince,
+	))
+	if err == nil {
+		return updated, nil
+	}
+	if pgErr, ok := errors.AsType[*pgconn.PgError](err); ok && pgErr.Code == "23505" {
+		return nil, domainerrors.ErrEmailAlreadyExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("replace user: %w", err)
+	}
+
+	if _, err = s.GetByID(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return nil, domainerrors.ErrPreconditionFailed
+}
+
+// DeleteUser hard-deletes the user and their identity_events at once,
+// leaving an anonymous row in account_deletions like the purge of
+// self-service deletions.
+func (s *Storage) DeleteUser(ctx context.Context, userID string) error {
+	query := `WITH deleted AS (
+                  DELETE FROM users WHERE id = $1 RETURNING id
+              ), events AS (
+                  DELETE FROM identity_events
+                  WHERE user_id IN (SELECT id FROM deleted)
+              )
+              INSERT INTO account_deletions (requested_at)
+              SELECT now() FROM deleted`
+
+	result, err := s.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrUserNotFound
+	}
+	return nil
+}
+
+// MarkDeleted starts the deletion grace period for an active user.
+func (s *Storage) MarkDeleted(ctx context.Context, userID string) error {
+	query := `UPDATE users
+              SET status = 'deleted', deleted_at = now(), updated_at = now()
+              WHERE id = $1 AND status = 'active'`
+
+	result, err := s.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("mark user deleted: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrUserNotFound
+	}
+	return nil
+}
+
+// RestoreDeleted reactivates a user whose deletion was requested after
+// deletedAfter. It reports false if the user is not in that state.
+func (s *Storage) RestoreDeleted(ctx context.Context, userID string, deletedAfter time.Time) (bool, error) {
+	query := `UPDATE users
+              SET status = 'active', deleted_at = NULL, updated_at = now()
+              WHERE id = $1 AND status = 'deleted' AND deleted_at > $2`
+
+	result, err := s.pool.Exec(ctx, query, userID, deletedAfter)
+	if err != nil {
+		return false, fmt.Errorf("restore user: %w", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+// PurgeDeleted hard-deletes up to limit users whose deletion was requested
+// before deletedBefore and returns them with only ID and TenantID set.
+// Refresh tokens and identities go with the row through ON DELETE CASCADE and
+// the user's identity_events are deleted in the same statement; each purge
+// leaves an anonymous row in account_deletions.
+func (s *Storage) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) ([]*model.User, error) {
+	query := `WITH purged AS (
+                  DELETE FROM users
+                  WHERE id IN (
+                      SELECT id FROM users
+                      WHERE status = 'deleted' AND deleted_at < $1
+                      ORDER BY deleted_at
+                      LIMIT $2
+                      FOR UPDATE SKIP LOCKED
+                  )
+                  RETURNING id, tenant_id, deleted_at
+              ), audit AS (
+                  INSERT INTO account_deletions (requested_at)
+                  SELECT deleted_at FROM purged
+              ), events AS (
+                  DELETE FROM identity_events
+                  WHERE user_id IN (SELECT id FROM purged)
+              )
+              SELECT id, tenant_id FROM purged`
+
+	rows, err := s.pool.Query(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("purge deleted users: %w", err)
+	}
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.User, error) {
+		var u model.User
+		err := row.Scan(&u.ID, &u.TenantID)
+		return &u, err //nolint:wrapcheck // callers wrap with query context
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect purged users: %w", err)
+	}
+	return users, nil
+}
+
+// SearchUsers returns up to limit users matching filter, newest first,
+// starting after the given cursor (nil for the first page).
+func (s *Storage) SearchUsers(
+	ctx context.Context,
+	filter model.UserFilter,
+	after *model.UserCursor,
+	limit int,
+) ([]*model.User, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	conds := userFilterConds(filter, arg)
+	if after != nil {
+		conds = append(conds, `(created_at, id) < (`+arg(after.CreatedAt)+`, `+arg(after.ID)+`::uuid)`)
+	}
+
+	query := selectUserColumns + ` WHERE ` + strings.Join(conds, ` AND `) + ` ORDER BY created_at DESC, id DESC LIMIT ` + arg(limit)
+
+	users, err := s.queryUsers(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search users: %w", err)
+	}
+	return users, nil
+}
+
+// ListUsers returns up to limit users matching filter, oldest first, after
+// skipping offset of them, together with the number of all matching users.
+func (s *Storage) ListUsers(
+	ctx context.Context,
+	filter model.UserFilter,
+	offset, limit int,
+) ([]*model.User, int, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := ` WHERE ` + strings.Join(userFilterConds(filter, arg), ` AND `)
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT count(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count users: %w", err)
+	}
+	if limit == 0 || offset >= total {
+		return []*model.User{}, total, nil
+	}
+
+	query := selectUserColumns + where + ` ORDER BY created_at, id OFFSET ` + arg(offset) + ` LIMIT ` + arg(limit)
+	users, err := s.queryUsers(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	return users, total, nil
+}
+
+func userFilterConds(filter model.UserFilter, arg func(v any) string) []string {
+	conds := []string{`tenant_id = ` + arg(filter.TenantID)}
+
+	if filter.Email != "" {
+		conds = append(conds, `email = `+arg(filter.Email))
+	}
+	if filter.EmailPrefix != "" {
+		conds = append(conds, `email LIKE `+arg(escapeLike(filter.EmailPrefix)+"%"))
+	}
+	if filter.ExternalID != "" {
+		conds = append(conds, `external_id = `+arg(filter.ExternalID))
+	}
+	if filter.Status != "" {
+		conds = append(conds, `status = `+arg(string(filter.Status)))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conds = append(conds, `created_at >= `+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conds = append(conds, `created_at < `+arg(filter.CreatedBefore))
+	}
+	if filter.MFAEnabled != nil {
+		conds = append(conds, `mfa_enabled = `+arg(*filter.MFAEnabled))
+	}
+	return conds
+}
+
+func (s *Storage) queryUsers(ctx context.Context, query string, args ...any) ([]*model.User, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // callers wrap with query context
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return users, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// UpdateStatus moves the user from one status to another. It returns
+// domainerrors.ErrInvalidUserStatus if the user is not in status from.
+func (s *Storage) UpdateStatus(ctx context.Context, userID string, from, to model.UserStatus) error {
+	query := `UPDATE users
+              SET status = $3, updated_at = now()
+              WHERE id = $1 AND status = $2`
+
+	result, err := s.pool.Exec(ctx, query, userID, string(from), string(to))
+	if err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrInvalidUserStatus
+	}
+	return nil
+}
+
+// ResetMFA turns MFA off and discards the enrolled secret.
+func (s *Storage) ResetMFA(ctx context.Context, userID string) error {
+	query := `UPDATE users
+              SET mfa_enabled = false, mfa_secret_enc = NULL, updated_at = now()
+              WHERE id = $1`
+
+	result, err := s.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("reset mfa: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return domainerrors.ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build integration
+
+package postgres
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// newTestStorage connects to the migrated database in SSO_TEST_POSTGRES_DSN.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	dsn := os.Getenv("SSO_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("SSO_TEST_POSTGRES_DSN is not set")
+	}
+	s, err := New(t.Context(), &Config{DSN: dsn, MaxConns: 2}, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestStorage_PurgeDeleted_RemovesEvents(t *testing.T) {
+	ctx := t.Context()
+	s := newTestStorage(t)
+
+	var userID, tenantID string
+	err := s.pool.QueryRow(ctx, `INSERT INTO users (tenant_id, email, status, deleted_at)
+                                 SELECT id, 'purge-' || gen_random_uuid() || '@example.com', 'deleted', now() - interval '1 hour'
+                                 FROM tenants WHERE slug = 'default'
+                                 RETURNING id, tenant_id`).Scan(&userID, &tenantID)
+	require.NoError(t, err)
+
+	for _, eventType := range []model.EventType{model.EventUserRegistered, model.EventUserBlocked} {
+		event := model.NewEvent(eventType, tenantID, userID)
+		event.Data["reason"] = "abuse"
+		require.NoError(t, s.AppendEvent(ctx, event))
+	}
+
+	purged, err := s.PurgeDeleted(ctx, time.Now(), 100)
+	require.NoError(t, err)
+	assert.Contains(t, purged, &model.User{ID: userID, TenantID: tenantID})
+
+	var left int
+	err = s.pool.QueryRow(ctx, `SELECT count(*) FROM identity_events WHERE user_id = $1`, userID).Scan(&left)
+	require.NoError(t, err)
+	assert.Zero(t, left)
+}
@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// gcraScript implements the generic cell rate algorithm. The key holds the
+// theoretical arrival time (TAT) in microseconds; a request is allowed when
+// admitting it keeps the TAT within burst*interval of now. Time comes from
+// the Redis server so that all instances share one clock.
+//
+// KEYS[1] - limiter key
+// ARGV[1] - burst (max requests per window)
+// ARGV[2] - emission interval in microseconds (window / burst)
+//
+// Returns {allowed, remaining, retry_after_us, reset_after_us}.
+var gcraScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if tat == nil or tat < now then
+  tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - burst * interval
+local diff = now - allow_at
+
+if diff < 0 then
+  return {0, 0, -diff, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor(diff / interval), 0, new_tat - now}
+`)
+
+type RateLimiter struct {
+	client *redis.Client
+}
+
+func NewRateLimiter(c *Cache) *RateLimiter {
+	return &RateLimiter{client: c.client}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (*model.RateLimitResult, error) {
+	if limit.MaxAttempts <= 0 || limit.Window <= 0 {
+		return &model.RateLimitResult{Allowed: true}, nil
+	}
+
+	interval := limit.Window.Microseconds() / int64(limit.MaxAttempts)
+
+	res, err := gcraScript.Run(ctx, l.client, []string{key}, limit.MaxAttempts, interval).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("redis rate limit %q: %w", key, err)
+	}
+
+	return &model.RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      limit.MaxAttempts,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+		ResetAfter: time.Duration(res[3]) * time.Microsecond,
+	}, nil
+}
@@ -86,3 +86,25 @@ func (c *Cache) Delete(ctx context.Context, key string) error {
 	}
 	return nil
 }
+
+func (c *Cache) GetDel(ctx context.Context, key string) (string, error) {
+	val, err := c.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", domainerrors.ErrKeyNotFound
+		}
+		return "", fmt.Errorf("redis getdel %q: %w", key, err)
+	}
+	return val, nil
+}
+
+// Incr increments the counter at key and pushes its expiry out to ttl.
+func (c *Cache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := c.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("redis incr %q: %w", key, err)
+	}
+	return incr.Val(), nil
+}
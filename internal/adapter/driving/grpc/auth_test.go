@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/grpc/mocks"
+	ssov1 "github.com/sanchey92/sso/pkg/api/sso/v1"
+)
+
+// apiKey sends a key as bearer metadata over the insecure test transport.
+type apiKey string
+
+func (k apiKey) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{authorizationHeader: "Bearer " + string(k)}, nil
+}
+
+func (apiKey) RequireTransportSecurity() bool { return false }
+
+func startAuthServer(t *testing.T, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	srv := NewServer(
+		&Config{APIKeys: []string{testAPIKey}, EventAPIKeys: []string{testEventAPIKey}},
+		NewIdentityHandler(mocks.NewTokenService(t), mocks.NewUserService(t), mocks.NewPermissionService(t), zap.NewNop()),
+		NewEventsHandler(mocks.NewEventService(t), zap.NewNop()),
+		zap.NewNop(),
+	)
+	return dialTestServerWith(t, srv, opts...)
+}
+
+func TestAuth_RejectsUnauthenticatedCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []grpc.DialOption
+	}{
+		{name: "no key"},
+		{name: "wrong key", opts: []grpc.DialOption{grpc.WithPerRPCCredentials(apiKey("wrong-key"))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := startAuthServer(t, tt.opts...)
+
+			_, err := ssov1.NewIdentityServiceClient(conn).RevokeUserSessions(t.Context(),
+				&ssov1.RevokeUserSessionsRequest{UserId: testUserID})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+			stream, err := ssov1.NewEventServiceClient(conn).WatchEvents(t.Context(), &ssov1.WatchEventsRequest{})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestAuth_EventStreamNeedsEventKey(t *testing.T) {
+	conn := startAuthServer(t, grpc.WithPerRPCCredentials(apiKey(testAPIKey)))
+
+	stream, err := ssov1.NewEventServiceClient(conn).WatchEvents(t.Context(), &ssov1.WatchEventsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuth_EventKeyDoesNotOpenIdentityService(t *testing.T) {
+	conn := startAuthServer(t, grpc.WithPerRPCCredentials(apiKey(testEventAPIKey)))
+
+	_, err := ssov1.NewIdentityServiceClient(conn).RevokeUserSessions(t.Context(),
+		&ssov1.RevokeUserSessionsRequest{UserId: testUserID})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuth_HealthCheckIsPublic(t *testing.T) {
+	conn := startAuthServer(t)
+
+	resp, err := healthpb.NewHealthClient(conn).Check(t.Context(), &healthpb.HealthCheckRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
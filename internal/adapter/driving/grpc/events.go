@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/events"
+	ssov1 "github.com/sanchey92/sso/pkg/api/sso/v1"
+)
+
+type EventService interface {
+	Watch(ctx context.Context, tenantID, cursor string, types []model.EventType, send func(*model.Event) error) error
+}
+
+type EventsHandler struct {
+	ssov1.UnimplementedEventServiceServer
+
+	svc EventService
+	log *zap.Logger
+}
+
+func NewEventsHandler(svc EventService, log *zap.Logger) *EventsHandler {
+	return &EventsHandler{svc: svc, log: log}
+}
+
+func (h *EventsHandler) WatchEvents(
+	req *ssov1.WatchEventsRequest,
+	stream grpc.ServerStreamingServer[ssov1.Event],
+) error {
+	types := make([]model.EventType, 0, len(req.GetTypes()))
+	for _, t := range req.GetTypes() {
+		mt, ok := fromProtoEventType(t)
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "unsupported event type %s", t)
+		}
+		types = append(types, mt)
+	}
+
+	// A key bound to a tenant only receives that tenant's events.
+	ctx := stream.Context()
+	err := h.svc.Watch(ctx, keyTenant(ctx), req.GetCursor(), types, func(e *model.Event) error {
+		return stream.Send(toProtoEvent(e))
+	})
+
+	switch {
+	case errors.Is(err, domainerrors.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, "invalid cursor")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case ctx.Err() != nil:
+		// send failed because the client went away.
+		return status.FromContextError(ctx.Err()).Err()
+	default:
+		h.log.Error("watch events failed", zap.Error(err))
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func toProtoEvent(e *model.Event) *ssov1.Event {
+	return &ssov1.Event{
+		Cursor:     events.Cursor(e),
+		Type:       toProtoEventType(e.Type),
+		TenantId:   e.TenantID,
+		UserId:     e.UserID,
+		Data:       e.Data,
+		OccurredAt: timestamppb.New(e.CreatedAt),
+	}
+}
+
+var eventTypes = map[model.EventType]ssov1.EventType{
+	model.EventUserRegistered: ssov1.EventType_EVENT_TYPE_USER_REGISTERED,
+	model.EventEmailVerified:  ssov1.EventType_EVENT_TYPE_EMAIL_VERIFIED,
+	model.EventPasswordReset:  ssov1.EventType_EVENT_TYPE_PASSWORD_RESET,
+	model.EventUserBlocked:    ssov1.EventType_EVENT_TYPE_USER_BLOCKED,
+	model.EventUserDeleted:    ssov1.EventType_EVENT_TYPE_USER_DELETED,
+}
+
+func toProtoEventType(t model.EventType) ssov1.EventType {
+	return eventTypes[t]
+}
+
+func fromProtoEventType(t ssov1.EventType) (model.EventType, bool) {
+	for mt, pt := range eventTypes {
+		if pt == t {
+			return mt, true
+		}
+	}
+	return "", false
+}
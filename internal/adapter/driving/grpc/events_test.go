@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/grpc/mocks"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	ssov1 "github.com/sanchey92/sso/pkg/api/sso/v1"
+)
+
+func startEventsServer(t *testing.T, key string) (*grpc.ClientConn, *mocks.EventService) {
+	t.Helper()
+	eventSvc := mocks.NewEventService(t)
+
+	srv := NewServer(
+		&Config{
+			APIKeys:            []string{testAPIKey},
+			EventAPIKeys:       []string{testEventAPIKey},
+			TenantEventAPIKeys: map[string]string{testTenantEventAPIKey: testTenantID},
+		},
+		NewIdentityHandler(mocks.NewTokenService(t), mocks.NewUserService(t), mocks.NewPermissionService(t), zap.NewNop()),
+		NewEventsHandler(eventSvc, zap.NewNop()),
+		zap.NewNop(),
+	)
+	return dialTestServerWith(t, srv, grpc.WithPerRPCCredentials(apiKey(key))), eventSvc
+}
+
+func TestWatchEvents(t *testing.T) {
+	occurred := time.Now().Truncate(time.Second)
+
+	conn, eventSvc := startEventsServer(t, testEventAPIKey)
+	eventSvc.EXPECT().
+		Watch(mock.Anything, "", "41", []model.EventType{model.EventUserBlocked}, mock.Anything).
+		RunAndReturn(func(_ context.Context, _, _ string, _ []model.EventType, send func(*model.Event) error) error {
+			if err := send(&model.Event{
+				ID:        42,
+				Type:      model.EventUserBlocked,
+				TenantID:  testTenantID,
+				UserID:    testUserID,
+				Data:      map[string]string{"reason": "abuse"},
+				CreatedAt: occurred,
+			}); err != nil {
+				return err
+			}
+			return fmt.Errorf("watch events: %w", context.Canceled)
+		})
+
+	stream, err := ssov1.NewEventServiceClient(conn).WatchEvents(t.Context(), &ssov1.WatchEventsRequest{
+		Cursor: "41",
+		Types:  []ssov1.EventType{ssov1.EventType_EVENT_TYPE_USER_BLOCKED},
+	})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "42", event.GetCursor())
+	assert.Equal(t, ssov1.EventType_EVENT_TYPE_USER_BLOCKED, event.GetType())
+	assert.Equal(t, testTenantID, event.GetTenantId())
+	assert.Equal(t, testUserID, event.GetUserId())
+	assert.Equal(t, map[string]string{"reason": "abuse"}, event.GetData())
+	assert.True(t, occurred.Equal(event.GetOccurredAt().AsTime()))
+
+	_, err = stream.Recv()
+	assert.NotEqual(t, io.EOF, err)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestWatchEvents_TenantKeySeesOnlyItsTenant(t *testing.T) {
+	conn, eventSvc := startEventsServer(t, testTenantEventAPIKey)
+	eventSvc.EXPECT().
+		Watch(mock.Anything, testTenantID, "", []model.EventType{}, mock.Anything).
+		Return(fmt.Errorf("watch events: %w", context.Canceled))
+
+	stream, err := ssov1.NewEventServiceClient(conn).WatchEvents(t.Context(), &ssov1.WatchEventsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestWatchEvents_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *ssov1.WatchEventsRequest
+		mockSetup func(es *mocks.EventService)
+		wantCode  codes.Code
+	}{
+		{
+			name:     "unspecified type",
+			req:      &ssov1.WatchEventsRequest{Types: []ssov1.EventType{ssov1.EventType_EVENT_TYPE_UNSPECIFIED}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "invalid cursor",
+			req:  &ssov1.WatchEventsRequest{Cursor: "abc"},
+			mockSetup: func(es *mocks.EventService) {
+				es.EXPECT().Watch(mock.Anything, "", "abc", []model.EventType{}, mock.Anything).
+					Return(domainerrors.ErrInvalidCursor)
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "repository error",
+			req:  &ssov1.WatchEventsRequest{},
+			mockSetup: func(es *mocks.EventService) {
+				es.EXPECT().Watch(mock.Anything, "", "", []model.EventType{}, mock.Anything).
+					Return(fmt.Errorf("list events: %w", assert.AnError))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, eventSvc := startEventsServer(t, testEventAPIKey)
+			if tt.mockSetup != nil {
+				tt.mockSetup(eventSvc)
+			}
+
+			stream, err := ssov1.NewEventServiceClient(conn).WatchEvents(t.Context(), tt.req)
+			require.NoError(t, err)
+
+			_, err = stream.Recv()
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
@@ -1 +1,117 @@
 package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	ssov1 "github.com/sanchey92/sso/pkg/api/sso/v1"
+)
+
+type Config struct {
+	Host       string
+	Port       int
+	Reflection bool
+	// APIKeys are the keys callers authenticate with; see authenticate.
+	APIKeys []string
+	// EventAPIKeys open the event stream with the events of every tenant;
+	// APIKeys do not open it.
+	EventAPIKeys []string
+	// TenantEventAPIKeys map further event stream keys to the id of the one
+	// tenant whose events they receive.
+	TenantEventAPIKeys map[string]string
+}
+
+type Server struct {
+	grpcServer *grpc.Server
+	health     *health.Server
+	addr       string
+	stopping   chan struct{}
+	log        *zap.Logger
+}
+
+func NewServer(cfg *Config, identityH *IdentityHandler, eventsH *EventsHandler, log *zap.Logger) *Server {
+	s := &Server{
+		health:   health.NewServer(),
+		addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		stopping: make(chan struct{}),
+		log:      log,
+	}
+
+	eventKeys := slices.Concat(cfg.EventAPIKeys, slices.Collect(maps.Keys(cfg.TenantEventAPIKeys)))
+	keys := keyring{
+		keys:     cfg.APIKeys,
+		services: map[string][]string{ssov1.EventService_ServiceDesc.ServiceName: eventKeys},
+		tenants:  cfg.TenantEventAPIKeys,
+	}
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryRecovery(log), unaryLogging(log), unaryAuth(keys)),
+		grpc.ChainStreamInterceptor(
+			streamRecovery(log), streamLogging(log), streamAuth(keys), streamShutdown(s.stopping),
+		),
+	)
+
+	ssov1.RegisterIdentityServiceServer(s.grpcServer, identityH)
+	ssov1.RegisterEventServiceServer(s.grpcServer, eventsH)
+
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	for _, name := range []string{
+		ssov1.IdentityService_ServiceDesc.ServiceName,
+		ssov1.EventService_ServiceDesc.ServiceName,
+	} {
+		s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	if cfg.Reflection {
+		reflection.Register(s.grpcServer)
+	}
+
+	return s
+}
+
+func (s *Server) Start() error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	return s.Serve(lis)
+}
+
+func (s *Server) Serve(lis net.Listener) error {
+	s.log.Info("starting gRPC server", zap.String("addr", lis.Addr().String()))
+	if err := s.grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("grpc server: %w", err)
+	}
+	return nil
+}
+
+// Stop reports NOT_SERVING to health checks, ends open streams and drains
+// in-flight calls. If ctx ends first, the remaining calls are cancelled.
+func (s *Server) Stop(ctx context.Context) error {
+	s.log.Info("stopping gRPC server")
+	s.health.Shutdown()
+	close(s.stopping)
+
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return fmt.Errorf("stop grpc server: %w", ctx.Err())
+	}
+}
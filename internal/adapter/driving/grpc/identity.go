@@ -0,0 +1,220 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	ssov1 "github.com/sanchey92/sso/pkg/api/sso/v1"
+)
+
+const maxBatchIDs = 100
+
+type TokenService interface {
+	ValidateAccessToken(ctx context.Context, accessToken string) (*model.AccessTokenClaims, error)
+	IntrospectRefreshToken(ctx context.Context, rawToken string) (*model.RefreshToken, error)
+	RevokeUserSessions(ctx context.Context, userID string) error
+}
+
+type UserService interface {
+	GetUser(ctx context.Context, userID string) (*model.User, error)
+	GetUsersByIDs(ctx context.Context, ids []string) ([]*model.User, error)
+}
+
+type PermissionService interface {
+	CheckPermission(ctx context.Context, userID, clientID, permission string) (bool, error)
+}
+
+type IdentityHandler struct {
+	ssov1.UnimplementedIdentityServiceServer
+
+	tokenSvc TokenService
+	userSvc  UserService
+	permSvc  PermissionService
+	log      *zap.Logger
+}
+
+func NewIdentityHandler(
+	tokenSvc TokenService,
+	userSvc UserService,
+	permSvc PermissionService,
+	log *zap.Logger,
+) *IdentityHandler {
+	return &IdentityHandler{tokenSvc: tokenSvc, userSvc: userSvc, permSvc: permSvc, log: log}
+}
+
+func (h *IdentityHandler) ValidateToken(
+	ctx context.Context,
+	req *ssov1.ValidateTokenRequest,
+) (*ssov1.ValidateTokenResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "access_token is required")
+	}
+
+	claims, err := h.tokenSvc.ValidateAccessToken(ctx, req.GetAccessToken())
+	if err != nil {
+		return nil, h.toStatus(err)
+	}
+
+	return &ssov1.ValidateTokenResponse{
+		Subject:     claims.Subject,
+		Issuer:      claims.Issuer,
+		Audience:    claims.Audience,
+		IssuedAt:    timestamppb.New(claims.IssuedAt),
+		ExpiresAt:   timestamppb.New(claims.ExpiresAt),
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Tenant:      claims.Tenant,
+	}, nil
+}
+
+func (h *IdentityHandler) GetUser(ctx context.Context, req *ssov1.GetUserRequest) (*ssov1.GetUserResponse, error) {
+	if err := uuid.Validate(req.GetId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "id must be a UUID")
+	}
+
+	user, err := h.userSvc.GetUser(ctx, req.GetId())
+	if err != nil {
+		return nil, h.toStatus(err)
+	}
+	return &ssov1.GetUserResponse{User: toProtoUser(user)}, nil
+}
+
+func (h *IdentityHandler) GetUsersByIDs(
+	ctx context.Context,
+	req *ssov1.GetUsersByIDsRequest,
+) (*ssov1.GetUsersByIDsResponse, error) {
+	if len(req.GetIds()) > maxBatchIDs {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids per request", maxBatchIDs)
+	}
+	for _, id := range req.GetIds() {
+		if err := uuid.Validate(id); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "id %q must be a UUID", id)
+		}
+	}
+
+	users, err := h.userSvc.GetUsersByIDs(ctx, req.GetIds())
+	if err != nil {
+		return nil, h.toStatus(err)
+	}
+
+	resp := &ssov1.GetUsersByIDsResponse{Users: make([]*ssov1.User, 0, len(users))}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toProtoUser(u))
+	}
+	return resp, nil
+}
+
+func (h *IdentityHandler) IntrospectRefreshToken(
+	ctx context.Context,
+	req *ssov1.IntrospectRefreshTokenRequest,
+) (*ssov1.IntrospectRefreshTokenResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh_token is required")
+	}
+
+	rt, err := h.tokenSvc.IntrospectRefreshToken(ctx, req.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidToken) {
+			return &ssov1.IntrospectRefreshTokenResponse{Active: false}, nil
+		}
+		return nil, h.toStatus(err)
+	}
+
+	return &ssov1.IntrospectRefreshTokenResponse{
+		Active:    true,
+		UserId:    rt.UserID,
+		ClientId:  rt.ClientID,
+		FamilyId:  rt.FamilyID,
+		Scopes:    rt.Scopes,
+		IssuedAt:  timestamppb.New(rt.CreatedAt),
+		ExpiresAt: timestamppb.New(rt.ExpiresAt),
+	}, nil
+}
+
+func (h *IdentityHandler) RevokeUserSessions(
+	ctx context.Context,
+	req *ssov1.RevokeUserSessionsRequest,
+) (*ssov1.RevokeUserSessionsResponse, error) {
+	if err := uuid.Validate(req.GetUserId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be a UUID")
+	}
+
+	if err := h.tokenSvc.RevokeUserSessions(ctx, req.GetUserId()); err != nil {
+		return nil, h.toStatus(err)
+	}
+	return &ssov1.RevokeUserSessionsResponse{}, nil
+}
+
+func (h *IdentityHandler) CheckPermission(
+	ctx context.Context,
+	req *ssov1.CheckPermissionRequest,
+) (*ssov1.CheckPermissionResponse, error) {
+	if err := uuid.Validate(req.GetUserId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "user_id must be a UUID")
+	}
+	if req.GetPermission() == "" {
+		return nil, status.Error(codes.InvalidArgument, "permission is required")
+	}
+	if req.GetClientId() != "" {
+		if err := uuid.Validate(req.GetClientId()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "client_id must be a UUID")
+		}
+	}
+
+	allowed, err := h.permSvc.CheckPermission(ctx, req.GetUserId(), req.GetClientId(), req.GetPermission())
+	if err != nil {
+		return nil, h.toStatus(err)
+	}
+	return &ssov1.CheckPermissionResponse{Allowed: allowed}, nil
+}
+
+// toStatus maps domain errors to gRPC status codes. Anything unexpected is
+// logged and reported as INTERNAL without details.
+func (h *IdentityHandler) toStatus(err error) error {
+	switch {
+	case errors.Is(err, domainerrors.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, domainerrors.ErrTokenExpired):
+		return status.Error(codes.Unauthenticated, "token expired")
+	case errors.Is(err, domainerrors.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "invalid token")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		h.log.Error("internal error", zap.Error(err))
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func toProtoUser(u *model.User) *ssov1.User {
+	return &ssov1.User{
+		Id:            u.ID,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		MfaEnabled:    u.MFAEnabled,
+		Status:        toProtoStatus(u.Status),
+		CreatedAt:     timestamppb.New(u.CreatedAt),
+		UpdatedAt:     timestamppb.New(u.UpdatedAt),
+	}
+}
+
+func toProtoStatus(s model.UserStatus) ssov1.UserStatus {
+	switch s {
+	case model.UserStatusActive:
+		return ssov1.UserStatus_USER_STATUS_ACTIVE
+	case model.UserStatusBlocked:
+		return ssov1.UserStatus_USER_STATUS_BLOCKED
+	case model.UserStatusDeleted:
+		return ssov1.UserStatus_USER_STATUS_DELETED
+	default:
+		return ssov1.UserStatus_USER_STATUS_UNSPECIFIED
+	}
+}
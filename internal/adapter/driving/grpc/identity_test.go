@@ -0,0 +1,328 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/grpc/mocks"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	ssov1 "github.com/sanchey92/sso/pkg/api/sso/v1"
+)
+
+const (
+	testUserID            = "3f1c2a4e-8a57-4c55-9d36-7a2c1f0b9e11"
+	testTenantID          = "9b2e7c41-5d3a-4f6e-8c1b-2a7d9e0f4b63"
+	testAPIKey            = "test-api-key"
+	testEventAPIKey       = "test-event-api-key"
+	testTenantEventAPIKey = "test-tenant-event-api-key"
+)
+
+// startTestServer serves the identity API over an in-memory listener and
+// returns a connected client.
+func startTestServer(t *testing.T) (*grpc.ClientConn, *mocks.TokenService, *mocks.UserService) {
+	t.Helper()
+	tokenSvc := mocks.NewTokenService(t)
+	userSvc := mocks.NewUserService(t)
+
+	srv := NewServer(
+		&Config{Reflection: true, APIKeys: []string{testAPIKey}},
+		NewIdentityHandler(tokenSvc, userSvc, mocks.NewPermissionService(t), zap.NewNop()),
+		NewEventsHandler(mocks.NewEventService(t), zap.NewNop()),
+		zap.NewNop(),
+	)
+	return dialTestServer(t, srv), tokenSvc, userSvc
+}
+
+// startPermissionServer is startTestServer for tests of permission checks.
+func startPermissionServer(t *testing.T) (*grpc.ClientConn, *mocks.PermissionService) {
+	t.Helper()
+	permSvc := mocks.NewPermissionService(t)
+
+	srv := NewServer(
+		&Config{APIKeys: []string{testAPIKey}},
+		NewIdentityHandler(mocks.NewTokenService(t), mocks.NewUserService(t), permSvc, zap.NewNop()),
+		NewEventsHandler(mocks.NewEventService(t), zap.NewNop()),
+		zap.NewNop(),
+	)
+	return dialTestServer(t, srv), permSvc
+}
+
+// dialTestServer connects to srv with testAPIKey.
+func dialTestServer(t *testing.T, srv *Server) *grpc.ClientConn {
+	t.Helper()
+	return dialTestServerWith(t, srv, grpc.WithPerRPCCredentials(apiKey(testAPIKey)))
+}
+
+func dialTestServerWith(t *testing.T, srv *Server, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestHealthCheck(t *testing.T) {
+	conn, _, _ := startTestServer(t)
+
+	resp, err := healthpb.NewHealthClient(conn).Check(t.Context(), &healthpb.HealthCheckRequest{
+		Service: ssov1.IdentityService_ServiceDesc.ServiceName,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestValidateToken(t *testing.T) {
+	issued := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		token     string
+		mockSetup func(ts *mocks.TokenService)
+		wantCode  codes.Code
+	}{
+		{
+			name:  "valid token",
+			token: "access",
+			mockSetup: func(ts *mocks.TokenService) {
+				ts.EXPECT().ValidateAccessToken(mock.Anything, "access").Return(&model.AccessTokenClaims{
+					Subject:     testUserID,
+					Issuer:      "sso",
+					IssuedAt:    issued,
+					ExpiresAt:   issued.Add(15 * time.Minute),
+					Roles:       []string{"editor"},
+					Permissions: []string{"docs:write"},
+					Tenant:      "acme",
+				}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:  "expired token",
+			token: "expired",
+			mockSetup: func(ts *mocks.TokenService) {
+				ts.EXPECT().ValidateAccessToken(mock.Anything, "expired").
+					Return(nil, fmt.Errorf("validate access token: %w", domainerrors.ErrTokenExpired))
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:      "missing token",
+			mockSetup: func(_ *mocks.TokenService) {},
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, ts, _ := startTestServer(t)
+			tt.mockSetup(ts)
+
+			resp, err := ssov1.NewIdentityServiceClient(conn).ValidateToken(t.Context(),
+				&ssov1.ValidateTokenRequest{AccessToken: tt.token})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, testUserID, resp.GetSubject())
+				assert.Equal(t, issued.Add(15*time.Minute), resp.GetExpiresAt().AsTime().Local())
+				assert.Equal(t, []string{"editor"}, resp.GetRoles())
+				assert.Equal(t, []string{"docs:write"}, resp.GetPermissions())
+				assert.Equal(t, "acme", resp.GetTenant())
+			}
+		})
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		mockSetup func(us *mocks.UserService)
+		wantCode  codes.Code
+	}{
+		{
+			name: "found",
+			id:   testUserID,
+			mockSetup: func(us *mocks.UserService) {
+				us.EXPECT().GetUser(mock.Anything, testUserID).Return(&model.User{
+					ID:     testUserID,
+					Email:  "user@example.com",
+					Status: model.UserStatusBlocked,
+				}, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "not found",
+			id:   testUserID,
+			mockSetup: func(us *mocks.UserService) {
+				us.EXPECT().GetUser(mock.Anything, testUserID).
+					Return(nil, fmt.Errorf("get user by id: %w", domainerrors.ErrUserNotFound))
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "storage failure",
+			id:   testUserID,
+			mockSetup: func(us *mocks.UserService) {
+				us.EXPECT().GetUser(mock.Anything, testUserID).Return(nil, errors.New("db down"))
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			name:      "malformed id",
+			id:        "not-a-uuid",
+			mockSetup: func(_ *mocks.UserService) {},
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, _, us := startTestServer(t)
+			tt.mockSetup(us)
+
+			resp, err := ssov1.NewIdentityServiceClient(conn).GetUser(t.Context(), &ssov1.GetUserRequest{Id: tt.id})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "user@example.com", resp.GetUser().GetEmail())
+				assert.Equal(t, ssov1.UserStatus_USER_STATUS_BLOCKED, resp.GetUser().GetStatus())
+			}
+		})
+	}
+}
+
+func TestGetUsersByIDs_TooMany(t *testing.T) {
+	conn, _, _ := startTestServer(t)
+
+	ids := make([]string, maxBatchIDs+1)
+	for i := range ids {
+		ids[i] = testUserID
+	}
+	_, err := ssov1.NewIdentityServiceClient(conn).GetUsersByIDs(t.Context(), &ssov1.GetUsersByIDsRequest{Ids: ids})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestIntrospectRefreshToken(t *testing.T) {
+	conn, ts, _ := startTestServer(t)
+	client := ssov1.NewIdentityServiceClient(conn)
+
+	ts.EXPECT().IntrospectRefreshToken(mock.Anything, "live").Return(&model.RefreshToken{
+		UserID:    testUserID,
+		FamilyID:  "fam-1",
+		Scopes:    []string{"openid"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+	ts.EXPECT().IntrospectRefreshToken(mock.Anything, "dead").
+		Return(nil, fmt.Errorf("get refresh token: %w", domainerrors.ErrInvalidToken))
+
+	resp, err := client.IntrospectRefreshToken(t.Context(), &ssov1.IntrospectRefreshTokenRequest{RefreshToken: "live"})
+	require.NoError(t, err)
+	assert.True(t, resp.GetActive())
+	assert.Equal(t, "fam-1", resp.GetFamilyId())
+
+	resp, err = client.IntrospectRefreshToken(t.Context(), &ssov1.IntrospectRefreshTokenRequest{RefreshToken: "dead"})
+	require.NoError(t, err)
+	assert.False(t, resp.GetActive())
+	assert.Empty(t, resp.GetUserId())
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	conn, ts, _ := startTestServer(t)
+	ts.EXPECT().RevokeUserSessions(mock.Anything, testUserID).Return(nil)
+
+	_, err := ssov1.NewIdentityServiceClient(conn).RevokeUserSessions(t.Context(),
+		&ssov1.RevokeUserSessionsRequest{UserId: testUserID})
+
+	require.NoError(t, err)
+}
+
+func TestCheckPermission(t *testing.T) {
+	const clientID = "8d6e3b1a-2c4f-4e5d-9a7b-1c2d3e4f5a6b"
+
+	tests := []struct {
+		name        string
+		req         *ssov1.CheckPermissionRequest
+		mockSetup   func(ps *mocks.PermissionService)
+		wantCode    codes.Code
+		wantAllowed bool
+	}{
+		{
+			name: "allowed",
+			req:  &ssov1.CheckPermissionRequest{UserId: testUserID, Permission: "docs:write", ClientId: clientID},
+			mockSetup: func(ps *mocks.PermissionService) {
+				ps.EXPECT().CheckPermission(mock.Anything, testUserID, clientID, "docs:write").Return(true, nil)
+			},
+			wantCode:    codes.OK,
+			wantAllowed: true,
+		},
+		{
+			name: "denied",
+			req:  &ssov1.CheckPermissionRequest{UserId: testUserID, Permission: "docs:write"},
+			mockSetup: func(ps *mocks.PermissionService) {
+				ps.EXPECT().CheckPermission(mock.Anything, testUserID, "", "docs:write").Return(false, nil)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:      "missing permission",
+			req:       &ssov1.CheckPermissionRequest{UserId: testUserID},
+			mockSetup: func(_ *mocks.PermissionService) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:      "bad client id",
+			req:       &ssov1.CheckPermissionRequest{UserId: testUserID, Permission: "docs:write", ClientId: "web"},
+			mockSetup: func(_ *mocks.PermissionService) {},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name: "store error",
+			req:  &ssov1.CheckPermissionRequest{UserId: testUserID, Permission: "docs:write"},
+			mockSetup: func(ps *mocks.PermissionService) {
+				ps.EXPECT().CheckPermission(mock.Anything, testUserID, "", "docs:write").
+					Return(false, fmt.Errorf("has permission: %w", errors.New("db down")))
+			},
+			wantCode: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, ps := startPermissionServer(t)
+			tt.mockSetup(ps)
+
+			resp, err := ssov1.NewIdentityServiceClient(conn).CheckPermission(t.Context(), tt.req)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantAllowed, resp.GetAllowed())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type LockoutService interface {
+	Unlock(ctx context.Context, userID string) error
+}
+
+type AdminUserService interface {
+	SearchUsers(ctx context.Context, filter model.UserFilter, cursor string, limit int) ([]*model.User, string, error)
+	BlockUser(ctx context.Context, userID, reason string) error
+	UnblockUser(ctx context.Context, userID string) error
+	ForceVerifyEmail(ctx context.Context, userID string) error
+	ResetMFA(ctx context.Context, userID string) error
+	SendPasswordReset(ctx context.Context, userID string) error
+	LogoutEverywhere(ctx context.Context, userID string) error
+}
+
+type AdminHandler struct {
+	lockout LockoutService
+	users   AdminUserService
+	log     *zap.Logger
+}
+
+func NewAdminHandler(lockout LockoutService, users AdminUserService, log *zap.Logger) *AdminHandler {
+	return &AdminHandler{lockout: lockout, users: users, log: log}
+}
+
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.lockout.Unlock(r.Context(), chi.URLParam(r, "id")); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SearchUsers lists users matching the query parameters email_prefix, status,
+// created_after, created_before (RFC 3339) and mfa_enabled, one page at a
+// time.
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := model.UserFilter{
+		TenantID:    tenantID(r),
+		EmailPrefix: q.Get("email_prefix"),
+		Status:      model.UserStatus(q.Get("status")),
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseTimeParam(q.Get("created_after")); err != nil {
+		respondError(w, http.StatusBadRequest, "created_after: must be an RFC 3339 timestamp", "VALIDATION_ERROR")
+		return
+	}
+	if filter.CreatedBefore, err = parseTimeParam(q.Get("created_before")); err != nil {
+		respondError(w, http.StatusBadRequest, "created_before: must be an RFC 3339 timestamp", "VALIDATION_ERROR")
+		return
+	}
+	if raw := q.Get("mfa_enabled"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "mfa_enabled: must be true or false", "VALIDATION_ERROR")
+			return
+		}
+		filter.MFAEnabled = &enabled
+	}
+	var limit int
+	if raw := q.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "limit: must be a positive integer", "VALIDATION_ERROR")
+			return
+		}
+	}
+
+	users, next, err := h.users.SearchUsers(r.Context(), filter, q.Get("cursor"), limit)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &userPageResponse{Users: make([]*adminUserResponse, 0, len(users)), NextCursor: next}
+	for _, u := range users {
+		resp.Users = append(resp.Users, toAdminUserResponse(u))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *AdminHandler) BlockUser(w http.ResponseWriter, r *http.Request) {
+	var req blockUserRequest
+	// The body is optional.
+	if err := decodeJSON(w, r, &req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	h.respond(w, r, h.users.BlockUser(r.Context(), chi.URLParam(r, "id"), req.Reason))
+}
+
+func (h *AdminHandler) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.users.UnblockUser(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *AdminHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.users.ForceVerifyEmail(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *AdminHandler) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.users.ResetMFA(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *AdminHandler) SendPasswordReset(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.users.SendPasswordReset(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *AdminHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.users.LogoutEverywhere(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *AdminHandler) respond(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseTimeParam(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw) //nolint:wrapcheck // reported as a validation error
+}
+
+func toAdminUserResponse(user *model.User) *adminUserResponse {
+	return &adminUserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		MFAEnabled:    user.MFAEnabled,
+		Status:        string(user.Status),
+		DisplayName:   user.Profile.DisplayName,
+		DeletedAt:     user.DeletedAt,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+type blockUserRequest struct {
+	Reason string `json:"reason"`
+}
+
+type adminUserResponse struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	MFAEnabled    bool       `json:"mfa_enabled"`
+	Status        string     `json:"status"`
+	DisplayName   string     `json:"display_name"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type userPageResponse struct {
+	Users      []*adminUserResponse `json:"users"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// withUserID adds the {id} route parameter chi would set.
+func withUserID(req *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestAdminUnlockUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockSetup  func(svc *mocks.LockoutService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			mockSetup: func(svc *mocks.LockoutService) {
+				svc.EXPECT().Unlock(mock.Anything, "user-uuid").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "store error",
+			mockSetup: func(svc *mocks.LockoutService) {
+				svc.EXPECT().Unlock(mock.Anything, "user-uuid").Return(fmt.Errorf("delete lockout: %w", errors.New("redis down")))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewLockoutService(t)
+			tt.mockSetup(svc)
+			h := NewAdminHandler(svc, mocks.NewAdminUserService(t), zap.NewNop())
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "user-uuid")
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-uuid/unlock", nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+			h.UnlockUser(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAdminSearchUsers(t *testing.T) {
+	mfa := true
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(svc *mocks.AdminUserService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "success",
+			query: "?email_prefix=ann&status=blocked&mfa_enabled=true&created_after=2026-10-01T00:00:00Z&limit=1&cursor=c1",
+			mockSetup: func(svc *mocks.AdminUserService) {
+				filter := model.UserFilter{
+					EmailPrefix:  "ann",
+					Status:       model.UserStatusBlocked,
+					CreatedAfter: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+					MFAEnabled:   &mfa,
+				}
+				svc.EXPECT().SearchUsers(mock.Anything, filter, "c1", 1).Return([]*model.User{{
+					ID:         "user-1",
+					Email:      "ann@example.com",
+					MFAEnabled: true,
+					Status:     model.UserStatusBlocked,
+					CreatedAt:  created,
+					UpdatedAt:  created,
+				}}, "c2", nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"users":[{"id":"user-1","email":"ann@example.com","email_verified":false,"mfa_enabled":true,
+				"status":"blocked","display_name":"","created_at":"2026-10-01T12:00:00Z","updated_at":"2026-10-01T12:00:00Z"}],
+				"next_cursor":"c2"}`,
+		},
+		{
+			name: "empty page",
+			mockSetup: func(svc *mocks.AdminUserService) {
+				svc.EXPECT().SearchUsers(mock.Anything, model.UserFilter{}, "", 0).Return(nil, "", nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"users":[]}`,
+		},
+		{
+			name:       "bad date",
+			query:      "?created_before=yesterday",
+			mockSetup:  func(_ *mocks.AdminUserService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"created_before: must be an RFC 3339 timestamp","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name:       "bad mfa flag",
+			query:      "?mfa_enabled=maybe",
+			mockSetup:  func(_ *mocks.AdminUserService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"mfa_enabled: must be true or false","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name:  "bad cursor",
+			query: "?cursor=junk",
+			mockSetup: func(svc *mocks.AdminUserService) {
+				svc.EXPECT().SearchUsers(mock.Anything, model.UserFilter{}, "junk", 0).Return(nil, "", domainerrors.ErrInvalidCursor)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid cursor","code":"INVALID_CURSOR"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewAdminUserService(t)
+			tt.mockSetup(svc)
+			h := NewAdminHandler(mocks.NewLockoutService(t), svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			h.SearchUsers(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestAdminBlockUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.AdminUserService)
+		wantStatus int
+	}{
+		{
+			name: "with reason",
+			body: `{"reason":"abuse"}`,
+			mockSetup: func(svc *mocks.AdminUserService) {
+				svc.EXPECT().BlockUser(mock.Anything, "user-uuid", "abuse").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "without body",
+			mockSetup: func(svc *mocks.AdminUserService) {
+				svc.EXPECT().BlockUser(mock.Anything, "user-uuid", "").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "pending deletion",
+			mockSetup: func(svc *mocks.AdminUserService) {
+				svc.EXPECT().BlockUser(mock.Anything, "user-uuid", "").Return(domainerrors.ErrInvalidUserStatus)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "malformed body",
+			body:       `{`,
+			mockSetup:  func(_ *mocks.AdminUserService) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewAdminUserService(t)
+			tt.mockSetup(svc)
+			h := NewAdminHandler(mocks.NewLockoutService(t), svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-uuid/block", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.BlockUser(rec, withUserID(req, "user-uuid"))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAdminUserActions(t *testing.T) {
+	notFound := fmt.Errorf("get user by id: %w", domainerrors.ErrUserNotFound)
+
+	tests := []struct {
+		name      string
+		serve     func(h *AdminHandler) http.HandlerFunc
+		mockSetup func(svc *mocks.AdminUserService, err error)
+	}{
+		{
+			name:  "unblock",
+			serve: func(h *AdminHandler) http.HandlerFunc { return h.UnblockUser },
+			mockSetup: func(svc *mocks.AdminUserService, err error) {
+				svc.EXPECT().UnblockUser(mock.Anything, "user-uuid").Return(err)
+			},
+		},
+		{
+			name:  "verify email",
+			serve: func(h *AdminHandler) http.HandlerFunc { return h.VerifyEmail },
+			mockSetup: func(svc *mocks.AdminUserService, err error) {
+				svc.EXPECT().ForceVerifyEmail(mock.Anything, "user-uuid").Return(err)
+			},
+		},
+		{
+			name:  "reset mfa",
+			serve: func(h *AdminHandler) http.HandlerFunc { return h.ResetMFA },
+			mockSetup: func(svc *mocks.AdminUserService, err error) {
+				svc.EXPECT().ResetMFA(mock.Anything, "user-uuid").Return(err)
+			},
+		},
+		{
+			name:  "password reset",
+			serve: func(h *AdminHandler) http.HandlerFunc { return h.SendPasswordReset },
+			mockSetup: func(svc *mocks.AdminUserService, err error) {
+				svc.EXPECT().SendPasswordReset(mock.Anything, "user-uuid").Return(err)
+			},
+		},
+		{
+			name:  "logout",
+			serve: func(h *AdminHandler) http.HandlerFunc { return h.Logout },
+			mockSetup: func(svc *mocks.AdminUserService, err error) {
+				svc.EXPECT().LogoutEverywhere(mock.Anything, "user-uuid").Return(err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, c := range []struct {
+			err        error
+			wantStatus int
+		}{
+			{err: nil, wantStatus: http.StatusNoContent},
+			{err: notFound, wantStatus: http.StatusNotFound},
+		} {
+			t.Run(fmt.Sprintf("%s/%d", tt.name, c.wantStatus), func(t *testing.T) {
+				svc := mocks.NewAdminUserService(t)
+				tt.mockSetup(svc, c.err)
+				h := NewAdminHandler(mocks.NewLockoutService(t), svc, zap.NewNop())
+
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-uuid/action", nil)
+				rec := httptest.NewRecorder()
+				tt.serve(h)(rec, withUserID(req, "user-uuid"))
+
+				assert.Equal(t, c.wantStatus, rec.Code)
+			})
+		}
+	}
+}
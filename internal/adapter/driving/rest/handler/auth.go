@@ -6,11 +6,12 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
 	"github.com/sanchey92/sso/internal/domain/model"
 )
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string) (*model.TokenPair, error)
+	Login(ctx context.Context, tenantID, email, password string) (*model.TokenPair, error)
 }
 
 type AuthHandler struct {
@@ -28,8 +29,11 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
 		return
 	}
+	if !middleware.LimitEmail(w, r, req.Email) {
+		return
+	}
 
-	pair, err := h.svc.Login(r.Context(), req.Email, req.Password)
+	pair, err := h.svc.Login(r.Context(), tenantID(r), req.Email, req.Password)
 	if err != nil {
 		handleServiceError(w, r, err, h.log)
 		return
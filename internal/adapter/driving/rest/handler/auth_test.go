@@ -32,7 +32,7 @@ func TestLogin(t *testing.T) {
 			name: "success",
 			body: `{"email":"test@example.com","password":"secret123"}`,
 			mockSetup: func(svc *mocks.AuthService) {
-				svc.EXPECT().Login(mock.Anything, "test@example.com", "secret123").
+				svc.EXPECT().Login(mock.Anything, "", "test@example.com", "secret123").
 					Return(&model.TokenPair{
 						AccessToken:  "access-tok",
 						RefreshToken: "refresh-tok",
@@ -53,7 +53,7 @@ func TestLogin(t *testing.T) {
 			name: "invalid credentials",
 			body: `{"email":"test@example.com","password":"wrong"}`,
 			mockSetup: func(svc *mocks.AuthService) {
-				svc.EXPECT().Login(mock.Anything, "test@example.com", "wrong").
+				svc.EXPECT().Login(mock.Anything, "", "test@example.com", "wrong").
 					Return(nil, domainerrors.ErrInvalidCredentials)
 			},
 			wantStatus: http.StatusUnauthorized,
@@ -63,12 +63,22 @@ func TestLogin(t *testing.T) {
 			name: "email not verified",
 			body: `{"email":"test@example.com","password":"secret123"}`,
 			mockSetup: func(svc *mocks.AuthService) {
-				svc.EXPECT().Login(mock.Anything, "test@example.com", "secret123").
+				svc.EXPECT().Login(mock.Anything, "", "test@example.com", "secret123").
 					Return(nil, domainerrors.ErrEmailNotVerified)
 			},
 			wantStatus: http.StatusForbidden,
 			wantBody:   `{"error":"email not verified","code":"EMAIL_NOT_VERIFIED"}`,
 		},
+		{
+			name: "password reset required",
+			body: `{"email":"test@example.com","password":"secret123"}`,
+			mockSetup: func(svc *mocks.AuthService) {
+				svc.EXPECT().Login(mock.Anything, "", "test@example.com", "secret123").
+					Return(nil, domainerrors.ErrPasswordResetRequired)
+			},
+			wantStatus: http.StatusForbidden,
+			wantBody:   `{"error":"password reset required","code":"PASSWORD_RESET_REQUIRED"}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type DiscoveryService interface {
+	Issuer(tenant string) string
+	JWKS(tenant string) (*model.JWKS, error)
+}
+
+// DiscoveryHandler serves the metadata token verifiers need for the request's
+// tenant.
+type DiscoveryHandler struct {
+	svc DiscoveryService
+	log *zap.Logger
+}
+
+func NewDiscoveryHandler(svc DiscoveryService, log *zap.Logger) *DiscoveryHandler {
+	return &DiscoveryHandler{svc: svc, log: log}
+}
+
+// Configuration returns the tenant's discovery document. Endpoint URLs are
+// relative to the tenant's issuer, which for tenants other than the default
+// one is the /t/{tenant} path.
+func (h *DiscoveryHandler) Configuration(w http.ResponseWriter, r *http.Request) {
+	issuer := h.svc.Issuer(tenantSlug(r))
+	respondJSON(w, http.StatusOK, &discoveryResponse{
+		Issuer:             issuer,
+		JWKSURI:            issuer + "/.well-known/jwks.json",
+		TokenEndpoint:      issuer + "/api/v1/auth/token/refresh",
+		RevocationEndpoint: issuer + "/api/v1/auth/token/revoke",
+		ScopesSupported:    []string{model.ScopeRoles, model.ScopePermissions, model.ScopeGroups},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "tenant", "roles", "permissions", "groups", "groups_overage",
+		},
+	})
+}
+
+// JWKS returns the public keys of the tenant's access tokens.
+func (h *DiscoveryHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	jwks, err := h.svc.JWKS(tenantSlug(r))
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, jwks)
+}
+
+func tenantSlug(r *http.Request) string {
+	if tenant := middleware.GetTenant(r.Context()); tenant != nil {
+		return tenant.Slug
+	}
+	return model.DefaultTenantSlug
+}
+
+type discoveryResponse struct {
+	Issuer             string   `json:"issuer"`
+	JWKSURI            string   `json:"jwks_uri"`
+	TokenEndpoint      string   `json:"token_endpoint"`
+	RevocationEndpoint string   `json:"revocation_endpoint"`
+	ScopesSupported    []string `json:"scopes_supported"`
+	ClaimsSupported    []string `json:"claims_supported"`
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestDiscoveryConfiguration(t *testing.T) {
+	svc := mocks.NewDiscoveryService(t)
+	svc.EXPECT().Issuer("acme").Return("https://sso.example.com/t/acme")
+	h := NewDiscoveryHandler(svc, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/t/acme/.well-known/openid-configuration", nil)
+	req = req.WithContext(middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1", Slug: "acme"}))
+	rec := httptest.NewRecorder()
+	h.Configuration(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"issuer":"https://sso.example.com/t/acme",
+		"jwks_uri":"https://sso.example.com/t/acme/.well-known/jwks.json",
+		"token_endpoint":"https://sso.example.com/t/acme/api/v1/auth/token/refresh",
+		"revocation_endpoint":"https://sso.example.com/t/acme/api/v1/auth/token/revoke",
+		"scopes_supported":["roles","permissions","groups"],
+		"claims_supported":["iss","sub","aud","exp","iat","tenant","roles","permissions","groups","groups_overage"]
+	}`, rec.Body.String())
+}
+
+func TestDiscoveryJWKS(t *testing.T) {
+	svc := mocks.NewDiscoveryService(t)
+	svc.EXPECT().JWKS(model.DefaultTenantSlug).Return(&model.JWKS{Keys: []model.JWK{
+		{KTY: "OKP", CRV: "Ed25519", KID: "kid-1", Use: "sig", X: "AAAA"},
+	}}, nil)
+	h := NewDiscoveryHandler(svc, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rec := httptest.NewRecorder()
+	h.JWKS(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"kid-1","use":"sig","x":"AAAA"}]}`, rec.Body.String())
+}
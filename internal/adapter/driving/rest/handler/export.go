@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type ExportService interface {
+	RequestExport(ctx context.Context, userID string, by model.DataExportRequester) (*model.DataExport, error)
+	Download(ctx context.Context, token string) (*model.DataExport, error)
+}
+
+// ExportHandler serves subject access requests. The archive itself is only
+// reachable through the link emailed to the account owner.
+type ExportHandler struct {
+	svc ExportService
+	log *zap.Logger
+}
+
+func NewExportHandler(svc ExportService, log *zap.Logger) *ExportHandler {
+	return &ExportHandler{svc: svc, log: log}
+}
+
+func (h *ExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	h.request(w, r, middleware.GetClaims(r.Context()).Subject, model.DataExportBySubject)
+}
+
+func (h *ExportHandler) AdminRequest(w http.ResponseWriter, r *http.Request) {
+	h.request(w, r, chi.URLParam(r, "id"), model.DataExportByAdmin)
+}
+
+func (h *ExportHandler) request(w http.ResponseWriter, r *http.Request, userID string, by model.DataExportRequester) {
+	export, err := h.svc.RequestExport(r.Context(), userID, by)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, &exportResponse{
+		ExportID: export.ID,
+		Message:  "the download link will be sent to the account email",
+	})
+}
+
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondError(w, http.StatusBadRequest, "token is required", "VALIDATION_ERROR")
+		return
+	}
+
+	export, err := h.svc.Download(r.Context(), token)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="data-export-`+export.ID+`.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(export.Archive) //nolint:gosec // error writing response body is unrecoverable
+}
+
+type exportResponse struct {
+	ExportID string `json:"export_id"`
+	Message  string `json:"message"`
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestExportRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockSetup  func(svc *mocks.ExportService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			mockSetup: func(svc *mocks.ExportService) {
+				svc.EXPECT().RequestExport(mock.Anything, "user-123", model.DataExportBySubject).
+					Return(&model.DataExport{ID: "export-1"}, nil)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `{"export_id":"export-1","message":"the download link will be sent to the account email"}`,
+		},
+		{
+			name: "already in progress",
+			mockSetup: func(svc *mocks.ExportService) {
+				svc.EXPECT().RequestExport(mock.Anything, "user-123", model.DataExportBySubject).
+					Return(nil, fmt.Errorf("create data export: %w", domainerrors.ErrExportInProgress))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"data export already in progress","code":"EXPORT_IN_PROGRESS"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewExportService(t)
+			tt.mockSetup(svc)
+			h := NewExportHandler(svc, zap.NewNop())
+
+			rec := serveProfile(h.Request, http.MethodPost, "", http.Header{})
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestExportAdminRequest(t *testing.T) {
+	svc := mocks.NewExportService(t)
+	svc.EXPECT().RequestExport(mock.Anything, "user-uuid", model.DataExportByAdmin).
+		Return(nil, fmt.Errorf("get user by id: %w", domainerrors.ErrUserNotFound))
+	h := NewExportHandler(svc, zap.NewNop())
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "user-uuid")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-uuid/export", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	h.AdminRequest(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestExportDownload(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		mockSetup  func(svc *mocks.ExportService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:  "success",
+			query: "?token=tok",
+			mockSetup: func(svc *mocks.ExportService) {
+				svc.EXPECT().Download(mock.Anything, "tok").
+					Return(&model.DataExport{ID: "export-1", Archive: []byte(`{"user":{}}`)}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"user":{}}`,
+		},
+		{
+			name:       "missing token",
+			mockSetup:  func(_ *mocks.ExportService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"token is required","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name:  "expired link",
+			query: "?token=old",
+			mockSetup: func(svc *mocks.ExportService) {
+				svc.EXPECT().Download(mock.Anything, "old").Return(nil, domainerrors.ErrInvalidExportLink)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"invalid or expired export link","code":"INVALID_EXPORT_LINK"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewExportService(t)
+			tt.mockSetup(svc)
+			h := NewExportHandler(svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/exports/download"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			h.Download(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, `attachment; filename="data-export-export-1.json"`, rec.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
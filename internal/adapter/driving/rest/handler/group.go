@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type GroupService interface {
+	CreateGroup(ctx context.Context, group *model.Group) error
+	ListGroups(ctx context.Context, tenantID string) ([]*model.Group, error)
+	GetGroup(ctx context.Context, tenantID, id string) (*model.Group, *model.GroupMembers, error)
+	UpdateGroup(ctx context.Context, tenantID, id, name, description string) (*model.Group, error)
+	DeleteGroup(ctx context.Context, tenantID, id string) error
+	AddMember(ctx context.Context, tenantID, groupID, userID string) error
+	RemoveMember(ctx context.Context, tenantID, groupID, userID string) error
+	AddSubgroup(ctx context.Context, tenantID, parentID, childID string) error
+	RemoveSubgroup(ctx context.Context, tenantID, parentID, childID string) error
+	ListUserGroups(ctx context.Context, userID string) ([]*model.UserGroup, error)
+}
+
+type GroupHandler struct {
+	svc GroupService
+	log *zap.Logger
+}
+
+func NewGroupHandler(svc GroupService, log *zap.Logger) *GroupHandler {
+	return &GroupHandler{svc: svc, log: log}
+}
+
+func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	group := &model.Group{TenantID: tenantID(r), Name: req.Name, Description: req.Description}
+	if err := h.svc.CreateGroup(r.Context(), group); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusCreated, toGroupResponse(group))
+}
+
+func (h *GroupHandler) List(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.svc.ListGroups(r.Context(), tenantID(r))
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &groupListResponse{Groups: make([]*groupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, toGroupResponse(g))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Get returns the group with its direct members.
+func (h *GroupHandler) Get(w http.ResponseWriter, r *http.Request) {
+	group, members, err := h.svc.GetGroup(r.Context(), tenantID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &groupDetailResponse{
+		groupResponse: toGroupResponse(group),
+		Members: groupMembersResponse{
+			Users:  members.UserIDs,
+			Groups: make([]*groupResponse, 0, len(members.Subgroups)),
+		},
+	}
+	if resp.Members.Users == nil {
+		resp.Members.Users = []string{}
+	}
+	for _, g := range members.Subgroups {
+		resp.Members.Groups = append(resp.Members.Groups, toGroupResponse(g))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	group, err := h.svc.UpdateGroup(r.Context(), tenantID(r), chi.URLParam(r, "id"), req.Name, req.Description)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, toGroupResponse(group))
+}
+
+func (h *GroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.DeleteGroup(r.Context(), tenantID(r), chi.URLParam(r, "id")))
+}
+
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.AddMember(r.Context(), tenantID(r), chi.URLParam(r, "id"), chi.URLParam(r, "userID")))
+}
+
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.RemoveMember(r.Context(), tenantID(r), chi.URLParam(r, "id"), chi.URLParam(r, "userID")))
+}
+
+func (h *GroupHandler) AddSubgroup(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.AddSubgroup(r.Context(), tenantID(r), chi.URLParam(r, "id"), chi.URLParam(r, "groupID")))
+}
+
+func (h *GroupHandler) RemoveSubgroup(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.RemoveSubgroup(r.Context(), tenantID(r), chi.URLParam(r, "id"), chi.URLParam(r, "groupID")))
+}
+
+// ListUserGroups returns the groups of the user in the {id} route parameter,
+// including nested ones.
+func (h *GroupHandler) ListUserGroups(w http.ResponseWriter, r *http.Request) {
+	h.listUserGroups(w, r, chi.URLParam(r, "id"))
+}
+
+// ListMyGroups returns the authenticated user's groups. Clients use it when a
+// token carries groups_overage instead of the groups claim.
+func (h *GroupHandler) ListMyGroups(w http.ResponseWriter, r *http.Request) {
+	h.listUserGroups(w, r, middleware.GetClaims(r.Context()).Subject)
+}
+
+func (h *GroupHandler) listUserGroups(w http.ResponseWriter, r *http.Request, userID string) {
+	groups, err := h.svc.ListUserGroups(r.Context(), userID)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &userGroupListResponse{Groups: make([]*userGroupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, &userGroupResponse{
+			groupResponse: toGroupResponse(&g.Group),
+			Direct:        g.Direct,
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *GroupHandler) respond(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toGroupResponse(group *model.Group) *groupResponse {
+	return &groupResponse{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		Source:      group.Source,
+		ExternalID:  group.ExternalID,
+		CreatedAt:   group.CreatedAt,
+		UpdatedAt:   group.UpdatedAt,
+	}
+}
+
+type groupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type groupResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Source      string    `json:"source,omitempty"`
+	ExternalID  string    `json:"external_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type groupListResponse struct {
+	Groups []*groupResponse `json:"groups"`
+}
+
+type groupMembersResponse struct {
+	Users  []string         `json:"users"`
+	Groups []*groupResponse `json:"groups"`
+}
+
+type groupDetailResponse struct {
+	*groupResponse
+	Members groupMembersResponse `json:"members"`
+}
+
+type userGroupResponse struct {
+	*groupResponse
+	Direct bool `json:"direct"`
+}
+
+type userGroupListResponse struct {
+	Groups []*userGroupResponse `json:"groups"`
+}
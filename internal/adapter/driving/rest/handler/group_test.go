@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestGroupCreate(t *testing.T) {
+	created := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.GroupService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			body: `{"name":"Engineering"}`,
+			mockSetup: func(svc *mocks.GroupService) {
+				svc.EXPECT().CreateGroup(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, g *model.Group) error {
+					g.ID, g.CreatedAt, g.UpdatedAt = "group-1", created, created
+					return nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody: `{"id":"group-1","name":"Engineering","description":"",
+				"created_at":"2026-10-19T16:00:00Z","updated_at":"2026-10-19T16:00:00Z"}`,
+		},
+		{
+			name: "duplicate",
+			body: `{"name":"Engineering"}`,
+			mockSetup: func(svc *mocks.GroupService) {
+				svc.EXPECT().CreateGroup(mock.Anything, mock.Anything).
+					Return(fmt.Errorf("create group: %w", domainerrors.ErrGroupAlreadyExists))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"group already exists","code":"GROUP_EXISTS"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewGroupService(t)
+			tt.mockSetup(svc)
+			h := NewGroupHandler(svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/groups", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.Create(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestGroupGet(t *testing.T) {
+	created := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	svc := mocks.NewGroupService(t)
+	svc.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(
+		&model.Group{ID: "group-1", Name: "Engineering", CreatedAt: created, UpdatedAt: created},
+		&model.GroupMembers{Subgroups: []*model.Group{{
+			ID: "group-2", Name: "Platform", Source: "corp-ad", ExternalID: "CN=Platform", CreatedAt: created, UpdatedAt: created,
+		}}},
+		nil,
+	)
+	h := NewGroupHandler(svc, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/groups/group-1", nil)
+	req = req.WithContext(middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1", Slug: "acme"}))
+	rec := httptest.NewRecorder()
+	h.Get(rec, withUserID(req, "group-1"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"group-1","name":"Engineering","description":"",
+		"created_at":"2026-10-19T16:00:00Z","updated_at":"2026-10-19T16:00:00Z",
+		"members":{"users":[],"groups":[{"id":"group-2","name":"Platform","description":"","source":"corp-ad",
+		"external_id":"CN=Platform","created_at":"2026-10-19T16:00:00Z","updated_at":"2026-10-19T16:00:00Z"}]}}`,
+		rec.Body.String())
+}
+
+func TestGroupAddSubgroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success", wantStatus: http.StatusNoContent},
+		{name: "cycle", err: fmt.Errorf("add subgroup: %w", domainerrors.ErrGroupCycle), wantStatus: http.StatusConflict},
+		{name: "unknown group", err: fmt.Errorf("add subgroup: %w", domainerrors.ErrGroupNotFound), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewGroupService(t)
+			svc.EXPECT().AddSubgroup(mock.Anything, "", "parent", "child").Return(tt.err)
+			h := NewGroupHandler(svc, zap.NewNop())
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "parent")
+			rctx.URLParams.Add("groupID", "child")
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/groups/parent/members/groups/child", nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+			h.AddSubgroup(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestGroupAddMember_ExternallyManaged(t *testing.T) {
+	svc := mocks.NewGroupService(t)
+	svc.EXPECT().AddMember(mock.Anything, "", "group-1", "user-1").Return(domainerrors.ErrGroupExternallyManaged)
+	h := NewGroupHandler(svc, zap.NewNop())
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "group-1")
+	rctx.URLParams.Add("userID", "user-1")
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/groups/group-1/members/users/user-1", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	h.AddMember(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, `{"error":"group is managed by an external directory","code":"GROUP_EXTERNALLY_MANAGED"}`,
+		rec.Body.String())
+}
+
+func TestGroupListMyGroups(t *testing.T) {
+	created := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	svc := mocks.NewGroupService(t)
+	svc.EXPECT().ListUserGroups(mock.Anything, "user-123").Return([]*model.UserGroup{
+		{Group: model.Group{ID: "group-1", Name: "Engineering", CreatedAt: created, UpdatedAt: created}},
+		{Group: model.Group{ID: "group-2", Name: "Platform", CreatedAt: created, UpdatedAt: created}, Direct: true},
+	}, nil)
+	h := NewGroupHandler(svc, zap.NewNop())
+
+	rec := serveProfile(h.ListMyGroups, http.MethodGet, "", http.Header{})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"groups":[
+		{"id":"group-1","name":"Engineering","description":"","created_at":"2026-10-19T16:00:00Z",
+		 "updated_at":"2026-10-19T16:00:00Z","direct":false},
+		{"id":"group-2","name":"Platform","description":"","created_at":"2026-10-19T16:00:00Z",
+		 "updated_at":"2026-10-19T16:00:00Z","direct":true}]}`, rec.Body.String())
+}
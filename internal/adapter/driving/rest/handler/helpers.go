@@ -14,9 +14,20 @@ import (
 
 const maxBodySize = 1 << 20
 
+// retryAfterOverloaded is the Retry-After, in seconds, of responses shed
+// because the service is overloaded.
+const retryAfterOverloaded = "1"
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 	Code  string `json:"code"`
+	// Violations lists the rules a rejected password breaks.
+	Violations []passwordViolationResponse `json:"violations,omitempty"`
+}
+
+type passwordViolationResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 type tokenResponse struct {
@@ -29,6 +40,14 @@ type messageResponse struct {
 	Message string `json:"message"`
 }
 
+// tenantID returns the ID of the tenant the request was resolved to.
+func tenantID(r *http.Request) string {
+	if tenant := middleware.GetTenant(r.Context()); tenant != nil {
+		return tenant.ID
+	}
+	return ""
+}
+
 func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
 	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
 	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
@@ -50,6 +69,15 @@ func respondError(w http.ResponseWriter, status int, msg, code string) {
 }
 
 func handleServiceError(w http.ResponseWriter, r *http.Request, err error, log *zap.Logger) {
+	if policyErr, ok := errors.AsType[*domainerrors.PasswordPolicyError](err); ok {
+		resp := ErrorResponse{Error: policyErr.Error(), Code: "WEAK_PASSWORD"}
+		for _, v := range policyErr.Violations {
+			resp.Violations = append(resp.Violations, passwordViolationResponse{Code: v.Code, Message: v.Message})
+		}
+		respondJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+
 	switch {
 	case errors.Is(err, domainerrors.ErrEmailAlreadyExists):
 		respondError(w, http.StatusConflict, "email already exists", "EMAIL_EXISTS")
@@ -57,16 +85,65 @@ func handleServiceError(w http.ResponseWriter, r *http.Request, err error, log *
 		respondError(w, http.StatusUnauthorized, "invalid credentials", "INVALID_CREDENTIALS")
 	case errors.Is(err, domainerrors.ErrEmailNotVerified):
 		respondError(w, http.StatusForbidden, "email not verified", "EMAIL_NOT_VERIFIED")
+	case errors.Is(err, domainerrors.ErrPasswordResetRequired):
+		respondError(w, http.StatusForbidden, "password reset required", "PASSWORD_RESET_REQUIRED")
 	case errors.Is(err, domainerrors.ErrInvalidVerificationToken):
 		respondError(w, http.StatusBadRequest, "invalid or expired verification token", "INVALID_VERIFICATION_TOKEN")
 	case errors.Is(err, domainerrors.ErrInvalidResetToken):
 		respondError(w, http.StatusBadRequest, "invalid or expired reset token", "INVALID_RESET_TOKEN")
+	case errors.Is(err, domainerrors.ErrInvalidEmailChangeToken):
+		respondError(w, http.StatusBadRequest, "invalid or expired email change token", "INVALID_EMAIL_CHANGE_TOKEN")
+	case errors.Is(err, domainerrors.ErrEmailChangeCooldown):
+		respondError(w, http.StatusTooManyRequests, "email change temporarily blocked", "EMAIL_CHANGE_COOLDOWN")
+	case errors.Is(err, domainerrors.ErrExportInProgress):
+		respondError(w, http.StatusConflict, "data export already in progress", "EXPORT_IN_PROGRESS")
+	case errors.Is(err, domainerrors.ErrInvalidExportLink):
+		respondError(w, http.StatusNotFound, "invalid or expired export link", "INVALID_EXPORT_LINK")
+	case errors.Is(err, domainerrors.ErrInvalidMagicLink):
+		respondError(w, http.StatusBadRequest, "invalid or expired magic link", "INVALID_MAGIC_LINK")
 	case errors.Is(err, domainerrors.ErrInvalidToken):
 		respondError(w, http.StatusUnauthorized, "invalid token", "INVALID_TOKEN")
 	case errors.Is(err, domainerrors.ErrTokenExpired):
 		respondError(w, http.StatusUnauthorized, "token expired", "TOKEN_EXPIRED")
 	case errors.Is(err, domainerrors.ErrTokenRevoked):
 		respondError(w, http.StatusUnauthorized, "token revoked", "TOKEN_REVOKED")
+	case errors.Is(err, domainerrors.ErrUserNotFound):
+		respondError(w, http.StatusNotFound, "user not found", "USER_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrInvalidUserStatus):
+		respondError(w, http.StatusConflict, "operation not allowed in the current user status", "INVALID_USER_STATUS")
+	case errors.Is(err, domainerrors.ErrRoleNotFound):
+		respondError(w, http.StatusNotFound, "role not found", "ROLE_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrRoleAlreadyExists):
+		respondError(w, http.StatusConflict, "role already exists", "ROLE_EXISTS")
+	case errors.Is(err, domainerrors.ErrPermissionNotFound):
+		respondError(w, http.StatusNotFound, "permission not found", "PERMISSION_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrPermissionAlreadyExists):
+		respondError(w, http.StatusConflict, "permission already exists", "PERMISSION_EXISTS")
+	case errors.Is(err, domainerrors.ErrClientNotFound):
+		respondError(w, http.StatusNotFound, "client not found", "CLIENT_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrGroupNotFound):
+		respondError(w, http.StatusNotFound, "group not found", "GROUP_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrGroupAlreadyExists):
+		respondError(w, http.StatusConflict, "group already exists", "GROUP_EXISTS")
+	case errors.Is(err, domainerrors.ErrGroupCycle):
+		respondError(w, http.StatusConflict, "group nesting would create a cycle", "GROUP_CYCLE")
+	case errors.Is(err, domainerrors.ErrGroupExternallyManaged):
+		respondError(w, http.StatusConflict, "group is managed by an external directory", "GROUP_EXTERNALLY_MANAGED")
+	case errors.Is(err, domainerrors.ErrTenantNotFound):
+		respondError(w, http.StatusNotFound, "tenant not found", "TENANT_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrTenantAlreadyExists):
+		respondError(w, http.StatusConflict, "tenant slug or host already in use", "TENANT_EXISTS")
+	case errors.Is(err, domainerrors.ErrSCIMTokenNotFound):
+		respondError(w, http.StatusNotFound, "scim token not found", "SCIM_TOKEN_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrSessionNotFound):
+		respondError(w, http.StatusNotFound, "session not found", "SESSION_NOT_FOUND")
+	case errors.Is(err, domainerrors.ErrInvalidCursor):
+		respondError(w, http.StatusBadRequest, "invalid cursor", "INVALID_CURSOR")
+	case errors.Is(err, domainerrors.ErrPreconditionFailed):
+		respondError(w, http.StatusPreconditionFailed, "resource was modified", "PRECONDITION_FAILED")
+	case errors.Is(err, domainerrors.ErrOverloaded):
+		w.Header().Set("Retry-After", retryAfterOverloaded)
+		respondError(w, http.StatusServiceUnavailable, "service overloaded, retry later", "OVERLOADED")
 	default:
 		if errors.Unwrap(err) == nil {
 			respondError(w, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// maxImportSize is the largest import file accepted. Files beyond that are
+// imported in parts or with the import-users command.
+const maxImportSize = 32 << 20
+
+type ImportService interface {
+	Import(
+		ctx context.Context,
+		tenantID string,
+		format model.ImportFormat,
+		r io.Reader,
+		dryRun bool,
+	) (*model.ImportResult, error)
+}
+
+// ImportHandler creates users of the request's tenant in bulk from a CSV or
+// NDJSON file.
+type ImportHandler struct {
+	svc ImportService
+	log *zap.Logger
+}
+
+func NewImportHandler(svc ImportService, log *zap.Logger) *ImportHandler {
+	return &ImportHandler{svc: svc, log: log}
+}
+
+// Import reads the file from the request body; its Content-Type selects the
+// format. With dry_run=true the file is only checked.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	var format model.ImportFormat
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		format = model.ImportFormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		format = model.ImportFormatNDJSON
+	default:
+		respondError(w, http.StatusUnsupportedMediaType,
+			"content type must be text/csv or application/x-ndjson", "UNSUPPORTED_MEDIA_TYPE")
+		return
+	}
+
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			respondError(w, http.StatusBadRequest, "dry_run: must be true or false", "VALIDATION_ERROR")
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	result, err := h.svc.Import(r.Context(), tenantID(r), format, body, dryRun)
+	if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+		respondError(w, http.StatusRequestEntityTooLarge, "import file too large", "PAYLOAD_TOO_LARGE")
+		return
+	}
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &importResponse{
+		Created: result.Created,
+		Failed:  result.Failed,
+		DryRun:  result.DryRun,
+		Errors:  make([]importErrorResponse, 0, len(result.Errors)),
+	}
+	for _, e := range result.Errors {
+		resp.Errors = append(resp.Errors, importErrorResponse{Line: e.Line, Email: e.Email, Error: e.Error})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+type importResponse struct {
+	Created int                   `json:"created"`
+	Failed  int                   `json:"failed"`
+	DryRun  bool                  `json:"dry_run"`
+	Errors  []importErrorResponse `json:"errors"`
+}
+
+type importErrorResponse struct {
+	Line  int    `json:"line"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		query       string
+		mockSetup   func(svc *mocks.ImportService)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:        "csv",
+			contentType: "text/csv; charset=utf-8",
+			mockSetup: func(svc *mocks.ImportService) {
+				svc.EXPECT().Import(mock.Anything, "tenant-1", model.ImportFormatCSV, mock.Anything, false).
+					Return(&model.ImportResult{
+						Created: 1,
+						Failed:  1,
+						Errors:  []model.ImportError{{Line: 3, Email: "bad", Error: "email: invalid format"}},
+					}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"created":1,"failed":1,"dry_run":false,
+				"errors":[{"line":3,"email":"bad","error":"email: invalid format"}]}`,
+		},
+		{
+			name:        "ndjson dry run",
+			contentType: "application/x-ndjson",
+			query:       "?dry_run=true",
+			mockSetup: func(svc *mocks.ImportService) {
+				svc.EXPECT().Import(mock.Anything, "tenant-1", model.ImportFormatNDJSON, mock.Anything, true).
+					Return(&model.ImportResult{Created: 2, DryRun: true}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"created":2,"failed":0,"dry_run":true,"errors":[]}`,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/json",
+			mockSetup:   func(_ *mocks.ImportService) {},
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantBody: `{"error":"content type must be text/csv or application/x-ndjson",
+				"code":"UNSUPPORTED_MEDIA_TYPE"}`,
+		},
+		{
+			name:        "invalid dry_run",
+			contentType: "text/csv",
+			query:       "?dry_run=maybe",
+			mockSetup:   func(_ *mocks.ImportService) {},
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    `{"error":"dry_run: must be true or false","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name:        "invalid file",
+			contentType: "text/csv",
+			mockSetup: func(svc *mocks.ImportService) {
+				svc.EXPECT().Import(mock.Anything, "tenant-1", model.ImportFormatCSV, mock.Anything, false).
+					Return(nil, errors.New("csv: email column is required"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"csv: email column is required","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name:        "file too large",
+			contentType: "text/csv",
+			mockSetup: func(svc *mocks.ImportService) {
+				svc.EXPECT().Import(mock.Anything, "tenant-1", model.ImportFormatCSV, mock.Anything, false).
+					Return(nil, fmt.Errorf("read csv: %w", &http.MaxBytesError{Limit: maxImportSize}))
+			},
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"error":"import file too large","code":"PAYLOAD_TOO_LARGE"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewImportService(t)
+			tt.mockSetup(svc)
+			h := NewImportHandler(svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import"+tt.query, strings.NewReader("email\n"))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = req.WithContext(middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1"}))
+			rec := httptest.NewRecorder()
+			h.Import(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
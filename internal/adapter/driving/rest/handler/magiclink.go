@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+const (
+	magicLinkBindingCookie = "sso_magic_link_binding"
+	magicLinkCookiePath    = "/api/v1/auth/magic-link"
+	magicLinkBindingLen    = 32
+)
+
+type MagicLinkService interface {
+	RequestLink(ctx context.Context, tenantID, email, binding, linkBase string) error
+	ConsumeLink(ctx context.Context, token, binding string) (*model.TokenPair, error)
+}
+
+type MagicLinkHandler struct {
+	svc MagicLinkService
+	log *zap.Logger
+}
+
+func NewMagicLinkHandler(svc MagicLinkService, log *zap.Logger) *MagicLinkHandler {
+	return &MagicLinkHandler{svc: svc, log: log}
+}
+
+func (h *MagicLinkHandler) Request(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if !middleware.LimitEmail(w, r, req.Email) {
+		return
+	}
+
+	binding, err := crypto.GenerateRandomToken(magicLinkBindingLen)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	if err = h.svc.RequestLink(r.Context(), tenantID(r), req.Email, binding, tenantLinkBase(r)); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkBindingCookie,
+		Value:    binding,
+		Path:     tenantRoutePrefix(r) + magicLinkCookiePath,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respondJSON(w, http.StatusOK, &messageResponse{
+		Message: "if the email exists, a sign-in link has been sent",
+	})
+}
+
+func (h *MagicLinkHandler) Consume(w http.ResponseWriter, r *http.Request) {
+	var req consumeMagicLinkRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required", "VALIDATION_ERROR")
+		return
+	}
+
+	var binding string
+	if c, err := r.Cookie(magicLinkBindingCookie); err == nil {
+		binding = c.Value
+	}
+
+	pair, err := h.svc.ConsumeLink(r.Context(), req.Token, binding)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkBindingCookie,
+		Path:     tenantRoutePrefix(r) + magicLinkCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respondJSON(w, http.StatusOK, &tokenResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+	})
+}
+
+type magicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type consumeMagicLinkRequest struct {
+	Token string `json:"token"`
+}
+
+// tenantRoutePrefix returns the /t/{tenant} prefix the request was routed
+// under, or "" for host-routed requests.
+func tenantRoutePrefix(r *http.Request) string {
+	if chi.URLParam(r, "tenant") == "" {
+		return ""
+	}
+	return "/t/" + tenantSlug(r)
+}
+
+// tenantLinkBase returns where emailed links for the request's tenant point:
+// the tenant's path below the deployment's base URL, the tenant's own host,
+// or "" for the base URL itself. It is built from the resolved tenant rather
+// than the Host header, which the caller controls.
+func tenantLinkBase(r *http.Request) string {
+	if prefix := tenantRoutePrefix(r); prefix != "" {
+		return prefix
+	}
+	if tenant := middleware.GetTenant(r.Context()); tenant != nil && tenant.Host != "" && !tenant.IsDefault() {
+		return "https://" + tenant.Host
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func newMagicLinkHandler(t *testing.T) (*MagicLinkHandler, *mocks.MagicLinkService) {
+	t.Helper()
+	svc := mocks.NewMagicLinkService(t)
+	return NewMagicLinkHandler(svc, zap.NewNop()), svc
+}
+
+func TestMagicLinkRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.MagicLinkService)
+		wantStatus int
+		wantBody   string
+		wantCookie bool
+	}{
+		{
+			name: "success",
+			body: `{"email":"test@example.com"}`,
+			mockSetup: func(svc *mocks.MagicLinkService) {
+				svc.EXPECT().RequestLink(mock.Anything, "", "test@example.com", mock.AnythingOfType("string"), "").
+					Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"message":"if the email exists, a sign-in link has been sent"}`,
+			wantCookie: true,
+		},
+		{
+			name:       "invalid json",
+			body:       `not json`,
+			mockSetup:  func(_ *mocks.MagicLinkService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid request body","code":"INVALID_REQUEST"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, svc := newMagicLinkHandler(t)
+			tt.mockSetup(svc)
+
+			rec := doRequest(h.Request, http.MethodPost, "/api/v1/auth/magic-link", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+
+			cookies := rec.Result().Cookies()
+			if !tt.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			assert.Equal(t, magicLinkBindingCookie, cookies[0].Name)
+			assert.NotEmpty(t, cookies[0].Value)
+			assert.True(t, cookies[0].HttpOnly)
+		})
+	}
+}
+
+func TestMagicLinkConsume(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		cookie     string
+		mockSetup  func(svc *mocks.MagicLinkService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "success with binding cookie",
+			body:   `{"token":"link-token"}`,
+			cookie: "browser-nonce",
+			mockSetup: func(svc *mocks.MagicLinkService) {
+				svc.EXPECT().ConsumeLink(mock.Anything, "link-token", "browser-nonce").
+					Return(&model.TokenPair{AccessToken: "access-tok", RefreshToken: "refresh-tok", ExpiresIn: 900}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"access_token":"access-tok","refresh_token":"refresh-tok","expires_in":900}`,
+		},
+		{
+			name: "invalid link without cookie",
+			body: `{"token":"link-token"}`,
+			mockSetup: func(svc *mocks.MagicLinkService) {
+				svc.EXPECT().ConsumeLink(mock.Anything, "link-token", "").
+					Return(nil, domainerrors.ErrInvalidMagicLink)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid or expired magic link","code":"INVALID_MAGIC_LINK"}`,
+		},
+		{
+			name:       "missing token",
+			body:       `{}`,
+			mockSetup:  func(_ *mocks.MagicLinkService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"token is required","code":"VALIDATION_ERROR"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, svc := newMagicLinkHandler(t)
+			tt.mockSetup(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/magic-link/consume", strings.NewReader(tt.body))
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: magicLinkBindingCookie, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			h.Consume(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestMagicLink_TenantRoute(t *testing.T) {
+	h, svc := newMagicLinkHandler(t)
+	svc.EXPECT().RequestLink(mock.Anything, "tenant-id", "test@example.com", mock.AnythingOfType("string"), "/t/acme").
+		Return(nil)
+	svc.EXPECT().ConsumeLink(mock.Anything, "link-token", "browser-nonce").
+		Return(&model.TokenPair{AccessToken: "access-tok"}, nil)
+
+	router := chi.NewRouter()
+	router.Route("/t/{tenant}", func(r chi.Router) {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tenant := &model.Tenant{ID: "tenant-id", Slug: "acme"}
+				next.ServeHTTP(w, r.WithContext(middleware.WithTenant(r.Context(), tenant)))
+			})
+		})
+		r.Post("/api/v1/auth/magic-link", h.Request)
+		r.Post("/api/v1/auth/magic-link/consume", h.Consume)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/t/acme/api/v1/auth/magic-link", strings.NewReader(`{"email":"test@example.com"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "/t/acme/api/v1/auth/magic-link", cookies[0].Path)
+
+	req = httptest.NewRequest(http.MethodPost, "/t/acme/api/v1/auth/magic-link/consume", strings.NewReader(`{"token":"link-token"}`))
+	req.AddCookie(&http.Cookie{Name: magicLinkBindingCookie, Value: "browser-nonce"})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies = rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "/t/acme/api/v1/auth/magic-link", cookies[0].Path)
+}
+
+func TestMagicLink_HostRoutedTenantLinkBase(t *testing.T) {
+	h, svc := newMagicLinkHandler(t)
+	svc.EXPECT().RequestLink(mock.Anything, "tenant-id", "test@example.com", mock.AnythingOfType("string"),
+		"https://login.acme.example").Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/magic-link", strings.NewReader(`{"email":"test@example.com"}`))
+	req.Host = "evil.example"
+	tenant := &model.Tenant{ID: "tenant-id", Slug: "acme", Host: "login.acme.example"}
+	req = req.WithContext(middleware.WithTenant(req.Context(), tenant))
+	rec := httptest.NewRecorder()
+	h.Request(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "/api/v1/auth/magic-link", cookies[0].Path)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type PasswordHashStatsProvider interface {
+	Stats() (model.PasswordHashStats, bool)
+}
+
+type HashPoolStatsProvider interface {
+	Stats() model.HashPoolStats
+}
+
+// MetricsHandler exposes service metrics in the Prometheus text format.
+type MetricsHandler struct {
+	hashes PasswordHashStatsProvider
+	pool   HashPoolStatsProvider
+}
+
+func NewMetricsHandler(hashes PasswordHashStatsProvider, pool HashPoolStatsProvider) *MetricsHandler {
+	return &MetricsHandler{hashes: hashes, pool: pool}
+}
+
+// Metrics writes the metrics. The password hash gauges are left out until the
+// first count of the stored hashes completes.
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, _ *http.Request) {
+	var b strings.Builder
+	writeHashPoolMetrics(&b, h.pool.Stats())
+	if stats, ok := h.hashes.Stats(); ok {
+		b.WriteString("# HELP sso_password_hashes Stored password hashes by whether they meet the current hashing parameters.\n")
+		b.WriteString("# TYPE sso_password_hashes gauge\n")
+		fmt.Fprintf(&b, "sso_password_hashes{status=\"current\"} %d\n", stats.Current)
+		fmt.Fprintf(&b, "sso_password_hashes{status=\"outdated\"} %d\n", stats.Outdated)
+		b.WriteString("# HELP sso_password_hashes_outdated_ratio Share of stored password hashes awaiting a rehash on login.\n")
+		b.WriteString("# TYPE sso_password_hashes_outdated_ratio gauge\n")
+		fmt.Fprintf(&b, "sso_password_hashes_outdated_ratio %g\n", stats.OutdatedShare())
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(b.String())) //nolint:gosec // error writing response body is unrecoverable
+}
+
+func writeHashPoolMetrics(b *strings.Builder, stats model.HashPoolStats) {
+	b.WriteString("# HELP sso_password_hash_workers Password hashes that may run at once.\n")
+	b.WriteString("# TYPE sso_password_hash_workers gauge\n")
+	fmt.Fprintf(b, "sso_password_hash_workers %d\n", stats.Workers)
+	b.WriteString("# HELP sso_password_hash_in_flight Password hashes running.\n")
+	b.WriteString("# TYPE sso_password_hash_in_flight gauge\n")
+	fmt.Fprintf(b, "sso_password_hash_in_flight %d\n", stats.InFlight)
+	b.WriteString("# HELP sso_password_hash_queue_depth Password hashes waiting for a worker.\n")
+	b.WriteString("# TYPE sso_password_hash_queue_depth gauge\n")
+	fmt.Fprintf(b, "sso_password_hash_queue_depth %d\n", stats.Queued)
+	b.WriteString("# HELP sso_password_hash_rejected_total Password hashes rejected because the queue was full or the wait too long.\n")
+	b.WriteString("# TYPE sso_password_hash_rejected_total counter\n")
+	fmt.Fprintf(b, "sso_password_hash_rejected_total %d\n", stats.Rejected)
+
+	d := stats.Duration
+	b.WriteString("# HELP sso_password_hash_duration_seconds Time to hash or verify a password, without the wait for a worker.\n")
+	b.WriteString("# TYPE sso_password_hash_duration_seconds histogram\n")
+	for i, bound := range d.Bounds {
+		fmt.Fprintf(b, "sso_password_hash_duration_seconds_bucket{le=\"%g\"} %d\n", bound, d.Counts[i])
+	}
+	fmt.Fprintf(b, "sso_password_hash_duration_seconds_bucket{le=\"+Inf\"} %d\n", d.Count)
+	fmt.Fprintf(b, "sso_password_hash_duration_seconds_sum %g\n", d.Sum)
+	fmt.Fprintf(b, "sso_password_hash_duration_seconds_count %d\n", d.Count)
+}
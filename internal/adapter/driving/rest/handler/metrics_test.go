@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestMetrics(t *testing.T) {
+	poolStats := model.HashPoolStats{
+		Workers:  4,
+		InFlight: 2,
+		Queued:   5,
+		Rejected: 7,
+		Duration: model.Histogram{Bounds: []float64{0.1, 1}, Counts: []uint64{1, 3}, Count: 4, Sum: 2.5},
+	}
+	poolBody := "# HELP sso_password_hash_workers Password hashes that may run at once.\n" +
+		"# TYPE sso_password_hash_workers gauge\n" +
+		"sso_password_hash_workers 4\n" +
+		"# HELP sso_password_hash_in_flight Password hashes running.\n" +
+		"# TYPE sso_password_hash_in_flight gauge\n" +
+		"sso_password_hash_in_flight 2\n" +
+		"# HELP sso_password_hash_queue_depth Password hashes waiting for a worker.\n" +
+		"# TYPE sso_password_hash_queue_depth gauge\n" +
+		"sso_password_hash_queue_depth 5\n" +
+		"# HELP sso_password_hash_rejected_total Password hashes rejected because the queue was full or the wait too long.\n" +
+		"# TYPE sso_password_hash_rejected_total counter\n" +
+		"sso_password_hash_rejected_total 7\n" +
+		"# HELP sso_password_hash_duration_seconds Time to hash or verify a password, without the wait for a worker.\n" +
+		"# TYPE sso_password_hash_duration_seconds histogram\n" +
+		"sso_password_hash_duration_seconds_bucket{le=\"0.1\"} 1\n" +
+		"sso_password_hash_duration_seconds_bucket{le=\"1\"} 3\n" +
+		"sso_password_hash_duration_seconds_bucket{le=\"+Inf\"} 4\n" +
+		"sso_password_hash_duration_seconds_sum 2.5\n" +
+		"sso_password_hash_duration_seconds_count 4\n"
+
+	tests := []struct {
+		name     string
+		stats    model.PasswordHashStats
+		counted  bool
+		wantBody string
+	}{
+		{
+			name:    "counted",
+			stats:   model.PasswordHashStats{Current: 3, Outdated: 1},
+			counted: true,
+			wantBody: poolBody +
+				"# HELP sso_password_hashes Stored password hashes by whether they meet the current hashing parameters.\n" +
+				"# TYPE sso_password_hashes gauge\n" +
+				"sso_password_hashes{status=\"current\"} 3\n" +
+				"sso_password_hashes{status=\"outdated\"} 1\n" +
+				"# HELP sso_password_hashes_outdated_ratio Share of stored password hashes awaiting a rehash on login.\n" +
+				"# TYPE sso_password_hashes_outdated_ratio gauge\n" +
+				"sso_password_hashes_outdated_ratio 0.25\n",
+		},
+		{
+			name:     "not counted yet",
+			wantBody: poolBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashes := mocks.NewPasswordHashStatsProvider(t)
+			hashes.EXPECT().Stats().Return(tt.stats, tt.counted)
+			pool := mocks.NewHashPoolStatsProvider(t)
+			pool.EXPECT().Stats().Return(poolStats)
+			h := NewMetricsHandler(hashes, pool)
+
+			rec := httptest.NewRecorder()
+			h.Metrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type ProfileService interface {
+	GetUser(ctx context.Context, userID string) (*model.User, error)
+	UpdateProfile(ctx context.Context, userID string, update model.ProfileUpdate, version time.Time) (*model.User, error)
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword, keepRefreshToken string) error
+	RequestEmailChange(ctx context.Context, userID, newEmail string) error
+	DeleteAccount(ctx context.Context, userID, password string) error
+}
+
+// ProfileHandler serves the authenticated user's own account. The user's
+// UpdatedAt is exposed as the ETag, and updates must send it back in
+// If-Match.
+type ProfileHandler struct {
+	svc ProfileService
+	log *zap.Logger
+}
+
+func NewProfileHandler(svc ProfileService, log *zap.Logger) *ProfileHandler {
+	return &ProfileHandler{svc: svc, log: log}
+}
+
+func (h *ProfileHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := h.svc.GetUser(r.Context(), middleware.GetClaims(r.Context()).Subject)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	etag := userETag(user)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	respondJSON(w, http.StatusOK, toProfileResponse(user))
+}
+
+func (h *ProfileHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondError(w, http.StatusPreconditionRequired, "If-Match header is required", "PRECONDITION_REQUIRED")
+		return
+	}
+	version, ok := parseUserETag(ifMatch)
+	if !ok {
+		respondError(w, http.StatusPreconditionFailed, "resource was modified", "PRECONDITION_FAILED")
+		return
+	}
+
+	var req updateProfileRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	user, err := h.svc.UpdateProfile(r.Context(), middleware.GetClaims(r.Context()).Subject, model.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+		AvatarURL:   req.AvatarURL,
+	}, version)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user))
+	respondJSON(w, http.StatusOK, toProfileResponse(user))
+}
+
+// ChangePassword signs out every other session. Sending the current refresh
+// token keeps this session signed in.
+func (h *ProfileHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req changePasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.CurrentPassword == "" {
+		respondError(w, http.StatusBadRequest, "current_password is required", "VALIDATION_ERROR")
+		return
+	}
+
+	err := h.svc.ChangePassword(r.Context(), middleware.GetClaims(r.Context()).Subject,
+		req.CurrentPassword, req.NewPassword, req.RefreshToken)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProfileHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req changeEmailRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	if err := h.svc.RequestEmailChange(r.Context(), middleware.GetClaims(r.Context()).Subject, req.NewEmail); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, &messageResponse{
+		Message: "check your new email to confirm the change",
+	})
+}
+
+// Delete schedules the account for deletion. The password is asked for again
+// so that a stolen access token is not enough to delete an account.
+func (h *ProfileHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	var req deleteAccountRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Password == "" {
+		respondError(w, http.StatusBadRequest, "password is required", "VALIDATION_ERROR")
+		return
+	}
+
+	if err := h.svc.DeleteAccount(r.Context(), middleware.GetClaims(r.Context()).Subject, req.Password); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, &messageResponse{
+		Message: "account scheduled for deletion; log in again during the grace period to restore it",
+	})
+}
+
+// userETag encodes UpdatedAt at the microsecond precision PostgreSQL stores.
+func userETag(user *model.User) string {
+	return `"` + strconv.FormatInt(user.UpdatedAt.UnixMicro(), 10) + `"`
+}
+
+func parseUserETag(etag string) (time.Time, bool) {
+	raw, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return time.Time{}, false
+	}
+	raw, ok = strings.CutSuffix(raw, `"`)
+	if !ok {
+		return time.Time{}, false
+	}
+	micros, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMicro(micros), true
+}
+
+func toProfileResponse(user *model.User) *profileResponse {
+	return &profileResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		MFAEnabled:    user.MFAEnabled,
+		DisplayName:   user.Profile.DisplayName,
+		Locale:        user.Profile.Locale,
+		Timezone:      user.Profile.Timezone,
+		AvatarURL:     user.Profile.AvatarURL,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+type updateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	Locale      *string `json:"locale"`
+	Timezone    *string `json:"timezone"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	RefreshToken    string `json:"refresh_token"`
+}
+
+type changeEmailRequest struct {
+	NewEmail string `json:"new_email"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type profileResponse struct {
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	MFAEnabled    bool      `json:"mfa_enabled"`
+	DisplayName   string    `json:"display_name"`
+	Locale        string    `json:"locale"`
+	Timezone      string    `json:"timezone"`
+	AvatarURL     string    `json:"avatar_url"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
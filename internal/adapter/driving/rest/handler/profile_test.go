@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type staticValidator string
+
+func (v staticValidator) ValidateAccessToken(_ context.Context, _ string) (*model.AccessTokenClaims, error) {
+	return &model.AccessTokenClaims{Subject: string(v)}, nil
+}
+
+var profileUpdatedAt = time.UnixMicro(1760868000123456).UTC()
+
+func profileUser() *model.User {
+	return &model.User{
+		ID:            "user-123",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Profile:       model.Profile{DisplayName: "Ann", Locale: "en-GB"},
+		CreatedAt:     profileUpdatedAt.Add(-time.Hour),
+		UpdatedAt:     profileUpdatedAt,
+	}
+}
+
+// serveProfile sends an authenticated request for user-123 to h.
+func serveProfile(h http.HandlerFunc, method, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/me", strings.NewReader(body))
+	req.Header = header
+	req.Header.Set("Authorization", "Bearer access")
+	rec := httptest.NewRecorder()
+	middleware.Authenticate(staticValidator("user-123"))(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProfileGet(t *testing.T) {
+	const etag = `"1760868000123456"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		mockSetup   func(svc *mocks.ProfileService)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name: "success",
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().GetUser(mock.Anything, "user-123").Return(profileUser(), nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"id":"user-123","email":"user@example.com","email_verified":true,"mfa_enabled":false,
+				"display_name":"Ann","locale":"en-GB","timezone":"","avatar_url":"",
+				"created_at":"2025-10-19T09:00:00.123456Z","updated_at":"2025-10-19T10:00:00.123456Z"}`,
+		},
+		{
+			name:        "not modified",
+			ifNoneMatch: etag,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().GetUser(mock.Anything, "user-123").Return(profileUser(), nil)
+			},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name: "user gone",
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().GetUser(mock.Anything, "user-123").
+					Return(nil, fmt.Errorf("get user by id: %w", domainerrors.ErrUserNotFound))
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"user not found","code":"USER_NOT_FOUND"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewProfileService(t)
+			tt.mockSetup(svc)
+			h := NewProfileHandler(svc, zap.NewNop())
+
+			header := http.Header{}
+			if tt.ifNoneMatch != "" {
+				header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := serveProfile(h.Get, http.MethodGet, "", header)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusNotFound {
+				assert.Empty(t, rec.Header().Get("ETag"))
+			} else {
+				assert.Equal(t, etag, rec.Header().Get("ETag"))
+			}
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProfileUpdate(t *testing.T) {
+	locale := "de-DE"
+	empty := ""
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		body       string
+		mockSetup  func(svc *mocks.ProfileService)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:    "success",
+			ifMatch: `"1760868000123456"`,
+			body:    `{"locale":"de-DE","avatar_url":""}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				updated := profileUser()
+				updated.UpdatedAt = profileUpdatedAt.Add(time.Second)
+				svc.EXPECT().UpdateProfile(mock.Anything, "user-123",
+					model.ProfileUpdate{Locale: &locale, AvatarURL: &empty}, profileUpdatedAt.Local()).
+					Return(updated, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "missing If-Match",
+			body:       `{}`,
+			mockSetup:  func(_ *mocks.ProfileService) {},
+			wantStatus: http.StatusPreconditionRequired,
+			wantCode:   "PRECONDITION_REQUIRED",
+		},
+		{
+			name:       "malformed If-Match",
+			ifMatch:    `W/"1"`,
+			body:       `{}`,
+			mockSetup:  func(_ *mocks.ProfileService) {},
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "PRECONDITION_FAILED",
+		},
+		{
+			name:    "stale version",
+			ifMatch: `"1"`,
+			body:    `{"display_name":"Bob"}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().UpdateProfile(mock.Anything, "user-123", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("update profile: %w", domainerrors.ErrPreconditionFailed))
+			},
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "PRECONDITION_FAILED",
+		},
+		{
+			name:    "validation error",
+			ifMatch: `"1760868000123456"`,
+			body:    `{"timezone":"Mars/Olympus"}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().UpdateProfile(mock.Anything, "user-123", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("timezone: unknown time zone"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "VALIDATION_ERROR",
+		},
+		{
+			name:       "invalid json",
+			ifMatch:    `"1760868000123456"`,
+			body:       `{bad`,
+			mockSetup:  func(_ *mocks.ProfileService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_REQUEST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewProfileService(t)
+			tt.mockSetup(svc)
+			h := NewProfileHandler(svc, zap.NewNop())
+
+			header := http.Header{}
+			if tt.ifMatch != "" {
+				header.Set("If-Match", tt.ifMatch)
+			}
+			rec := serveProfile(h.Update, http.MethodPatch, tt.body, header)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+				return
+			}
+			assert.Equal(t, `"1760868001123456"`, rec.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestProfileChangePassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.ProfileService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			body: `{"current_password":"oldpassword","new_password":"newpassword","refresh_token":"raw"}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().ChangePassword(mock.Anything, "user-123", "oldpassword", "newpassword", "raw").Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "missing current password",
+			body:       `{"new_password":"newpassword"}`,
+			mockSetup:  func(_ *mocks.ProfileService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"current_password is required","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name: "wrong current password",
+			body: `{"current_password":"wrong","new_password":"newpassword"}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().ChangePassword(mock.Anything, "user-123", "wrong", "newpassword", "").
+					Return(domainerrors.ErrInvalidCredentials)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `{"error":"invalid credentials","code":"INVALID_CREDENTIALS"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewProfileService(t)
+			tt.mockSetup(svc)
+			h := NewProfileHandler(svc, zap.NewNop())
+
+			rec := serveProfile(h.ChangePassword, http.MethodPost, tt.body, http.Header{})
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProfileRequestEmailChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockErr    error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusAccepted,
+			wantBody:   `{"message":"check your new email to confirm the change"}`,
+		},
+		{
+			name:       "cooldown",
+			mockErr:    domainerrors.ErrEmailChangeCooldown,
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   `{"error":"email change temporarily blocked","code":"EMAIL_CHANGE_COOLDOWN"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewProfileService(t)
+			svc.EXPECT().RequestEmailChange(mock.Anything, "user-123", "new@example.com").Return(tt.mockErr)
+			h := NewProfileHandler(svc, zap.NewNop())
+
+			rec := serveProfile(h.RequestEmailChange, http.MethodPost, `{"new_email":"new@example.com"}`, http.Header{})
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestProfileDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.ProfileService)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "success",
+			body: `{"password":"securepassword"}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().DeleteAccount(mock.Anything, "user-123", "securepassword").Return(nil)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "missing password",
+			body:       `{}`,
+			mockSetup:  func(_ *mocks.ProfileService) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "VALIDATION_ERROR",
+		},
+		{
+			name: "wrong password",
+			body: `{"password":"wrong"}`,
+			mockSetup: func(svc *mocks.ProfileService) {
+				svc.EXPECT().DeleteAccount(mock.Anything, "user-123", "wrong").Return(domainerrors.ErrInvalidCredentials)
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "INVALID_CREDENTIALS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewProfileService(t)
+			tt.mockSetup(svc)
+			h := NewProfileHandler(svc, zap.NewNop())
+
+			rec := serveProfile(h.Delete, http.MethodDelete, tt.body, http.Header{})
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.wantCode+`"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type RBACService interface {
+	CreateRole(ctx context.Context, role *model.Role) error
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	DeleteRole(ctx context.Context, id string) error
+	SetRolePermissions(ctx context.Context, roleID string, permissions []string) error
+	CreatePermission(ctx context.Context, permission *model.Permission) error
+	ListPermissions(ctx context.Context) ([]*model.Permission, error)
+	DeletePermission(ctx context.Context, id string) error
+	ListUserRoles(ctx context.Context, userID string) ([]*model.Role, error)
+	AssignRole(ctx context.Context, userID, roleID string) error
+	UnassignRole(ctx context.Context, userID, roleID string) error
+}
+
+type RBACHandler struct {
+	svc RBACService
+	log *zap.Logger
+}
+
+func NewRBACHandler(svc RBACService, log *zap.Logger) *RBACHandler {
+	return &RBACHandler{svc: svc, log: log}
+}
+
+func (h *RBACHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req createRoleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	role := &model.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		ClientID:    req.ClientID,
+		Permissions: req.Permissions,
+	}
+	if err := h.svc.CreateRole(r.Context(), role); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusCreated, toRoleResponse(role))
+}
+
+func (h *RBACHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.svc.ListRoles(r.Context())
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, toRoleListResponse(roles))
+}
+
+func (h *RBACHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.DeleteRole(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *RBACHandler) SetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	var req setPermissionsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	h.respond(w, r, h.svc.SetRolePermissions(r.Context(), chi.URLParam(r, "id"), req.Permissions))
+}
+
+func (h *RBACHandler) CreatePermission(w http.ResponseWriter, r *http.Request) {
+	var req createPermissionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	permission := &model.Permission{Name: req.Name, Description: req.Description}
+	if err := h.svc.CreatePermission(r.Context(), permission); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusCreated, toPermissionResponse(permission))
+}
+
+func (h *RBACHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.svc.ListPermissions(r.Context())
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &permissionListResponse{Permissions: make([]*permissionResponse, 0, len(permissions))}
+	for _, p := range permissions {
+		resp.Permissions = append(resp.Permissions, toPermissionResponse(p))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *RBACHandler) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.DeletePermission(r.Context(), chi.URLParam(r, "id")))
+}
+
+func (h *RBACHandler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.svc.ListUserRoles(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, toRoleListResponse(roles))
+}
+
+func (h *RBACHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.AssignRole(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "roleID")))
+}
+
+func (h *RBACHandler) UnassignRole(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.svc.UnassignRole(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "roleID")))
+}
+
+func (h *RBACHandler) respond(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toRoleResponse(role *model.Role) *roleResponse {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return &roleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		ClientID:    role.ClientID,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+func toRoleListResponse(roles []*model.Role) *roleListResponse {
+	resp := &roleListResponse{Roles: make([]*roleResponse, 0, len(roles))}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, toRoleResponse(role))
+	}
+	return resp
+}
+
+func toPermissionResponse(permission *model.Permission) *permissionResponse {
+	return &permissionResponse{
+		ID:          permission.ID,
+		Name:        permission.Name,
+		Description: permission.Description,
+		CreatedAt:   permission.CreatedAt,
+	}
+}
+
+type createRoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	ClientID    string   `json:"client_id"`
+	Permissions []string `json:"permissions"`
+}
+
+type setPermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type createPermissionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type roleResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ClientID    string    `json:"client_id,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type roleListResponse struct {
+	Roles []*roleResponse `json:"roles"`
+}
+
+type permissionResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type permissionListResponse struct {
+	Permissions []*permissionResponse `json:"permissions"`
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestRBACCreateRole(t *testing.T) {
+	created := time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.RBACService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			body: `{"name":"editor","description":"Edits docs","permissions":["docs:write"]}`,
+			mockSetup: func(svc *mocks.RBACService) {
+				svc.EXPECT().CreateRole(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, role *model.Role) error {
+					role.ID = "role-1"
+					role.CreatedAt = created
+					return nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody: `{"id":"role-1","name":"editor","description":"Edits docs","permissions":["docs:write"],
+				"created_at":"2026-10-19T15:00:00Z"}`,
+		},
+		{
+			name: "duplicate",
+			body: `{"name":"editor"}`,
+			mockSetup: func(svc *mocks.RBACService) {
+				svc.EXPECT().CreateRole(mock.Anything, mock.Anything).
+					Return(fmt.Errorf("create role: %w", domainerrors.ErrRoleAlreadyExists))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"role already exists","code":"ROLE_EXISTS"}`,
+		},
+		{
+			name: "unknown permission",
+			body: `{"name":"editor","permissions":["docs:delete"]}`,
+			mockSetup: func(svc *mocks.RBACService) {
+				svc.EXPECT().CreateRole(mock.Anything, mock.Anything).
+					Return(fmt.Errorf("create role: %w", domainerrors.ErrPermissionNotFound))
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"permission not found","code":"PERMISSION_NOT_FOUND"}`,
+		},
+		{
+			name:       "malformed body",
+			body:       `{`,
+			mockSetup:  func(_ *mocks.RBACService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid request body","code":"INVALID_REQUEST"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewRBACService(t)
+			tt.mockSetup(svc)
+			h := NewRBACHandler(svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/roles", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.CreateRole(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRBACListUserRoles(t *testing.T) {
+	svc := mocks.NewRBACService(t)
+	svc.EXPECT().ListUserRoles(mock.Anything, "user-uuid").Return([]*model.Role{
+		{ID: "role-1", Name: "admin", CreatedAt: time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)},
+	}, nil)
+	h := NewRBACHandler(svc, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users/user-uuid/roles", nil)
+	rec := httptest.NewRecorder()
+	h.ListUserRoles(rec, withUserID(req, "user-uuid"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"roles":[{"id":"role-1","name":"admin","description":"","permissions":[],
+		"created_at":"2026-10-19T15:00:00Z"}]}`, rec.Body.String())
+}
+
+func TestRBACAssignRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success", wantStatus: http.StatusNoContent},
+		{name: "unknown role", err: fmt.Errorf("assign role: %w", domainerrors.ErrRoleNotFound), wantStatus: http.StatusNotFound},
+		{name: "unknown user", err: fmt.Errorf("assign role: %w", domainerrors.ErrUserNotFound), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewRBACService(t)
+			svc.EXPECT().AssignRole(mock.Anything, "user-uuid", "role-uuid").Return(tt.err)
+			h := NewRBACHandler(svc, zap.NewNop())
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "user-uuid")
+			rctx.URLParams.Add("roleID", "role-uuid")
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/user-uuid/roles/role-uuid", nil)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := httptest.NewRecorder()
+			h.AssignRole(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestRBACSetRolePermissions(t *testing.T) {
+	svc := mocks.NewRBACService(t)
+	svc.EXPECT().SetRolePermissions(mock.Anything, "role-uuid", []string{"docs:read"}).Return(nil)
+	h := NewRBACHandler(svc, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/roles/role-uuid/permissions",
+		strings.NewReader(`{"permissions":["docs:read"]}`))
+	rec := httptest.NewRecorder()
+	h.SetRolePermissions(rec, withUserID(req, "role-uuid"))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
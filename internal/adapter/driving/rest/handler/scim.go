@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type SCIMTokenService interface {
+	CreateToken(ctx context.Context, tenantID, name string) (*model.SCIMToken, string, error)
+	ListTokens(ctx context.Context, tenantID string) ([]*model.SCIMToken, error)
+	DeleteToken(ctx context.Context, tenantID, id string) error
+}
+
+// SCIMTokenHandler manages the bearer tokens SCIM provisioning clients of the
+// request's tenant authenticate with.
+type SCIMTokenHandler struct {
+	svc SCIMTokenService
+	log *zap.Logger
+}
+
+func NewSCIMTokenHandler(svc SCIMTokenService, log *zap.Logger) *SCIMTokenHandler {
+	return &SCIMTokenHandler{svc: svc, log: log}
+}
+
+// Create issues a token. The raw token is only returned here.
+func (h *SCIMTokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req scimTokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	token, raw, err := h.svc.CreateToken(r.Context(), tenantID(r), req.Name)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	resp := toSCIMTokenResponse(token)
+	resp.Token = raw
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+func (h *SCIMTokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.svc.ListTokens(r.Context(), tenantID(r))
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &scimTokenListResponse{Tokens: make([]*scimTokenResponse, 0, len(tokens))}
+	for _, t := range tokens {
+		resp.Tokens = append(resp.Tokens, toSCIMTokenResponse(t))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *SCIMTokenHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteToken(r.Context(), tenantID(r), chi.URLParam(r, "id")); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toSCIMTokenResponse(token *model.SCIMToken) *scimTokenResponse {
+	return &scimTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		CreatedAt:  token.CreatedAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
+
+type scimTokenRequest struct {
+	Name string `json:"name"`
+}
+
+type scimTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type scimTokenListResponse struct {
+	Tokens []*scimTokenResponse `json:"tokens"`
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestSCIMTokenCreate(t *testing.T) {
+	created := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.SCIMTokenService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			body: `{"name":"Okta"}`,
+			mockSetup: func(svc *mocks.SCIMTokenService) {
+				svc.EXPECT().CreateToken(mock.Anything, "tenant-1", "Okta").
+					Return(&model.SCIMToken{ID: "token-1", TenantID: "tenant-1", Name: "Okta", CreatedAt: created}, "raw", nil)
+			},
+			wantStatus: http.StatusCreated,
+			wantBody: `{"id":"token-1","name":"Okta","token":"raw","created_at":"2026-10-19T18:00:00Z",
+				"last_used_at":null}`,
+		},
+		{
+			name:       "malformed body",
+			body:       `{"name":`,
+			mockSetup:  func(_ *mocks.SCIMTokenService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid request body","code":"INVALID_REQUEST"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewSCIMTokenService(t)
+			tt.mockSetup(svc)
+			h := NewSCIMTokenHandler(svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/scim/tokens", strings.NewReader(tt.body))
+			req = req.WithContext(middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1"}))
+			rec := httptest.NewRecorder()
+			h.Create(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestSCIMTokenList(t *testing.T) {
+	used := time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC)
+	svc := mocks.NewSCIMTokenService(t)
+	svc.EXPECT().ListTokens(mock.Anything, "tenant-1").Return([]*model.SCIMToken{
+		{ID: "token-1", Name: "Okta", TokenHash: "hash", CreatedAt: used, LastUsedAt: &used},
+	}, nil)
+	h := NewSCIMTokenHandler(svc, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/scim/tokens", nil)
+	req = req.WithContext(middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1"}))
+	rec := httptest.NewRecorder()
+	h.List(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"tokens":[{"id":"token-1","name":"Okta","created_at":"2026-10-19T19:00:00Z",
+		"last_used_at":"2026-10-19T19:00:00Z"}]}`, rec.Body.String())
+}
+
+func TestSCIMTokenDelete(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success", wantStatus: http.StatusNoContent},
+		{
+			name:       "unknown token",
+			err:        fmt.Errorf("delete scim token: %w", domainerrors.ErrSCIMTokenNotFound),
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewSCIMTokenService(t)
+			svc.EXPECT().DeleteToken(mock.Anything, "tenant-1", "token-1").Return(tt.err)
+			h := NewSCIMTokenHandler(svc, zap.NewNop())
+
+			req := withUserID(httptest.NewRequest(http.MethodDelete, "/api/v1/admin/scim/tokens/token-1", nil), "token-1")
+			req = req.WithContext(middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1"}))
+			rec := httptest.NewRecorder()
+			h.Delete(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userID string) ([]*model.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) error
+}
+
+// SessionHandler lets the authenticated user see where they are signed in
+// and sign out sessions remotely. The session of the request is the one its
+// access token was issued to.
+type SessionHandler struct {
+	svc SessionService
+	log *zap.Logger
+}
+
+func NewSessionHandler(svc SessionService, log *zap.Logger) *SessionHandler {
+	return &SessionHandler{svc: svc, log: log}
+}
+
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetClaims(r.Context())
+	sessions, err := h.svc.ListSessions(r.Context(), claims.Subject)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &sessionListResponse{Sessions: make([]*sessionResponse, 0, len(sessions))}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, &sessionResponse{
+			ID:              s.ID,
+			ClientID:        s.ClientID,
+			ClientName:      s.ClientName,
+			UserAgent:       s.UserAgent,
+			IP:              s.IP,
+			CreatedAt:       s.CreatedAt,
+			LastRefreshedAt: s.LastRefreshedAt,
+			Current:         s.ID == claims.SessionID,
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Revoke signs out one session, which may be the current one. Access tokens
+// already issued to it stay valid until they expire. An id that is not a
+// UUID cannot name a session and is answered as an unknown one.
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	if err := uuid.Validate(sessionID); err != nil {
+		respondError(w, http.StatusNotFound, "session not found", "SESSION_NOT_FOUND")
+		return
+	}
+
+	err := h.svc.RevokeSession(r.Context(), middleware.GetClaims(r.Context()).Subject, sessionID)
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOthers signs out every session but the current one. Access tokens
+// issued before sessions were tracked name no session, so signing in again
+// is required first.
+func (h *SessionHandler) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetClaims(r.Context())
+	if claims.SessionID == "" {
+		respondError(w, http.StatusConflict, "access token is not bound to a session, sign in again", "SESSION_UNKNOWN")
+		return
+	}
+
+	if err := h.svc.RevokeOtherSessions(r.Context(), claims.Subject, claims.SessionID); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type sessionListResponse struct {
+	Sessions []*sessionResponse `json:"sessions"`
+}
+
+type sessionResponse struct {
+	ID              string    `json:"id"`
+	ClientID        string    `json:"client_id,omitempty"`
+	ClientName      string    `json:"client_name,omitempty"`
+	UserAgent       string    `json:"user_agent"`
+	IP              string    `json:"ip"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	Current         bool      `json:"current"`
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// sessionValidator accepts any access token as one of user-123 issued to the
+// session it names.
+type sessionValidator string
+
+func (v sessionValidator) ValidateAccessToken(_ context.Context, _ string) (*model.AccessTokenClaims, error) {
+	return &model.AccessTokenClaims{Subject: "user-123", SessionID: string(v)}, nil
+}
+
+func serveSession(h http.HandlerFunc, req *http.Request, sessionID string) *httptest.ResponseRecorder {
+	req.Header.Set("Authorization", "Bearer access")
+	rec := httptest.NewRecorder()
+	middleware.Authenticate(sessionValidator(sessionID))(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSessionList(t *testing.T) {
+	created := time.Date(2026, 10, 19, 16, 0, 0, 0, time.UTC)
+	refreshed := created.Add(time.Hour)
+	svc := mocks.NewSessionService(t)
+	svc.EXPECT().ListSessions(mock.Anything, "user-123").Return([]*model.Session{
+		{ID: "family-1", UserAgent: "Firefox", IP: "203.0.113.7", CreatedAt: created, LastRefreshedAt: refreshed},
+		{ID: "family-2", ClientID: "client-1", ClientName: "Mobile", CreatedAt: created, LastRefreshedAt: created},
+	}, nil)
+	h := NewSessionHandler(svc, zap.NewNop())
+
+	rec := serveSession(h.List, httptest.NewRequest(http.MethodGet, "/api/v1/me/sessions", nil), "family-1")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"sessions":[
+		{"id":"family-1","user_agent":"Firefox","ip":"203.0.113.7","created_at":"2026-10-19T16:00:00Z",
+		 "last_refreshed_at":"2026-10-19T17:00:00Z","current":true},
+		{"id":"family-2","client_id":"client-1","client_name":"Mobile","user_agent":"","ip":"",
+		 "created_at":"2026-10-19T16:00:00Z","last_refreshed_at":"2026-10-19T16:00:00Z","current":false}]}`,
+		rec.Body.String())
+}
+
+func TestSessionRevoke(t *testing.T) {
+	const sessionID = "6f1c2b7e-3d4a-4c5b-9e8f-0a1b2c3d4e5f"
+
+	tests := []struct {
+		name       string
+		id         string
+		setupMock  func(svc *mocks.SessionService)
+		wantStatus int
+	}{
+		{
+			name: "success",
+			id:   sessionID,
+			setupMock: func(svc *mocks.SessionService) {
+				svc.EXPECT().RevokeSession(mock.Anything, "user-123", sessionID).Return(nil)
+			},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name: "unknown session",
+			id:   sessionID,
+			setupMock: func(svc *mocks.SessionService) {
+				svc.EXPECT().RevokeSession(mock.Anything, "user-123", sessionID).
+					Return(fmt.Errorf("get session: %w", domainerrors.ErrSessionNotFound))
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "not a uuid",
+			id:         "family-2",
+			setupMock:  func(_ *mocks.SessionService) {},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewSessionService(t)
+			tt.setupMock(svc)
+			h := NewSessionHandler(svc, zap.NewNop())
+
+			req := withUserID(httptest.NewRequest(http.MethodDelete, "/api/v1/me/sessions/"+tt.id, nil), tt.id)
+			rec := serveSession(h.Revoke, req, "family-1")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusNotFound {
+				assert.Contains(t, rec.Body.String(), "SESSION_NOT_FOUND")
+			}
+		})
+	}
+}
+
+func TestSessionRevokeOthers(t *testing.T) {
+	t.Run("keeps current session", func(t *testing.T) {
+		svc := mocks.NewSessionService(t)
+		svc.EXPECT().RevokeOtherSessions(mock.Anything, "user-123", "family-1").Return(nil)
+		h := NewSessionHandler(svc, zap.NewNop())
+
+		rec := serveSession(h.RevokeOthers, httptest.NewRequest(http.MethodPost, "/api/v1/me/sessions/logout-others", nil), "family-1")
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("token without session", func(t *testing.T) {
+		h := NewSessionHandler(mocks.NewSessionService(t), zap.NewNop())
+
+		rec := serveSession(h.RevokeOthers, httptest.NewRequest(http.MethodPost, "/api/v1/me/sessions/logout-others", nil), "")
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.JSONEq(t, `{"error":"access token is not bound to a session, sign in again","code":"SESSION_UNKNOWN"}`,
+			rec.Body.String())
+	})
+}
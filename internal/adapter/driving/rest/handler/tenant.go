@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type TenantService interface {
+	CreateTenant(ctx context.Context, tenant *model.Tenant) error
+	ListTenants(ctx context.Context) ([]*model.Tenant, error)
+	GetTenant(ctx context.Context, id string) (*model.Tenant, error)
+	UpdateTenant(ctx context.Context, id, name, host string, branding model.Branding) (*model.Tenant, error)
+}
+
+type TenantHandler struct {
+	svc TenantService
+	log *zap.Logger
+}
+
+func NewTenantHandler(svc TenantService, log *zap.Logger) *TenantHandler {
+	return &TenantHandler{svc: svc, log: log}
+}
+
+func (h *TenantHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req tenantRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	tenant := &model.Tenant{Slug: req.Slug, Name: req.Name, Host: req.Host, Branding: req.branding()}
+	if err := h.svc.CreateTenant(r.Context(), tenant); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusCreated, toTenantResponse(tenant))
+}
+
+func (h *TenantHandler) List(w http.ResponseWriter, r *http.Request) {
+	tenants, err := h.svc.ListTenants(r.Context())
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+
+	resp := &tenantListResponse{Tenants: make([]*tenantResponse, 0, len(tenants))}
+	for _, t := range tenants {
+		resp.Tenants = append(resp.Tenants, toTenantResponse(t))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *TenantHandler) Get(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.svc.GetTenant(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, toTenantResponse(tenant))
+}
+
+// Update replaces the name, host and branding of a tenant. The slug is part
+// of issued tokens and cannot be changed.
+func (h *TenantHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req tenantRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+
+	tenant, err := h.svc.UpdateTenant(r.Context(), chi.URLParam(r, "id"), req.Name, req.Host, req.branding())
+	if err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, toTenantResponse(tenant))
+}
+
+// Branding returns the public branding of the request's tenant for login
+// pages. The display name falls back to the tenant name.
+func (h *TenantHandler) Branding(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.GetTenant(r.Context())
+	resp := &brandingResponse{
+		Tenant:       tenant.Slug,
+		DisplayName:  tenant.Branding.DisplayName,
+		LogoURL:      tenant.Branding.LogoURL,
+		PrimaryColor: tenant.Branding.PrimaryColor,
+	}
+	if resp.DisplayName == "" {
+		resp.DisplayName = tenant.Name
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func toTenantResponse(tenant *model.Tenant) *tenantResponse {
+	return &tenantResponse{
+		ID:           tenant.ID,
+		Slug:         tenant.Slug,
+		Name:         tenant.Name,
+		Host:         tenant.Host,
+		DisplayName:  tenant.Branding.DisplayName,
+		LogoURL:      tenant.Branding.LogoURL,
+		PrimaryColor: tenant.Branding.PrimaryColor,
+		CreatedAt:    tenant.CreatedAt,
+		UpdatedAt:    tenant.UpdatedAt,
+	}
+}
+
+type tenantRequest struct {
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	Host         string `json:"host"`
+	DisplayName  string `json:"display_name"`
+	LogoURL      string `json:"logo_url"`
+	PrimaryColor string `json:"primary_color"`
+}
+
+func (r *tenantRequest) branding() model.Branding {
+	return model.Branding{DisplayName: r.DisplayName, LogoURL: r.LogoURL, PrimaryColor: r.PrimaryColor}
+}
+
+type tenantResponse struct {
+	ID           string    `json:"id"`
+	Slug         string    `json:"slug"`
+	Name         string    `json:"name"`
+	Host         string    `json:"host,omitempty"`
+	DisplayName  string    `json:"display_name"`
+	LogoURL      string    `json:"logo_url"`
+	PrimaryColor string    `json:"primary_color"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type tenantListResponse struct {
+	Tenants []*tenantResponse `json:"tenants"`
+}
+
+type brandingResponse struct {
+	Tenant       string `json:"tenant"`
+	DisplayName  string `json:"display_name"`
+	LogoURL      string `json:"logo_url"`
+	PrimaryColor string `json:"primary_color"`
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler/mocks"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestTenantCreate(t *testing.T) {
+	created := time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.TenantService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			body: `{"slug":"acme","name":"Acme","host":"login.acme.example","primary_color":"#ff6600"}`,
+			mockSetup: func(svc *mocks.TenantService) {
+				svc.EXPECT().CreateTenant(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, t *model.Tenant) error {
+					t.ID, t.CreatedAt, t.UpdatedAt = "tenant-1", created, created
+					return nil
+				})
+			},
+			wantStatus: http.StatusCreated,
+			wantBody: `{"id":"tenant-1","slug":"acme","name":"Acme","host":"login.acme.example",
+				"display_name":"","logo_url":"","primary_color":"#ff6600",
+				"created_at":"2026-10-19T17:00:00Z","updated_at":"2026-10-19T17:00:00Z"}`,
+		},
+		{
+			name: "invalid slug",
+			body: `{"slug":"Acme!","name":"Acme"}`,
+			mockSetup: func(svc *mocks.TenantService) {
+				svc.EXPECT().CreateTenant(mock.Anything, mock.Anything).
+					Return(errors.New("slug: must be lowercase letters, digits and dashes"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"slug: must be lowercase letters, digits and dashes","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name: "duplicate",
+			body: `{"slug":"acme","name":"Acme"}`,
+			mockSetup: func(svc *mocks.TenantService) {
+				svc.EXPECT().CreateTenant(mock.Anything, mock.Anything).
+					Return(fmt.Errorf("create tenant: %w", domainerrors.ErrTenantAlreadyExists))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"tenant slug or host already in use","code":"TENANT_EXISTS"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewTenantService(t)
+			tt.mockSetup(svc)
+			h := NewTenantHandler(svc, zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/tenants", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.Create(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestTenantBranding(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenant   *model.Tenant
+		wantBody string
+	}{
+		{
+			name: "configured",
+			tenant: &model.Tenant{Slug: "acme", Name: "Acme Inc", Branding: model.Branding{
+				DisplayName: "Acme", LogoURL: "https://acme.example/logo.png", PrimaryColor: "#ff6600",
+			}},
+			wantBody: `{"tenant":"acme","display_name":"Acme","logo_url":"https://acme.example/logo.png",
+				"primary_color":"#ff6600"}`,
+		},
+		{
+			name:     "falls back to the tenant name",
+			tenant:   &model.Tenant{Slug: "acme", Name: "Acme Inc"},
+			wantBody: `{"tenant":"acme","display_name":"Acme Inc","logo_url":"","primary_color":""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTenantHandler(mocks.NewTenantService(t), zap.NewNop())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/branding", nil)
+			req = req.WithContext(middleware.WithTenant(req.Context(), tt.tenant))
+			rec := httptest.NewRecorder()
+			h.Branding(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
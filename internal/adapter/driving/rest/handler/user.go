@@ -6,14 +6,17 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
 	"github.com/sanchey92/sso/internal/domain/model"
 )
 
 type UserService interface {
-	Register(ctx context.Context, email, password string) (*model.User, error)
+	Register(ctx context.Context, tenantID, email, password string) (*model.User, error)
 	VerifyEmail(ctx context.Context, token string) error
-	RequestPasswordReset(ctx context.Context, email string) error
+	RequestPasswordReset(ctx context.Context, tenantID, email string) error
 	ResetPassword(ctx context.Context, token, newPassword string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+	RevertEmailChange(ctx context.Context, token string) error
 }
 
 type UserHandler struct {
@@ -32,12 +35,22 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
 		return
 	}
+	if !middleware.LimitEmail(w, r, req.Email) {
+		return
+	}
 
-	user, err := h.svc.Register(r.Context(), req.Email, req.Password)
+	user, err := h.svc.Register(r.Context(), tenantID(r), req.Email, req.Password)
 	if err != nil {
 		handleServiceError(w, r, err, h.log)
 		return
 	}
+	// An enumeration-safe service does not say whether an account was created.
+	if user == nil {
+		respondJSON(w, http.StatusAccepted, &messageResponse{
+			Message: "check your email to complete registration",
+		})
+		return
+	}
 
 	respondJSON(w, http.StatusCreated, &registerResponse{
 		UserID:  user.ID,
@@ -71,8 +84,11 @@ func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Reques
 		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
 		return
 	}
+	if !middleware.LimitEmail(w, r, req.Email) {
+		return
+	}
 
-	if err := h.svc.RequestPasswordReset(r.Context(), req.Email); err != nil {
+	if err := h.svc.RequestPasswordReset(r.Context(), tenantID(r), req.Email); err != nil {
 		handleServiceError(w, r, err, h.log)
 		return
 	}
@@ -103,6 +119,46 @@ func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *UserHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required", "VALIDATION_ERROR")
+		return
+	}
+
+	if err := h.svc.ConfirmEmailChange(r.Context(), req.Token); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, &messageResponse{
+		Message: "email changed successfully",
+	})
+}
+
+func (h *UserHandler) RevertEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body", "INVALID_REQUEST")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required", "VALIDATION_ERROR")
+		return
+	}
+
+	if err := h.svc.RevertEmailChange(r.Context(), req.Token); err != nil {
+		handleServiceError(w, r, err, h.log)
+		return
+	}
+	respondJSON(w, http.StatusOK, &messageResponse{
+		Message: "email change reverted and all sessions signed out",
+	})
+}
+
 type registerRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -33,12 +33,22 @@ func TestRegister(t *testing.T) {
 			name: "success",
 			body: `{"email":"test@example.com","password":"secret123"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().Register(mock.Anything, "test@example.com", "secret123").
+				svc.EXPECT().Register(mock.Anything, "", "test@example.com", "secret123").
 					Return(&model.User{ID: "user-123"}, nil)
 			},
 			wantStatus: http.StatusCreated,
 			wantBody:   `{"user_id":"user-123","message":"user registered successfully"}`,
 		},
+		{
+			name: "enumeration-safe service hides outcome",
+			body: `{"email":"test@example.com","password":"secret123"}`,
+			mockSetup: func(svc *mocks.UserService) {
+				svc.EXPECT().Register(mock.Anything, "", "test@example.com", "secret123").
+					Return(nil, nil)
+			},
+			wantStatus: http.StatusAccepted,
+			wantBody:   `{"message":"check your email to complete registration"}`,
+		},
 		{
 			name:       "invalid json",
 			body:       `{invalid`,
@@ -50,7 +60,7 @@ func TestRegister(t *testing.T) {
 			name: "email already exists",
 			body: `{"email":"dup@example.com","password":"secret123"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().Register(mock.Anything, "dup@example.com", "secret123").
+				svc.EXPECT().Register(mock.Anything, "", "dup@example.com", "secret123").
 					Return(nil, domainerrors.ErrEmailAlreadyExists)
 			},
 			wantStatus: http.StatusConflict,
@@ -60,17 +70,34 @@ func TestRegister(t *testing.T) {
 			name: "validation error",
 			body: `{"email":"bad","password":"short"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().Register(mock.Anything, "bad", "short").
+				svc.EXPECT().Register(mock.Anything, "", "bad", "short").
 					Return(nil, fmt.Errorf("email: invalid format"))
 			},
 			wantStatus: http.StatusBadRequest,
 			wantBody:   `{"error":"email: invalid format","code":"VALIDATION_ERROR"}`,
 		},
+		{
+			name: "password rejected by policy",
+			body: `{"email":"test@example.com","password":"password"}`,
+			mockSetup: func(svc *mocks.UserService) {
+				svc.EXPECT().Register(mock.Anything, "", "test@example.com", "password").
+					Return(nil, fmt.Errorf("check password: %w", &domainerrors.PasswordPolicyError{
+						Violations: []domainerrors.PasswordViolation{
+							{Code: "common", Message: "is too common"},
+							{Code: "too_weak", Message: "is too easy to guess"},
+						},
+					}))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"password: is too common; is too easy to guess","code":"WEAK_PASSWORD",` +
+				`"violations":[{"code":"common","message":"is too common"},` +
+				`{"code":"too_weak","message":"is too easy to guess"}]}`,
+		},
 		{
 			name: "internal error",
 			body: `{"email":"test@example.com","password":"secret123"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().Register(mock.Anything, "test@example.com", "secret123").
+				svc.EXPECT().Register(mock.Anything, "", "test@example.com", "secret123").
 					Return(nil, fmt.Errorf("hash password: %w", fmt.Errorf("something broke")))
 			},
 			wantStatus: http.StatusInternalServerError,
@@ -103,7 +130,7 @@ func TestRequestPasswordReset(t *testing.T) {
 			name: "success",
 			body: `{"email":"user@example.com"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().RequestPasswordReset(mock.Anything, "user@example.com").Return(nil)
+				svc.EXPECT().RequestPasswordReset(mock.Anything, "", "user@example.com").Return(nil)
 			},
 			wantStatus: http.StatusOK,
 			wantBody:   `{"message":"if the email exists, a password reset link has been sent"}`,
@@ -119,7 +146,7 @@ func TestRequestPasswordReset(t *testing.T) {
 			name: "non-existent email still returns 200 (anti-enumeration)",
 			body: `{"email":"nobody@example.com"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().RequestPasswordReset(mock.Anything, "nobody@example.com").Return(nil)
+				svc.EXPECT().RequestPasswordReset(mock.Anything, "", "nobody@example.com").Return(nil)
 			},
 			wantStatus: http.StatusOK,
 			wantBody:   `{"message":"if the email exists, a password reset link has been sent"}`,
@@ -128,7 +155,7 @@ func TestRequestPasswordReset(t *testing.T) {
 			name: "internal error",
 			body: `{"email":"user@example.com"}`,
 			mockSetup: func(svc *mocks.UserService) {
-				svc.EXPECT().RequestPasswordReset(mock.Anything, "user@example.com").
+				svc.EXPECT().RequestPasswordReset(mock.Anything, "", "user@example.com").
 					Return(fmt.Errorf("save reset token: %w", fmt.Errorf("redis error")))
 			},
 			wantStatus: http.StatusInternalServerError,
@@ -304,3 +331,90 @@ func TestVerifyEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestConfirmEmailChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.UserService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			body: `{"token":"tok"}`,
+			mockSetup: func(svc *mocks.UserService) {
+				svc.EXPECT().ConfirmEmailChange(mock.Anything, "tok").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"message":"email changed successfully"}`,
+		},
+		{
+			name:       "missing token",
+			body:       `{}`,
+			mockSetup:  func(_ *mocks.UserService) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"token is required","code":"VALIDATION_ERROR"}`,
+		},
+		{
+			name: "address taken",
+			body: `{"token":"tok"}`,
+			mockSetup: func(svc *mocks.UserService) {
+				svc.EXPECT().ConfirmEmailChange(mock.Anything, "tok").
+					Return(fmt.Errorf("update email: %w", domainerrors.ErrEmailAlreadyExists))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"email already exists","code":"EMAIL_EXISTS"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, us := newUserHandler(t)
+			tt.mockSetup(us)
+
+			rec := doRequest(h.ConfirmEmailChange, http.MethodPost, "/api/v1/auth/email/change/confirm", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestRevertEmailChange(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockSetup  func(svc *mocks.UserService)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "success",
+			mockSetup: func(svc *mocks.UserService) {
+				svc.EXPECT().RevertEmailChange(mock.Anything, "tok").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"message":"email change reverted and all sessions signed out"}`,
+		},
+		{
+			name: "expired token",
+			mockSetup: func(svc *mocks.UserService) {
+				svc.EXPECT().RevertEmailChange(mock.Anything, "tok").Return(domainerrors.ErrInvalidEmailChangeToken)
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid or expired email change token","code":"INVALID_EMAIL_CHANGE_TOKEN"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, us := newUserHandler(t)
+			tt.mockSetup(us)
+
+			rec := doRequest(h.RevertEmailChange, http.MethodPost, "/api/v1/auth/email/change/revert", `{"token":"tok"}`)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const claimsCtxKey contextKey = "claims"
+
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, accessToken string) (*model.AccessTokenClaims, error)
+}
+
+// Authenticate requires a valid access token in the Authorization header and
+// makes its claims available through GetClaims. Behind ResolveTenant the token
+// must also belong to the request's tenant.
+func Authenticate(tokens TokenValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || raw == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				writeUnauthorized(w)
+				return
+			}
+
+			claims, err := tokens.ValidateAccessToken(r.Context(), raw)
+			// A token is only good in the tenant that issued it.
+			if tenant := GetTenant(r.Context()); err == nil && tenant != nil && claims.Tenant != tenant.Slug {
+				err = domainerrors.ErrInvalidToken
+			}
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeUnauthorized(w)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), claimsCtxKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClaims returns the access token claims set by Authenticate, or nil on
+// unauthenticated routes.
+func GetClaims(ctx context.Context) *model.AccessTokenClaims {
+	claims, _ := ctx.Value(claimsCtxKey).(*model.AccessTokenClaims)
+	return claims
+}
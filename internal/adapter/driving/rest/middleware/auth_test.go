@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type fakeValidator map[string]string
+
+func (v fakeValidator) ValidateAccessToken(_ context.Context, token string) (*model.AccessTokenClaims, error) {
+	subject, ok := v[token]
+	if !ok {
+		return nil, domainerrors.ErrInvalidToken
+	}
+	return &model.AccessTokenClaims{Subject: subject}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantStatus  int
+		wantSubject string
+	}{
+		{name: "valid token", header: "Bearer good", wantStatus: http.StatusOK, wantSubject: "user-1"},
+		{name: "invalid token", header: "Bearer bad", wantStatus: http.StatusUnauthorized},
+		{name: "missing header", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", header: "Basic good", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			h := Authenticate(fakeValidator{"good": "user-1"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				subject = GetClaims(r.Context()).Subject
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantSubject, subject)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAuthenticate_Tenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenant     *model.Tenant
+		wantStatus int
+	}{
+		{name: "token of the tenant", tenant: &model.Tenant{ID: "tenant-1", Slug: "acme"}, wantStatus: http.StatusOK},
+		{name: "token of another tenant", tenant: &model.Tenant{ID: "tenant-2", Slug: "globex"}, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Authenticate(tenantValidator("acme"))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+			req = req.WithContext(WithTenant(req.Context(), tt.tenant))
+			req.Header.Set("Authorization", "Bearer good")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+// tenantValidator accepts any token as issued by the tenant with its slug.
+type tenantValidator string
+
+func (v tenantValidator) ValidateAccessToken(_ context.Context, _ string) (*model.AccessTokenClaims, error) {
+	return &model.AccessTokenClaims{Subject: "user-1", Tenant: string(v)}, nil
+}
@@ -3,10 +3,13 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
 )
 
 type contextKey string
@@ -39,6 +42,22 @@ func RequestID(next http.Handler) http.Handler {
 	})
 }
 
+// maxUserAgentLen caps the user agent recorded for a session.
+const maxUserAgentLen = 512
+
+// ClientInfo records the user agent and IP address of the request in its
+// context as a model.ClientInfo, for sessions started by the request.
+func ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent := r.UserAgent()
+		if len(userAgent) > maxUserAgentLen {
+			userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLen], "")
+		}
+		ctx := model.WithClientInfo(r.Context(), model.ClientInfo{UserAgent: userAgent, IP: ClientIP(r)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func Logging(log *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -77,9 +96,9 @@ func Recovery(log *zap.Logger) func(http.Handler) http.Handler {
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
-		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
 		if r.Method == http.MethodOptions {
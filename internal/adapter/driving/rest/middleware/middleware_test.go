@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestClientInfo(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      model.ClientInfo
+	}{
+		{name: "recorded", userAgent: "Firefox", want: model.ClientInfo{UserAgent: "Firefox", IP: "203.0.113.7"}},
+		{
+			name:      "long user agent truncated",
+			userAgent: strings.Repeat("a", maxUserAgentLen-1) + "é",
+			want:      model.ClientInfo{UserAgent: strings.Repeat("a", maxUserAgentLen-1), IP: "203.0.113.7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got model.ClientInfo
+			h := ClientInfo(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = model.ClientInfoFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+			req.RemoteAddr = "203.0.113.7:51234"
+			req.Header.Set("User-Agent", tt.userAgent)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
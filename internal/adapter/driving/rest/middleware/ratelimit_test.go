@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// fakeLimiter allows the first max requests per key.
+type fakeLimiter struct {
+	max    int
+	counts map[string]int
+	err    error
+}
+
+func newFakeLimiter(maxRequests int) *fakeLimiter {
+	return &fakeLimiter{max: maxRequests, counts: map[string]int{}}
+}
+
+func (l *fakeLimiter) Allow(_ context.Context, key string, limit model.RateLimit) (*model.RateLimitResult, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.counts[key]++
+	remaining := l.max - l.counts[key]
+	if remaining < 0 {
+		return &model.RateLimitResult{
+			Limit:      limit.MaxAttempts,
+			RetryAfter: 1500 * time.Millisecond,
+			ResetAfter: limit.Window,
+		}, nil
+	}
+	return &model.RateLimitResult{
+		Allowed:    true,
+		Limit:      limit.MaxAttempts,
+		Remaining:  remaining,
+		ResetAfter: limit.Window,
+	}, nil
+}
+
+func testPolicy(by KeyBy) Policy {
+	return Policy{Name: "login", Limit: model.RateLimit{MaxAttempts: 1, Window: time.Minute}, By: by}
+}
+
+func serve(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimit_ByIP(t *testing.T) {
+	limiter := newFakeLimiter(1)
+	h := RateLimit(limiter, testPolicy(ByIP), zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := serve(h, "203.0.113.7:5555")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = serve(h, "203.0.113.7:6666")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"too many requests","code":"RATE_LIMITED"}`, rec.Body.String())
+
+	rec = serve(h, "198.51.100.1:5555")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, limiter.counts["ratelimit:login:ip:198.51.100.1"])
+}
+
+func TestRateLimit_ByEmail(t *testing.T) {
+	limiter := newFakeLimiter(1)
+	h := RateLimit(limiter, testPolicy(ByEmail), zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !LimitEmail(w, r, r.Header.Get("X-Email")) {
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(email, ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("X-Email", email)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("User@Example.com", "203.0.113.7"))
+	assert.Equal(t, http.StatusTooManyRequests, send("user@example.com ", "198.51.100.1"))
+	assert.Equal(t, http.StatusOK, send("other@example.com", "203.0.113.7"))
+	assert.NotContains(t, limiter.counts, "ratelimit:login:ip:203.0.113.7")
+}
+
+func TestRateLimit_PerTenant(t *testing.T) {
+	limiter := newFakeLimiter(1)
+	h := RateLimit(limiter, testPolicy(ByIP), zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(tenantID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "203.0.113.7:5555"
+		req = req.WithContext(WithTenant(req.Context(), &model.Tenant{ID: tenantID}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("tenant-a"))
+	assert.Equal(t, http.StatusTooManyRequests, send("tenant-a"))
+	assert.Equal(t, http.StatusOK, send("tenant-b"))
+	assert.Equal(t, 1, limiter.counts["ratelimit:tenant-b:login:ip:203.0.113.7"])
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	limiter := newFakeLimiter(0)
+	limiter.err = errors.New("redis down")
+	h := RateLimit(limiter, testPolicy(ByIP), zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := serve(h, "203.0.113.7:5555")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestLimitEmail_WithoutPolicy(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	rec := httptest.NewRecorder()
+
+	assert.True(t, LimitEmail(rec, req, "user@example.com"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireRole rejects requests whose access token lacks role. It must run
+// after Authenticate.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetClaims(r.Context())
+			if claims == nil {
+				writeUnauthorized(w)
+				return
+			}
+			if !claims.HasRole(role) {
+				writeForbidden(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	writeError(w, http.StatusUnauthorized, `{"error":"unauthorized","code":"UNAUTHORIZED"}`)
+}
+
+func writeForbidden(w http.ResponseWriter) {
+	writeError(w, http.StatusForbidden, `{"error":"forbidden","code":"FORBIDDEN"}`)
+}
+
+func writeError(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body)) //nolint:gosec // error writing response body is unrecoverable
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *model.AccessTokenClaims
+		wantStatus int
+	}{
+		{name: "has role", claims: &model.AccessTokenClaims{Roles: []string{"auditor", "admin"}}, wantStatus: http.StatusOK},
+		{name: "missing role", claims: &model.AccessTokenClaims{Roles: []string{"auditor"}}, wantStatus: http.StatusForbidden},
+		{name: "no roles", claims: &model.AccessTokenClaims{}, wantStatus: http.StatusForbidden},
+		{name: "unauthenticated", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RequireRole("admin")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/id/unlock", nil)
+			if tt.claims != nil {
+				req = req.WithContext(context.WithValue(req.Context(), claimsCtxKey, tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const tenantCtxKey contextKey = "tenant"
+
+// TenantResolver finds the tenant a request is addressed to.
+type TenantResolver interface {
+	GetTenantBySlug(ctx context.Context, slug string) (*model.Tenant, error)
+	// ResolveHost returns the tenant mapped to host, falling back to the
+	// default tenant.
+	ResolveHost(ctx context.Context, host string) (*model.Tenant, error)
+}
+
+// TenantUserGetter looks up users to check which tenant they belong to.
+type TenantUserGetter interface {
+	GetUser(ctx context.Context, userID string) (*model.User, error)
+}
+
+// ResolveTenant selects the tenant named by the {tenant} route parameter or,
+// on routes without one, by the Host header, and makes it available through
+// GetTenant.
+func ResolveTenant(resolver TenantResolver, log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var tenant *model.Tenant
+			var err error
+			if slug := chi.URLParam(r, "tenant"); slug != "" {
+				tenant, err = resolver.GetTenantBySlug(r.Context(), slug)
+			} else {
+				tenant, err = resolver.ResolveHost(r.Context(), r.Host)
+			}
+			if err != nil {
+				if errors.Is(err, domainerrors.ErrTenantNotFound) {
+					writeError(w, http.StatusNotFound, `{"error":"tenant not found","code":"TENANT_NOT_FOUND"}`)
+					return
+				}
+				log.Error("failed to resolve tenant",
+					zap.Error(err),
+					zap.String("request_id", GetRequestID(r.Context())),
+				)
+				writeError(w, http.StatusInternalServerError, `{"error":"internal server error","code":"INTERNAL_ERROR"}`)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+		})
+	}
+}
+
+// WithTenant returns a copy of ctx carrying tenant.
+func WithTenant(ctx context.Context, tenant *model.Tenant) context.Context {
+	return context.WithValue(ctx, tenantCtxKey, tenant)
+}
+
+// GetTenant returns the tenant set by ResolveTenant, or nil outside tenant
+// routes.
+func GetTenant(ctx context.Context) *model.Tenant {
+	tenant, _ := ctx.Value(tenantCtxKey).(*model.Tenant)
+	return tenant
+}
+
+// RequireDefaultTenant limits a route to the default tenant, whose
+// administrators operate the whole deployment. It must run after
+// ResolveTenant.
+func RequireDefaultTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenant := GetTenant(r.Context()); tenant == nil || !tenant.IsDefault() {
+			writeForbidden(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireTenantUser rejects requests whose {id} route parameter names a user
+// outside the request's tenant, as if the user did not exist. An id that is
+// not a UUID cannot name a user and is rejected the same way. It must run
+// after ResolveTenant.
+func RequireTenantUser(users TenantUserGetter, log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := chi.URLParam(r, "id")
+			if err := uuid.Validate(userID); err != nil {
+				writeError(w, http.StatusNotFound, `{"error":"user not found","code":"USER_NOT_FOUND"}`)
+				return
+			}
+			user, err := users.GetUser(r.Context(), userID)
+			if err != nil && !errors.Is(err, domainerrors.ErrUserNotFound) {
+				log.Error("failed to look up user tenant",
+					zap.Error(err),
+					zap.String("request_id", GetRequestID(r.Context())),
+				)
+				writeError(w, http.StatusInternalServerError, `{"error":"internal server error","code":"INTERNAL_ERROR"}`)
+				return
+			}
+			tenant := GetTenant(r.Context())
+			if user == nil || tenant == nil || user.TenantID != tenant.ID {
+				writeError(w, http.StatusNotFound, `{"error":"user not found","code":"USER_NOT_FOUND"}`)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type fakeTenants map[string]*model.Tenant
+
+func (f fakeTenants) GetTenantBySlug(_ context.Context, slug string) (*model.Tenant, error) {
+	for _, t := range f {
+		if t.Slug == slug {
+			return t, nil
+		}
+	}
+	return nil, domainerrors.ErrTenantNotFound
+}
+
+func (f fakeTenants) ResolveHost(_ context.Context, host string) (*model.Tenant, error) {
+	if t, ok := f[host]; ok {
+		return t, nil
+	}
+	return f.GetTenantBySlug(context.Background(), model.DefaultTenantSlug)
+}
+
+const (
+	testUser1      = "0b6f3c2e-1d4a-4e8b-9a57-3c2f1e0d9b41"
+	testUser2      = "5e9a1c7d-3b2f-4a6e-8d14-7f0c2b9e6a53"
+	testUser3      = "9c4d2e1f-6a7b-4c3d-8e5f-1a2b3c4d5e67"
+	testUserBroken = "e1d2c3b4-a5f6-4789-9abc-def012345678"
+)
+
+type fakeUsers map[string]*model.User
+
+func (f fakeUsers) GetUser(_ context.Context, userID string) (*model.User, error) {
+	if userID == testUserBroken {
+		return nil, errors.New("db down")
+	}
+	if u, ok := f[userID]; ok {
+		return u, nil
+	}
+	return nil, domainerrors.ErrUserNotFound
+}
+
+var (
+	defaultTenant = &model.Tenant{ID: "tenant-0", Slug: model.DefaultTenantSlug}
+	acmeTenant    = &model.Tenant{ID: "tenant-1", Slug: "acme"}
+	testTenants   = fakeTenants{"sso.example.com": defaultTenant, "login.acme.example": acmeTenant}
+)
+
+func TestResolveTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		host       string
+		path       string
+		wantStatus int
+		wantTenant string
+	}{
+		{name: "by host", host: "login.acme.example", path: "/api/v1/me", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "unknown host", host: "other.example", path: "/api/v1/me", wantStatus: http.StatusOK, wantTenant: "default"},
+		{name: "by path", host: "sso.example.com", path: "/t/acme/api/v1/me", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "unknown slug", host: "sso.example.com", path: "/t/globex/api/v1/me", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenant string
+			handle := func(w http.ResponseWriter, r *http.Request) {
+				tenant = GetTenant(r.Context()).Slug
+				w.WriteHeader(http.StatusOK)
+			}
+			r := chi.NewRouter()
+			r.With(ResolveTenant(testTenants, zap.NewNop())).Get("/api/v1/me", handle)
+			r.With(ResolveTenant(testTenants, zap.NewNop())).Get("/t/{tenant}/api/v1/me", handle)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantTenant, tenant)
+		})
+	}
+}
+
+func TestRequireTenantUser(t *testing.T) {
+	users := fakeUsers{
+		testUser1: {ID: testUser1, TenantID: acmeTenant.ID},
+		testUser2: {ID: testUser2, TenantID: defaultTenant.ID},
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		wantStatus int
+	}{
+		{name: "user of the tenant", userID: testUser1, wantStatus: http.StatusOK},
+		{name: "user of another tenant", userID: testUser2, wantStatus: http.StatusNotFound},
+		{name: "unknown user", userID: testUser3, wantStatus: http.StatusNotFound},
+		{name: "not a uuid", userID: "not-a-uuid", wantStatus: http.StatusNotFound},
+		{name: "lookup error", userID: testUserBroken, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.With(RequireTenantUser(users, zap.NewNop())).Post("/users/{id}/block", func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/users/"+tt.userID+"/block", nil)
+			req = req.WithContext(WithTenant(req.Context(), acmeTenant))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestRequireDefaultTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenant     *model.Tenant
+		wantStatus int
+	}{
+		{name: "default tenant", tenant: defaultTenant, wantStatus: http.StatusOK},
+		{name: "other tenant", tenant: acmeTenant, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RequireDefaultTenant(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/tenants", nil)
+			req = req.WithContext(WithTenant(req.Context(), tt.tenant))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
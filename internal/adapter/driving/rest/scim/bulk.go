@@ -0,0 +1,196 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	bulkRequestSchema  = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	bulkResponseSchema = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	bulkIDPrefix       = "bulkId:"
+)
+
+type bulkRequest struct {
+	FailOnErrors int             `json:"failOnErrors"`
+	Operations   []bulkOperation `json:"Operations"`
+}
+
+type bulkOperation struct {
+	Method  string          `json:"method"`
+	BulkID  string          `json:"bulkId,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Path    string          `json:"path"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type bulkResult struct {
+	Method   string          `json:"method"`
+	BulkID   string          `json:"bulkId,omitempty"`
+	Version  string          `json:"version,omitempty"`
+	Location string          `json:"location,omitempty"`
+	Status   string          `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+type bulkResponse struct {
+	Schemas    []string     `json:"schemas"`
+	Operations []bulkResult `json:"Operations"`
+}
+
+// bulk runs the operations of a bulk request in order against the Users and
+// Groups endpoints. A "bulkId:x" reference in a path or in data is replaced
+// by the id of the resource the operation with bulkId x created. Processing
+// stops once failOnErrors operations have failed.
+func (h *Handler) bulk(w http.ResponseWriter, r *http.Request) {
+	var req bulkRequest
+	if err := decode(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if len(req.Operations) > maxOperations {
+		writeError(w, http.StatusRequestEntityTooLarge, "",
+			"a bulk request may contain at most "+strconv.Itoa(maxOperations)+" operations")
+		return
+	}
+
+	ids := make(map[string]string)
+	results := make([]bulkResult, 0, len(req.Operations))
+	var failed int
+	for _, op := range req.Operations {
+		result := h.bulkOperation(r, op, ids)
+		results = append(results, result)
+		if result.Status[0] != '2' {
+			failed++
+			if req.FailOnErrors > 0 && failed >= req.FailOnErrors {
+				break
+			}
+		}
+	}
+
+	respond(w, http.StatusOK, &bulkResponse{
+		Schemas:    []string{bulkResponseSchema},
+		Operations: results,
+	})
+}
+
+func (h *Handler) bulkOperation(r *http.Request, op bulkOperation, ids map[string]string) bulkResult {
+	result := bulkResult{Method: op.Method, BulkID: op.BulkID}
+	method := strings.ToUpper(op.Method)
+	fail := func(status int, scimType, detail string) bulkResult {
+		rec := newBulkRecorder()
+		writeError(rec, status, scimType, detail)
+		result.Status, result.Response = strconv.Itoa(status), rec.body.Bytes()
+		return result
+	}
+
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return fail(http.StatusBadRequest, "invalidSyntax", "unsupported method "+strconv.Quote(op.Method))
+	}
+	if method == http.MethodPost && op.BulkID == "" {
+		return fail(http.StatusBadRequest, "invalidSyntax", "POST operations require a bulkId")
+	}
+	if !strings.HasPrefix(op.Path, "/Users") && !strings.HasPrefix(op.Path, "/Groups") {
+		return fail(http.StatusBadRequest, "invalidPath", "path must address /Users or /Groups")
+	}
+
+	path, data, unresolved := resolveBulkIDs(op.Path, op.Data, ids)
+	if unresolved != "" {
+		return fail(http.StatusConflict, "invalidValue", "unresolved reference "+strconv.Quote(bulkIDPrefix+unresolved))
+	}
+
+	// A nil route context makes the resources router route the operation
+	// from scratch.
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, (*chi.Context)(nil))
+	inner, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(data))
+	if err != nil {
+		return fail(http.StatusBadRequest, "invalidPath", "invalid path")
+	}
+	inner.Host, inner.TLS = r.Host, r.TLS
+	inner.Header.Set("Content-Type", contentType)
+	if op.Version != "" {
+		inner.Header.Set("If-Match", op.Version)
+	}
+
+	rec := newBulkRecorder()
+	h.resources.ServeHTTP(rec, inner)
+
+	result.Status = strconv.Itoa(rec.status)
+	result.Location = rec.header.Get("Location")
+	result.Version = rec.header.Get("ETag")
+	if rec.status >= http.StatusBadRequest {
+		result.Response = rec.body.Bytes()
+		return result
+	}
+	if method == http.MethodPost {
+		var created struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(rec.body.Bytes(), &created) == nil {
+			ids[op.BulkID] = created.ID
+		}
+	}
+	return result
+}
+
+// resolveBulkIDs replaces the bulkId references in path and data with the
+// ids of created resources. It returns the first reference that cannot be
+// resolved, if any.
+func resolveBulkIDs(path string, data []byte, ids map[string]string) (string, []byte, string) {
+	var unresolved string
+	replace := func(s string) string {
+		for {
+			i := strings.Index(s, bulkIDPrefix)
+			if i < 0 {
+				return s
+			}
+			end := i + len(bulkIDPrefix)
+			for end < len(s) && s[end] != '"' && s[end] != '/' && s[end] != '\\' {
+				end++
+			}
+			ref := s[i+len(bulkIDPrefix) : end]
+			id, ok := ids[ref]
+			if !ok {
+				if unresolved == "" {
+					unresolved = ref
+				}
+				return s
+			}
+			s = s[:i] + id + s[end:]
+		}
+	}
+	path = replace(path)
+	data = []byte(replace(string(data)))
+	return path, data, unresolved
+}
+
+// bulkRecorder captures the response to one bulk operation.
+type bulkRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBulkRecorder() *bulkRecorder {
+	return &bulkRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (rec *bulkRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *bulkRecorder) Write(b []byte) (int, error) {
+	return rec.body.Write(b) //nolint:wrapcheck // bytes.Buffer never fails
+}
+
+func (rec *bulkRecorder) WriteHeader(status int) {
+	rec.status = status
+}
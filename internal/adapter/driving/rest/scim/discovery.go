@@ -0,0 +1,97 @@
+package scim
+
+import "net/http"
+
+const (
+	serviceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	resourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+type supported struct {
+	Supported bool `json:"supported"`
+}
+
+type bulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type filterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type authenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary"`
+}
+
+type serviceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 supported              `json:"patch"`
+	Bulk                  bulkSupport            `json:"bulk"`
+	Filter                filterSupport          `json:"filter"`
+	ChangePassword        supported              `json:"changePassword"`
+	Sort                  supported              `json:"sort"`
+	ETag                  supported              `json:"etag"`
+	AuthenticationSchemes []authenticationScheme `json:"authenticationSchemes"`
+	Meta                  meta                   `json:"meta"`
+}
+
+type resourceType struct {
+	Schemas  []string `json:"schemas"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Endpoint string   `json:"endpoint"`
+	Schema   string   `json:"schema"`
+	Meta     meta     `json:"meta"`
+}
+
+func (h *Handler) serviceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, &serviceProviderConfig{
+		Schemas:        []string{serviceProviderConfigSchema},
+		Patch:          supported{Supported: true},
+		Bulk:           bulkSupport{Supported: true, MaxOperations: maxOperations, MaxPayloadSize: maxPayloadSize},
+		Filter:         filterSupport{Supported: true, MaxResults: maxResults},
+		ChangePassword: supported{Supported: true},
+		ETag:           supported{Supported: true},
+		AuthenticationSchemes: []authenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer Token",
+			Description: "A SCIM token issued by a tenant administrator",
+			Primary:     true,
+		}},
+		Meta: meta{ResourceType: "ServiceProviderConfig", Location: location(r, "ServiceProviderConfig")},
+	})
+}
+
+func (h *Handler) resourceTypes(w http.ResponseWriter, r *http.Request) {
+	resources := []any{
+		&resourceType{
+			Schemas:  []string{resourceTypeSchema},
+			ID:       "User",
+			Name:     "User",
+			Endpoint: "/Users",
+			Schema:   userSchema,
+			Meta:     meta{ResourceType: "ResourceType", Location: location(r, "ResourceTypes/User")},
+		},
+		&resourceType{
+			Schemas:  []string{resourceTypeSchema},
+			ID:       "Group",
+			Name:     "Group",
+			Endpoint: "/Groups",
+			Schema:   groupSchema,
+			Meta:     meta{ResourceType: "ResourceType", Location: location(r, "ResourceTypes/Group")},
+		},
+	}
+	respond(w, http.StatusOK, &listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
@@ -0,0 +1,48 @@
+package scim
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// filter is a parsed SCIM filter. Only the single comparison `attr eq
+// "value"` is supported, which is what provisioning clients send to look up a
+// resource before creating it.
+type filter struct {
+	attr  string
+	value string
+}
+
+var errInvalidFilter = errors.New("only filters of the form `attribute eq \"value\"` are supported")
+
+// parseFilter parses expr. An empty expression yields a nil filter.
+func parseFilter(expr string) (*filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	attr, rest, ok := strings.Cut(expr, " ")
+	if !ok {
+		return nil, errInvalidFilter
+	}
+	op, value, ok := strings.Cut(strings.TrimSpace(rest), " ")
+	if !ok || !strings.EqualFold(op, "eq") {
+		return nil, errInvalidFilter
+	}
+	value, err := strconv.Unquote(strings.TrimSpace(value))
+	if err != nil {
+		return nil, errInvalidFilter
+	}
+	return &filter{attr: attrName(attr), value: value}, nil
+}
+
+// attrName strips the schema URN from a fully qualified attribute name and
+// lower-cases it, since SCIM attribute names are case-insensitive.
+func attrName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,226 @@
+package scim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const groupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+var groupPatcher = patcher{attrs: map[string]bool{
+	"displayname": true,
+	"members":     true,
+}}
+
+type groupResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []member `json:"members,omitempty"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+type member struct {
+	Value string `json:"value"`
+	Ref   string `json:"$ref,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+// groupETag is the version of a group. Membership changes do not touch the
+// group row, so the members are part of it.
+func groupETag(group *model.Group, members *model.GroupMembers) string {
+	ids := slices.Clone(members.UserIDs)
+	slices.Sort(ids)
+
+	hash := sha256.New()
+	hash.Write([]byte(strconv.FormatInt(group.UpdatedAt.UnixMicro(), 10)))
+	for _, id := range ids {
+		hash.Write([]byte{0})
+		hash.Write([]byte(id))
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:8]) + `"`
+}
+
+// toGroupResource converts a group. members is nil in listings, which leave
+// out the members and the version.
+func toGroupResource(r *http.Request, group *model.Group, members *model.GroupMembers) *groupResource {
+	res := &groupResource{
+		Schemas:     []string{groupSchema},
+		ID:          group.ID,
+		DisplayName: group.Name,
+		Meta: &meta{
+			ResourceType: "Group",
+			Created:      &group.CreatedAt,
+			LastModified: &group.UpdatedAt,
+			Location:     location(r, "Groups/"+group.ID),
+		},
+	}
+	if members != nil {
+		res.Meta.Version = groupETag(group, members)
+		res.Members = make([]member, len(members.UserIDs))
+		for i, id := range members.UserIDs {
+			res.Members[i] = member{Value: id, Ref: location(r, "Users/"+id), Type: "User"}
+		}
+	}
+	return res
+}
+
+func memberIDs(res *groupResource) []string {
+	ids := make([]string, len(res.Members))
+	for i, m := range res.Members {
+		ids[i] = m.Value
+	}
+	return ids
+}
+
+func (h *Handler) respondGroup(w http.ResponseWriter, r *http.Request, status int, id string) {
+	group, members, err := h.svc.GetGroup(r.Context(), tenantID(r), id)
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	res := toGroupResource(r, group, members)
+	w.Header().Set("ETag", res.Meta.Version)
+	if status == http.StatusCreated {
+		w.Header().Set("Location", res.Meta.Location)
+	}
+	respond(w, status, res)
+}
+
+func (h *Handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	if f != nil && f.attr != "displayname" {
+		writeError(w, http.StatusBadRequest, "invalidFilter", "groups can be filtered by displayName")
+		return
+	}
+	startIndex, count, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	var groupName string
+	if f != nil {
+		groupName = f.value
+	}
+	groups, total, err := h.svc.ListGroups(r.Context(), tenantID(r), groupName, startIndex, count)
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+
+	resources := make([]any, len(groups))
+	for i, group := range groups {
+		resources[i] = toGroupResource(r, group, nil)
+	}
+	respond(w, http.StatusOK, &listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var res groupResource
+	if err := decode(w, r, &res); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	group := &model.Group{TenantID: tenantID(r), Name: res.DisplayName}
+	if err := h.svc.CreateGroup(r.Context(), group, memberIDs(&res)); err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	h.respondGroup(w, r, http.StatusCreated, group.ID)
+}
+
+func (h *Handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	h.respondGroup(w, r, http.StatusOK, chi.URLParam(r, "id"))
+}
+
+func (h *Handler) replaceGroup(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.currentGroup(w, r); !ok {
+		return
+	}
+
+	var res groupResource
+	if err := decode(w, r, &res); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	h.storeGroup(w, r, &res)
+}
+
+func (h *Handler) patchGroup(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.currentGroup(w, r)
+	if !ok {
+		return
+	}
+
+	var req patchRequest
+	if err := decode(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	doc, err := toDocument(current)
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	var res groupResource
+	if err = groupPatcher.apply(doc, req.Operations); err == nil {
+		err = fromDocument(doc, &res)
+	}
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+	h.storeGroup(w, r, &res)
+}
+
+// currentGroup loads the group a write is addressed to and checks If-Match.
+func (h *Handler) currentGroup(w http.ResponseWriter, r *http.Request) (*groupResource, bool) {
+	group, members, err := h.svc.GetGroup(r.Context(), tenantID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		h.serviceError(w, r, err)
+		return nil, false
+	}
+	res := toGroupResource(r, group, members)
+	if !checkIfMatch(r, res.Meta.Version) {
+		writeError(w, http.StatusPreconditionFailed, "", "resource was modified")
+		return nil, false
+	}
+	return res, true
+}
+
+func (h *Handler) storeGroup(w http.ResponseWriter, r *http.Request, res *groupResource) {
+	id := chi.URLParam(r, "id")
+	if err := h.svc.ReplaceGroup(r.Context(), tenantID(r), id, res.DisplayName, memberIDs(res)); err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	h.respondGroup(w, r, http.StatusOK, id)
+}
+
+func (h *Handler) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteGroup(r.Context(), tenantID(r), chi.URLParam(r, "id")); err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
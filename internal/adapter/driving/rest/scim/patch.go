@@ -0,0 +1,353 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+
+	coreSchemaPrefix = "urn:ietf:params:scim:schemas:core:2.0:"
+)
+
+type patchRequest struct {
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// patchError is a PATCH operation the resource cannot apply.
+type patchError struct {
+	scimType string
+	detail   string
+}
+
+func (e *patchError) Error() string {
+	return e.detail
+}
+
+// path is a parsed PATCH path: attr, attr.sub, attr[filter] or
+// attr[filter].sub. Names are lower-case.
+type path struct {
+	attr   string
+	filter *filter
+	sub    string
+}
+
+// parsePath parses a PATCH path. ok is false for attributes of extension
+// schemas, which are accepted and ignored.
+func parsePath(raw string) (p path, ok bool, err error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(strings.ToLower(raw), "urn:") {
+		rest, found := strings.CutPrefix(strings.ToLower(raw), coreSchemaPrefix)
+		if !found {
+			return path{}, false, nil
+		}
+		_, after, found := strings.Cut(rest, ":")
+		if !found {
+			return path{}, false, &patchError{scimType: "invalidPath", detail: "invalid path " + strconv.Quote(raw)}
+		}
+		raw = raw[len(raw)-len(after):]
+	}
+
+	if open := strings.IndexByte(raw, '['); open >= 0 {
+		closing := strings.IndexByte(raw, ']')
+		if closing < open {
+			return path{}, false, &patchError{scimType: "invalidPath", detail: "invalid path " + strconv.Quote(raw)}
+		}
+		f, err := parseFilter(raw[open+1 : closing])
+		if err != nil || f == nil {
+			return path{}, false, &patchError{scimType: "invalidFilter", detail: errInvalidFilter.Error()}
+		}
+		p.attr, p.filter = strings.ToLower(raw[:open]), f
+		raw = raw[closing+1:]
+		if raw != "" {
+			sub, found := strings.CutPrefix(raw, ".")
+			if !found {
+				return path{}, false, &patchError{scimType: "invalidPath", detail: "invalid path " + strconv.Quote(raw)}
+			}
+			p.sub = strings.ToLower(sub)
+		}
+		return p, true, nil
+	}
+
+	attr, sub, _ := strings.Cut(raw, ".")
+	return path{attr: strings.ToLower(attr), sub: strings.ToLower(sub)}, true, nil
+}
+
+// patcher applies PATCH operations to a resource held as a JSON object with
+// lower-case keys. Only the top-level attributes in attrs may be changed.
+type patcher struct {
+	attrs map[string]bool
+}
+
+func (pt patcher) apply(doc map[string]any, ops []patchOperation) error {
+	for _, op := range ops {
+		var value any
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return &patchError{scimType: "invalidSyntax", detail: "invalid operation value"}
+			}
+		}
+		if err := pt.applyOperation(doc, strings.ToLower(op.Op), op.Path, lowerKeys(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pt patcher) applyOperation(doc map[string]any, op, rawPath string, value any) error {
+	switch op {
+	case opAdd, opReplace, opRemove:
+	default:
+		return &patchError{scimType: "invalidSyntax", detail: fmt.Sprintf("unsupported operation %q", op)}
+	}
+
+	if rawPath == "" {
+		if op == opRemove {
+			return &patchError{scimType: "noTarget", detail: "remove requires a path"}
+		}
+		attrs, ok := value.(map[string]any)
+		if !ok {
+			return &patchError{scimType: "invalidValue", detail: "value must be an object when path is omitted"}
+		}
+		for name, v := range attrs {
+			switch name {
+			case "schemas", "id", "meta":
+				continue
+			}
+			if err := pt.applyOperation(doc, op, name, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p, ok, err := parsePath(rawPath)
+	if err != nil || !ok {
+		return err
+	}
+	if !pt.attrs[p.attr] {
+		return &patchError{scimType: "invalidPath", detail: fmt.Sprintf("attribute %q cannot be modified", p.attr)}
+	}
+
+	switch {
+	case p.filter != nil:
+		return applyFiltered(doc, op, p, value)
+	case p.sub != "":
+		return applySub(doc, op, p, value)
+	default:
+		applyAttr(doc, op, p.attr, value)
+		return nil
+	}
+}
+
+// applyAttr applies an operation to a whole attribute. Adding to a
+// multi-valued attribute appends; removing with a value removes the listed
+// elements only.
+func applyAttr(doc map[string]any, op, attr string, value any) {
+	current, isList := doc[attr].([]any)
+	switch op {
+	case opRemove:
+		if isList && value != nil {
+			doc[attr] = withoutElements(current, toList(value))
+			return
+		}
+		delete(doc, attr)
+	case opAdd:
+		if isList {
+			for _, v := range toList(value) {
+				if !containsElement(current, v) {
+					current = append(current, v)
+				}
+			}
+			doc[attr] = current
+			return
+		}
+		if obj, ok := doc[attr].(map[string]any); ok {
+			if patch, ok := value.(map[string]any); ok {
+				for k, v := range patch {
+					obj[k] = v
+				}
+				return
+			}
+		}
+		doc[attr] = value
+	default:
+		doc[attr] = value
+	}
+}
+
+func applySub(doc map[string]any, op string, p path, value any) error {
+	obj, ok := doc[p.attr].(map[string]any)
+	if !ok {
+		if _, isList := doc[p.attr].([]any); isList {
+			return &patchError{scimType: "invalidPath", detail: fmt.Sprintf("attribute %q is multi-valued", p.attr)}
+		}
+		if op == opRemove {
+			return nil
+		}
+		obj = map[string]any{}
+		doc[p.attr] = obj
+	}
+	if op == opRemove {
+		delete(obj, p.sub)
+		return nil
+	}
+	obj[p.sub] = value
+	return nil
+}
+
+// applyFiltered applies an operation to the elements of a multi-valued
+// attribute matching the path filter. Adding or replacing with no match adds
+// an element.
+func applyFiltered(doc map[string]any, op string, p path, value any) error {
+	list, _ := doc[p.attr].([]any)
+	kept := list[:0:0]
+	matched := false
+	for _, elem := range list {
+		obj, ok := elem.(map[string]any)
+		if !ok || !p.filter.matches(obj) {
+			kept = append(kept, elem)
+			continue
+		}
+		matched = true
+		switch {
+		case op == opRemove && p.sub == "":
+			continue
+		case op == opRemove:
+			delete(obj, p.sub)
+		case p.sub != "":
+			obj[p.sub] = value
+		default:
+			patch, ok := value.(map[string]any)
+			if !ok {
+				return &patchError{scimType: "invalidValue", detail: "value must be an object"}
+			}
+			for k, v := range patch {
+				obj[k] = v
+			}
+		}
+		kept = append(kept, obj)
+	}
+
+	if !matched && op != opRemove {
+		elem := map[string]any{p.filter.attr: p.filter.value}
+		if p.sub != "" {
+			elem[p.sub] = value
+		} else if patch, ok := value.(map[string]any); ok {
+			for k, v := range patch {
+				elem[k] = v
+			}
+		}
+		kept = append(kept, elem)
+	}
+	doc[p.attr] = kept
+	return nil
+}
+
+// matches reports whether the element of a multi-valued attribute satisfies
+// the filter. Comparison is case-insensitive.
+func (f *filter) matches(obj map[string]any) bool {
+	switch v := obj[f.attr].(type) {
+	case string:
+		return strings.EqualFold(v, f.value)
+	case nil:
+		return false
+	default:
+		return strings.EqualFold(fmt.Sprint(v), f.value)
+	}
+}
+
+func toList(v any) []any {
+	if list, ok := v.([]any); ok {
+		return list
+	}
+	return []any{v}
+}
+
+// elementKey identifies an element of a multi-valued attribute by its value
+// sub-attribute, or by the element itself for simple values.
+func elementKey(v any) string {
+	if obj, ok := v.(map[string]any); ok {
+		v = obj["value"]
+	}
+	return fmt.Sprint(v)
+}
+
+func containsElement(list []any, v any) bool {
+	key := elementKey(v)
+	for _, elem := range list {
+		if elementKey(elem) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutElements(list, remove []any) []any {
+	kept := list[:0:0]
+	for _, elem := range list {
+		if !containsElement(remove, elem) {
+			kept = append(kept, elem)
+		}
+	}
+	return kept
+}
+
+// lowerKeys returns v with the keys of all objects lower-cased, since SCIM
+// attribute names are case-insensitive.
+func lowerKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[strings.ToLower(k)] = lowerKeys(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = lowerKeys(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// toDocument converts a resource to the form patcher works on.
+func toDocument(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("marshal resource: %w", err)
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal resource: %w", err)
+	}
+	doc, _ = lowerKeys(doc).(map[string]any)
+	return doc, nil
+}
+
+// fromDocument decodes a patched document into resource. It fails with a
+// patchError when an attribute has a value of the wrong type.
+func fromDocument(doc map[string]any, resource any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal resource: %w", err)
+	}
+	if err = json.Unmarshal(data, resource); err != nil {
+		return &patchError{scimType: "invalidValue", detail: "invalid attribute value: " + err.Error()}
+	}
+	return nil
+}
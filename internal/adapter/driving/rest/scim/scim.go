@@ -0,0 +1,261 @@
+// Package scim serves the SCIM 2.0 provisioning API (RFC 7643, RFC 7644) for
+// the users and groups of a tenant.
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	contentType = "application/scim+json"
+	// Prefix is the path the API is served under, relative to the tenant.
+	Prefix = "/scim/v2"
+
+	maxPayloadSize = 1 << 20
+	maxOperations  = 100
+	maxResults     = 200
+)
+
+type Service interface {
+	Authenticate(ctx context.Context, raw string) (*model.SCIMToken, error)
+	ListUsers(ctx context.Context, filter model.UserFilter, startIndex, count int) ([]*model.User, int, error)
+	GetUser(ctx context.Context, tenantID, id string) (*model.User, error)
+	CreateUser(ctx context.Context, user *model.User, password string) error
+	ReplaceUser(ctx context.Context, user *model.User, password string, version time.Time) (*model.User, error)
+	DeleteUser(ctx context.Context, tenantID, id string) error
+	ListGroups(ctx context.Context, tenantID, name string, startIndex, count int) ([]*model.Group, int, error)
+	GetGroup(ctx context.Context, tenantID, id string) (*model.Group, *model.GroupMembers, error)
+	CreateGroup(ctx context.Context, group *model.Group, memberIDs []string) error
+	ReplaceGroup(ctx context.Context, tenantID, id, name string, memberIDs []string) error
+	DeleteGroup(ctx context.Context, tenantID, id string) error
+}
+
+// Handler serves the SCIM API. Requests must carry a SCIM bearer token of the
+// tenant they are addressed to.
+type Handler struct {
+	svc       Service
+	log       *zap.Logger
+	router    chi.Router
+	resources chi.Router
+}
+
+func NewHandler(svc Service, log *zap.Logger) *Handler {
+	h := &Handler{svc: svc, log: log}
+
+	h.resources = chi.NewRouter()
+	h.resources.Get("/Users", h.listUsers)
+	h.resources.Post("/Users", h.createUser)
+	h.resources.Get("/Users/{id}", h.getUser)
+	h.resources.Put("/Users/{id}", h.replaceUser)
+	h.resources.Patch("/Users/{id}", h.patchUser)
+	h.resources.Delete("/Users/{id}", h.deleteUser)
+	h.resources.Get("/Groups", h.listGroups)
+	h.resources.Post("/Groups", h.createGroup)
+	h.resources.Get("/Groups/{id}", h.getGroup)
+	h.resources.Put("/Groups/{id}", h.replaceGroup)
+	h.resources.Patch("/Groups/{id}", h.patchGroup)
+	h.resources.Delete("/Groups/{id}", h.deleteGroup)
+	h.resources.NotFound(func(w http.ResponseWriter, _ *http.Request) {
+		writeError(w, http.StatusNotFound, "", "resource endpoint not found")
+	})
+	h.resources.MethodNotAllowed(func(w http.ResponseWriter, _ *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, "", "method not allowed")
+	})
+
+	h.router = chi.NewRouter()
+	h.router.Use(h.authenticate)
+	h.router.Get("/ServiceProviderConfig", h.serviceProviderConfig)
+	h.router.Get("/ResourceTypes", h.resourceTypes)
+	h.router.Post("/Bulk", h.bulk)
+	h.router.Mount("/", h.resources)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+type ctxKey string
+
+const (
+	tenantKey  ctxKey = "scim_tenant"
+	baseURLKey ctxKey = "scim_base_url"
+)
+
+// authenticate checks the SCIM bearer token. A token only works for its own
+// tenant.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || raw == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			writeError(w, http.StatusUnauthorized, "", "missing bearer token")
+			return
+		}
+
+		token, err := h.svc.Authenticate(r.Context(), raw)
+		if err != nil {
+			if !errors.Is(err, domainerrors.ErrInvalidToken) {
+				h.serviceError(w, r, err)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "", "invalid bearer token")
+			return
+		}
+		if tenant := middleware.GetTenant(r.Context()); tenant != nil && tenant.ID != token.TenantID {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim", error="invalid_token"`)
+			writeError(w, http.StatusUnauthorized, "", "invalid bearer token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), tenantKey, token.TenantID)
+		ctx = context.WithValue(ctx, baseURLKey, baseURL(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func tenantID(r *http.Request) string {
+	id, _ := r.Context().Value(tenantKey).(string)
+	return id
+}
+
+// location returns the absolute URL of a resource, e.g. "Users/{id}".
+func location(r *http.Request, path string) string {
+	base, _ := r.Context().Value(baseURLKey).(string)
+	return base + "/" + path
+}
+
+// baseURL returns the absolute URL the API is served under for r, including
+// a /t/{tenant} prefix.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	prefix, _, found := strings.Cut(r.URL.Path, Prefix+"/")
+	if !found {
+		prefix = ""
+	}
+	return scheme + "://" + r.Host + prefix + Prefix
+}
+
+// pagination reads the 1-based startIndex and count query parameters. A
+// missing count is returned as -1, selecting the default page size.
+func pagination(r *http.Request) (int, int, error) {
+	startIndex, count := 1, -1
+	if v := r.URL.Query().Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, errors.New("startIndex must be an integer")
+		}
+		startIndex = max(n, 1)
+	}
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, errors.New("count must be an integer")
+		}
+		count = max(n, 0)
+	}
+	return startIndex, count, nil
+}
+
+// checkIfMatch reports whether the If-Match header, if any, matches etag.
+func checkIfMatch(r *http.Request, etag string) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPayloadSize)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+	return nil
+}
+
+func respond(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v) //nolint:gosec // error writing response body is unrecoverable
+}
+
+const errorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, scimType, detail string) {
+	respond(w, status, &errorResponse{
+		Schemas:  []string{errorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
+
+// serviceError maps an error of the service to a SCIM error response. Errors
+// that wrap nothing are validation messages.
+func (h *Handler) serviceError(w http.ResponseWriter, r *http.Request, err error) {
+	if policyErr, ok := errors.AsType[*domainerrors.PasswordPolicyError](err); ok {
+		writeError(w, http.StatusBadRequest, "invalidValue", policyErr.Error())
+		return
+	}
+
+	switch {
+	case errors.Is(err, domainerrors.ErrUserNotFound):
+		writeError(w, http.StatusNotFound, "", "user not found")
+	case errors.Is(err, domainerrors.ErrGroupNotFound):
+		writeError(w, http.StatusNotFound, "", "group not found")
+	case errors.Is(err, domainerrors.ErrEmailAlreadyExists):
+		writeError(w, http.StatusConflict, "uniqueness", "userName is already taken")
+	case errors.Is(err, domainerrors.ErrGroupAlreadyExists):
+		writeError(w, http.StatusConflict, "uniqueness", "displayName is already taken")
+	case errors.Is(err, domainerrors.ErrPreconditionFailed):
+		writeError(w, http.StatusPreconditionFailed, "", "resource was modified")
+	case errors.Is(err, domainerrors.ErrGroupExternallyManaged):
+		writeError(w, http.StatusConflict, "mutability", "group is managed by an external directory")
+	case errors.Is(err, domainerrors.ErrInvalidUserStatus):
+		writeError(w, http.StatusConflict, "mutability", "user status does not allow this change")
+	case errors.Is(err, domainerrors.ErrOverloaded):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "", "service overloaded, retry later")
+	case errors.Unwrap(err) == nil:
+		writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		h.log.Error("scim request failed",
+			zap.Error(err),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("request_id", middleware.GetRequestID(r.Context())),
+		)
+		writeError(w, http.StatusInternalServerError, "", "internal server error")
+	}
+}
@@ -0,0 +1,419 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/scim/mocks"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+var updatedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func testUser() *model.User {
+	return &model.User{
+		ID:        "user-1",
+		TenantID:  "tenant-1",
+		Email:     "alice@example.com",
+		Status:    model.UserStatusActive,
+		Profile:   model.Profile{DisplayName: "Alice", AvatarURL: "https://cdn.example.com/a.png"},
+		CreatedAt: updatedAt,
+		UpdatedAt: updatedAt,
+	}
+}
+
+// newService returns a service mock that accepts the token "secret" of
+// tenant-1.
+func newService(t *testing.T) *mocks.Service {
+	svc := mocks.NewService(t)
+	svc.EXPECT().Authenticate(mock.Anything, "secret").
+		Return(&model.SCIMToken{ID: "token-1", TenantID: "tenant-1"}, nil).Maybe()
+	return svc
+}
+
+func serve(svc Service, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	ctx := middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1", Slug: "acme"})
+
+	h := NewHandler(svc, zap.NewNop())
+	r := chi.NewRouter()
+	r.Mount(Prefix, h)
+	r.Mount("/t/{tenant}"+Prefix, h)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req.WithContext(ctx))
+	return rec
+}
+
+func TestHandler_Authentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		mockSetup  func(svc *mocks.Service)
+		wantDetail string
+	}{
+		{name: "missing token", mockSetup: func(_ *mocks.Service) {}, wantDetail: "missing bearer token"},
+		{
+			name:       "unknown token",
+			header:     "Bearer other",
+			wantDetail: "invalid bearer token",
+			mockSetup: func(svc *mocks.Service) {
+				svc.EXPECT().Authenticate(mock.Anything, "other").Return(nil, domainerrors.ErrInvalidToken)
+			},
+		},
+		{
+			name:       "token of another tenant",
+			header:     "Bearer other",
+			wantDetail: "invalid bearer token",
+			mockSetup: func(svc *mocks.Service) {
+				svc.EXPECT().Authenticate(mock.Anything, "other").
+					Return(&model.SCIMToken{ID: "token-2", TenantID: "tenant-2"}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocks.NewService(t)
+			tt.mockSetup(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/Users", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			ctx := middleware.WithTenant(req.Context(), &model.Tenant{ID: "tenant-1"})
+			rec := httptest.NewRecorder()
+			NewHandler(svc, zap.NewNop()).ServeHTTP(rec, req.WithContext(ctx))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"401",
+				"detail":"`+tt.wantDetail+`"}`, rec.Body.String())
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    *filter
+		wantErr bool
+	}{
+		{expr: "", want: nil},
+		{expr: `userName eq "Alice@Example.com"`, want: &filter{attr: "username", value: "Alice@Example.com"}},
+		{expr: `emails.value EQ "a@b.c"`, want: &filter{attr: "emails.value", value: "a@b.c"}},
+		{
+			expr: `urn:ietf:params:scim:schemas:core:2.0:User:externalId eq "x y"`,
+			want: &filter{attr: "externalid", value: "x y"},
+		},
+		{expr: `userName sw "a"`, wantErr: true},
+		{expr: `userName eq a`, wantErr: true},
+		{expr: `userName eq "a" and active eq true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseFilter(tt.expr)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, errInvalidFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHandler_ListUsers(t *testing.T) {
+	t.Run("filters by userName", func(t *testing.T) {
+		svc := newService(t)
+		svc.EXPECT().ListUsers(mock.Anything,
+			model.UserFilter{TenantID: "tenant-1", Email: "alice@example.com"}, 1, -1).
+			Return([]*model.User{testUser()}, 1, nil)
+
+		rec := serve(svc, http.MethodGet, `/scim/v2/Users?filter=userName+eq+"alice@example.com"`, "", nil)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp struct {
+			TotalResults int `json:"totalResults"`
+			Resources    []struct {
+				ID       string `json:"id"`
+				UserName string `json:"userName"`
+				Active   bool   `json:"active"`
+			} `json:"Resources"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.TotalResults)
+		require.Len(t, resp.Resources, 1)
+		assert.Equal(t, "alice@example.com", resp.Resources[0].UserName)
+		assert.True(t, resp.Resources[0].Active)
+	})
+
+	t.Run("unsupported attribute", func(t *testing.T) {
+		rec := serve(newService(t), http.MethodGet, `/scim/v2/Users?filter=displayName+eq+"Alice"`, "", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"scimType":"invalidFilter"`)
+	})
+}
+
+func TestHandler_CreateUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		mockSetup  func(svc *mocks.Service)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "created",
+			body: `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"bob@example.com",
+				"externalId":"00u1","name":{"givenName":"Bob","familyName":"Jones"},"active":"True",
+				"password":"correct horse"}`,
+			mockSetup: func(svc *mocks.Service) {
+				svc.EXPECT().CreateUser(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.TenantID == "tenant-1" && u.Email == "bob@example.com" && u.ExternalID == "00u1" &&
+						u.Profile.DisplayName == "Bob Jones" && u.Status == model.UserStatusActive
+				}), "correct horse").Run(func(_ context.Context, u *model.User, _ string) {
+					u.ID, u.UpdatedAt = "user-2", updatedAt
+				}).Return(nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "duplicate",
+			body: `{"userName":"alice@example.com"}`,
+			mockSetup: func(svc *mocks.Service) {
+				svc.EXPECT().CreateUser(mock.Anything, mock.Anything, "").Return(domainerrors.ErrEmailAlreadyExists)
+			},
+			wantStatus: http.StatusConflict,
+			wantBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"409",
+				"scimType":"uniqueness","detail":"userName is already taken"}`,
+		},
+		{
+			name:       "malformed body",
+			body:       `{"userName":`,
+			mockSetup:  func(_ *mocks.Service) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"400",
+				"scimType":"invalidSyntax","detail":"invalid request body"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t)
+			tt.mockSetup(svc)
+
+			rec := serve(svc, http.MethodPost, "/t/acme/scim/v2/Users", tt.body, nil)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusCreated {
+				assert.Equal(t, "http://example.com/t/acme/scim/v2/Users/user-2", rec.Header().Get("Location"))
+				assert.NotContains(t, rec.Body.String(), "password")
+			}
+		})
+	}
+}
+
+func TestHandler_PatchUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		header     http.Header
+		check      func(u *model.User, password string) bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "deactivate without path",
+			body: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+				"Operations":[{"op":"Replace","value":{"active":"False"}}]}`,
+			check: func(u *model.User, _ string) bool {
+				return u.Status == model.UserStatusBlocked && u.Profile.AvatarURL != ""
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "paths with filters and schema URNs",
+			body: `{"Operations":[
+				{"op":"replace","path":"emails[type eq \"work\"].value","value":"ignored@example.com"},
+				{"op":"replace","path":"urn:ietf:params:scim:schemas:core:2.0:User:displayName","value":"Alice Smith"},
+				{"op":"add","path":"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department",
+				 "value":"R&D"},
+				{"op":"remove","path":"locale"}]}`,
+			check: func(u *model.User, _ string) bool {
+				return u.Email == "alice@example.com" && u.Profile.DisplayName == "Alice Smith"
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "password",
+			body: `{"Operations":[{"op":"replace","path":"password","value":"correct horse"}]}`,
+			check: func(_ *model.User, password string) bool {
+				return password == "correct horse"
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "read-only attribute",
+			body:       `{"Operations":[{"op":"replace","path":"id","value":"other"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"400",
+				"scimType":"invalidPath","detail":"attribute \"id\" cannot be modified"}`,
+		},
+		{
+			name:       "stale If-Match",
+			body:       `{"Operations":[]}`,
+			header:     http.Header{"If-Match": []string{`W/"1"`}},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t)
+			svc.EXPECT().GetUser(mock.Anything, "tenant-1", "user-1").Return(testUser(), nil)
+			if tt.check != nil {
+				svc.EXPECT().ReplaceUser(mock.Anything, mock.Anything, mock.Anything, updatedAt).
+					RunAndReturn(func(_ context.Context, u *model.User, password string, _ time.Time) (*model.User, error) {
+						assert.True(t, tt.check(u, password))
+						return u, nil
+					})
+			}
+
+			rec := serve(svc, http.MethodPatch, "/scim/v2/Users/user-1", tt.body, tt.header)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_PatchGroup(t *testing.T) {
+	group := &model.Group{ID: "group-1", TenantID: "tenant-1", Name: "Engineering", UpdatedAt: updatedAt}
+	members := &model.GroupMembers{UserIDs: []string{"user-1", "user-2"}}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantName    string
+		wantMembers []string
+	}{
+		{
+			name: "add and remove members",
+			body: `{"Operations":[
+				{"op":"add","path":"members","value":[{"value":"user-3"},{"value":"user-1"}]},
+				{"op":"remove","path":"members","value":[{"value":"user-2"}]}]}`,
+			wantName:    "Engineering",
+			wantMembers: []string{"user-1", "user-3"},
+		},
+		{
+			name:        "remove by filter",
+			body:        `{"Operations":[{"op":"remove","path":"members[value eq \"user-1\"]"}]}`,
+			wantName:    "Engineering",
+			wantMembers: []string{"user-2"},
+		},
+		{
+			name: "rename and replace members",
+			body: `{"Operations":[{"op":"replace","value":{"id":"group-1","displayName":"Platform"}},
+				{"op":"replace","path":"members","value":[{"value":"user-4"}]}]}`,
+			wantName:    "Platform",
+			wantMembers: []string{"user-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newService(t)
+			svc.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(group, members, nil)
+			svc.EXPECT().ReplaceGroup(mock.Anything, "tenant-1", "group-1", tt.wantName, tt.wantMembers).Return(nil)
+
+			rec := serve(svc, http.MethodPatch, "/scim/v2/Groups/group-1", tt.body, nil)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetGroup(t *testing.T) {
+	svc := newService(t)
+	group := &model.Group{ID: "group-1", TenantID: "tenant-1", Name: "Engineering", UpdatedAt: updatedAt}
+	svc.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").
+		Return(group, &model.GroupMembers{UserIDs: []string{"user-1"}}, nil).Twice()
+
+	rec := serve(svc, http.MethodGet, "/scim/v2/Groups/group-1", "", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+	assert.Contains(t, rec.Body.String(), `"members":[{"value":"user-1","$ref":"http://example.com/scim/v2/Users/user-1","type":"User"}]`)
+
+	// Another member changes the version.
+	rec = serve(svc, http.MethodPut, "/scim/v2/Groups/group-1", `{"displayName":"Engineering"}`,
+		http.Header{"If-Match": []string{`W/"0000000000000000"`}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
+
+func TestHandler_Bulk(t *testing.T) {
+	svc := newService(t)
+	svc.EXPECT().CreateUser(mock.Anything, mock.Anything, "").
+		Run(func(_ context.Context, u *model.User, _ string) { u.ID = "user-9" }).Return(nil)
+	svc.EXPECT().CreateGroup(mock.Anything, mock.Anything, []string{"user-9"}).
+		Run(func(_ context.Context, g *model.Group, _ []string) { g.ID = "group-9" }).Return(nil)
+	svc.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-9").
+		Return(&model.Group{ID: "group-9", Name: "New"}, &model.GroupMembers{UserIDs: []string{"user-9"}}, nil)
+	svc.EXPECT().DeleteUser(mock.Anything, "tenant-1", "missing").Return(domainerrors.ErrUserNotFound)
+
+	body := `{"schemas":["urn:ietf:params:scim:api:messages:2.0:BulkRequest"],"failOnErrors":1,"Operations":[
+		{"method":"POST","bulkId":"u","path":"/Users","data":{"userName":"new@example.com"}},
+		{"method":"POST","bulkId":"g","path":"/Groups","data":{"displayName":"New","members":[{"value":"bulkId:u"}]}},
+		{"method":"DELETE","path":"/Users/missing"},
+		{"method":"DELETE","path":"/Users/never-run"}]}`
+	rec := serve(svc, http.MethodPost, "/scim/v2/Bulk", body, nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Operations []struct {
+			BulkID   string `json:"bulkId"`
+			Location string `json:"location"`
+			Status   string `json:"status"`
+		} `json:"Operations"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Operations, 3)
+	assert.Equal(t, "201", resp.Operations[0].Status)
+	assert.Equal(t, "http://example.com/scim/v2/Users/user-9", resp.Operations[0].Location)
+	assert.Equal(t, "201", resp.Operations[1].Status)
+	assert.Equal(t, "404", resp.Operations[2].Status)
+}
+
+func TestHandler_BulkUnresolvedReference(t *testing.T) {
+	body := `{"Operations":[{"method":"PATCH","path":"/Groups/bulkId:nope","data":{"Operations":[]}}]}`
+	rec := serve(newService(t), http.MethodPost, "/scim/v2/Bulk", body, nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"409"`)
+}
@@ -0,0 +1,322 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	userSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	listResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+)
+
+// userPatcher may change the attributes of a user that are stored. emails is
+// accepted but has no effect, as the email is userName.
+var userPatcher = patcher{attrs: map[string]bool{
+	"username":    true,
+	"externalid":  true,
+	"name":        true,
+	"displayname": true,
+	"emails":      true,
+	"active":      true,
+	"locale":      true,
+	"timezone":    true,
+	"password":    true,
+}}
+
+type userResource struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id,omitempty"`
+	ExternalID  string    `json:"externalId,omitempty"`
+	UserName    string    `json:"userName"`
+	Name        *name     `json:"name,omitempty"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Emails      []email   `json:"emails,omitempty"`
+	Active      *flexBool `json:"active,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	Password    string    `json:"password,omitempty"`
+	Meta        *meta     `json:"meta,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	Version      string     `json:"version,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// flexBool is a boolean that also accepts "true" and "false" as strings,
+// which some provisioning clients send.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, err := strconv.ParseBool(strings.ToLower(s))
+		if err != nil {
+			return errors.New("active: must be a boolean")
+		}
+		*b = flexBool(v)
+		return nil
+	}
+	var v bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.New("active: must be a boolean")
+	}
+	*b = flexBool(v)
+	return nil
+}
+
+// userETag is the version of a user, derived from when it last changed.
+func userETag(user *model.User) string {
+	return `W/"` + strconv.FormatInt(user.UpdatedAt.UnixMicro(), 10) + `"`
+}
+
+func toUserResource(r *http.Request, user *model.User) *userResource {
+	active := flexBool(user.Status == model.UserStatusActive)
+	res := &userResource{
+		Schemas:     []string{userSchema},
+		ID:          user.ID,
+		ExternalID:  user.ExternalID,
+		UserName:    user.Email,
+		DisplayName: user.Profile.DisplayName,
+		Emails:      []email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Locale:      user.Profile.Locale,
+		Timezone:    user.Profile.Timezone,
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      &user.CreatedAt,
+			LastModified: &user.UpdatedAt,
+			Location:     location(r, "Users/"+user.ID),
+			Version:      userETag(user),
+		},
+	}
+	if user.Profile.DisplayName != "" {
+		res.Name = &name{Formatted: user.Profile.DisplayName}
+	}
+	return res
+}
+
+// toUser overlays the attributes of res on a copy of current. Attributes
+// SCIM does not know, such as the avatar, are kept. Without active the status
+// is kept too.
+func toUser(res *userResource, current *model.User) *model.User {
+	user := *current
+	user.Email = res.UserName
+	user.ExternalID = res.ExternalID
+	user.Profile.DisplayName = res.DisplayName
+	if user.Profile.DisplayName == "" && res.Name != nil {
+		user.Profile.DisplayName = res.Name.Formatted
+		if user.Profile.DisplayName == "" {
+			user.Profile.DisplayName = strings.TrimSpace(res.Name.GivenName + " " + res.Name.FamilyName)
+		}
+	}
+	user.Profile.Locale = res.Locale
+	user.Profile.Timezone = res.Timezone
+
+	switch {
+	case res.Active == nil:
+	case bool(*res.Active):
+		user.Status = model.UserStatusActive
+	case current.Status == model.UserStatusActive:
+		user.Status = model.UserStatusBlocked
+	}
+	return &user
+}
+
+func (h *Handler) respondUser(w http.ResponseWriter, r *http.Request, status int, user *model.User) {
+	res := toUserResource(r, user)
+	w.Header().Set("ETag", res.Meta.Version)
+	if status == http.StatusCreated {
+		w.Header().Set("Location", res.Meta.Location)
+	}
+	respond(w, status, res)
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+	startIndex, count, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	filter := model.UserFilter{TenantID: tenantID(r)}
+	if f != nil {
+		switch f.attr {
+		case "username", "emails", "emails.value":
+			filter.Email = f.value
+		case "externalid":
+			filter.ExternalID = f.value
+		default:
+			writeError(w, http.StatusBadRequest, "invalidFilter", "users can be filtered by userName, externalId or emails.value")
+			return
+		}
+	}
+
+	users, total, err := h.svc.ListUsers(r.Context(), filter, startIndex, count)
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+
+	resources := make([]any, len(users))
+	for i, user := range users {
+		resources[i] = toUserResource(r, user)
+	}
+	respond(w, http.StatusOK, &listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	var res userResource
+	if err := decode(w, r, &res); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	user := toUser(&res, model.NewUser(tenantID(r), "", ""))
+	if err := h.svc.CreateUser(r.Context(), user, res.Password); err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	h.respondUser(w, r, http.StatusCreated, user)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.svc.GetUser(r.Context(), tenantID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	h.respondUser(w, r, http.StatusOK, user)
+}
+
+func (h *Handler) replaceUser(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var res userResource
+	if err := decode(w, r, &res); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	h.storeUser(w, r, &res, current)
+}
+
+func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request) {
+	current, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req patchRequest
+	if err := decode(w, r, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	doc, err := toDocument(toUserResource(r, current))
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	var res userResource
+	if err = userPatcher.apply(doc, req.Operations); err == nil {
+		err = fromDocument(doc, &res)
+	}
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+	h.storeUser(w, r, &res, current)
+}
+
+// currentUser loads the user a write is addressed to and checks If-Match.
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	current, err := h.svc.GetUser(r.Context(), tenantID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		h.serviceError(w, r, err)
+		return nil, false
+	}
+	if !checkIfMatch(r, userETag(current)) {
+		writeError(w, http.StatusPreconditionFailed, "", "resource was modified")
+		return nil, false
+	}
+	return current, true
+}
+
+func (h *Handler) storeUser(w http.ResponseWriter, r *http.Request, res *userResource, current *model.User) {
+	user, err := h.svc.ReplaceUser(r.Context(), toUser(res, current), res.Password, current.UpdatedAt)
+	if err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	h.respondUser(w, r, http.StatusOK, user)
+}
+
+func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteUser(r.Context(), tenantID(r), chi.URLParam(r, "id")); err != nil {
+		h.serviceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+		writeError(w, http.StatusRequestEntityTooLarge, "", "request body too large")
+		return
+	}
+	writeError(w, http.StatusBadRequest, "invalidSyntax", "invalid request body")
+}
+
+func writePatchError(w http.ResponseWriter, err error) {
+	if pErr, ok := errors.AsType[*patchError](err); ok {
+		writeError(w, http.StatusBadRequest, pErr.scimType, pErr.detail)
+		return
+	}
+	writeError(w, http.StatusBadRequest, "invalidValue", err.Error())
+}
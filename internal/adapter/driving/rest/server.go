@@ -12,6 +12,8 @@ import (
 
 	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler"
 	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/scim"
+	"github.com/sanchey92/sso/internal/domain/model"
 )
 
 type Config struct {
@@ -19,14 +21,46 @@ type Config struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// MetricsPath is where metrics are served; empty disables them.
+	MetricsPath string
+	RateLimits  RateLimitPolicies
+}
+
+type RateLimitPolicies struct {
+	Login         middleware.Policy
+	Register      middleware.Policy
+	PasswordReset middleware.Policy
+	VerifyEmail   middleware.Policy
+	MagicLink     middleware.Policy
+	TokenRefresh  middleware.Policy
+	TokenConsume  middleware.Policy
 }
 
 type Server struct {
 	httpServer   *http.Server
 	router       chi.Router
+	limiter      middleware.Limiter
+	tokens       middleware.TokenValidator
+	rateLimits   RateLimitPolicies
 	userHandler  *handler.UserHandler
 	authHandler  *handler.AuthHandler
 	tokenHandler *handler.TokenHandler
+	magicHandler *handler.MagicLinkHandler
+	profileH     *handler.ProfileHandler
+	sessionH     *handler.SessionHandler
+	exportH      *handler.ExportHandler
+	adminHandler *handler.AdminHandler
+	rbacHandler  *handler.RBACHandler
+	groupHandler *handler.GroupHandler
+	tenantH      *handler.TenantHandler
+	discoveryH   *handler.DiscoveryHandler
+	scimTokenH   *handler.SCIMTokenHandler
+	scimH        *scim.Handler
+	importH      *handler.ImportHandler
+	metricsH     *handler.MetricsHandler
+	metricsPath  string
+	tenants      middleware.TenantResolver
+	users        middleware.TenantUserGetter
 	log          *zap.Logger
 }
 
@@ -35,15 +69,51 @@ func NewServer(
 	userH *handler.UserHandler,
 	authH *handler.AuthHandler,
 	tokenH *handler.TokenHandler,
+	magicH *handler.MagicLinkHandler,
+	profileH *handler.ProfileHandler,
+	sessionH *handler.SessionHandler,
+	exportH *handler.ExportHandler,
+	adminH *handler.AdminHandler,
+	rbacH *handler.RBACHandler,
+	groupH *handler.GroupHandler,
+	tenantH *handler.TenantHandler,
+	discoveryH *handler.DiscoveryHandler,
+	scimTokenH *handler.SCIMTokenHandler,
+	scimH *scim.Handler,
+	importH *handler.ImportHandler,
+	metricsH *handler.MetricsHandler,
+	limiter middleware.Limiter,
+	tokens middleware.TokenValidator,
+	tenants middleware.TenantResolver,
+	users middleware.TenantUserGetter,
 	log *zap.Logger,
 ) *Server {
 	r := chi.NewRouter()
 
 	s := &Server{
 		router:       r,
+		limiter:      limiter,
+		tokens:       tokens,
+		rateLimits:   cfg.RateLimits,
 		userHandler:  userH,
 		authHandler:  authH,
 		tokenHandler: tokenH,
+		magicHandler: magicH,
+		profileH:     profileH,
+		sessionH:     sessionH,
+		exportH:      exportH,
+		adminHandler: adminH,
+		rbacHandler:  rbacH,
+		groupHandler: groupH,
+		tenantH:      tenantH,
+		discoveryH:   discoveryH,
+		scimTokenH:   scimTokenH,
+		scimH:        scimH,
+		importH:      importH,
+		metricsH:     metricsH,
+		metricsPath:  cfg.MetricsPath,
+		tenants:      tenants,
+		users:        users,
 		log:          log,
 	}
 
@@ -64,18 +134,20 @@ func (s *Server) setupMiddleware() {
 	s.router.Use(middleware.RequestID)
 	s.router.Use(middleware.Recovery(s.log))
 	s.router.Use(middleware.Logging(s.log))
+	s.router.Use(middleware.ClientInfo)
 	s.router.Use(middleware.CORS)
 }
 
 func (s *Server) setupRoutes() {
-	s.router.Route("/api/v1/auth", func(r chi.Router) {
-		r.Post("/register", s.userHandler.Register)
-		r.Post("/login", s.authHandler.Login)
-		r.Post("/token/refresh", s.tokenHandler.Refresh)
-		r.Post("/token/revoke", s.tokenHandler.Revoke)
-		r.Post("/email/verify", s.userHandler.VerifyEmail)
-		r.Post("/password/reset-request", s.userHandler.RequestPasswordReset)
-		r.Post("/password/reset", s.userHandler.ResetPassword)
+	s.router.Group(func(r chi.Router) {
+		r.Use(middleware.ResolveTenant(s.tenants, s.log))
+		s.tenantRoutes(r)
+	})
+	// Every tenant is also served under its own path, the base of its issuer
+	// URL.
+	s.router.Route("/t/{tenant}", func(r chi.Router) {
+		r.Use(middleware.ResolveTenant(s.tenants, s.log))
+		s.tenantRoutes(r)
 	})
 
 	s.router.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -83,6 +155,107 @@ func (s *Server) setupRoutes() {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte(`{"status": "ok"}`)) //nolint:gosec // error writing response body is unrecoverable
 	})
+	if s.metricsPath != "" {
+		s.router.Get(s.metricsPath, s.metricsH.Metrics)
+	}
+}
+
+// tenantRoutes registers the API of one tenant on r, behind ResolveTenant.
+func (s *Server) tenantRoutes(r chi.Router) {
+	r.Get("/.well-known/openid-configuration", s.discoveryH.Configuration)
+	r.Get("/.well-known/jwks.json", s.discoveryH.JWKS)
+	r.Get("/api/v1/branding", s.tenantH.Branding)
+
+	r.Route("/api/v1/auth", func(r chi.Router) {
+		r.With(s.rateLimit(s.rateLimits.Register)).Post("/register", s.userHandler.Register)
+		r.With(s.rateLimit(s.rateLimits.Login)).Post("/login", s.authHandler.Login)
+		r.With(s.rateLimit(s.rateLimits.TokenRefresh)).Post("/token/refresh", s.tokenHandler.Refresh)
+		r.Post("/token/revoke", s.tokenHandler.Revoke)
+		r.With(s.rateLimit(s.rateLimits.VerifyEmail)).Post("/email/verify", s.userHandler.VerifyEmail)
+		r.With(s.rateLimit(s.rateLimits.PasswordReset)).Post("/password/reset-request", s.userHandler.RequestPasswordReset)
+		r.With(s.rateLimit(s.rateLimits.TokenConsume)).Post("/password/reset", s.userHandler.ResetPassword)
+		r.With(s.rateLimit(s.rateLimits.VerifyEmail)).Post("/email/change/confirm", s.userHandler.ConfirmEmailChange)
+		r.With(s.rateLimit(s.rateLimits.VerifyEmail)).Post("/email/change/revert", s.userHandler.RevertEmailChange)
+		r.With(s.rateLimit(s.rateLimits.MagicLink)).Post("/magic-link", s.magicHandler.Request)
+		r.With(s.rateLimit(s.rateLimits.TokenConsume)).Post("/magic-link/consume", s.magicHandler.Consume)
+	})
+
+	r.Route("/api/v1/me", func(r chi.Router) {
+		r.Use(middleware.Authenticate(s.tokens))
+		r.Get("/", s.profileH.Get)
+		r.Patch("/", s.profileH.Update)
+		r.With(s.rateLimit(s.rateLimits.Login)).Delete("/", s.profileH.Delete)
+		// Shares the login budget: both let a caller test password guesses.
+		r.With(s.rateLimit(s.rateLimits.Login)).Post("/password", s.profileH.ChangePassword)
+		r.With(s.rateLimit(s.rateLimits.VerifyEmail)).Post("/email", s.profileH.RequestEmailChange)
+		r.Post("/export", s.exportH.Request)
+		r.Get("/groups", s.groupHandler.ListMyGroups)
+		r.Get("/sessions", s.sessionH.List)
+		r.Post("/sessions/logout-others", s.sessionH.RevokeOthers)
+		r.Delete("/sessions/{id}", s.sessionH.Revoke)
+	})
+
+	r.Get("/api/v1/exports/download", s.exportH.Download)
+
+	r.Mount(scim.Prefix, s.scimH)
+
+	r.Route("/api/v1/admin", func(r chi.Router) {
+		r.Use(middleware.Authenticate(s.tokens), middleware.RequireRole(model.RoleAdmin))
+		r.Get("/users", s.adminHandler.SearchUsers)
+		r.Post("/users/import", s.importH.Import)
+		r.Route("/users/{id}", func(r chi.Router) {
+			r.Use(middleware.RequireTenantUser(s.users, s.log))
+			r.Post("/block", s.adminHandler.BlockUser)
+			r.Post("/unblock", s.adminHandler.UnblockUser)
+			r.Post("/verify-email", s.adminHandler.VerifyEmail)
+			r.Post("/mfa/reset", s.adminHandler.ResetMFA)
+			r.Post("/password-reset", s.adminHandler.SendPasswordReset)
+			r.Post("/logout", s.adminHandler.Logout)
+			r.Post("/unlock", s.adminHandler.UnlockUser)
+			r.Post("/export", s.exportH.AdminRequest)
+			r.Get("/roles", s.rbacHandler.ListUserRoles)
+			r.Put("/roles/{roleID}", s.rbacHandler.AssignRole)
+			r.Delete("/roles/{roleID}", s.rbacHandler.UnassignRole)
+			r.Get("/groups", s.groupHandler.ListUserGroups)
+		})
+		// The role and permission catalog is shared by all tenants, so only
+		// operators of the default tenant change it.
+		r.Get("/roles", s.rbacHandler.ListRoles)
+		r.With(middleware.RequireDefaultTenant).Post("/roles", s.rbacHandler.CreateRole)
+		r.With(middleware.RequireDefaultTenant).Delete("/roles/{id}", s.rbacHandler.DeleteRole)
+		r.With(middleware.RequireDefaultTenant).Put("/roles/{id}/permissions", s.rbacHandler.SetRolePermissions)
+		r.Get("/permissions", s.rbacHandler.ListPermissions)
+		r.With(middleware.RequireDefaultTenant).Post("/permissions", s.rbacHandler.CreatePermission)
+		r.With(middleware.RequireDefaultTenant).Delete("/permissions/{id}", s.rbacHandler.DeletePermission)
+		r.Get("/groups", s.groupHandler.List)
+		r.Post("/groups", s.groupHandler.Create)
+		r.Get("/groups/{id}", s.groupHandler.Get)
+		r.Put("/groups/{id}", s.groupHandler.Update)
+		r.Delete("/groups/{id}", s.groupHandler.Delete)
+		r.Put("/groups/{id}/members/users/{userID}", s.groupHandler.AddMember)
+		r.Delete("/groups/{id}/members/users/{userID}", s.groupHandler.RemoveMember)
+		r.Put("/groups/{id}/members/groups/{groupID}", s.groupHandler.AddSubgroup)
+		r.Delete("/groups/{id}/members/groups/{groupID}", s.groupHandler.RemoveSubgroup)
+		r.Get("/scim/tokens", s.scimTokenH.List)
+		r.Post("/scim/tokens", s.scimTokenH.Create)
+		r.Delete("/scim/tokens/{id}", s.scimTokenH.Delete)
+		r.Route("/tenants", func(r chi.Router) {
+			r.Use(middleware.RequireDefaultTenant)
+			r.Get("/", s.tenantH.List)
+			r.Post("/", s.tenantH.Create)
+			r.Get("/{id}", s.tenantH.Get)
+			r.Put("/{id}", s.tenantH.Update)
+		})
+	})
+}
+
+// rateLimit returns the middleware enforcing policy, or a pass-through when
+// no limiter is configured or the policy does not select a key.
+func (s *Server) rateLimit(policy middleware.Policy) func(http.Handler) http.Handler {
+	if s.limiter == nil || policy.By == 0 || policy.Limit.MaxAttempts <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return middleware.RateLimit(s.limiter, policy, s.log)
 }
 
 func (s *Server) Start() error {
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,14 +12,70 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/scim"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
 )
 
+type roleValidator []string
+
+func (v roleValidator) ValidateAccessToken(_ context.Context, _ string) (*model.AccessTokenClaims, error) {
+	return &model.AccessTokenClaims{Subject: "user-123", Roles: v, Tenant: model.DefaultTenantSlug}, nil
+}
+
+// tenantAdmin issues admin tokens of the tenant with the given slug.
+type tenantAdmin string
+
+func (v tenantAdmin) ValidateAccessToken(_ context.Context, _ string) (*model.AccessTokenClaims, error) {
+	return &model.AccessTokenClaims{Subject: "user-123", Roles: []string{model.RoleAdmin}, Tenant: string(v)}, nil
+}
+
+// testTenants knows the default tenant and "acme"; every host resolves to the
+// default tenant.
+type testTenants struct{}
+
+func (testTenants) GetTenantBySlug(_ context.Context, slug string) (*model.Tenant, error) {
+	switch slug {
+	case model.DefaultTenantSlug:
+		return &model.Tenant{ID: "tenant-default", Slug: slug}, nil
+	case "acme":
+		return &model.Tenant{ID: "tenant-acme", Slug: slug}, nil
+	}
+	return nil, domainerrors.ErrTenantNotFound
+}
+
+func (t testTenants) ResolveHost(ctx context.Context, _ string) (*model.Tenant, error) {
+	return t.GetTenantBySlug(ctx, model.DefaultTenantSlug)
+}
+
 func newTestServer() *Server {
+	return newTestServerWithTokens(nil)
+}
+
+func newTestServerWithTokens(tokens middleware.TokenValidator) *Server {
 	return NewServer(
 		&Config{Host: "localhost", Port: 0},
 		&handler.UserHandler{},
 		&handler.AuthHandler{},
 		&handler.TokenHandler{},
+		&handler.MagicLinkHandler{},
+		&handler.ProfileHandler{},
+		&handler.SessionHandler{},
+		&handler.ExportHandler{},
+		&handler.AdminHandler{},
+		&handler.RBACHandler{},
+		&handler.GroupHandler{},
+		&handler.TenantHandler{},
+		&handler.DiscoveryHandler{},
+		&handler.SCIMTokenHandler{},
+		scim.NewHandler(nil, zap.NewNop()),
+		&handler.ImportHandler{},
+		&handler.MetricsHandler{},
+		nil,
+		tokens,
+		testTenants{},
+		nil,
 		zap.NewNop(),
 	)
 }
@@ -75,6 +132,113 @@ func TestCORSHeaders(t *testing.T) {
 	assert.Equal(t, http.StatusNoContent, rec.Code)
 	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
 	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "POST")
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), "PATCH")
 	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "If-Match")
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "If-None-Match")
 	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID")
+	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "ETag")
+}
+
+func TestMeRequiresAuthentication(t *testing.T) {
+	srv := newTestServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t, `{"error":"unauthorized","code":"UNAUTHORIZED"}`, rec.Body.String())
+}
+
+func TestAdminRequiresAdminRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     middleware.TokenValidator
+		header     string
+		wantStatus int
+	}{
+		{name: "no token", tokens: roleValidator{model.RoleAdmin}, wantStatus: http.StatusUnauthorized},
+		{name: "not an admin", tokens: roleValidator{}, header: "Bearer access", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServerWithTokens(tt.tokens)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestTenantRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		tokens     middleware.TokenValidator
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "unknown tenant",
+			tokens:     roleValidator{model.RoleAdmin},
+			method:     http.MethodGet,
+			path:       "/t/unknown/api/v1/me",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "token of another tenant",
+			tokens:     roleValidator{model.RoleAdmin},
+			method:     http.MethodGet,
+			path:       "/t/acme/api/v1/admin/users",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "tenant admin cannot manage tenants",
+			tokens:     tenantAdmin("acme"),
+			method:     http.MethodGet,
+			path:       "/t/acme/api/v1/admin/tenants",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "tenant admin cannot change the role catalog",
+			tokens:     tenantAdmin("acme"),
+			method:     http.MethodPost,
+			path:       "/t/acme/api/v1/admin/roles",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServerWithTokens(tt.tokens)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer access")
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestSCIMRequiresToken(t *testing.T) {
+	srv := newTestServer()
+
+	for _, path := range []string{"/scim/v2/Users", "/t/acme/scim/v2/Groups"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, path)
+		assert.Equal(t, "application/scim+json", rec.Header().Get("Content-Type"), path)
+	}
 }
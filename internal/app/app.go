@@ -2,22 +2,45 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/signal"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"github.com/sanchey92/sso/internal/adapter/driven/breach"
 	"github.com/sanchey92/sso/internal/adapter/driven/email"
 	"github.com/sanchey92/sso/internal/adapter/driven/hasher"
 	jwtadapter "github.com/sanchey92/sso/internal/adapter/driven/jwt"
+	"github.com/sanchey92/sso/internal/adapter/driven/ldap"
+	"github.com/sanchey92/sso/internal/adapter/driven/passwordpolicy"
 	"github.com/sanchey92/sso/internal/adapter/driven/postgres"
 	"github.com/sanchey92/sso/internal/adapter/driven/redis"
+	grpcadapter "github.com/sanchey92/sso/internal/adapter/driving/grpc"
 	"github.com/sanchey92/sso/internal/adapter/driving/rest"
 	"github.com/sanchey92/sso/internal/adapter/driving/rest/handler"
+	"github.com/sanchey92/sso/internal/adapter/driving/rest/middleware"
+	scimadapter "github.com/sanchey92/sso/internal/adapter/driving/rest/scim"
 	"github.com/sanchey92/sso/internal/config"
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
 	"github.com/sanchey92/sso/internal/usecase/auth"
+	"github.com/sanchey92/sso/internal/usecase/events"
+	"github.com/sanchey92/sso/internal/usecase/export"
+	"github.com/sanchey92/sso/internal/usecase/group"
+	"github.com/sanchey92/sso/internal/usecase/hashstats"
+	"github.com/sanchey92/sso/internal/usecase/importer"
+	"github.com/sanchey92/sso/internal/usecase/lockout"
+	"github.com/sanchey92/sso/internal/usecase/magiclink"
+	"github.com/sanchey92/sso/internal/usecase/purge"
+	"github.com/sanchey92/sso/internal/usecase/rbac"
+	"github.com/sanchey92/sso/internal/usecase/scim"
+	"github.com/sanchey92/sso/internal/usecase/tenant"
 	"github.com/sanchey92/sso/internal/usecase/token"
 	"github.com/sanchey92/sso/internal/usecase/user"
 	"github.com/sanchey92/sso/pkg/logger"
@@ -29,6 +52,10 @@ type App struct {
 	storage    *postgres.Storage
 	cache      *redis.Cache
 	httpServer *rest.Server
+	grpcServer *grpcadapter.Server
+	purger     *purge.Service
+	exporter   *export.Service
+	hashStats  *hashstats.Service
 }
 
 func NewApp(cfg *config.Config) (*App, error) {
@@ -49,14 +76,100 @@ func NewApp(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("jwt: %w", err)
 	}
 
-	h := hasher.New(hasher.DefaultConfig())
+	grantAdmins(storage, cfg.Security.AdminEmails, log)
+	tenantService := tenant.New(storage, log)
+
+	h, err := initHasher(&cfg.Security.PasswordHash, log)
+	if err != nil {
+		return nil, fmt.Errorf("hasher: %w", err)
+	}
+	passwordPolicy, err := initPasswordPolicy(&cfg.Security.PasswordPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("password policy: %w", err)
+	}
+	breachChecker, err := initBreachChecker(&cfg.Security.BreachedPasswords)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords: %w", err)
+	}
 	emailSender := email.NewLogSender(log, "http://localhost:8080")
 
-	tokenService := token.New(jwtService, storage, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL, log)
-	userService := user.New(storage, h, cache, emailSender, storage, log)
-	authService := auth.New(storage, h, tokenService, log)
+	tokenService := token.New(jwtService, storage, storage, storage,
+		cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL, cfg.Auth.RefreshReuseGrace, cfg.Auth.GroupsClaimLimit, log)
+	groupService := group.New(storage, log)
+	lockoutService := lockout.New(cache, emailSender, &lockout.Config{
+		MaxFailures:   cfg.Security.Lockout.MaxFailures,
+		DelayAfter:    cfg.Security.Lockout.DelayAfter,
+		BaseDelay:     cfg.Security.Lockout.BaseDelay,
+		MaxDelay:      cfg.Security.Lockout.MaxDelay,
+		LockDuration:  cfg.Security.Lockout.LockDuration,
+		FailureWindow: cfg.Security.Lockout.FailureWindow,
+	}, log)
+	eventService := events.New(storage, &events.Config{
+		PollInterval: cfg.Server.GRPC.Events.PollInterval,
+		BatchSize:    cfg.Server.GRPC.Events.BatchSize,
+	}, log)
+	userService := user.New(
+		storage, h, passwordPolicy, breachChecker, storage, cache, emailSender, storage, lockoutService, eventService, &user.Config{
+			EnumerationSafe:          cfg.Auth.Registration.EnumerationSafe,
+			EmailChangeCooldown:      cfg.Auth.EmailChange.Cooldown,
+			PasswordHistorySize:      cfg.Security.PasswordHistory.Size,
+			PasswordHistoryRetention: cfg.Security.PasswordHistory.Retention,
+		}, log,
+	)
+	authBackends := initAuthBackends(&cfg.Directory, tenantService, log)
+	authService := auth.New(
+		storage, h, tokenService, storage, authBackends, groupService, lockoutService, storage, storage,
+		passwordPolicy, breachChecker, &auth.Config{DeletionGracePeriod: cfg.Auth.Deletion.GracePeriod}, log,
+	)
+	purgeService := purge.New(storage, eventService, &purge.Config{
+		GracePeriod: cfg.Auth.Deletion.GracePeriod,
+		Interval:    cfg.Auth.Deletion.PurgeInterval,
+		BatchSize:   cfg.Auth.Deletion.PurgeBatchSize,
+	}, log)
+	exportService := export.New(storage, storage, emailSender, &export.Config{
+		LinkTTL:           cfg.Auth.DataExport.LinkTTL,
+		PollInterval:      cfg.Auth.DataExport.PollInterval,
+		ProcessingTimeout: cfg.Auth.DataExport.ProcessingTimeout,
+	}, log)
+
+	rbacService := rbac.New(storage, log)
+	scimService := scim.New(storage, storage, userService, groupService, storage, h, passwordPolicy, eventService, log)
+	importService := importer.New(storage, h, eventService, log)
+	hashStatsService := hashstats.New(storage, h, &hashstats.Config{
+		Interval: cfg.Observability.Metrics.PasswordHashInterval,
+	}, log)
 
-	httpServer := initHTTPServer(&cfg.Server.HTTP, userService, authService, tokenService, log)
+	magicLinkService := magiclink.New(storage, cache, emailSender, tokenService, authBackends, &magiclink.Config{
+		TTL:           cfg.Auth.MagicLink.TTL,
+		BindToBrowser: cfg.Auth.MagicLink.BindToBrowser,
+		SigningKey:    cfg.Security.EncryptionKey,
+	}, log)
+
+	httpServer := initHTTPServer(
+		&cfg.Server.HTTP,
+		&cfg.Security,
+		&cfg.Observability.Metrics,
+		userService,
+		authService,
+		tokenService,
+		magicLinkService,
+		exportService,
+		lockoutService,
+		rbacService,
+		groupService,
+		tenantService,
+		scimService,
+		importService,
+		hashStatsService,
+		h,
+		redis.NewRateLimiter(cache),
+		log,
+	)
+
+	grpcServer, err := initGRPCServer(&cfg.Server.GRPC, tokenService, userService, rbacService, eventService, log)
+	if err != nil {
+		return nil, fmt.Errorf("grpc: %w", err)
+	}
 
 	return &App{
 		cfg:        cfg,
@@ -64,6 +177,10 @@ func NewApp(cfg *config.Config) (*App, error) {
 		storage:    storage,
 		cache:      cache,
 		httpServer: httpServer,
+		grpcServer: grpcServer,
+		purger:     purgeService,
+		exporter:   exportService,
+		hashStats:  hashStatsService,
 	}, nil
 }
 
@@ -77,6 +194,18 @@ func (a *App) Run() {
 		}
 	}()
 
+	go func() {
+		if err := a.grpcServer.Start(); err != nil {
+			a.log.Fatal("grpc server error", zap.Error(err))
+		}
+	}()
+
+	go a.purger.Run(ctx)
+	go a.exporter.Run(ctx)
+	if a.cfg.Observability.Metrics.Enabled {
+		go a.hashStats.Run(ctx)
+	}
+
 	a.log.Info("usecase started")
 
 	<-ctx.Done()
@@ -90,6 +219,9 @@ func (a *App) Stop() {
 	if err := a.httpServer.Stop(shutdownCtx); err != nil {
 		a.log.Error("http server shutdown error", zap.Error(err))
 	}
+	if err := a.grpcServer.Stop(shutdownCtx); err != nil {
+		a.log.Error("grpc server shutdown error", zap.Error(err))
+	}
 
 	a.storage.Close()
 	if err := a.cache.Close(); err != nil {
@@ -119,6 +251,25 @@ func initPostgres(cfg *config.PostgresConfig, log *zap.Logger) (*postgres.Storag
 	return s, nil
 }
 
+// grantAdmins gives the configured bootstrap administrators the admin role.
+// Emails without an account are skipped; the grant is retried on the next
+// start.
+func grantAdmins(storage *postgres.Storage, emails []string, log *zap.Logger) {
+	for _, e := range emails {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		err := storage.GrantRoleByEmail(context.Background(), model.DefaultTenantSlug, e, model.RoleAdmin)
+		switch {
+		case errors.Is(err, domainerrors.ErrUserNotFound):
+			log.Warn("bootstrap admin has no account", zap.String("email", e))
+		case err != nil:
+			log.Error("failed to grant admin role", zap.Error(err), zap.String("email", e))
+		}
+	}
+}
+
 func initCache(cfg *config.RedisConfig, log *zap.Logger) (*redis.Cache, error) {
 	c, err := redis.NewCache(&redis.Config{
 		Address:         cfg.Addr,
@@ -148,21 +299,204 @@ func initJWT(cfg *config.AuthConfig) (*jwtadapter.Service, error) {
 	return s, nil
 }
 
+// initAuthBackends builds the configured directories. A directory whose
+// tenant does not exist is skipped.
+func initAuthBackends(cfg *config.DirectoryConfig, tenants *tenant.Service, log *zap.Logger) auth.Backends {
+	backends := make(auth.Backends)
+	for i := range cfg.LDAP {
+		c := &cfg.LDAP[i]
+		slug := c.Tenant
+		if slug == "" {
+			slug = model.DefaultTenantSlug
+		}
+		t, err := tenants.GetTenantBySlug(context.Background(), slug)
+		if err != nil {
+			log.Error("skipping directory", zap.Error(err), zap.String("name", c.Name), zap.String("tenant", slug))
+			continue
+		}
+		backend := ldap.New(&ldap.Config{
+			Name:                 c.Name,
+			URL:                  c.URL,
+			StartTLS:             c.StartTLS,
+			InsecureSkipVerify:   c.InsecureSkipVerify,
+			BindDN:               c.BindDN,
+			BindPassword:         c.BindPassword,
+			BaseDN:               c.BaseDN,
+			UserFilter:           c.UserFilter,
+			EmailAttribute:       c.EmailAttribute,
+			DisplayNameAttribute: c.DisplayNameAttribute,
+			GroupAttribute:       c.GroupAttribute,
+			Attributes:           c.Attributes,
+			Timeout:              c.Timeout,
+		}, log)
+		for _, domain := range c.Domains {
+			backends.Add(t.ID, strings.ToLower(domain), backend)
+		}
+	}
+	return backends
+}
+
+func initHasher(cfg *config.PasswordHashConfig, log *zap.Logger) (*hasher.Pool, error) {
+	hashCfg := &hasher.Config{
+		Memory:      cfg.Memory,
+		Iterations:  cfg.Iterations,
+		Parallelism: cfg.Parallelism,
+		KeyLen:      cfg.KeyLen,
+		SaltLen:     cfg.SaltLen,
+	}
+	if err := hashCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid password hash parameters: %w", err)
+	}
+	if cfg.CalibrateTarget > 0 {
+		hashCfg = hasher.Calibrate(hashCfg, cfg.CalibrateTarget)
+		log.Info("password hash parameters calibrated",
+			zap.Duration("target", cfg.CalibrateTarget),
+			zap.Uint32("memory", hashCfg.Memory),
+			zap.Uint32("iterations", hashCfg.Iterations),
+			zap.Uint8("parallelism", hashCfg.Parallelism),
+		)
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return hasher.NewPool(hasher.New(hashCfg), &hasher.PoolConfig{
+		Workers:  workers,
+		MaxQueue: cfg.MaxQueue,
+		MaxWait:  cfg.MaxQueueWait,
+	}), nil
+}
+
+func initPasswordPolicy(cfg *config.PasswordPolicyConfig) (*passwordpolicy.Policy, error) {
+	policyCfg := &passwordpolicy.Config{
+		MinLength:    cfg.MinLength,
+		MaxLength:    cfg.MaxLength,
+		MinScore:     cfg.MinScore,
+		RejectEmail:  cfg.RejectEmail,
+		RejectCommon: cfg.RejectCommon,
+	}
+	if err := policyCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid password policy: %w", err)
+	}
+	return passwordpolicy.New(policyCfg), nil
+}
+
+func initBreachChecker(cfg *config.BreachedPasswordsConfig) (*breach.Checker, error) {
+	breachCfg := &breach.Config{Dir: cfg.Dir, MinCount: cfg.MinCount}
+	if err := breachCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid breach corpus: %w", err)
+	}
+	return breach.New(breachCfg), nil
+}
+
+// metricsPath is where the HTTP server exposes metrics, or empty if they are
+// disabled.
+func metricsPath(cfg *config.MetricsConfig) string {
+	if !cfg.Enabled {
+		return ""
+	}
+	return cfg.Path
+}
+
 func initHTTPServer(
 	cfg *config.HTTPServerConfig,
+	securityCfg *config.SecurityConfig,
+	metricsCfg *config.MetricsConfig,
 	userSvc *user.Service,
 	authSvc *auth.Service,
 	tokenSvc *token.Service,
+	magicLinkSvc *magiclink.Service,
+	exportSvc *export.Service,
+	lockoutSvc *lockout.Service,
+	rbacSvc *rbac.Service,
+	groupSvc *group.Service,
+	tenantSvc *tenant.Service,
+	scimSvc *scim.Service,
+	importSvc *importer.Service,
+	hashStatsSvc *hashstats.Service,
+	hasherPool *hasher.Pool,
+	limiter middleware.Limiter,
 	log *zap.Logger,
 ) *rest.Server {
 	userHandler := handler.NewUserHandler(userSvc, log)
 	authHandler := handler.NewAuthHandler(authSvc, log)
 	tokenHandler := handler.NewTokenHandler(tokenSvc, log)
+	magicLinkHandler := handler.NewMagicLinkHandler(magicLinkSvc, log)
+	profileHandler := handler.NewProfileHandler(userSvc, log)
+	sessionHandler := handler.NewSessionHandler(tokenSvc, log)
+	exportHandler := handler.NewExportHandler(exportSvc, log)
+	adminHandler := handler.NewAdminHandler(lockoutSvc, userSvc, log)
+	rbacHandler := handler.NewRBACHandler(rbacSvc, log)
+	groupHandler := handler.NewGroupHandler(groupSvc, log)
+	tenantHandler := handler.NewTenantHandler(tenantSvc, log)
+	discoveryHandler := handler.NewDiscoveryHandler(tokenSvc, log)
+	scimTokenHandler := handler.NewSCIMTokenHandler(scimSvc, log)
+	scimHandler := scimadapter.NewHandler(scimSvc, log)
+	importHandler := handler.NewImportHandler(importSvc, log)
+	metricsHandler := handler.NewMetricsHandler(hashStatsSvc, hasherPool)
+	rateLimitCfg := &securityCfg.RateLimit
 
 	return rest.NewServer(&rest.Config{
 		Host:         cfg.Host,
 		Port:         cfg.Port,
 		ReadTimeout:  cfg.ReadTimeout,
 		WriteTimeout: cfg.WriteTimeout,
-	}, userHandler, authHandler, tokenHandler, log)
+		MetricsPath:  metricsPath(metricsCfg),
+		RateLimits: rest.RateLimitPolicies{
+			Login:         ratePolicy("login", rateLimitCfg.Login),
+			Register:      ratePolicy("register", rateLimitCfg.Register),
+			PasswordReset: ratePolicy("password_reset", rateLimitCfg.PasswordReset),
+			VerifyEmail:   ratePolicy("verify_email", rateLimitCfg.VerifyEmail),
+			MagicLink:     ratePolicy("magic_link", rateLimitCfg.MagicLink),
+			TokenRefresh:  ratePolicy("token_refresh", rateLimitCfg.TokenRefresh),
+			TokenConsume:  ratePolicy("token_consume", rateLimitCfg.TokenConsume),
+		},
+	}, userHandler, authHandler, tokenHandler, magicLinkHandler, profileHandler, sessionHandler, exportHandler,
+		adminHandler, rbacHandler, groupHandler, tenantHandler, discoveryHandler,
+		scimTokenHandler, scimHandler, importHandler, metricsHandler, limiter, tokenSvc, tenantSvc, userSvc, log)
+}
+
+func initGRPCServer(
+	cfg *config.GRPCServerConfig,
+	tokenSvc *token.Service,
+	userSvc *user.Service,
+	rbacSvc *rbac.Service,
+	eventSvc *events.Service,
+	log *zap.Logger,
+) (*grpcadapter.Server, error) {
+	for _, tenantID := range cfg.Events.TenantAPIKeys {
+		if err := uuid.Validate(tenantID); err != nil {
+			return nil, fmt.Errorf("invalid tenant id %q for events api key: %w", tenantID, err)
+		}
+	}
+
+	identityHandler := grpcadapter.NewIdentityHandler(tokenSvc, userSvc, rbacSvc, log)
+	eventsHandler := grpcadapter.NewEventsHandler(eventSvc, log)
+
+	return grpcadapter.NewServer(&grpcadapter.Config{
+		Host:               cfg.Host,
+		Port:               cfg.Port,
+		Reflection:         cfg.Reflection,
+		APIKeys:            cfg.APIKeys,
+		EventAPIKeys:       cfg.Events.APIKeys,
+		TenantEventAPIKeys: cfg.Events.TenantAPIKeys,
+	}, identityHandler, eventsHandler, log), nil
+}
+
+func ratePolicy(name string, entry config.RateLimitEntry) middleware.Policy {
+	var by middleware.KeyBy
+	switch entry.Key {
+	case "ip":
+		by = middleware.ByIP
+	case "email":
+		by = middleware.ByEmail
+	case "ip_email":
+		by = middleware.ByIPAndEmail
+	}
+	return middleware.Policy{
+		Name:  name,
+		Limit: model.RateLimit{MaxAttempts: entry.MaxAttempts, Window: entry.Window},
+		By:    by,
+	}
 }
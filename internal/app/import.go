@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/sanchey92/sso/internal/config"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/events"
+	"github.com/sanchey92/sso/internal/usecase/importer"
+)
+
+// ImportUsers imports the users in r into the tenant with tenantSlug, as the
+// admin import endpoint does, for files too large to upload.
+func ImportUsers(
+	ctx context.Context,
+	cfg *config.Config,
+	tenantSlug string,
+	format model.ImportFormat,
+	r io.Reader,
+	dryRun bool,
+) (*model.ImportResult, error) {
+	log := initLogger(cfg.Observability.Log)
+
+	h, err := initHasher(&cfg.Security.PasswordHash, log)
+	if err != nil {
+		return nil, fmt.Errorf("hasher: %w", err)
+	}
+
+	storage, err := initPostgres(&cfg.Database.Postgres, log)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: %w", err)
+	}
+	defer storage.Close()
+
+	tenant, err := storage.GetTenantBySlug(ctx, tenantSlug)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant %q: %w", tenantSlug, err)
+	}
+
+	eventService := events.New(storage, &events.Config{
+		PollInterval: cfg.Server.GRPC.Events.PollInterval,
+		BatchSize:    cfg.Server.GRPC.Events.BatchSize,
+	}, log)
+	svc := importer.New(storage, h, eventService, log)
+
+	result, err := svc.Import(ctx, tenant.ID, format, r, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", tenantSlug, err)
+	}
+	return result, nil
+}
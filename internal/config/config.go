@@ -11,6 +11,7 @@ type Config struct {
 	Database      DatabaseConfig      `yaml:"database"`
 	Auth          AuthConfig          `yaml:"auth"`
 	Federation    FederationConfig    `yaml:"federation"`
+	Directory     DirectoryConfig     `yaml:"directory"`
 	MFA           MFAConfig           `yaml:"mfa"`
 	Security      SecurityConfig      `yaml:"security"`
 	Observability ObservabilityConfig `yaml:"observability"`
@@ -29,8 +30,25 @@ type HTTPServerConfig struct {
 }
 
 type GRPCServerConfig struct {
-	Host string `yaml:"host" env:"SSO_SERVER_GRPC_HOST" env-default:"0.0.0.0"`
-	Port int    `yaml:"port" env:"SSO_SERVER_GRPC_PORT" env-default:"9090"`
+	Host       string `yaml:"host"       env:"SSO_SERVER_GRPC_HOST"       env-default:"0.0.0.0"`
+	Port       int    `yaml:"port"       env:"SSO_SERVER_GRPC_PORT"       env-default:"9090"`
+	Reflection bool   `yaml:"reflection" env:"SSO_SERVER_GRPC_REFLECTION" env-default:"true"`
+	// APIKeys are the shared secrets internal services call the gRPC API
+	// with; every call except health checks needs one of them.
+	APIKeys []string     `yaml:"api_keys" env:"SSO_SERVER_GRPC_API_KEYS" env-separator:"," env-required:"true"`
+	Events  EventsConfig `yaml:"events"`
+}
+
+type EventsConfig struct {
+	// APIKeys open WatchEvents, whose events span all tenants. They are
+	// separate from the server's keys, which do not open the stream; with
+	// none set the stream is closed to everyone.
+	APIKeys []string `yaml:"api_keys" env:"SSO_SERVER_GRPC_EVENTS_API_KEYS" env-separator:","`
+	// TenantAPIKeys map further keys to a tenant id; such a key opens
+	// WatchEvents for the events of that tenant only.
+	TenantAPIKeys map[string]string `yaml:"tenant_api_keys" env:"SSO_SERVER_GRPC_EVENTS_TENANT_API_KEYS" env-separator:","`
+	PollInterval  time.Duration     `yaml:"poll_interval"   env:"SSO_SERVER_GRPC_EVENTS_POLL_INTERVAL"   env-default:"2s"`
+	BatchSize     int               `yaml:"batch_size"      env:"SSO_SERVER_GRPC_EVENTS_BATCH_SIZE"      env-default:"100"`
 }
 
 type DatabaseConfig struct {
@@ -59,10 +77,42 @@ type RedisConfig struct {
 }
 
 type AuthConfig struct {
-	AccessTokenTTL      time.Duration `yaml:"access_token_ttl"      env:"SSO_AUTH_ACCESS_TOKEN_TTL"      env-default:"15m"`
-	RefreshTokenTTL     time.Duration `yaml:"refresh_token_ttl"     env:"SSO_AUTH_REFRESH_TOKEN_TTL"     env-default:"168h"`
-	Issuer              string        `yaml:"issuer"                env:"SSO_AUTH_ISSUER"                env-required:"true"`
-	JWTSigningAlgorithm string        `yaml:"jwt_signing_algorithm" env:"SSO_AUTH_JWT_SIGNING_ALGORITHM" env-default:"EdDSA"`
+	AccessTokenTTL      time.Duration      `yaml:"access_token_ttl"      env:"SSO_AUTH_ACCESS_TOKEN_TTL"      env-default:"15m"`
+	RefreshTokenTTL     time.Duration      `yaml:"refresh_token_ttl"     env:"SSO_AUTH_REFRESH_TOKEN_TTL"     env-default:"168h"`
+	RefreshReuseGrace   time.Duration      `yaml:"refresh_reuse_grace"   env:"SSO_AUTH_REFRESH_REUSE_GRACE"   env-default:"10s"`
+	Issuer              string             `yaml:"issuer"                env:"SSO_AUTH_ISSUER"                env-required:"true"`
+	JWTSigningAlgorithm string             `yaml:"jwt_signing_algorithm" env:"SSO_AUTH_JWT_SIGNING_ALGORITHM" env-default:"EdDSA"`
+	GroupsClaimLimit    int                `yaml:"groups_claim_limit"    env:"SSO_AUTH_GROUPS_CLAIM_LIMIT"    env-default:"100"`
+	MagicLink           MagicLinkConfig    `yaml:"magic_link"`
+	Registration        RegistrationConfig `yaml:"registration"`
+	EmailChange         EmailChangeConfig  `yaml:"email_change"`
+	Deletion            DeletionConfig     `yaml:"deletion"`
+	DataExport          DataExportConfig   `yaml:"data_export"`
+}
+
+type RegistrationConfig struct {
+	EnumerationSafe bool `yaml:"enumeration_safe" env:"SSO_AUTH_REGISTRATION_ENUMERATION_SAFE" env-default:"false"`
+}
+
+type EmailChangeConfig struct {
+	Cooldown time.Duration `yaml:"cooldown" env:"SSO_AUTH_EMAIL_CHANGE_COOLDOWN" env-default:"24h"`
+}
+
+type DeletionConfig struct {
+	GracePeriod    time.Duration `yaml:"grace_period"     env:"SSO_AUTH_DELETION_GRACE_PERIOD"     env-default:"720h"`
+	PurgeInterval  time.Duration `yaml:"purge_interval"   env:"SSO_AUTH_DELETION_PURGE_INTERVAL"   env-default:"1h"`
+	PurgeBatchSize int           `yaml:"purge_batch_size" env:"SSO_AUTH_DELETION_PURGE_BATCH_SIZE" env-default:"100"`
+}
+
+type DataExportConfig struct {
+	LinkTTL           time.Duration `yaml:"link_ttl"           env:"SSO_AUTH_DATA_EXPORT_LINK_TTL"           env-default:"72h"`
+	PollInterval      time.Duration `yaml:"poll_interval"      env:"SSO_AUTH_DATA_EXPORT_POLL_INTERVAL"      env-default:"30s"`
+	ProcessingTimeout time.Duration `yaml:"processing_timeout" env:"SSO_AUTH_DATA_EXPORT_PROCESSING_TIMEOUT" env-default:"10m"`
+}
+
+type MagicLinkConfig struct {
+	TTL           time.Duration `yaml:"ttl"             env:"SSO_AUTH_MAGIC_LINK_TTL"             env-default:"15m"`
+	BindToBrowser bool          `yaml:"bind_to_browser" env:"SSO_AUTH_MAGIC_LINK_BIND_TO_BROWSER" env-default:"true"`
 }
 
 type OAuthProviderConfig struct {
@@ -76,6 +126,28 @@ type FederationConfig struct {
 	GitHub OAuthProviderConfig `yaml:"github" env-prefix:"SSO_FEDERATION_GITHUB_"`
 }
 
+type DirectoryConfig struct {
+	LDAP []LDAPConfig `yaml:"ldap"`
+}
+
+type LDAPConfig struct {
+	Name                 string        `yaml:"name"`
+	URL                  string        `yaml:"url"`
+	StartTLS             bool          `yaml:"start_tls"`
+	InsecureSkipVerify   bool          `yaml:"insecure_skip_verify"`
+	BindDN               string        `yaml:"bind_dn"`
+	BindPassword         string        `yaml:"bind_password"`
+	BaseDN               string        `yaml:"base_dn"`
+	UserFilter           string        `yaml:"user_filter"`
+	EmailAttribute       string        `yaml:"email_attribute"`
+	DisplayNameAttribute string        `yaml:"display_name_attribute"`
+	GroupAttribute       string        `yaml:"group_attribute"`
+	Attributes           []string      `yaml:"attributes"`
+	Domains              []string      `yaml:"domains"`
+	Tenant               string        `yaml:"tenant"`
+	Timeout              time.Duration `yaml:"timeout"`
+}
+
 type TOTPConfig struct {
 	Issuer string `yaml:"issuer" env:"SSO_MFA_TOTP_ISSUER" env-default:"MySSO"`
 	Skew   int    `yaml:"skew"   env:"SSO_MFA_TOTP_SKEW"   env-default:"1"`
@@ -85,20 +157,94 @@ type MFAConfig struct {
 	TOTP TOTPConfig `yaml:"totp"`
 }
 
+// RateLimitEntry allows MaxAttempts requests per Window. Key selects what
+// requests are counted by: "ip", "email" or "ip_email" (separately by both).
 type RateLimitEntry struct {
 	MaxAttempts int           `yaml:"max_attempts"`
 	Window      time.Duration `yaml:"window"`
+	Key         string        `yaml:"key"`
 }
 
 type SecurityConfig struct {
-	EncryptionKey string          `yaml:"encryption_key" env:"SSO_SECURITY_ENCRYPTION_KEY" env-required:"true"`
-	RateLimit     RateLimitConfig `yaml:"rate_limit"`
+	EncryptionKey string `yaml:"encryption_key" env:"SSO_SECURITY_ENCRYPTION_KEY" env-required:"true"`
+	// AdminEmails are granted the admin role at startup, so the first
+	// administrators can reach the admin API.
+	AdminEmails       []string                `yaml:"admin_emails" env:"SSO_SECURITY_ADMIN_EMAILS" env-separator:","`
+	RateLimit         RateLimitConfig         `yaml:"rate_limit"`
+	Lockout           LockoutConfig           `yaml:"lockout"`
+	PasswordHash      PasswordHashConfig      `yaml:"password_hash"`
+	PasswordPolicy    PasswordPolicyConfig    `yaml:"password_policy"`
+	BreachedPasswords BreachedPasswordsConfig `yaml:"breached_passwords"`
+	PasswordHistory   PasswordHistoryConfig   `yaml:"password_history"`
+}
+
+// PasswordHashConfig sets the argon2id parameters of new password hashes.
+// Stored hashes with weaker parameters are rehashed on the next login.
+type PasswordHashConfig struct {
+	Memory      uint32 `yaml:"memory"      env:"SSO_SECURITY_PASSWORD_HASH_MEMORY"      env-default:"65536"` // KiB
+	Iterations  uint32 `yaml:"iterations"  env:"SSO_SECURITY_PASSWORD_HASH_ITERATIONS"  env-default:"3"`
+	Parallelism uint8  `yaml:"parallelism" env:"SSO_SECURITY_PASSWORD_HASH_PARALLELISM" env-default:"4"`
+	KeyLen      uint32 `yaml:"key_len"     env:"SSO_SECURITY_PASSWORD_HASH_KEY_LEN"     env-default:"32"`
+	SaltLen     uint32 `yaml:"salt_len"    env:"SSO_SECURITY_PASSWORD_HASH_SALT_LEN"    env-default:"16"`
+	// Workers bounds the hashes running at once, each holding Memory; zero
+	// means one per CPU.
+	Workers      int           `yaml:"workers"        env:"SSO_SECURITY_PASSWORD_HASH_WORKERS"        env-default:"0"`
+	MaxQueue     int           `yaml:"max_queue"      env:"SSO_SECURITY_PASSWORD_HASH_MAX_QUEUE"      env-default:"64"`
+	MaxQueueWait time.Duration `yaml:"max_queue_wait" env:"SSO_SECURITY_PASSWORD_HASH_MAX_QUEUE_WAIT" env-default:"2s"`
+	// CalibrateTarget, if set, raises Iterations at startup until a hash
+	// takes about this long on the machine.
+	CalibrateTarget time.Duration `yaml:"calibrate_target" env:"SSO_SECURITY_PASSWORD_HASH_CALIBRATE_TARGET" env-default:"0s"`
+}
+
+// PasswordPolicyConfig sets the rules new passwords must meet. Lengths count
+// characters after NFKC normalization.
+type PasswordPolicyConfig struct {
+	MinLength int `yaml:"min_length" env:"SSO_SECURITY_PASSWORD_POLICY_MIN_LENGTH" env-default:"8"`
+	MaxLength int `yaml:"max_length" env:"SSO_SECURITY_PASSWORD_POLICY_MAX_LENGTH" env-default:"128"`
+	// MinScore is the lowest accepted strength, from 0 (guessable within a
+	// thousand tries) to 4 (beyond ten billion); zero disables the check.
+	MinScore     int  `yaml:"min_score"     env:"SSO_SECURITY_PASSWORD_POLICY_MIN_SCORE"     env-default:"2"`
+	RejectEmail  bool `yaml:"reject_email"  env:"SSO_SECURITY_PASSWORD_POLICY_REJECT_EMAIL"  env-default:"true"`
+	RejectCommon bool `yaml:"reject_common" env:"SSO_SECURITY_PASSWORD_POLICY_REJECT_COMMON" env-default:"true"`
+}
+
+// BreachedPasswordsConfig points to a local breached-password corpus in the
+// Have I Been Pwned range format. An empty Dir disables the check.
+type BreachedPasswordsConfig struct {
+	Dir      string `yaml:"dir"       env:"SSO_SECURITY_BREACHED_PASSWORDS_DIR"`
+	MinCount int    `yaml:"min_count" env:"SSO_SECURITY_BREACHED_PASSWORDS_MIN_COUNT" env-default:"1"`
+}
+
+// PasswordHistoryConfig blocks reuse of recent passwords on reset and change.
+type PasswordHistoryConfig struct {
+	// Size is how many recent passwords, the current one included, a new
+	// password must differ from; zero disables the check.
+	Size int `yaml:"size" env:"SSO_SECURITY_PASSWORD_HISTORY_SIZE" env-default:"4"`
+	// Retention is how long replaced passwords are remembered; zero keeps
+	// them until Size newer ones replace them.
+	Retention time.Duration `yaml:"retention" env:"SSO_SECURITY_PASSWORD_HISTORY_RETENTION" env-default:"8760h"`
+}
+
+type LockoutConfig struct {
+	MaxFailures   int           `yaml:"max_failures"   env:"SSO_SECURITY_LOCKOUT_MAX_FAILURES"   env-default:"10"`
+	DelayAfter    int           `yaml:"delay_after"    env:"SSO_SECURITY_LOCKOUT_DELAY_AFTER"    env-default:"3"`
+	BaseDelay     time.Duration `yaml:"base_delay"     env:"SSO_SECURITY_LOCKOUT_BASE_DELAY"     env-default:"1s"`
+	MaxDelay      time.Duration `yaml:"max_delay"      env:"SSO_SECURITY_LOCKOUT_MAX_DELAY"      env-default:"1m"`
+	LockDuration  time.Duration `yaml:"lock_duration"  env:"SSO_SECURITY_LOCKOUT_LOCK_DURATION"  env-default:"30m"`
+	FailureWindow time.Duration `yaml:"failure_window" env:"SSO_SECURITY_LOCKOUT_FAILURE_WINDOW" env-default:"1h"`
 }
 
 type RateLimitConfig struct {
-	Login     RateLimitEntry `yaml:"login"`
-	TOTP      RateLimitEntry `yaml:"totp"`
-	MagicLink RateLimitEntry `yaml:"magic_link"`
+	Login         RateLimitEntry `yaml:"login"`
+	Register      RateLimitEntry `yaml:"register"`
+	PasswordReset RateLimitEntry `yaml:"password_reset"`
+	VerifyEmail   RateLimitEntry `yaml:"verify_email"`
+	TOTP          RateLimitEntry `yaml:"totp"`
+	MagicLink     RateLimitEntry `yaml:"magic_link"`
+	TokenRefresh  RateLimitEntry `yaml:"token_refresh"`
+	// TokenConsume covers redeeming emailed one-time tokens: password reset
+	// and magic link sign-in.
+	TokenConsume RateLimitEntry `yaml:"token_consume"`
 }
 
 type ObservabilityConfig struct {
@@ -115,6 +261,9 @@ type LogConfig struct {
 type MetricsConfig struct {
 	Enabled bool   `yaml:"enabled" env:"SSO_METRICS_ENABLED" env-default:"true"`
 	Path    string `yaml:"path"    env:"SSO_METRICS_PATH"    env-default:"/metrics"`
+	// PasswordHashInterval is how often the stored password hashes are
+	// counted by whether they meet the current hashing parameters.
+	PasswordHashInterval time.Duration `yaml:"password_hash_interval" env:"SSO_METRICS_PASSWORD_HASH_INTERVAL" env-default:"10m"`
 }
 
 type TracingConfig struct {
@@ -1,6 +1,9 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrTokenExpired             = errors.New("token expired")
@@ -13,4 +16,54 @@ var (
 	ErrInvalidVerificationToken = errors.New("invalid or expired token")
 	ErrKeyNotFound              = errors.New("key not found")
 	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrInvalidMagicLink         = errors.New("invalid or expired magic link")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrPreconditionFailed       = errors.New("resource was modified")
+	ErrInvalidEmailChangeToken  = errors.New("invalid or expired email change token")
+	ErrEmailChangeCooldown      = errors.New("email change temporarily blocked")
+	ErrExportInProgress         = errors.New("data export already in progress")
+	ErrExportNotFound           = errors.New("data export not found")
+	ErrInvalidExportLink        = errors.New("invalid or expired export link")
+	ErrInvalidUserStatus        = errors.New("operation not allowed in the current user status")
+	ErrRoleNotFound             = errors.New("role not found")
+	ErrRoleAlreadyExists        = errors.New("role already exists")
+	ErrPermissionNotFound       = errors.New("permission not found")
+	ErrPermissionAlreadyExists  = errors.New("permission already exists")
+	ErrClientNotFound           = errors.New("client not found")
+	ErrGroupNotFound            = errors.New("group not found")
+	ErrGroupAlreadyExists       = errors.New("group already exists")
+	ErrGroupCycle               = errors.New("group nesting would create a cycle")
+	ErrGroupExternallyManaged   = errors.New("group is managed by an external directory")
+	ErrTenantNotFound           = errors.New("tenant not found")
+	ErrTenantAlreadyExists      = errors.New("tenant already exists")
+	ErrSCIMTokenNotFound        = errors.New("scim token not found")
+	ErrOverloaded               = errors.New("service overloaded")
+	ErrWeakPassword             = errors.New("password does not meet the policy")
+	ErrPasswordResetRequired    = errors.New("password reset required")
+	ErrSessionNotFound          = errors.New("session not found")
 )
+
+// PasswordViolation is one rule of the password policy a password breaks.
+type PasswordViolation struct {
+	// Code identifies the rule, e.g. "too_short" or "common".
+	Code    string
+	Message string
+}
+
+// PasswordPolicyError reports every rule a rejected password breaks. It
+// matches ErrWeakPassword.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
+	}
+	return "password: " + strings.Join(msgs, "; ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
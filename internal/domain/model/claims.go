@@ -0,0 +1,35 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// AccessTokenClaims are the verified claims of an access token.
+type AccessTokenClaims struct {
+	Subject string
+	Issuer  string
+	Tenant  string
+	// SessionID is the session the token was issued to; tokens issued
+	// before sessions were tracked have none.
+	SessionID   string
+	Audience    string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	Roles       []string
+	Permissions []string
+	Groups      []string
+	// GroupsOverage is set instead of Groups when the user is in more groups
+	// than fit in a token.
+	GroupsOverage bool
+}
+
+// HasRole reports whether the token grants role.
+func (c *AccessTokenClaims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasPermission reports whether the token grants permission.
+func (c *AccessTokenClaims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
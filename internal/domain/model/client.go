@@ -4,6 +4,7 @@ import "time"
 
 type OAuthClient struct {
 	ID             string
+	TenantID       string
 	SecretHash     string
 	Name           string
 	RedirectURIs   []string
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type EventType string
+
+const (
+	EventUserRegistered EventType = "user.registered"
+	EventEmailVerified  EventType = "user.email_verified"
+	EventPasswordReset  EventType = "user.password_reset"
+	EventUserBlocked    EventType = "user.blocked"
+	EventUserDeleted    EventType = "user.deleted"
+)
+
+// Event is a durable record of something that happened to a user. IDs grow
+// in commit order, so they double as stream positions.
+type Event struct {
+	ID        int64
+	Type      EventType
+	TenantID  string
+	UserID    string
+	Data      map[string]string
+	CreatedAt time.Time
+}
+
+func NewEvent(t EventType, tenantID, userID string) *Event {
+	return &Event{Type: t, TenantID: tenantID, UserID: userID, Data: map[string]string{}}
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready"
+	DataExportFailed     DataExportStatus = "failed"
+)
+
+// DataExportRequester records who asked for an export.
+type DataExportRequester string
+
+const (
+	DataExportBySubject DataExportRequester = "user"
+	DataExportByAdmin   DataExportRequester = "admin"
+)
+
+// DataExport is a subject access request: an archive of everything stored
+// about a user, downloadable through an emailed link until ExpiresAt.
+type DataExport struct {
+	ID          string
+	UserID      string
+	Status      DataExportStatus
+	RequestedBy DataExportRequester
+	Archive     []byte
+	TokenHash   string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	CompletedAt time.Time
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// ScopeGroups adds the groups claim to access tokens.
+const ScopeGroups = "groups"
+
+// Group is a set of users and other groups. Groups synced from an external
+// directory have a Source and ExternalID and their user membership is managed
+// by that directory.
+type Group struct {
+	ID          string
+	TenantID    string
+	Name        string
+	Description string
+	Source      string
+	ExternalID  string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IsExternal reports whether the group is synced from an external directory.
+func (g *Group) IsExternal() bool {
+	return g.Source != ""
+}
+
+// GroupMembers are the direct members of a group.
+type GroupMembers struct {
+	UserIDs   []string
+	Subgroups []*Group
+}
+
+// UserGroup is a group a user belongs to, either directly or through nested
+// groups.
+type UserGroup struct {
+	Group
+	Direct bool
+}
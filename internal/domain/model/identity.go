@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type UserIdentity struct {
+	ID          string
+	UserID      string
+	Provider    string
+	Subject     string
+	Email       string
+	DisplayName string
+	Groups      []string
+	Attributes  map[string]string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,28 @@
+package model
+
+// ImportFormat is the encoding of a user import file.
+type ImportFormat string
+
+const (
+	// ImportFormatCSV is comma-separated values with a header row naming the
+	// columns.
+	ImportFormatCSV ImportFormat = "csv"
+	// ImportFormatNDJSON is one JSON object per line.
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+// ImportResult reports the outcome of a user import. Errors lists the first
+// of the rejected lines; Failed counts all of them.
+type ImportResult struct {
+	Created int
+	Failed  int
+	DryRun  bool
+	Errors  []ImportError
+}
+
+// ImportError is a line of an import file that was rejected.
+type ImportError struct {
+	Line  int
+	Email string
+	Error string
+}
@@ -0,0 +1,15 @@
+package model
+
+// JWK is a public signing key in JSON Web Key form.
+type JWK struct {
+	KTY string `json:"kty"`
+	CRV string `json:"crv"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	X   string `json:"x"`
+}
+
+// JWKS is a JSON Web Key Set, as served to token verifiers.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,44 @@
+package model
+
+// PasswordHashGroup is a set of stored password hashes of the same scheme and
+// cost parameters, represented by one of them.
+type PasswordHashGroup struct {
+	Sample string
+	Count  int
+}
+
+// PasswordHashStats counts stored password hashes by whether they meet the
+// current hashing parameters. Outdated hashes are replaced on the next login.
+type PasswordHashStats struct {
+	Current  int
+	Outdated int
+}
+
+// OutdatedShare is the fraction of stored hashes that are outdated, or 0
+// when there are none.
+func (s *PasswordHashStats) OutdatedShare() float64 {
+	total := s.Current + s.Outdated
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Outdated) / float64(total)
+}
+
+// HashPoolStats is the load of the workers that hash passwords.
+type HashPoolStats struct {
+	Workers  int
+	InFlight int
+	Queued   int
+	Rejected uint64
+	Duration Histogram
+}
+
+// Histogram is a distribution of observations in seconds. Counts[i] is the
+// number of observations up to Bounds[i]; Count includes those above the last
+// bound.
+type Histogram struct {
+	Bounds []float64
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxDisplayNameLen = 100
+	maxAvatarURLLen   = 2048
+	maxTimezoneLen    = 64
+)
+
+// localePattern accepts BCP 47 style tags such as "en", "pt-BR" or
+// "zh-Hant-TW".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8}){0,3}$`)
+
+// Profile holds the self-service attributes a user can edit. Empty fields are
+// unset.
+type Profile struct {
+	DisplayName string
+	Locale      string
+	Timezone    string
+	AvatarURL   string
+}
+
+// ProfileUpdate is a partial change to a Profile. Nil fields are left as they
+// are; an empty string clears the field.
+type ProfileUpdate struct {
+	DisplayName *string
+	Locale      *string
+	Timezone    *string
+	AvatarURL   *string
+}
+
+// Apply returns p with the fields set in u replaced.
+func (u ProfileUpdate) Apply(p Profile) Profile {
+	if u.DisplayName != nil {
+		p.DisplayName = *u.DisplayName
+	}
+	if u.Locale != nil {
+		p.Locale = *u.Locale
+	}
+	if u.Timezone != nil {
+		p.Timezone = *u.Timezone
+	}
+	if u.AvatarURL != nil {
+		p.AvatarURL = *u.AvatarURL
+	}
+	return p
+}
+
+// Validate checks p the same way wherever a profile is written: self-service,
+// SCIM and import. Callers trim the fields first.
+func (p Profile) Validate() error {
+	switch {
+	case utf8.RuneCountInString(p.DisplayName) > MaxDisplayNameLen:
+		return fmt.Errorf("display_name: must be at most %d characters", MaxDisplayNameLen)
+	case strings.ContainsFunc(p.DisplayName, unicode.IsControl):
+		return errors.New("display_name: must not contain control characters")
+	case p.Locale != "" && !localePattern.MatchString(p.Locale):
+		return errors.New("locale: invalid format")
+	}
+	if p.Timezone != "" {
+		if len(p.Timezone) > maxTimezoneLen || p.Timezone == "Local" {
+			return errors.New("timezone: unknown time zone")
+		}
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return errors.New("timezone: unknown time zone")
+		}
+	}
+	if p.AvatarURL != "" {
+		if len(p.AvatarURL) > maxAvatarURLLen {
+			return fmt.Errorf("avatar_url: must be at most %d characters", maxAvatarURLLen)
+		}
+		u, err := url.Parse(p.AvatarURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("avatar_url: must be an absolute https URL")
+		}
+	}
+	return nil
+}
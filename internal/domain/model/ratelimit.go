@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type RateLimit struct {
+	MaxAttempts int
+	Window      time.Duration
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
@@ -12,6 +12,13 @@ type RefreshToken struct {
 	Revoked   bool
 	ExpiresAt time.Time
 	CreatedAt time.Time
+	// RotatedAt is when the token was exchanged for its successor, nil if it
+	// never was.
+	RotatedAt *time.Time
+	// SealedSuccessor is the raw successor token sealed with this token's raw
+	// value, for a client that presents this token again shortly after
+	// rotation.
+	SealedSuccessor []byte
 }
 
 type TokenPair struct {
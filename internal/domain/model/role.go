@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// RoleAdmin grants access to the admin API.
+const RoleAdmin = "admin"
+
+// Scopes that add authorization data to access tokens.
+const (
+	ScopeRoles       = "roles"
+	ScopePermissions = "permissions"
+)
+
+// Role is a named set of permissions. A role with a ClientID only applies to
+// tokens issued for that OAuth client; an empty ClientID makes it global.
+type Role struct {
+	ID          string
+	Name        string
+	Description string
+	ClientID    string
+	Permissions []string
+	CreatedAt   time.Time
+}
+
+type Permission struct {
+	ID          string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+// Authorization is what a user is allowed to do in the context of one client.
+// Tenant is the slug of the user's tenant, whose issuer and keys sign the
+// token.
+type Authorization struct {
+	Tenant        string
+	Roles         []string
+	Permissions   []string
+	Groups        []string
+	GroupsOverage bool
+}
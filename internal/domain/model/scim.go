@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// SCIMToken is a bearer token a SCIM provisioning client of a tenant uses.
+// Only the hash of the token is stored.
+type SCIMToken struct {
+	ID         string
+	TenantID   string
+	Name       string
+	TokenHash  string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
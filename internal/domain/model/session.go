@@ -0,0 +1,42 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// Session is a login session: a refresh token family and the device it was
+// issued to. Its ID is the family ID of its refresh tokens.
+type Session struct {
+	ID         string
+	UserID     string
+	ClientID   string
+	ClientName string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	// LastRefreshedAt is when the session last rotated its refresh token, or
+	// CreatedAt if it never did.
+	LastRefreshedAt time.Time
+}
+
+// ClientInfo describes the device a request comes from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type clientInfoCtxKey struct{}
+
+// WithClientInfo returns a copy of ctx carrying info, which sessions started
+// within ctx record.
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoCtxKey{}, info)
+}
+
+// ClientInfoFromContext returns the client info set by WithClientInfo, or the
+// zero value.
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoCtxKey{}).(ClientInfo)
+	return info
+}
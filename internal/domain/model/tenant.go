@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// DefaultTenantSlug is the tenant that existing accounts belong to and that
+// requests resolve to when neither the path nor the host names a tenant. Its
+// administrators operate the deployment.
+const DefaultTenantSlug = "default"
+
+// Tenant is an organization with its own users, clients, groups, directories
+// and signing keys. Slug names it in tenant paths (/t/{slug}/...) and issuer
+// URLs; Host optionally maps a dedicated hostname to it.
+type Tenant struct {
+	ID        string
+	Slug      string
+	Name      string
+	Host      string
+	Branding  Branding
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsDefault reports whether t is the default tenant.
+func (t *Tenant) IsDefault() bool {
+	return t.Slug == DefaultTenantSlug
+}
+
+// Branding is what login pages and emails show for a tenant. Empty fields fall
+// back to the deployment's defaults.
+type Branding struct {
+	DisplayName  string
+	LogoURL      string
+	PrimaryColor string
+}
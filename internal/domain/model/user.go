@@ -11,19 +11,31 @@ const (
 )
 
 type User struct {
-	ID            string
-	Email         string
+	ID       string
+	TenantID string
+	Email    string
+	// ExternalID is the identifier a SCIM provisioning client assigned to
+	// the user, if any.
+	ExternalID    string
 	PasswordHash  string
 	EmailVerified bool
 	MFAEnabled    bool
 	MFASecretEnc  []byte
 	Status        UserStatus
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	Profile       Profile
+	// PasswordResetRequired blocks password logins until the password is
+	// reset, e.g. because it was found in a breach corpus.
+	PasswordResetRequired bool
+	// DeletedAt is when the owner asked for the account to be deleted. Set
+	// only while Status is UserStatusDeleted.
+	DeletedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
-func NewUser(email, hash string) *User {
+func NewUser(tenantID, email, hash string) *User {
 	return &User{
+		TenantID:      tenantID,
 		Email:         email,
 		PasswordHash:  hash,
 		EmailVerified: false,
@@ -31,3 +43,23 @@ func NewUser(email, hash string) *User {
 		Status:        UserStatusActive,
 	}
 }
+
+// UserFilter selects users for the admin search. Zero fields do not filter,
+// except TenantID, which is always set.
+type UserFilter struct {
+	TenantID      string
+	Email         string
+	EmailPrefix   string
+	ExternalID    string
+	Status        UserStatus
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	MFAEnabled    *bool
+}
+
+// UserCursor is the position after which a users page starts. Pages are
+// ordered by creation time, newest first.
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -12,61 +14,396 @@ import (
 	"github.com/sanchey92/sso/internal/domain/model"
 )
 
+const dummyPassword = "dummy-password-for-timing-equalization"
+
 type UserGetter interface {
-	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByEmail(ctx context.Context, tenantID, email string) (*model.User, error)
 }
 
+// PasswordVerifier checks passwords against stored hashes. Hash derives the
+// dummy hash that unknown emails are verified against, and the replacement for
+// stored hashes NeedsRehash reports as outdated, such as imported ones.
 type PasswordVerifier interface {
-	Verify(password, encodedHash string) (bool, error)
+	Hash(ctx context.Context, password string) (string, error)
+	Verify(ctx context.Context, password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
+
+// PasswordNormalizer returns the form passwords are hashed in.
+type PasswordNormalizer interface {
+	Normalize(password string) string
+}
+
+// PasswordUpdater stores the new hash of a rehashed password and flags
+// passwords that have to be reset.
+type PasswordUpdater interface {
+	ReplacePasswordHash(ctx context.Context, userID, oldHash, newHash string) error
+	RequirePasswordReset(ctx context.Context, userID string) error
+}
+
+// BreachChecker reports whether a password appears in known data breaches.
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
 }
 
 type TokenIssuer interface {
 	IssueTokenPair(ctx context.Context, userID, clientID string, scopes []string) (*model.TokenPair, error)
 }
 
+// Backend authenticates credentials against an external directory and returns
+// the identity it holds for the user. Rejected credentials are reported as
+// domainerrors.ErrInvalidCredentials.
+type Backend interface {
+	Authenticate(ctx context.Context, email, password string) (*model.UserIdentity, error)
+}
+
+// UserProvisioner creates local users for directory accounts on first login
+// and keeps their synced identity up to date.
+type UserProvisioner interface {
+	Create(ctx context.Context, user *model.User) error
+	UpsertIdentity(ctx context.Context, identity *model.UserIdentity) error
+}
+
+// GroupSyncer mirrors the groups a directory reports for a user.
+type GroupSyncer interface {
+	SyncExternalGroups(ctx context.Context, tenantID, userID, source string, groups []string) error
+}
+
+// LoginGuard throttles password guessing against local accounts.
+type LoginGuard interface {
+	IsLocked(ctx context.Context, userID string) (bool, error)
+	RegisterFailure(ctx context.Context, user *model.User) error
+	Reset(ctx context.Context, userID string) error
+}
+
+// AccountRestorer brings back accounts whose deletion is still in its grace
+// period.
+type AccountRestorer interface {
+	RestoreDeleted(ctx context.Context, userID string, deletedAfter time.Time) (bool, error)
+}
+
+type Config struct {
+	// DeletionGracePeriod is how long after a deletion request logging in
+	// restores the account.
+	DeletionGracePeriod time.Duration
+}
+
+// Backends routes logins to an external Backend by tenant ID and email
+// domain. Emails whose domain has no backend in the tenant are checked against
+// the local password hash.
+type Backends map[string]map[string]Backend
+
+// Add registers backend for logins to the tenant with emails in domain.
+func (b Backends) Add(tenantID, domain string, backend Backend) {
+	if b[tenantID] == nil {
+		b[tenantID] = make(map[string]Backend)
+	}
+	b[tenantID][domain] = backend
+}
+
+func (b Backends) Route(tenantID, email string) (Backend, bool) {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return nil, false
+	}
+	backend, ok := b[tenantID][email[at+1:]]
+	return backend, ok
+}
+
+// Handles reports whether logins for email in the tenant go to a backend.
+func (b Backends) Handles(tenantID, email string) bool {
+	_, ok := b.Route(tenantID, email)
+	return ok
+}
+
 type Service struct {
-	userRepo UserGetter
-	hasher   PasswordVerifier
-	tokenSvc TokenIssuer
-	log      *zap.Logger
+	userRepo    UserGetter
+	hasher      PasswordVerifier
+	tokenSvc    TokenIssuer
+	provisioner UserProvisioner
+	backends    Backends
+	groups      GroupSyncer
+	guard       LoginGuard
+	restorer    AccountRestorer
+	passwords   PasswordUpdater
+	normalizer  PasswordNormalizer
+	breaches    BreachChecker
+	cfg         *Config
+	log         *zap.Logger
+
+	dummyMu   sync.Mutex
+	dummyHash string
 }
 
-func New(ur UserGetter, h PasswordVerifier, ts TokenIssuer, log *zap.Logger) *Service {
+func New(
+	ur UserGetter,
+	h PasswordVerifier,
+	ts TokenIssuer,
+	up UserProvisioner,
+	backends Backends,
+	gs GroupSyncer,
+	lg LoginGuard,
+	ar AccountRestorer,
+	pu PasswordUpdater,
+	pn PasswordNormalizer,
+	bc BreachChecker,
+	cfg *Config,
+	log *zap.Logger,
+) *Service {
 	return &Service{
-		userRepo: ur,
-		hasher:   h,
-		tokenSvc: ts,
-		log:      log,
+		userRepo:    ur,
+		hasher:      h,
+		tokenSvc:    ts,
+		provisioner: up,
+		backends:    backends,
+		groups:      gs,
+		guard:       lg,
+		restorer:    ar,
+		passwords:   pu,
+		normalizer:  pn,
+		breaches:    bc,
+		cfg:         cfg,
+		log:         log,
 	}
 }
 
-func (s *Service) Login(ctx context.Context, email, password string) (*model.TokenPair, error) {
+// Login authenticates the user with email in the tenant.
+func (s *Service) Login(ctx context.Context, tenantID, email, password string) (*model.TokenPair, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
-	user, err := s.userRepo.GetByEmail(ctx, email)
+	if backend, ok := s.backends.Route(tenantID, email); ok {
+		return s.loginWithBackend(ctx, backend, tenantID, email, password)
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, tenantID, email)
 	if err != nil {
 		if errors.Is(err, domainerrors.ErrUserNotFound) {
+			if err = s.verifyDummy(ctx, password); err != nil {
+				return nil, err
+			}
 			return nil, domainerrors.ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("get user by email: %w", err)
 	}
 
-	match, err := s.hasher.Verify(password, user.PasswordHash)
+	if user.PasswordHash == "" {
+		if err = s.verifyDummy(ctx, password); err != nil {
+			return nil, err
+		}
+		return nil, domainerrors.ErrInvalidCredentials
+	}
+
+	locked, err := s.guard.IsLocked(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("check lockout: %w", err)
+	}
+
+	// The password is verified even for locked accounts so that a lockout
+	// cannot be told apart from a wrong password by response time. A match
+	// on the password as typed is rehashed below.
+	normalized := s.normalizer.Normalize(password)
+	match, unnormalized, err := s.verify(ctx, password, normalized, user.PasswordHash)
 	if err != nil {
 		return nil, fmt.Errorf("verify password: %w", err)
 	}
+	if locked {
+		return nil, domainerrors.ErrInvalidCredentials
+	}
 	if !match {
+		if err = s.guard.RegisterFailure(ctx, user); err != nil {
+			s.log.Error("failed to register login failure",
+				zap.Error(err),
+				zap.String("user_id", user.ID),
+			)
+		}
 		return nil, domainerrors.ErrInvalidCredentials
 	}
 
+	if err = s.guard.Reset(ctx, user.ID); err != nil {
+		s.log.Error("failed to reset login failures",
+			zap.Error(err),
+			zap.String("user_id", user.ID),
+		)
+	}
+	// Blocked and deleted accounts are refused before anything that would
+	// tell a caller the password was right.
+	if err = s.activate(ctx, user); err != nil {
+		return nil, err
+	}
 	if !user.EmailVerified {
 		return nil, domainerrors.ErrEmailNotVerified
 	}
+	if user.PasswordResetRequired || s.breached(ctx, user.ID, normalized) {
+		return nil, domainerrors.ErrPasswordResetRequired
+	}
+	if unnormalized || s.hasher.NeedsRehash(user.PasswordHash) {
+		s.rehash(ctx, user, normalized)
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// verify checks password against hash in its normalized form and, if that
+// differs and does not match, as typed: hashes stored before passwords were
+// normalized may hold the typed form. unnormalized reports a match on the
+// typed form only. verifyDummy goes through here too, so a wrong password
+// costs the same number of hashes whether or not the account exists.
+func (s *Service) verify(ctx context.Context, password, normalized, hash string) (match, unnormalized bool, err error) {
+	match, err = s.hasher.Verify(ctx, normalized, hash)
+	if err != nil || match || normalized == password {
+		return match, false, err //nolint:wrapcheck // callers wrap with context
+	}
+	match, err = s.hasher.Verify(ctx, password, hash)
+	return match, match, err //nolint:wrapcheck // callers wrap with context
+}
+
+// breached reports whether the password the user just logged in with appears
+// in the breach corpus and, if so, requires a reset before the next password
+// login. It takes the normalized password, the form the policy checks on
+// registration and reset. When the corpus cannot be read the login goes ahead.
+func (s *Service) breached(ctx context.Context, userID, password string) bool {
+	breached, err := s.breaches.Breached(ctx, password)
+	if err != nil {
+		s.log.Error("failed to check password against breach corpus",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+		return false
+	}
+	if !breached {
+		return false
+	}
+
+	s.log.Warn("breached password used to log in, password reset required", zap.String("user_id", userID))
+	if err = s.passwords.RequirePasswordReset(ctx, userID); err != nil {
+		s.log.Error("failed to require password reset",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+	return true
+}
+
+// rehash replaces an outdated password hash while the password is at hand. A
+// failure only costs another attempt on the next login, so it is logged. The
+// password itself is unchanged, so the password history is left alone.
+func (s *Service) rehash(ctx context.Context, user *model.User, password string) {
+	hash, err := s.hasher.Hash(ctx, password)
+	if err == nil {
+		err = s.passwords.ReplacePasswordHash(ctx, user.ID, user.PasswordHash, hash)
+	}
+	if err != nil {
+		s.log.Error("failed to rehash password",
+			zap.Error(err),
+			zap.String("user_id", user.ID),
+		)
+	}
+}
+
+// verifyDummy spends the same time as a real password check, so a login for
+// an email without a local password cannot be told apart by response time.
+// The dummy hash is derived on first use, which keeps it in step with the
+// hasher's current cost parameters. Only an overloaded hasher or the end of
+// ctx is reported, as a real check would report them too.
+func (s *Service) verifyDummy(ctx context.Context, password string) error {
+	hash, err := s.dummy(ctx)
+	if err == nil {
+		_, _, err = s.verify(ctx, password, s.normalizer.Normalize(password), hash)
+	}
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, domainerrors.ErrOverloaded) || ctx.Err() != nil {
+		return fmt.Errorf("verify password: %w", err)
+	}
+	s.log.Error("failed to verify dummy password hash", zap.Error(err))
+	return nil
+}
+
+func (s *Service) dummy(ctx context.Context) (string, error) {
+	s.dummyMu.Lock()
+	defer s.dummyMu.Unlock()
+
+	if s.dummyHash == "" {
+		hash, err := s.hasher.Hash(ctx, dummyPassword)
+		if err != nil {
+			return "", fmt.Errorf("derive dummy password hash: %w", err)
+		}
+		s.dummyHash = hash
+	}
+	return s.dummyHash, nil
+}
+
+func (s *Service) loginWithBackend(
+	ctx context.Context,
+	backend Backend,
+	tenantID, email, password string,
+) (*model.TokenPair, error) {
+	identity, err := backend.Authenticate(ctx, email, password)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidCredentials) {
+			return nil, domainerrors.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("authenticate with backend: %w", err)
+	}
 
+	user, err := s.userRepo.GetByEmail(ctx, tenantID, email)
+	if err != nil {
+		if !errors.Is(err, domainerrors.ErrUserNotFound) {
+			return nil, fmt.Errorf("get user by email: %w", err)
+		}
+		if user, err = s.provisionUser(ctx, tenantID, email); err != nil {
+			return nil, err
+		}
+	}
+
+	identity.UserID = user.ID
+	if err = s.provisioner.UpsertIdentity(ctx, identity); err != nil {
+		return nil, fmt.Errorf("sync identity: %w", err)
+	}
+	// Fail the login rather than issue a token with groups the directory
+	// may have taken away.
+	if err = s.groups.SyncExternalGroups(ctx, tenantID, user.ID, identity.Provider, identity.Groups); err != nil {
+		return nil, fmt.Errorf("sync groups: %w", err)
+	}
+
+	if err = s.activate(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// provisionUser creates the local account for a directory user on first
+// login. The directory vouches for the address, so it starts out verified and
+// has no local password.
+func (s *Service) provisionUser(ctx context.Context, tenantID, email string) (*model.User, error) {
+	user := model.NewUser(tenantID, email, "")
+	user.EmailVerified = true
+
+	if err := s.provisioner.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("provision user: %w", err)
+	}
+
+	s.log.Info("user provisioned from directory", zap.String("user_id", user.ID))
+	return user, nil
+}
+
+// activate lets only active accounts log in, restoring a deleted one first
+// when it is still in its grace period. Other accounts get the same error as
+// a wrong password.
+func (s *Service) activate(ctx context.Context, user *model.User) error {
+	if user.Status == model.UserStatusDeleted {
+		if err := s.restore(ctx, user); err != nil {
+			return err
+		}
+	}
 	if user.Status != model.UserStatusActive {
-		return nil, domainerrors.ErrInvalidCredentials
+		return domainerrors.ErrInvalidCredentials
 	}
+	return nil
+}
 
+func (s *Service) issueTokens(ctx context.Context, user *model.User) (*model.TokenPair, error) {
 	pair, err := s.tokenSvc.IssueTokenPair(ctx, user.ID, "", nil)
 	if err != nil {
 		return nil, fmt.Errorf("issue token pair: %w", err)
@@ -76,3 +413,18 @@ func (s *Service) Login(ctx context.Context, email, password string) (*model.Tok
 
 	return pair, nil
 }
+
+// restore reactivates a deleted account if its grace period has not ended.
+// Past the grace period the account only awaits purging and stays deleted.
+func (s *Service) restore(ctx context.Context, user *model.User) error {
+	restored, err := s.restorer.RestoreDeleted(ctx, user.ID, time.Now().Add(-s.cfg.DeletionGracePeriod))
+	if err != nil {
+		return fmt.Errorf("restore account: %w", err)
+	}
+	if restored {
+		user.Status = model.UserStatusActive
+		user.DeletedAt = nil
+		s.log.Info("deleted account restored by login", zap.String("user_id", user.ID))
+	}
+	return nil
+}
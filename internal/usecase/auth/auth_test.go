@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -39,9 +41,9 @@ func TestService_Login(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, ti *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(validUser, nil)
-				pv.EXPECT().Verify("securepassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").
 					Return(true, nil)
 				ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).
 					Return(&model.TokenPair{
@@ -60,9 +62,11 @@ func TestService_Login(t *testing.T) {
 			name:     "user not found returns invalid credentials",
 			email:    "nobody@example.com",
 			password: "securepassword",
-			setupMock: func(ug *mocks.UserGetter, _ *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "nobody@example.com").
+			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "nobody@example.com").
 					Return(nil, domainerrors.ErrUserNotFound)
+				pv.EXPECT().Hash(mock.Anything, dummyPassword).Return("dummy-hash", nil)
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "dummy-hash").Return(false, nil)
 			},
 			wantErr: domainerrors.ErrInvalidCredentials.Error(),
 		},
@@ -71,9 +75,9 @@ func TestService_Login(t *testing.T) {
 			email:    "user@example.com",
 			password: "wrongpassword",
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(validUser, nil)
-				pv.EXPECT().Verify("wrongpassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "wrongpassword", "argon2id-hash").
 					Return(false, nil)
 			},
 			wantErr: domainerrors.ErrInvalidCredentials.Error(),
@@ -85,9 +89,9 @@ func TestService_Login(t *testing.T) {
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
 				unverified := *validUser
 				unverified.EmailVerified = false
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(&unverified, nil)
-				pv.EXPECT().Verify("securepassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").
 					Return(true, nil)
 			},
 			wantErr: domainerrors.ErrEmailNotVerified.Error(),
@@ -99,19 +103,33 @@ func TestService_Login(t *testing.T) {
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
 				blocked := *validUser
 				blocked.Status = model.UserStatusBlocked
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(&blocked, nil)
-				pv.EXPECT().Verify("securepassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").
 					Return(true, nil)
 			},
 			wantErr: domainerrors.ErrInvalidCredentials.Error(),
 		},
+		{
+			name:     "directory-only user cannot log in with local password",
+			email:    "user@example.com",
+			password: "securepassword",
+			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
+				provisioned := *validUser
+				provisioned.PasswordHash = ""
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
+					Return(&provisioned, nil)
+				pv.EXPECT().Hash(mock.Anything, dummyPassword).Return("dummy-hash", nil)
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "dummy-hash").Return(false, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials.Error(),
+		},
 		{
 			name:     "repository unexpected error",
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(ug *mocks.UserGetter, _ *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(nil, errors.New("db connection lost"))
 			},
 			wantErr: "get user by email: db connection lost",
@@ -121,9 +139,9 @@ func TestService_Login(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(validUser, nil)
-				pv.EXPECT().Verify("securepassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").
 					Return(false, errors.New("decode failed"))
 			},
 			wantErr: "verify password: decode failed",
@@ -133,9 +151,9 @@ func TestService_Login(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, ti *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(validUser, nil)
-				pv.EXPECT().Verify("securepassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").
 					Return(true, nil)
 				ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).
 					Return(nil, fmt.Errorf("generate access token: signing failed"))
@@ -147,9 +165,9 @@ func TestService_Login(t *testing.T) {
 			email:    "  User@Example.COM  ",
 			password: "securepassword",
 			setupMock: func(ug *mocks.UserGetter, pv *mocks.PasswordVerifier, ti *mocks.TokenIssuer) {
-				ug.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(validUser, nil)
-				pv.EXPECT().Verify("securepassword", "argon2id-hash").
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").
 					Return(true, nil)
 				ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).
 					Return(&model.TokenPair{
@@ -170,10 +188,17 @@ func TestService_Login(t *testing.T) {
 			passVerifier := mocks.NewPasswordVerifier(t)
 			tokenIssuer := mocks.NewTokenIssuer(t)
 			tt.setupMock(userGetter, passVerifier, tokenIssuer)
+			passVerifier.EXPECT().NeedsRehash(mock.Anything).Return(false).Maybe()
+
+			guard := mocks.NewLoginGuard(t)
+			guard.EXPECT().IsLocked(mock.Anything, mock.Anything).Return(false, nil).Maybe()
+			guard.EXPECT().RegisterFailure(mock.Anything, mock.Anything).Return(nil).Maybe()
+			guard.EXPECT().Reset(mock.Anything, mock.Anything).Return(nil).Maybe()
 
-			svc := New(userGetter, passVerifier, tokenIssuer, zap.NewNop())
+			svc := New(userGetter, passVerifier, tokenIssuer, mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+				mocks.NewAccountRestorer(t), mocks.NewPasswordUpdater(t), identityNormalizer(t), noBreaches(t), &Config{}, zap.NewNop())
 
-			pair, err := svc.Login(ctx, tt.email, tt.password)
+			pair, err := svc.Login(ctx, "tenant-1", tt.email, tt.password)
 
 			if tt.wantErr != "" {
 				require.Error(t, err)
@@ -191,3 +216,591 @@ func TestService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestService_Login_UnknownEmailVerifiesDummyHash(t *testing.T) {
+	ctx := t.Context()
+
+	userGetter := mocks.NewUserGetter(t)
+	userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", mock.Anything).Return(nil, domainerrors.ErrUserNotFound)
+	passVerifier := mocks.NewPasswordVerifier(t)
+	passVerifier.EXPECT().Hash(mock.Anything, dummyPassword).Return("dummy-hash", nil).Once()
+	passVerifier.EXPECT().Verify(mock.Anything, mock.Anything, "dummy-hash").Return(false, nil).Times(2)
+
+	svc := New(userGetter, passVerifier, mocks.NewTokenIssuer(t), mocks.NewUserProvisioner(t), nil,
+		mocks.NewGroupSyncer(t), mocks.NewLoginGuard(t), mocks.NewAccountRestorer(t), mocks.NewPasswordUpdater(t), identityNormalizer(t), noBreaches(t), &Config{}, zap.NewNop())
+
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		_, err := svc.Login(ctx, "tenant-1", email, "securepassword")
+		require.ErrorIs(t, err, domainerrors.ErrInvalidCredentials)
+	}
+}
+
+func TestService_Login_UnknownEmailVerifiesTypedForm(t *testing.T) {
+	userGetter := mocks.NewUserGetter(t)
+	userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "a@example.com").Return(nil, domainerrors.ErrUserNotFound)
+	normalizer := mocks.NewPasswordNormalizer(t)
+	normalizer.EXPECT().Normalize("ｓｅｃｕｒｅ").Return("secure")
+	passVerifier := mocks.NewPasswordVerifier(t)
+	passVerifier.EXPECT().Hash(mock.Anything, dummyPassword).Return("dummy-hash", nil)
+	// Both forms are checked, as for an existing account with a wrong
+	// password, so the two take the same time.
+	passVerifier.EXPECT().Verify(mock.Anything, "secure", "dummy-hash").Return(false, nil).Once()
+	passVerifier.EXPECT().Verify(mock.Anything, "ｓｅｃｕｒｅ", "dummy-hash").Return(false, nil).Once()
+
+	svc := New(userGetter, passVerifier, mocks.NewTokenIssuer(t), mocks.NewUserProvisioner(t), nil,
+		mocks.NewGroupSyncer(t), mocks.NewLoginGuard(t), mocks.NewAccountRestorer(t), mocks.NewPasswordUpdater(t), normalizer, noBreaches(t), &Config{}, zap.NewNop())
+
+	_, err := svc.Login(t.Context(), "tenant-1", "a@example.com", "ｓｅｃｕｒｅ")
+
+	require.ErrorIs(t, err, domainerrors.ErrInvalidCredentials)
+}
+
+func TestService_Login_UnknownEmailOverloaded(t *testing.T) {
+	ctx := t.Context()
+
+	userGetter := mocks.NewUserGetter(t)
+	userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", mock.Anything).Return(nil, domainerrors.ErrUserNotFound)
+	passVerifier := mocks.NewPasswordVerifier(t)
+	passVerifier.EXPECT().Hash(mock.Anything, dummyPassword).Return("dummy-hash", nil).Once()
+	passVerifier.EXPECT().Verify(mock.Anything, mock.Anything, "dummy-hash").Return(false, domainerrors.ErrOverloaded)
+
+	svc := New(userGetter, passVerifier, mocks.NewTokenIssuer(t), mocks.NewUserProvisioner(t), nil,
+		mocks.NewGroupSyncer(t), mocks.NewLoginGuard(t), mocks.NewAccountRestorer(t), mocks.NewPasswordUpdater(t), identityNormalizer(t), noBreaches(t), &Config{}, zap.NewNop())
+
+	_, err := svc.Login(ctx, "tenant-1", "a@example.com", "securepassword")
+
+	require.ErrorIs(t, err, domainerrors.ErrOverloaded)
+}
+
+func TestService_Login_Lockout(t *testing.T) {
+	ctx := t.Context()
+
+	user := &model.User{
+		ID:            "user-uuid",
+		Email:         "user@example.com",
+		PasswordHash:  "argon2id-hash",
+		EmailVerified: true,
+		Status:        model.UserStatusActive,
+	}
+
+	tests := []struct {
+		name      string
+		password  string
+		setupMock func(lg *mocks.LoginGuard, pv *mocks.PasswordVerifier, ti *mocks.TokenIssuer)
+		wantErr   string
+	}{
+		{
+			name:     "locked account rejects correct password",
+			password: "securepassword",
+			setupMock: func(lg *mocks.LoginGuard, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
+				lg.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(true, nil)
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").Return(true, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials.Error(),
+		},
+		{
+			name:     "wrong password registers failure",
+			password: "wrongpassword",
+			setupMock: func(lg *mocks.LoginGuard, pv *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
+				lg.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+				pv.EXPECT().Verify(mock.Anything, "wrongpassword", "argon2id-hash").Return(false, nil)
+				lg.EXPECT().RegisterFailure(mock.Anything, user).Return(nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials.Error(),
+		},
+		{
+			name:     "successful login resets failures",
+			password: "securepassword",
+			setupMock: func(lg *mocks.LoginGuard, pv *mocks.PasswordVerifier, ti *mocks.TokenIssuer) {
+				lg.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+				pv.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").Return(true, nil)
+				lg.EXPECT().Reset(mock.Anything, "user-uuid").Return(errors.New("redis down"))
+				ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).
+					Return(&model.TokenPair{AccessToken: "access-jwt-token"}, nil)
+			},
+		},
+		{
+			name:     "lockout store error",
+			password: "securepassword",
+			setupMock: func(lg *mocks.LoginGuard, _ *mocks.PasswordVerifier, _ *mocks.TokenIssuer) {
+				lg.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, errors.New("redis down"))
+			},
+			wantErr: "check lockout: redis down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userGetter := mocks.NewUserGetter(t)
+			userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(user, nil)
+			guard := mocks.NewLoginGuard(t)
+			passVerifier := mocks.NewPasswordVerifier(t)
+			tokenIssuer := mocks.NewTokenIssuer(t)
+			tt.setupMock(guard, passVerifier, tokenIssuer)
+			passVerifier.EXPECT().NeedsRehash(mock.Anything).Return(false).Maybe()
+
+			svc := New(userGetter, passVerifier, tokenIssuer, mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+				mocks.NewAccountRestorer(t), mocks.NewPasswordUpdater(t), identityNormalizer(t), noBreaches(t), &Config{}, zap.NewNop())
+
+			pair, err := svc.Login(ctx, "tenant-1", "user@example.com", tt.password)
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, pair)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access-jwt-token", pair.AccessToken)
+		})
+	}
+}
+
+func TestService_Login_Backend(t *testing.T) {
+	ctx := t.Context()
+
+	pair := &model.TokenPair{AccessToken: "access-jwt-token", RefreshToken: "refresh-token", ExpiresIn: 60}
+
+	newIdentity := func() *model.UserIdentity {
+		return &model.UserIdentity{
+			Provider: "corp-ad",
+			Subject:  "CN=Jane,OU=Staff,DC=corp,DC=example",
+			Email:    "jane@corp.example",
+			Groups:   []string{"CN=Engineering,OU=Groups,DC=corp,DC=example"},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		email     string
+		setupMock func(b *mocks.Backend, ug *mocks.UserGetter, up *mocks.UserProvisioner, ti *mocks.TokenIssuer)
+		syncErr   error
+		wantErr   string
+	}{
+		{
+			name:  "existing user syncs identity",
+			email: "Jane@Corp.Example",
+			setupMock: func(b *mocks.Backend, ug *mocks.UserGetter, up *mocks.UserProvisioner, ti *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").Return(newIdentity(), nil)
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "jane@corp.example").
+					Return(&model.User{ID: "user-uuid", Email: "jane@corp.example", Status: model.UserStatusActive}, nil)
+				up.EXPECT().UpsertIdentity(mock.Anything, mock.MatchedBy(func(i *model.UserIdentity) bool {
+					return i.UserID == "user-uuid" && i.Provider == "corp-ad" && len(i.Groups) == 1
+				})).Return(nil)
+				ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).Return(pair, nil)
+			},
+		},
+		{
+			name:  "first login provisions verified user without password",
+			email: "jane@corp.example",
+			setupMock: func(b *mocks.Backend, ug *mocks.UserGetter, up *mocks.UserProvisioner, ti *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").Return(newIdentity(), nil)
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "jane@corp.example").Return(nil, domainerrors.ErrUserNotFound)
+				up.EXPECT().Create(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.TenantID == "tenant-1" && u.Email == "jane@corp.example" && u.PasswordHash == "" && u.EmailVerified
+				})).Run(func(_ context.Context, u *model.User) {
+					u.ID = "new-uuid"
+				}).Return(nil)
+				up.EXPECT().UpsertIdentity(mock.Anything, mock.MatchedBy(func(i *model.UserIdentity) bool {
+					return i.UserID == "new-uuid"
+				})).Return(nil)
+				ti.EXPECT().IssueTokenPair(mock.Anything, "new-uuid", "", mock.Anything).Return(pair, nil)
+			},
+		},
+		{
+			name:  "rejected by directory",
+			email: "jane@corp.example",
+			setupMock: func(b *mocks.Backend, _ *mocks.UserGetter, _ *mocks.UserProvisioner, _ *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").
+					Return(nil, domainerrors.ErrInvalidCredentials)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials.Error(),
+		},
+		{
+			name:  "directory unavailable",
+			email: "jane@corp.example",
+			setupMock: func(b *mocks.Backend, _ *mocks.UserGetter, _ *mocks.UserProvisioner, _ *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").
+					Return(nil, errors.New("connection refused"))
+			},
+			wantErr: "authenticate with backend: connection refused",
+		},
+		{
+			name:  "blocked local user",
+			email: "jane@corp.example",
+			setupMock: func(b *mocks.Backend, ug *mocks.UserGetter, up *mocks.UserProvisioner, _ *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").Return(newIdentity(), nil)
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "jane@corp.example").
+					Return(&model.User{ID: "user-uuid", Status: model.UserStatusBlocked}, nil)
+				up.EXPECT().UpsertIdentity(mock.Anything, mock.Anything).Return(nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials.Error(),
+		},
+		{
+			name:  "provisioning fails",
+			email: "jane@corp.example",
+			setupMock: func(b *mocks.Backend, ug *mocks.UserGetter, up *mocks.UserProvisioner, _ *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").Return(newIdentity(), nil)
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "jane@corp.example").Return(nil, domainerrors.ErrUserNotFound)
+				up.EXPECT().Create(mock.Anything, mock.Anything).Return(errors.New("insert failed"))
+			},
+			wantErr: "provision user: insert failed",
+		},
+		{
+			name:  "group sync fails",
+			email: "jane@corp.example",
+			setupMock: func(b *mocks.Backend, ug *mocks.UserGetter, up *mocks.UserProvisioner, _ *mocks.TokenIssuer) {
+				b.EXPECT().Authenticate(mock.Anything, "jane@corp.example", "secret").Return(newIdentity(), nil)
+				ug.EXPECT().GetByEmail(mock.Anything, "tenant-1", "jane@corp.example").
+					Return(&model.User{ID: "user-uuid", Status: model.UserStatusActive}, nil)
+				up.EXPECT().UpsertIdentity(mock.Anything, mock.Anything).Return(nil)
+			},
+			syncErr: errors.New("db down"),
+			wantErr: "sync groups: db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := mocks.NewBackend(t)
+			userGetter := mocks.NewUserGetter(t)
+			provisioner := mocks.NewUserProvisioner(t)
+			tokenIssuer := mocks.NewTokenIssuer(t)
+			tt.setupMock(backend, userGetter, provisioner, tokenIssuer)
+			groups := mocks.NewGroupSyncer(t)
+			groups.EXPECT().SyncExternalGroups(mock.Anything, "tenant-1", mock.Anything, "corp-ad",
+				[]string{"CN=Engineering,OU=Groups,DC=corp,DC=example"}).Return(tt.syncErr).Maybe()
+
+			svc := New(userGetter, mocks.NewPasswordVerifier(t), tokenIssuer, provisioner,
+				Backends{"tenant-1": {"corp.example": backend}}, groups, mocks.NewLoginGuard(t), mocks.NewAccountRestorer(t),
+				mocks.NewPasswordUpdater(t), identityNormalizer(t), noBreaches(t), &Config{}, zap.NewNop())
+
+			got, err := svc.Login(ctx, "tenant-1", tt.email, "secret")
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, pair, got)
+		})
+	}
+}
+
+func TestBackends_Route(t *testing.T) {
+	backend := mocks.NewBackend(t)
+	backends := make(Backends)
+	backends.Add("tenant-1", "corp.example", backend)
+
+	got, ok := backends.Route("tenant-1", "jane@corp.example")
+	assert.True(t, ok)
+	assert.Same(t, backend, got)
+
+	_, ok = backends.Route("tenant-2", "jane@corp.example")
+	assert.False(t, ok)
+
+	_, ok = backends.Route("tenant-1", "jane@other.example")
+	assert.False(t, ok)
+
+	_, ok = backends.Route("tenant-1", "not-an-email")
+	assert.False(t, ok)
+}
+
+func TestService_Login_DeletedAccount(t *testing.T) {
+	ctx := t.Context()
+	deletedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		restored bool
+		wantErr  error
+	}{
+		{name: "restored within grace period", restored: true},
+		{name: "rejected after grace period", restored: false, wantErr: domainerrors.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userGetter := mocks.NewUserGetter(t)
+			userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(&model.User{
+				ID:            "user-uuid",
+				Email:         "user@example.com",
+				PasswordHash:  "argon2id-hash",
+				EmailVerified: true,
+				Status:        model.UserStatusDeleted,
+				DeletedAt:     &deletedAt,
+			}, nil)
+			passVerifier := mocks.NewPasswordVerifier(t)
+			passVerifier.EXPECT().Verify(mock.Anything, "securepassword", "argon2id-hash").Return(true, nil)
+			passVerifier.EXPECT().NeedsRehash("argon2id-hash").Return(false).Maybe()
+			guard := mocks.NewLoginGuard(t)
+			guard.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+			guard.EXPECT().Reset(mock.Anything, "user-uuid").Return(nil)
+			restorer := mocks.NewAccountRestorer(t)
+			restorer.EXPECT().RestoreDeleted(mock.Anything, "user-uuid", mock.MatchedBy(func(after time.Time) bool {
+				return time.Until(after) > -31*24*time.Hour && time.Until(after) < -29*24*time.Hour
+			})).Return(tt.restored, nil)
+			tokenIssuer := mocks.NewTokenIssuer(t)
+			if tt.restored {
+				tokenIssuer.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", []string(nil)).
+					Return(&model.TokenPair{AccessToken: "access"}, nil)
+			}
+
+			svc := New(userGetter, passVerifier, tokenIssuer, mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+				restorer, mocks.NewPasswordUpdater(t), identityNormalizer(t), noBreaches(t), &Config{DeletionGracePeriod: 30 * 24 * time.Hour}, zap.NewNop())
+
+			pair, err := svc.Login(ctx, "tenant-1", "user@example.com", "securepassword")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access", pair.AccessToken)
+		})
+	}
+}
+
+func TestService_Login_Rehash(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		name      string
+		updateErr error
+	}{
+		{name: "imported hash replaced"},
+		{name: "update failure does not fail login", updateErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userGetter := mocks.NewUserGetter(t)
+			userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(&model.User{
+				ID:            "user-uuid",
+				Email:         "user@example.com",
+				PasswordHash:  "$2a$10$bcrypt-hash",
+				EmailVerified: true,
+				Status:        model.UserStatusActive,
+			}, nil)
+			passVerifier := mocks.NewPasswordVerifier(t)
+			passVerifier.EXPECT().Verify(mock.Anything, "securepassword", "$2a$10$bcrypt-hash").Return(true, nil)
+			passVerifier.EXPECT().NeedsRehash("$2a$10$bcrypt-hash").Return(true)
+			passVerifier.EXPECT().Hash(mock.Anything, "securepassword").Return("argon2id-hash", nil)
+			guard := mocks.NewLoginGuard(t)
+			guard.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+			guard.EXPECT().Reset(mock.Anything, "user-uuid").Return(nil)
+			passwords := mocks.NewPasswordUpdater(t)
+			passwords.EXPECT().ReplacePasswordHash(mock.Anything, "user-uuid", "$2a$10$bcrypt-hash", "argon2id-hash").Return(tt.updateErr)
+			tokenIssuer := mocks.NewTokenIssuer(t)
+			tokenIssuer.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", []string(nil)).
+				Return(&model.TokenPair{AccessToken: "access"}, nil)
+
+			svc := New(userGetter, passVerifier, tokenIssuer, mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+				mocks.NewAccountRestorer(t), passwords, identityNormalizer(t), noBreaches(t), &Config{}, zap.NewNop())
+
+			pair, err := svc.Login(ctx, "tenant-1", "user@example.com", "securepassword")
+
+			require.NoError(t, err)
+			assert.Equal(t, "access", pair.AccessToken)
+		})
+	}
+}
+
+// identityNormalizer leaves passwords as typed.
+func identityNormalizer(t *testing.T) *mocks.PasswordNormalizer {
+	n := mocks.NewPasswordNormalizer(t)
+	n.EXPECT().Normalize(mock.Anything).RunAndReturn(func(password string) string { return password }).Maybe()
+	return n
+}
+
+func TestService_Login_UnnormalizedHash(t *testing.T) {
+	ctx := t.Context()
+
+	userGetter := mocks.NewUserGetter(t)
+	userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(&model.User{
+		ID:            "user-uuid",
+		Email:         "user@example.com",
+		PasswordHash:  "typed-hash",
+		EmailVerified: true,
+		Status:        model.UserStatusActive,
+	}, nil)
+	normalizer := mocks.NewPasswordNormalizer(t)
+	normalizer.EXPECT().Normalize("ｓｅｃｕｒｅ").Return("secure")
+	passVerifier := mocks.NewPasswordVerifier(t)
+	passVerifier.EXPECT().Verify(mock.Anything, "secure", "typed-hash").Return(false, nil)
+	passVerifier.EXPECT().Verify(mock.Anything, "ｓｅｃｕｒｅ", "typed-hash").Return(true, nil)
+	passVerifier.EXPECT().NeedsRehash("typed-hash").Return(false).Maybe()
+	passVerifier.EXPECT().Hash(mock.Anything, "secure").Return("normalized-hash", nil)
+	guard := mocks.NewLoginGuard(t)
+	guard.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+	guard.EXPECT().Reset(mock.Anything, "user-uuid").Return(nil)
+	passwords := mocks.NewPasswordUpdater(t)
+	passwords.EXPECT().ReplacePasswordHash(mock.Anything, "user-uuid", "typed-hash", "normalized-hash").Return(nil)
+	breaches := mocks.NewBreachChecker(t)
+	breaches.EXPECT().Breached(mock.Anything, "secure").Return(false, nil)
+	tokenIssuer := mocks.NewTokenIssuer(t)
+	tokenIssuer.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", []string(nil)).
+		Return(&model.TokenPair{AccessToken: "access"}, nil)
+
+	svc := New(userGetter, passVerifier, tokenIssuer, mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+		mocks.NewAccountRestorer(t), passwords, normalizer, breaches, &Config{}, zap.NewNop())
+
+	pair, err := svc.Login(ctx, "tenant-1", "user@example.com", "ｓｅｃｕｒｅ")
+
+	require.NoError(t, err)
+	assert.Equal(t, "access", pair.AccessToken)
+}
+
+// noBreaches finds no password in the breach corpus.
+func noBreaches(t *testing.T) *mocks.BreachChecker {
+	b := mocks.NewBreachChecker(t)
+	b.EXPECT().Breached(mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	return b
+}
+
+func TestService_Login_BreachedPassword(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		name          string
+		resetRequired bool
+		setupMock     func(b *mocks.BreachChecker, pu *mocks.PasswordUpdater)
+		wantErr       error
+	}{
+		{
+			name:          "already flagged",
+			resetRequired: true,
+			setupMock:     func(_ *mocks.BreachChecker, _ *mocks.PasswordUpdater) {},
+			wantErr:       domainerrors.ErrPasswordResetRequired,
+		},
+		{
+			name: "found in corpus",
+			setupMock: func(b *mocks.BreachChecker, pu *mocks.PasswordUpdater) {
+				b.EXPECT().Breached(mock.Anything, "securepassword").Return(true, nil)
+				pu.EXPECT().RequirePasswordReset(mock.Anything, "user-uuid").Return(nil)
+			},
+			wantErr: domainerrors.ErrPasswordResetRequired,
+		},
+		{
+			name: "flag failure still refuses login",
+			setupMock: func(b *mocks.BreachChecker, pu *mocks.PasswordUpdater) {
+				b.EXPECT().Breached(mock.Anything, "securepassword").Return(true, nil)
+				pu.EXPECT().RequirePasswordReset(mock.Anything, "user-uuid").Return(errors.New("db down"))
+			},
+			wantErr: domainerrors.ErrPasswordResetRequired,
+		},
+		{
+			name: "unreadable corpus lets login through",
+			setupMock: func(b *mocks.BreachChecker, _ *mocks.PasswordUpdater) {
+				b.EXPECT().Breached(mock.Anything, "securepassword").Return(false, errors.New("corpus unmounted"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userGetter := mocks.NewUserGetter(t)
+			userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(&model.User{
+				ID:                    "user-uuid",
+				Email:                 "user@example.com",
+				PasswordHash:          "hash",
+				EmailVerified:         true,
+				Status:                model.UserStatusActive,
+				PasswordResetRequired: tt.resetRequired,
+			}, nil)
+			passVerifier := mocks.NewPasswordVerifier(t)
+			passVerifier.EXPECT().Verify(mock.Anything, "securepassword", "hash").Return(true, nil)
+			passVerifier.EXPECT().NeedsRehash("hash").Return(false).Maybe()
+			guard := mocks.NewLoginGuard(t)
+			guard.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+			guard.EXPECT().Reset(mock.Anything, "user-uuid").Return(nil)
+			breaches := mocks.NewBreachChecker(t)
+			passwords := mocks.NewPasswordUpdater(t)
+			tt.setupMock(breaches, passwords)
+			tokenIssuer := mocks.NewTokenIssuer(t)
+			if tt.wantErr == nil {
+				tokenIssuer.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", []string(nil)).
+					Return(&model.TokenPair{AccessToken: "access"}, nil)
+			}
+
+			svc := New(userGetter, passVerifier, tokenIssuer, mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+				mocks.NewAccountRestorer(t), passwords, identityNormalizer(t), breaches, &Config{}, zap.NewNop())
+
+			pair, err := svc.Login(ctx, "tenant-1", "user@example.com", "securepassword")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, pair)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access", pair.AccessToken)
+		})
+	}
+}
+
+func TestService_Login_BlockedBreachedPasswordLooksWrong(t *testing.T) {
+	login := func(password string) error {
+		userGetter := mocks.NewUserGetter(t)
+		userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(&model.User{
+			ID:            "user-uuid",
+			Email:         "user@example.com",
+			PasswordHash:  "hash",
+			EmailVerified: true,
+			Status:        model.UserStatusBlocked,
+		}, nil)
+		passVerifier := mocks.NewPasswordVerifier(t)
+		passVerifier.EXPECT().Verify(mock.Anything, password, "hash").Return(password == "breached", nil)
+		guard := mocks.NewLoginGuard(t)
+		guard.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+		guard.EXPECT().Reset(mock.Anything, "user-uuid").Return(nil).Maybe()
+		guard.EXPECT().RegisterFailure(mock.Anything, mock.Anything).Return(nil).Maybe()
+		// The corpus is never consulted, so it cannot flag the account.
+		breaches := mocks.NewBreachChecker(t)
+
+		svc := New(userGetter, passVerifier, mocks.NewTokenIssuer(t), mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+			mocks.NewAccountRestorer(t), mocks.NewPasswordUpdater(t), identityNormalizer(t), breaches, &Config{}, zap.NewNop())
+		_, err := svc.Login(t.Context(), "tenant-1", "user@example.com", password)
+		return err
+	}
+
+	right := login("breached")
+	wrong := login("wrong")
+
+	require.ErrorIs(t, right, domainerrors.ErrInvalidCredentials)
+	assert.Equal(t, wrong, right)
+}
+
+func TestService_Login_DeletedAccountWithBreachedPasswordIsRestored(t *testing.T) {
+	deletedAt := time.Now().Add(-time.Hour)
+	userGetter := mocks.NewUserGetter(t)
+	userGetter.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(&model.User{
+		ID:            "user-uuid",
+		Email:         "user@example.com",
+		PasswordHash:  "hash",
+		EmailVerified: true,
+		Status:        model.UserStatusDeleted,
+		DeletedAt:     &deletedAt,
+	}, nil)
+	passVerifier := mocks.NewPasswordVerifier(t)
+	passVerifier.EXPECT().Verify(mock.Anything, "securepassword", "hash").Return(true, nil)
+	guard := mocks.NewLoginGuard(t)
+	guard.EXPECT().IsLocked(mock.Anything, "user-uuid").Return(false, nil)
+	guard.EXPECT().Reset(mock.Anything, "user-uuid").Return(nil)
+	restorer := mocks.NewAccountRestorer(t)
+	restorer.EXPECT().RestoreDeleted(mock.Anything, "user-uuid", mock.Anything).Return(true, nil)
+	breaches := mocks.NewBreachChecker(t)
+	breaches.EXPECT().Breached(mock.Anything, "securepassword").Return(true, nil)
+	passwords := mocks.NewPasswordUpdater(t)
+	passwords.EXPECT().RequirePasswordReset(mock.Anything, "user-uuid").Return(nil)
+
+	svc := New(userGetter, passVerifier, mocks.NewTokenIssuer(t), mocks.NewUserProvisioner(t), nil, mocks.NewGroupSyncer(t), guard,
+		restorer, passwords, identityNormalizer(t), breaches, &Config{DeletionGracePeriod: 30 * 24 * time.Hour}, zap.NewNop())
+
+	_, err := svc.Login(t.Context(), "tenant-1", "user@example.com", "securepassword")
+
+	require.ErrorIs(t, err, domainerrors.ErrPasswordResetRequired)
+}
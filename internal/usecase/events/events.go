@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type EventRepository interface {
+	AppendEvent(ctx context.Context, event *model.Event) error
+	ListEventsAfter(ctx context.Context, afterID int64, tenantID string, types []model.EventType, limit int) ([]*model.Event, error)
+	LatestEventID(ctx context.Context) (int64, error)
+}
+
+// Config controls how watchers follow the event table. Events published by
+// this instance wake watchers immediately; PollInterval bounds the delay for
+// events written by other instances.
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+type Service struct {
+	repo EventRepository
+	cfg  *Config
+	log  *zap.Logger
+
+	mu   sync.Mutex
+	wake chan struct{}
+}
+
+func New(repo EventRepository, cfg *Config, log *zap.Logger) *Service {
+	return &Service{
+		repo: repo,
+		cfg:  cfg,
+		log:  log,
+		wake: make(chan struct{}),
+	}
+}
+
+// Publish stores event and wakes local watchers. Callers publish after their
+// change has committed, in a separate write, so delivery is at most once: an
+// event lost to a failed write or a crash in between is not retried.
+func (s *Service) Publish(ctx context.Context, event *model.Event) error {
+	if err := s.repo.AppendEvent(ctx, event); err != nil {
+		return fmt.Errorf("append event: %w", err)
+	}
+
+	s.mu.Lock()
+	close(s.wake)
+	s.wake = make(chan struct{})
+	s.mu.Unlock()
+	return nil
+}
+
+// Watch calls send for every event after cursor, oldest first, then keeps
+// following new events until ctx ends or send fails. An empty cursor starts
+// at the next event to be published. Only events of tenantID and of the
+// given types are sent; an empty tenantID selects every tenant and an empty
+// types every type.
+func (s *Service) Watch(
+	ctx context.Context,
+	tenantID string,
+	cursor string,
+	types []model.EventType,
+	send func(*model.Event) error,
+) error {
+	after, err := s.resolveCursor(ctx, cursor)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Grab the wake channel before reading so that an event published
+		// between the read and the wait is not missed.
+		s.mu.Lock()
+		wake := s.wake
+		s.mu.Unlock()
+
+		batch, err := s.repo.ListEventsAfter(ctx, after, tenantID, types, s.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("list events: %w", err)
+		}
+		for _, e := range batch {
+			if err = send(e); err != nil {
+				return err
+			}
+			after = e.ID
+		}
+		if len(batch) == s.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("watch events: %w", ctx.Err())
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cursor returns the position right after e, for resuming a watch.
+func Cursor(e *model.Event) string {
+	return strconv.FormatInt(e.ID, 10)
+}
+
+func (s *Service) resolveCursor(ctx context.Context, cursor string) (int64, error) {
+	if cursor == "" {
+		id, err := s.repo.LatestEventID(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("get latest event: %w", err)
+		}
+		return id, nil
+	}
+	id, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || id < 0 {
+		return 0, domainerrors.ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/events/mocks"
+)
+
+var errStop = errors.New("stop")
+
+func newTestService(repo EventRepository) *Service {
+	return New(repo, &Config{PollInterval: time.Hour, BatchSize: 2}, zap.NewNop())
+}
+
+func TestService_Watch_FromCursor(t *testing.T) {
+	repo := mocks.NewEventRepository(t)
+	types := []model.EventType{model.EventUserRegistered}
+	repo.EXPECT().ListEventsAfter(mock.Anything, int64(5), "tenant-1", types, 2).
+		Return([]*model.Event{{ID: 6}, {ID: 8}}, nil).Once()
+	repo.EXPECT().ListEventsAfter(mock.Anything, int64(8), "tenant-1", types, 2).
+		Return([]*model.Event{{ID: 9}}, nil).Once()
+
+	var got []int64
+	err := newTestService(repo).Watch(t.Context(), "tenant-1", "5", types, func(e *model.Event) error {
+		got = append(got, e.ID)
+		if e.ID == 9 {
+			return errStop
+		}
+		return nil
+	})
+
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []int64{6, 8, 9}, got)
+}
+
+func TestService_Watch_InvalidCursor(t *testing.T) {
+	for _, cursor := range []string{"abc", "-1"} {
+		t.Run(cursor, func(t *testing.T) {
+			err := newTestService(mocks.NewEventRepository(t)).Watch(t.Context(), "", cursor, nil, func(*model.Event) error {
+				return nil
+			})
+
+			assert.ErrorIs(t, err, domainerrors.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestService_Watch_WakesOnPublish(t *testing.T) {
+	repo := mocks.NewEventRepository(t)
+	repo.EXPECT().LatestEventID(mock.Anything).Return(int64(10), nil)
+
+	listed := make(chan struct{}, 1)
+	repo.EXPECT().ListEventsAfter(mock.Anything, int64(10), "", []model.EventType(nil), 2).
+		RunAndReturn(func(context.Context, int64, string, []model.EventType, int) ([]*model.Event, error) {
+			listed <- struct{}{}
+			return nil, nil
+		}).Once()
+	repo.EXPECT().ListEventsAfter(mock.Anything, int64(10), "", []model.EventType(nil), 2).
+		Return([]*model.Event{{ID: 11, Type: model.EventEmailVerified}}, nil).Once()
+	repo.EXPECT().AppendEvent(mock.Anything, mock.Anything).Return(nil)
+
+	svc := newTestService(repo)
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.Watch(t.Context(), "", "", nil, func(*model.Event) error { return errStop })
+	}()
+
+	<-listed
+	require.NoError(t, svc.Publish(t.Context(), model.NewEvent(model.EventEmailVerified, "tenant-1", "user-1")))
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, errStop)
+	case <-time.After(time.Second):
+		t.Fatal("watcher was not woken by publish")
+	}
+}
+
+func TestService_Watch_ContextCanceled(t *testing.T) {
+	repo := mocks.NewEventRepository(t)
+	repo.EXPECT().ListEventsAfter(mock.Anything, int64(0), "", []model.EventType(nil), 2).Return(nil, nil)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err := newTestService(repo).Watch(ctx, "", "0", nil, func(*model.Event) error { return nil })
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
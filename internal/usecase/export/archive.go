@@ -0,0 +1,140 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// archive is the JSON document handed to the data subject. Secrets such as
+// password and MFA secret hashes and refresh token hashes are left out.
+type archive struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	User        archiveUser       `json:"user"`
+	MFA         archiveMFA        `json:"mfa"`
+	Sessions    []archiveSession  `json:"sessions"`
+	Identities  []archiveIdentity `json:"identities"`
+	Events      []archiveEvent    `json:"events"`
+}
+
+type archiveUser struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	HasPassword   bool       `json:"has_password"`
+	Status        string     `json:"status"`
+	DisplayName   string     `json:"display_name"`
+	Locale        string     `json:"locale"`
+	Timezone      string     `json:"timezone"`
+	AvatarURL     string     `json:"avatar_url"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type archiveMFA struct {
+	TOTPEnabled bool `json:"totp_enabled"`
+}
+
+type archiveSession struct {
+	ID        string    `json:"id"`
+	FamilyID  string    `json:"family_id"`
+	ClientID  string    `json:"client_id,omitempty"`
+	Scopes    []string  `json:"scopes"`
+	Revoked   bool      `json:"revoked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type archiveIdentity struct {
+	Provider    string            `json:"provider"`
+	Subject     string            `json:"subject"`
+	Email       string            `json:"email"`
+	DisplayName string            `json:"display_name"`
+	Groups      []string          `json:"groups"`
+	Attributes  map[string]string `json:"attributes"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type archiveEvent struct {
+	Type       string            `json:"type"`
+	Data       map[string]string `json:"data"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+func (s *Service) buildArchive(ctx context.Context, user *model.User) ([]byte, error) {
+	tokens, err := s.source.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list refresh tokens: %w", err)
+	}
+	identities, err := s.source.ListIdentitiesByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list identities: %w", err)
+	}
+	events, err := s.source.ListEventsByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	a := archive{
+		GeneratedAt: time.Now().UTC(),
+		User: archiveUser{
+			ID:            user.ID,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			HasPassword:   user.PasswordHash != "",
+			Status:        string(user.Status),
+			DisplayName:   user.Profile.DisplayName,
+			Locale:        user.Profile.Locale,
+			Timezone:      user.Profile.Timezone,
+			AvatarURL:     user.Profile.AvatarURL,
+			DeletedAt:     user.DeletedAt,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+		},
+		MFA:        archiveMFA{TOTPEnabled: user.MFAEnabled},
+		Sessions:   make([]archiveSession, 0, len(tokens)),
+		Identities: make([]archiveIdentity, 0, len(identities)),
+		Events:     make([]archiveEvent, 0, len(events)),
+	}
+	for _, t := range tokens {
+		a.Sessions = append(a.Sessions, archiveSession{
+			ID:        t.ID,
+			FamilyID:  t.FamilyID,
+			ClientID:  t.ClientID,
+			Scopes:    t.Scopes,
+			Revoked:   t.Revoked,
+			ExpiresAt: t.ExpiresAt,
+			CreatedAt: t.CreatedAt,
+		})
+	}
+	for _, i := range identities {
+		a.Identities = append(a.Identities, archiveIdentity{
+			Provider:    i.Provider,
+			Subject:     i.Subject,
+			Email:       i.Email,
+			DisplayName: i.DisplayName,
+			Groups:      i.Groups,
+			Attributes:  i.Attributes,
+			CreatedAt:   i.CreatedAt,
+			UpdatedAt:   i.UpdatedAt,
+		})
+	}
+	for _, e := range events {
+		a.Events = append(a.Events, archiveEvent{
+			Type:       string(e.Type),
+			Data:       e.Data,
+			OccurredAt: e.CreatedAt,
+		})
+	}
+
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal archive: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+const downloadTokenLen = 32
+
+type ExportRepository interface {
+	CreateExport(ctx context.Context, export *model.DataExport) error
+	ClaimPendingExport(ctx context.Context, staleBefore time.Time) (*model.DataExport, error)
+	CompleteExport(ctx context.Context, id string, archive []byte, tokenHash string, expiresAt time.Time) error
+	FailExport(ctx context.Context, id string) error
+	GetExportByTokenHash(ctx context.Context, hash string) (*model.DataExport, error)
+	DeleteExpiredExports(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserDataSource reads everything stored about a user.
+type UserDataSource interface {
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	ListByUserID(ctx context.Context, userID string) ([]*model.RefreshToken, error)
+	ListIdentitiesByUserID(ctx context.Context, userID string) ([]*model.UserIdentity, error)
+	ListEventsByUserID(ctx context.Context, userID string) ([]*model.Event, error)
+}
+
+type EmailSender interface {
+	SendDataExportEmail(ctx context.Context, toEmail, token string, expiresAt time.Time) error
+}
+
+// Config controls the export worker. LinkTTL is how long a finished archive
+// can be downloaded; exports left processing for ProcessingTimeout are
+// retried.
+type Config struct {
+	LinkTTL           time.Duration
+	PollInterval      time.Duration
+	ProcessingTimeout time.Duration
+}
+
+// Service answers subject access requests. Requests are queued and built by
+// Run in the background; the owner gets an emailed download link.
+type Service struct {
+	repo   ExportRepository
+	source UserDataSource
+	email  EmailSender
+	cfg    *Config
+	log    *zap.Logger
+	wake   chan struct{}
+}
+
+func New(repo ExportRepository, src UserDataSource, es EmailSender, cfg *Config, log *zap.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		source: src,
+		email:  es,
+		cfg:    cfg,
+		log:    log,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// RequestExport queues an export of the user's data. Only one export per user
+// can be in flight; a second request gets domainerrors.ErrExportInProgress.
+func (s *Service) RequestExport(ctx context.Context, userID string, by model.DataExportRequester) (*model.DataExport, error) {
+	if _, err := s.source.GetByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+
+	export := &model.DataExport{
+		UserID:      userID,
+		Status:      model.DataExportPending,
+		RequestedBy: by,
+	}
+	if err := s.repo.CreateExport(ctx, export); err != nil {
+		return nil, fmt.Errorf("create data export: %w", err)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.log.Info("data export requested",
+		zap.String("user_id", userID),
+		zap.String("export_id", export.ID),
+		zap.String("requested_by", string(by)),
+	)
+	return export, nil
+}
+
+// Download returns the finished export the link token belongs to.
+func (s *Service) Download(ctx context.Context, token string) (*model.DataExport, error) {
+	export, err := s.repo.GetExportByTokenHash(ctx, crypto.HashToken(token))
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrExportNotFound) {
+			return nil, domainerrors.ErrInvalidExportLink
+		}
+		return nil, fmt.Errorf("get data export: %w", err)
+	}
+	if export.Status != model.DataExportReady || time.Now().After(export.ExpiresAt) {
+		return nil, domainerrors.ErrInvalidExportLink
+	}
+
+	s.log.Info("data export downloaded",
+		zap.String("user_id", export.UserID),
+		zap.String("export_id", export.ID),
+	)
+	return export, nil
+}
+
+// Run builds queued exports and deletes expired ones until ctx ends.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessPending(ctx); err != nil {
+			s.log.Error("failed to process data exports", zap.Error(err))
+		}
+		if n, err := s.repo.DeleteExpiredExports(ctx, time.Now()); err != nil {
+			s.log.Error("failed to delete expired data exports", zap.Error(err))
+		} else if n > 0 {
+			s.log.Info("expired data exports deleted", zap.Int64("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending builds every queued export and returns how many it handled.
+// An export that cannot be built is marked failed; the owner can ask again.
+func (s *Service) ProcessPending(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		export, err := s.repo.ClaimPendingExport(ctx, time.Now().Add(-s.cfg.ProcessingTimeout))
+		if errors.Is(err, domainerrors.ErrExportNotFound) {
+			return processed, nil
+		}
+		if err != nil {
+			return processed, fmt.Errorf("claim data export: %w", err)
+		}
+
+		if err = s.process(ctx, export); err != nil {
+			s.log.Error("failed to build data export",
+				zap.Error(err),
+				zap.String("export_id", export.ID),
+			)
+			if err = s.repo.FailExport(ctx, export.ID); err != nil {
+				return processed, fmt.Errorf("fail data export: %w", err)
+			}
+		}
+		processed++
+	}
+}
+
+func (s *Service) process(ctx context.Context, export *model.DataExport) error {
+	user, err := s.source.GetByID(ctx, export.UserID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+
+	archive, err := s.buildArchive(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	token, err := crypto.GenerateRandomToken(downloadTokenLen)
+	if err != nil {
+		return fmt.Errorf("generate download token: %w", err)
+	}
+	expiresAt := time.Now().Add(s.cfg.LinkTTL)
+
+	if err = s.repo.CompleteExport(ctx, export.ID, archive, crypto.HashToken(token), expiresAt); err != nil {
+		return fmt.Errorf("complete data export: %w", err)
+	}
+
+	if err = s.email.SendDataExportEmail(ctx, user.Email, token, expiresAt); err != nil {
+		s.log.Error("failed to send data export email",
+			zap.Error(err),
+			zap.String("user_id", user.ID),
+			zap.String("export_id", export.ID),
+		)
+	}
+
+	s.log.Info("data export ready",
+		zap.String("user_id", user.ID),
+		zap.String("export_id", export.ID),
+	)
+	return nil
+}
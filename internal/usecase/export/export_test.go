@@ -0,0 +1,228 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/export/mocks"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+type testDeps struct {
+	repo   *mocks.ExportRepository
+	source *mocks.UserDataSource
+	email  *mocks.EmailSender
+}
+
+func newTestService(t *testing.T) (*Service, *testDeps) {
+	t.Helper()
+	d := &testDeps{
+		repo:   mocks.NewExportRepository(t),
+		source: mocks.NewUserDataSource(t),
+		email:  mocks.NewEmailSender(t),
+	}
+	svc := New(d.repo, d.source, d.email, &Config{
+		LinkTTL:           72 * time.Hour,
+		PollInterval:      time.Hour,
+		ProcessingTimeout: 10 * time.Minute,
+	}, zap.NewNop())
+	return svc, d
+}
+
+func TestService_RequestExport(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(d *testDeps)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			mockSetup: func(d *testDeps) {
+				d.source.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+				d.repo.EXPECT().CreateExport(mock.Anything, mock.MatchedBy(func(e *model.DataExport) bool {
+					return e.UserID == "user-1" && e.Status == model.DataExportPending && e.RequestedBy == model.DataExportByAdmin
+				})).Return(nil)
+			},
+		},
+		{
+			name: "user not found",
+			mockSetup: func(d *testDeps) {
+				d.source.EXPECT().GetByID(mock.Anything, "user-1").Return(nil, domainerrors.ErrUserNotFound)
+			},
+			wantErr: domainerrors.ErrUserNotFound,
+		},
+		{
+			name: "already in progress",
+			mockSetup: func(d *testDeps) {
+				d.source.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+				d.repo.EXPECT().CreateExport(mock.Anything, mock.Anything).Return(domainerrors.ErrExportInProgress)
+			},
+			wantErr: domainerrors.ErrExportInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, d := newTestService(t)
+			tt.mockSetup(d)
+
+			export, err := svc.RequestExport(t.Context(), "user-1", model.DataExportByAdmin)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", export.UserID)
+			assert.Len(t, svc.wake, 1)
+		})
+	}
+}
+
+func TestService_Download(t *testing.T) {
+	hash := crypto.HashToken("tok")
+
+	tests := []struct {
+		name      string
+		export    *model.DataExport
+		repoErr   error
+		wantErr   error
+		wantFound bool
+	}{
+		{
+			name:      "ready",
+			export:    &model.DataExport{ID: "e1", Status: model.DataExportReady, ExpiresAt: time.Now().Add(time.Hour)},
+			wantFound: true,
+		},
+		{
+			name:    "expired",
+			export:  &model.DataExport{ID: "e1", Status: model.DataExportReady, ExpiresAt: time.Now().Add(-time.Minute)},
+			wantErr: domainerrors.ErrInvalidExportLink,
+		},
+		{
+			name:    "failed",
+			export:  &model.DataExport{ID: "e1", Status: model.DataExportFailed, ExpiresAt: time.Now().Add(time.Hour)},
+			wantErr: domainerrors.ErrInvalidExportLink,
+		},
+		{
+			name:    "unknown token",
+			repoErr: domainerrors.ErrExportNotFound,
+			wantErr: domainerrors.ErrInvalidExportLink,
+		},
+		{
+			name:    "repository error",
+			repoErr: assert.AnError,
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, d := newTestService(t)
+			d.repo.EXPECT().GetExportByTokenHash(mock.Anything, hash).Return(tt.export, tt.repoErr)
+
+			export, err := svc.Download(t.Context(), "tok")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.export, export)
+		})
+	}
+}
+
+func TestService_ProcessPending(t *testing.T) {
+	staleBefore := mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= 10*time.Minute && time.Since(before) < 11*time.Minute
+	})
+
+	t.Run("builds archive and emails link", func(t *testing.T) {
+		svc, d := newTestService(t)
+		user := &model.User{
+			ID:           "user-1",
+			Email:        "user@example.com",
+			PasswordHash: "$argon2id$secret",
+			MFASecretEnc: []byte("totp-secret"),
+			MFAEnabled:   true,
+			Status:       model.UserStatusActive,
+		}
+		d.repo.EXPECT().ClaimPendingExport(mock.Anything, staleBefore).
+			Return(&model.DataExport{ID: "e1", UserID: "user-1"}, nil).Once()
+		d.repo.EXPECT().ClaimPendingExport(mock.Anything, staleBefore).
+			Return(nil, domainerrors.ErrExportNotFound).Once()
+		d.source.EXPECT().GetByID(mock.Anything, "user-1").Return(user, nil)
+		d.source.EXPECT().ListByUserID(mock.Anything, "user-1").
+			Return([]*model.RefreshToken{{ID: "rt-1", FamilyID: "fam-1", TokenHash: "rt-hash"}}, nil)
+		d.source.EXPECT().ListIdentitiesByUserID(mock.Anything, "user-1").
+			Return([]*model.UserIdentity{{Provider: "google", Subject: "g-1"}}, nil)
+		d.source.EXPECT().ListEventsByUserID(mock.Anything, "user-1").
+			Return([]*model.Event{{Type: model.EventUserRegistered}}, nil)
+
+		var data []byte
+		var tokenHash string
+		d.repo.EXPECT().CompleteExport(mock.Anything, "e1", mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ string, a []byte, h string, _ time.Time) error {
+				data, tokenHash = a, h
+				return nil
+			})
+		d.email.EXPECT().SendDataExportEmail(mock.Anything, "user@example.com", mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ string, token string, _ time.Time) error {
+				assert.Equal(t, tokenHash, crypto.HashToken(token))
+				return nil
+			})
+
+		n, err := svc.ProcessPending(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NotContains(t, string(data), "secret")
+		assert.NotContains(t, string(data), "rt-hash")
+
+		var got archive
+		require.NoError(t, json.Unmarshal(data, &got))
+		assert.True(t, got.User.HasPassword)
+		assert.True(t, got.MFA.TOTPEnabled)
+		assert.Len(t, got.Sessions, 1)
+		assert.Equal(t, "google", got.Identities[0].Provider)
+		assert.Equal(t, "user.registered", got.Events[0].Type)
+	})
+
+	t.Run("marks export failed when data cannot be read", func(t *testing.T) {
+		svc, d := newTestService(t)
+		d.repo.EXPECT().ClaimPendingExport(mock.Anything, staleBefore).
+			Return(&model.DataExport{ID: "e1", UserID: "user-1"}, nil).Once()
+		d.repo.EXPECT().ClaimPendingExport(mock.Anything, staleBefore).
+			Return(nil, domainerrors.ErrExportNotFound).Once()
+		d.source.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+		d.source.EXPECT().ListByUserID(mock.Anything, "user-1").Return(nil, errors.New("db down"))
+		d.repo.EXPECT().FailExport(mock.Anything, "e1").Return(nil)
+
+		n, err := svc.ProcessPending(t.Context())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("claim error", func(t *testing.T) {
+		svc, d := newTestService(t)
+		d.repo.EXPECT().ClaimPendingExport(mock.Anything, staleBefore).
+			Return(nil, fmt.Errorf("claim: %w", assert.AnError))
+
+		_, err := svc.ProcessPending(t.Context())
+
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
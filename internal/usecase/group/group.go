@@ -0,0 +1,250 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	maxNameLength        = 128
+	maxDescriptionLength = 256
+	maxExternalIDLength  = 512
+)
+
+type Repository interface {
+	CreateGroup(ctx context.Context, group *model.Group) error
+	ListGroups(ctx context.Context, tenantID string) ([]*model.Group, error)
+	GetGroup(ctx context.Context, tenantID, id string) (*model.Group, error)
+	UpdateGroup(ctx context.Context, group *model.Group) error
+	DeleteGroup(ctx context.Context, tenantID, id string) error
+	ListGroupMembers(ctx context.Context, groupID string) (*model.GroupMembers, error)
+	AddGroupMember(ctx context.Context, groupID, userID string) error
+	RemoveGroupMember(ctx context.Context, groupID, userID string) error
+	AddSubgroup(ctx context.Context, parentID, childID string) error
+	RemoveSubgroup(ctx context.Context, parentID, childID string) error
+	ListUserGroups(ctx context.Context, userID string) ([]*model.UserGroup, error)
+	SyncExternalGroups(ctx context.Context, userID, source string, groups []*model.Group) error
+}
+
+type Service struct {
+	repo Repository
+	log  *zap.Logger
+}
+
+func New(repo Repository, log *zap.Logger) *Service {
+	return &Service{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (s *Service) CreateGroup(ctx context.Context, group *model.Group) error {
+	if err := validateGroup(group); err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateGroup(ctx, group); err != nil {
+		return fmt.Errorf("create group: %w", err)
+	}
+
+	s.log.Info("group created", zap.String("group_id", group.ID), zap.String("name", group.Name))
+	return nil
+}
+
+func (s *Service) ListGroups(ctx context.Context, tenantID string) ([]*model.Group, error) {
+	groups, err := s.repo.ListGroups(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	return groups, nil
+}
+
+// GetGroup returns the group with its direct members.
+func (s *Service) GetGroup(ctx context.Context, tenantID, id string) (*model.Group, *model.GroupMembers, error) {
+	group, err := s.repo.GetGroup(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get group: %w", err)
+	}
+	members, err := s.repo.ListGroupMembers(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list group members: %w", err)
+	}
+	return group, members, nil
+}
+
+// UpdateGroup renames the group and replaces its description. Synced groups
+// take their name from the directory and cannot be renamed.
+func (s *Service) UpdateGroup(ctx context.Context, tenantID, id, name, description string) (*model.Group, error) {
+	group, err := s.repo.GetGroup(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	name = strings.TrimSpace(name)
+	if group.IsExternal() && name != group.Name {
+		return nil, domainerrors.ErrGroupExternallyManaged
+	}
+
+	group.Name, group.Description = name, description
+	if err = validateGroup(group); err != nil {
+		return nil, err
+	}
+	if err = s.repo.UpdateGroup(ctx, group); err != nil {
+		return nil, fmt.Errorf("update group: %w", err)
+	}
+
+	s.log.Info("group updated", zap.String("group_id", id))
+	return group, nil
+}
+
+// DeleteGroup removes the group. Users and groups it contained keep their
+// other memberships.
+func (s *Service) DeleteGroup(ctx context.Context, tenantID, id string) error {
+	if err := s.repo.DeleteGroup(ctx, tenantID, id); err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	s.log.Info("group deleted", zap.String("group_id", id))
+	return nil
+}
+
+// AddMember adds the user to a local group. Membership of synced groups is
+// managed by their directory.
+func (s *Service) AddMember(ctx context.Context, tenantID, groupID, userID string) error {
+	if err := s.requireLocal(ctx, tenantID, groupID); err != nil {
+		return err
+	}
+	if err := s.repo.AddGroupMember(ctx, groupID, userID); err != nil {
+		return fmt.Errorf("add group member: %w", err)
+	}
+	s.log.Info("group member added", zap.String("group_id", groupID), zap.String("user_id", userID))
+	return nil
+}
+
+func (s *Service) RemoveMember(ctx context.Context, tenantID, groupID, userID string) error {
+	if err := s.requireLocal(ctx, tenantID, groupID); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveGroupMember(ctx, groupID, userID); err != nil {
+		return fmt.Errorf("remove group member: %w", err)
+	}
+	s.log.Info("group member removed", zap.String("group_id", groupID), zap.String("user_id", userID))
+	return nil
+}
+
+// AddSubgroup makes the members of child members of parent as well. Synced
+// groups can be nested like local ones; both groups must be in the tenant.
+func (s *Service) AddSubgroup(ctx context.Context, tenantID, parentID, childID string) error {
+	for _, id := range []string{parentID, childID} {
+		if _, err := s.repo.GetGroup(ctx, tenantID, id); err != nil {
+			return fmt.Errorf("get group: %w", err)
+		}
+	}
+	if err := s.repo.AddSubgroup(ctx, parentID, childID); err != nil {
+		return fmt.Errorf("add subgroup: %w", err)
+	}
+	s.log.Info("subgroup added", zap.String("group_id", parentID), zap.String("subgroup_id", childID))
+	return nil
+}
+
+func (s *Service) RemoveSubgroup(ctx context.Context, tenantID, parentID, childID string) error {
+	if _, err := s.repo.GetGroup(ctx, tenantID, parentID); err != nil {
+		return fmt.Errorf("get group: %w", err)
+	}
+	if err := s.repo.RemoveSubgroup(ctx, parentID, childID); err != nil {
+		return fmt.Errorf("remove subgroup: %w", err)
+	}
+	s.log.Info("subgroup removed", zap.String("group_id", parentID), zap.String("subgroup_id", childID))
+	return nil
+}
+
+// ListUserGroups returns every group the user belongs to, including groups
+// reached through nesting.
+func (s *Service) ListUserGroups(ctx context.Context, userID string) ([]*model.UserGroup, error) {
+	groups, err := s.repo.ListUserGroups(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user groups: %w", err)
+	}
+	return groups, nil
+}
+
+// SyncExternalGroups mirrors the groups a directory reports for the user into
+// the user's tenant. Groups are identified by the directory's value, e.g. an
+// LDAP DN, and named after its first component. The user leaves synced groups
+// of source the directory no longer reports.
+func (s *Service) SyncExternalGroups(ctx context.Context, tenantID, userID, source string, externalIDs []string) error {
+	groups := make([]*model.Group, 0, len(externalIDs))
+	for _, id := range externalIDs {
+		name := externalGroupName(id)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength || len(id) > maxExternalIDLength {
+			s.log.Warn("skipping external group", zap.String("source", source), zap.String("external_id", id))
+			continue
+		}
+		groups = append(groups, &model.Group{TenantID: tenantID, Name: name, Source: source, ExternalID: id})
+	}
+
+	if err := s.repo.SyncExternalGroups(ctx, userID, source, groups); err != nil {
+		return fmt.Errorf("sync external groups: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) requireLocal(ctx context.Context, tenantID, groupID string) error {
+	group, err := s.repo.GetGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return fmt.Errorf("get group: %w", err)
+	}
+	if group.IsExternal() {
+		return domainerrors.ErrGroupExternallyManaged
+	}
+	return nil
+}
+
+func validateGroup(group *model.Group) error {
+	group.Name = strings.TrimSpace(group.Name)
+	switch {
+	case group.Name == "":
+		return errors.New("name: must not be empty")
+	case utf8.RuneCountInString(group.Name) > maxNameLength:
+		return fmt.Errorf("name: must not exceed %d characters", maxNameLength)
+	case strings.ContainsFunc(group.Name, unicode.IsControl):
+		return errors.New("name: must not contain control characters")
+	case utf8.RuneCountInString(group.Description) > maxDescriptionLength:
+		return fmt.Errorf("description: must not exceed %d characters", maxDescriptionLength)
+	}
+	return nil
+}
+
+// externalGroupName derives a display name from a directory group value:
+// the value of the first RDN for a DN such as "CN=Engineering,OU=Groups,..."
+// and the value itself otherwise.
+func externalGroupName(externalID string) string {
+	attr, rest, ok := strings.Cut(externalID, "=")
+	if !ok || attr == "" || strings.ContainsFunc(attr, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '.'
+	}) {
+		return strings.TrimSpace(externalID)
+	}
+
+	var name strings.Builder
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		if c == '\\' && i+1 < len(rest) {
+			i++
+			name.WriteByte(rest[i])
+			continue
+		}
+		if c == ',' || c == '+' {
+			break
+		}
+		name.WriteByte(c)
+	}
+	return strings.TrimSpace(name.String())
+}
@@ -0,0 +1,200 @@
+package group
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/group/mocks"
+)
+
+func TestService_CreateGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		group     *model.Group
+		mockSetup func(repo *mocks.Repository)
+		wantErr   string
+	}{
+		{
+			name:  "trims name",
+			group: &model.Group{Name: " Engineering "},
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().CreateGroup(mock.Anything, &model.Group{Name: "Engineering"}).Return(nil)
+			},
+		},
+		{
+			name:      "empty name",
+			group:     &model.Group{Name: "  "},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "name: must not be empty",
+		},
+		{
+			name:      "control characters",
+			group:     &model.Group{Name: "eng\nops"},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "name: must not contain control characters",
+		},
+		{
+			name:  "duplicate",
+			group: &model.Group{Name: "Engineering"},
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().CreateGroup(mock.Anything, mock.Anything).Return(domainerrors.ErrGroupAlreadyExists)
+			},
+			wantErr: "create group: group already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			err := New(repo, zap.NewNop()).CreateGroup(t.Context(), tt.group)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_UpdateGroup(t *testing.T) {
+	synced := &model.Group{ID: "group-1", Name: "Engineering", Source: "corp-ad", ExternalID: "CN=Engineering"}
+
+	t.Run("synced group keeps its name", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(synced, nil)
+
+		_, err := New(repo, zap.NewNop()).UpdateGroup(t.Context(), "tenant-1", "group-1", "Eng", "")
+
+		assert.ErrorIs(t, err, domainerrors.ErrGroupExternallyManaged)
+	})
+
+	t.Run("synced group description can change", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(synced, nil)
+		repo.EXPECT().UpdateGroup(mock.Anything, mock.MatchedBy(func(g *model.Group) bool {
+			return g.Name == "Engineering" && g.Description == "All engineers"
+		})).Return(nil)
+
+		group, err := New(repo, zap.NewNop()).UpdateGroup(t.Context(), "tenant-1", "group-1", "Engineering", "All engineers")
+
+		require.NoError(t, err)
+		assert.Equal(t, "All engineers", group.Description)
+	})
+}
+
+func TestService_AddMember(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(repo *mocks.Repository)
+		wantErr   error
+	}{
+		{
+			name: "local group",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(&model.Group{ID: "group-1"}, nil)
+				repo.EXPECT().AddGroupMember(mock.Anything, "group-1", "user-1").Return(nil)
+			},
+		},
+		{
+			name: "synced group",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").
+					Return(&model.Group{ID: "group-1", Source: "corp-ad", ExternalID: "CN=Eng"}, nil)
+			},
+			wantErr: domainerrors.ErrGroupExternallyManaged,
+		},
+		{
+			name: "unknown group",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(nil, domainerrors.ErrGroupNotFound)
+			},
+			wantErr: domainerrors.ErrGroupNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			err := New(repo, zap.NewNop()).AddMember(t.Context(), "tenant-1", "group-1", "user-1")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_AddSubgroup(t *testing.T) {
+	t.Run("cycle", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "parent").Return(&model.Group{ID: "parent"}, nil)
+		repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "child").Return(&model.Group{ID: "child"}, nil)
+		repo.EXPECT().AddSubgroup(mock.Anything, "parent", "child").Return(domainerrors.ErrGroupCycle)
+
+		err := New(repo, zap.NewNop()).AddSubgroup(t.Context(), "tenant-1", "parent", "child")
+
+		assert.ErrorIs(t, err, domainerrors.ErrGroupCycle)
+	})
+
+	t.Run("child of another tenant", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "parent").Return(&model.Group{ID: "parent"}, nil)
+		repo.EXPECT().GetGroup(mock.Anything, "tenant-1", "child").Return(nil, domainerrors.ErrGroupNotFound)
+
+		err := New(repo, zap.NewNop()).AddSubgroup(t.Context(), "tenant-1", "parent", "child")
+
+		assert.ErrorIs(t, err, domainerrors.ErrGroupNotFound)
+	})
+}
+
+func TestService_SyncExternalGroups(t *testing.T) {
+	t.Run("names groups after the first RDN", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().SyncExternalGroups(mock.Anything, "user-1", "corp-ad", []*model.Group{
+			{TenantID: "tenant-1", Name: "Engineering", Source: "corp-ad",
+				ExternalID: "CN=Engineering,OU=Groups,DC=corp,DC=example"},
+			{TenantID: "tenant-1", Name: "R&D, Berlin", Source: "corp-ad",
+				ExternalID: `CN=R&D\, Berlin,OU=Groups,DC=corp,DC=example`},
+			{TenantID: "tenant-1", Name: "sales-team", Source: "corp-ad", ExternalID: "sales-team"},
+		}).Return(nil)
+
+		err := New(repo, zap.NewNop()).SyncExternalGroups(t.Context(), "tenant-1", "user-1", "corp-ad", []string{
+			"CN=Engineering,OU=Groups,DC=corp,DC=example",
+			`CN=R&D\, Berlin,OU=Groups,DC=corp,DC=example`,
+			"sales-team",
+			"CN=,OU=Groups",
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("no groups clears memberships", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().SyncExternalGroups(mock.Anything, "user-1", "corp-ad", []*model.Group{}).Return(nil)
+
+		require.NoError(t, New(repo, zap.NewNop()).SyncExternalGroups(t.Context(), "tenant-1", "user-1", "corp-ad", nil))
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().SyncExternalGroups(mock.Anything, "user-1", "corp-ad", mock.Anything).Return(errors.New("db down"))
+
+		err := New(repo, zap.NewNop()).SyncExternalGroups(t.Context(), "tenant-1", "user-1", "corp-ad", []string{"eng"})
+
+		require.EqualError(t, err, "sync external groups: db down")
+	})
+}
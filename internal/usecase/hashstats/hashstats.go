@@ -0,0 +1,99 @@
+package hashstats
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type HashRepository interface {
+	GroupPasswordHashes(ctx context.Context) ([]*model.PasswordHashGroup, error)
+}
+
+// HashPolicy tells which stored hashes fall short of the current hashing
+// parameters.
+type HashPolicy interface {
+	NeedsRehash(encodedHash string) bool
+}
+
+type Config struct {
+	Interval time.Duration
+}
+
+// Service keeps count of the stored password hashes that are still on
+// outdated parameters or formats, to follow the progress of rehashing on
+// login after the parameters are raised.
+type Service struct {
+	repo   HashRepository
+	policy HashPolicy
+	cfg    *Config
+	log    *zap.Logger
+
+	mu    sync.RWMutex
+	stats *model.PasswordHashStats
+}
+
+func New(repo HashRepository, policy HashPolicy, cfg *Config, log *zap.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		policy: policy,
+		cfg:    cfg,
+		log:    log,
+	}
+}
+
+// Run recounts the hashes every Interval until ctx ends.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			s.log.Error("failed to count password hashes", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh counts the stored hashes against the current parameters.
+func (s *Service) Refresh(ctx context.Context) error {
+	groups, err := s.repo.GroupPasswordHashes(ctx)
+	if err != nil {
+		return fmt.Errorf("group password hashes: %w", err)
+	}
+
+	stats := &model.PasswordHashStats{}
+	for _, g := range groups {
+		if s.policy.NeedsRehash(g.Sample) {
+			stats.Outdated += g.Count
+		} else {
+			stats.Current += g.Count
+		}
+	}
+
+	s.mu.Lock()
+	s.stats = stats
+	s.mu.Unlock()
+	return nil
+}
+
+// Stats returns the latest count, or false if none has completed yet.
+func (s *Service) Stats() (model.PasswordHashStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.stats == nil {
+		return model.PasswordHashStats{}, false
+	}
+	return *s.stats, true
+}
@@ -0,0 +1,58 @@
+package hashstats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/hashstats/mocks"
+)
+
+func TestService_Refresh(t *testing.T) {
+	repo := mocks.NewHashRepository(t)
+	repo.EXPECT().GroupPasswordHashes(t.Context()).Return([]*model.PasswordHashGroup{
+		{Sample: "$argon2id$v=19$m=65536,t=3,p=4$salt$hash", Count: 6},
+		{Sample: "$argon2id$v=19$m=19456,t=2,p=1$salt$hash", Count: 3},
+		{Sample: "$2a$10$bcrypt", Count: 1},
+	}, nil)
+	policy := mocks.NewHashPolicy(t)
+	policy.EXPECT().NeedsRehash("$argon2id$v=19$m=65536,t=3,p=4$salt$hash").Return(false)
+	policy.EXPECT().NeedsRehash("$argon2id$v=19$m=19456,t=2,p=1$salt$hash").Return(true)
+	policy.EXPECT().NeedsRehash("$2a$10$bcrypt").Return(true)
+
+	svc := New(repo, policy, &Config{Interval: time.Minute}, zap.NewNop())
+	_, ok := svc.Stats()
+	assert.False(t, ok)
+
+	require.NoError(t, svc.Refresh(t.Context()))
+
+	stats, ok := svc.Stats()
+	require.True(t, ok)
+	assert.Equal(t, model.PasswordHashStats{Current: 6, Outdated: 4}, stats)
+	assert.InDelta(t, 0.4, stats.OutdatedShare(), 1e-9)
+}
+
+func TestService_Refresh_KeepsLastCount(t *testing.T) {
+	repo := mocks.NewHashRepository(t)
+	repo.EXPECT().GroupPasswordHashes(t.Context()).Return([]*model.PasswordHashGroup{
+		{Sample: "current", Count: 2},
+	}, nil).Once()
+	repo.EXPECT().GroupPasswordHashes(t.Context()).Return(nil, errors.New("db down")).Once()
+	policy := mocks.NewHashPolicy(t)
+	policy.EXPECT().NeedsRehash("current").Return(false)
+
+	svc := New(repo, policy, &Config{Interval: time.Minute}, zap.NewNop())
+	require.NoError(t, svc.Refresh(t.Context()))
+
+	err := svc.Refresh(t.Context())
+
+	require.EqualError(t, err, "group password hashes: db down")
+	stats, ok := svc.Stats()
+	require.True(t, ok)
+	assert.Equal(t, 2, stats.Current)
+}
@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	maxExternalIDLength = 512
+
+	// maxReportedErrors caps the rejected lines listed in a result, so that
+	// a file in the wrong format does not produce a response as large as
+	// itself.
+	maxReportedErrors = 100
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByEmail(ctx context.Context, tenantID, email string) (*model.User, error)
+}
+
+// HashChecker tells which password hash formats logins can verify.
+type HashChecker interface {
+	Supports(encodedHash string) bool
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event *model.Event) error
+}
+
+// Service creates users in bulk from the export of another identity system.
+// Password hashes are stored as they are; logins verify them in their
+// original format and replace them with the current one.
+type Service struct {
+	users  UserRepository
+	hashes HashChecker
+	events EventPublisher
+	log    *zap.Logger
+}
+
+func New(users UserRepository, hashes HashChecker, events EventPublisher, log *zap.Logger) *Service {
+	return &Service{
+		users:  users,
+		hashes: hashes,
+		events: events,
+		log:    log,
+	}
+}
+
+// Import creates a user in the tenant for every line of r. Lines that are
+// invalid or whose email is taken are skipped and reported in the result. With
+// dryRun the lines are only checked and nothing is created.
+//
+// An error is returned when r cannot be read as a whole, or when storing a
+// user fails; the users of earlier lines are kept then.
+func (s *Service) Import(
+	ctx context.Context,
+	tenantID string,
+	format model.ImportFormat,
+	r io.Reader,
+	dryRun bool,
+) (*model.ImportResult, error) {
+	var records recordReader
+	switch format {
+	case model.ImportFormatCSV:
+		cr, err := newCSVReader(r)
+		if err != nil {
+			return nil, err
+		}
+		records = cr
+	case model.ImportFormatNDJSON:
+		records = newNDJSONReader(r)
+	default:
+		return nil, fmt.Errorf("format: must be %s or %s", model.ImportFormatCSV, model.ImportFormatNDJSON)
+	}
+
+	result := &model.ImportResult{DryRun: dryRun}
+	reject := func(line int, email, reason string) {
+		result.Failed++
+		if len(result.Errors) < maxReportedErrors {
+			result.Errors = append(result.Errors, model.ImportError{Line: line, Email: email, Error: reason})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for {
+		rec, err := records.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if lineErr, ok := errors.AsType[*lineError](err); ok {
+			reject(lineErr.line, "", lineErr.reason)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := s.toUser(tenantID, rec)
+		if err != nil {
+			reject(rec.line, rec.Email, err.Error())
+			continue
+		}
+		if seen[user.Email] {
+			reject(rec.line, user.Email, "email: duplicate in file")
+			continue
+		}
+		seen[user.Email] = true
+
+		if dryRun {
+			err = s.checkAvailable(ctx, user)
+		} else {
+			err = s.users.Create(ctx, user)
+		}
+		if errors.Is(err, domainerrors.ErrEmailAlreadyExists) {
+			reject(rec.line, user.Email, "email: already exists")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: create user: %w", rec.line, err)
+		}
+		result.Created++
+		if !dryRun {
+			s.publish(ctx, model.NewEvent(model.EventUserRegistered, user.TenantID, user.ID))
+		}
+	}
+
+	s.log.Info("users imported",
+		zap.String("tenant_id", tenantID),
+		zap.Int("created", result.Created),
+		zap.Int("failed", result.Failed),
+		zap.Bool("dry_run", dryRun),
+	)
+	return result, nil
+}
+
+// checkAvailable reports domainerrors.ErrEmailAlreadyExists if the email of
+// user is taken, as creating the user would.
+func (s *Service) checkAvailable(ctx context.Context, user *model.User) error {
+	_, err := s.users.GetByEmail(ctx, user.TenantID, user.Email)
+	switch {
+	case err == nil:
+		return domainerrors.ErrEmailAlreadyExists
+	case errors.Is(err, domainerrors.ErrUserNotFound):
+		return nil
+	default:
+		return fmt.Errorf("get user by email: %w", err)
+	}
+}
+
+func (s *Service) toUser(tenantID string, rec *record) (*model.User, error) {
+	hash := strings.TrimSpace(rec.PasswordHash)
+	if rec.KeycloakCredential != nil {
+		if hash != "" {
+			return nil, errors.New("password_hash: must not be set together with keycloak_credential")
+		}
+		var err error
+		if hash, err = rec.KeycloakCredential.encode(); err != nil {
+			return nil, err
+		}
+	}
+	if hash != "" && !s.hashes.Supports(hash) {
+		return nil, errors.New("password_hash: unsupported format")
+	}
+
+	user := model.NewUser(tenantID, strings.ToLower(strings.TrimSpace(rec.Email)), hash)
+	user.ExternalID = strings.TrimSpace(rec.ExternalID)
+	user.EmailVerified = rec.EmailVerified
+	user.Profile = model.Profile{
+		DisplayName: strings.TrimSpace(rec.DisplayName),
+		Locale:      strings.TrimSpace(rec.Locale),
+		Timezone:    strings.TrimSpace(rec.Timezone),
+	}
+	if err := validateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Service) publish(ctx context.Context, event *model.Event) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.log.Error("failed to publish event",
+			zap.Error(err),
+			zap.String("type", string(event.Type)),
+			zap.String("user_id", event.UserID),
+		)
+	}
+}
+
+func validateUser(user *model.User) error {
+	p := user.Profile
+	if user.Email == "" {
+		return errors.New("email: cannot be empty")
+	}
+	if _, err := mail.ParseAddress(user.Email); err != nil {
+		return errors.New("email: invalid format")
+	}
+	if len(user.ExternalID) > maxExternalIDLength {
+		return fmt.Errorf("external_id: must not exceed %d characters", maxExternalIDLength)
+	}
+	return p.Validate() //nolint:wrapcheck // reported as the line's reason
+}
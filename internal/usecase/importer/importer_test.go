@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/importer/mocks"
+)
+
+func TestService_Import_CSV(t *testing.T) {
+	ctx := t.Context()
+	input := "email,password_hash,email_verified,display_name\n" +
+		"Alice@Example.com,$2a$10$hash,true,Alice\n" +
+		"bob@example.com,,false,Bob\n" +
+		"carol@example.com,md5:abc,true,Carol\n" +
+		"not-an-email,,,\n" +
+		"alice@example.com,,,\n" +
+		"dave@example.com,,yes please,\n" +
+		"erin@example.com\n" +
+		"taken@example.com,,,\n"
+
+	users := mocks.NewUserRepository(t)
+	var created []*model.User
+	users.EXPECT().Create(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, u *model.User) error {
+		if u.Email == "taken@example.com" {
+			return domainerrors.ErrEmailAlreadyExists
+		}
+		u.ID = "id-" + u.Email
+		created = append(created, u)
+		return nil
+	})
+	hashes := mocks.NewHashChecker(t)
+	hashes.EXPECT().Supports("$2a$10$hash").Return(true)
+	hashes.EXPECT().Supports("md5:abc").Return(false)
+	events := mocks.NewEventPublisher(t)
+	events.EXPECT().Publish(mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+		return e.Type == model.EventUserRegistered
+	})).Return(nil).Times(2)
+
+	svc := New(users, hashes, events, zap.NewNop())
+	result, err := svc.Import(ctx, "tenant-1", model.ImportFormatCSV, strings.NewReader(input), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 6, result.Failed)
+	assert.Equal(t, []model.ImportError{
+		{Line: 4, Email: "carol@example.com", Error: "password_hash: unsupported format"},
+		{Line: 5, Email: "not-an-email", Error: "email: invalid format"},
+		{Line: 6, Email: "alice@example.com", Error: "email: duplicate in file"},
+		{Line: 7, Error: "email_verified: must be true or false"},
+		{Line: 8, Error: "wrong number of fields"},
+		{Line: 9, Email: "taken@example.com", Error: "email: already exists"},
+	}, result.Errors)
+
+	require.Len(t, created, 2)
+	assert.Equal(t, "tenant-1", created[0].TenantID)
+	assert.Equal(t, "alice@example.com", created[0].Email)
+	assert.Equal(t, "$2a$10$hash", created[0].PasswordHash)
+	assert.True(t, created[0].EmailVerified)
+	assert.Equal(t, "Alice", created[0].Profile.DisplayName)
+	assert.Empty(t, created[1].PasswordHash)
+	assert.False(t, created[1].EmailVerified)
+}
+
+func TestService_Import_NDJSONKeycloak(t *testing.T) {
+	ctx := t.Context()
+	input := `{"email":"user@example.com","email_verified":true,"keycloak_credential":{` +
+		`"secretData":"{\"value\":\"aGFzaA==\",\"salt\":\"c2FsdA==\"}",` +
+		`"credentialData":"{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\"}"}}` + "\n" +
+		"\n" +
+		`{"email":"other@example.com","keycloak_credential":{"secretData":"{\"value\":\"aGFzaA==\"}",` +
+		`"credentialData":"{\"hashIterations\":1,\"algorithm\":\"md5\"}"}}` + "\n" +
+		"{not json\n"
+
+	users := mocks.NewUserRepository(t)
+	users.EXPECT().Create(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.Email == "user@example.com" && u.EmailVerified
+	})).Return(nil)
+	hashes := mocks.NewHashChecker(t)
+	hashes.EXPECT().Supports("$pbkdf2-sha256$i=27500$c2FsdA==$aGFzaA==").Return(true)
+	events := mocks.NewEventPublisher(t)
+	events.EXPECT().Publish(mock.Anything, mock.Anything).Return(nil)
+
+	svc := New(users, hashes, events, zap.NewNop())
+	result, err := svc.Import(ctx, "tenant-1", model.ImportFormatNDJSON, strings.NewReader(input), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, []model.ImportError{
+		{Line: 3, Email: "other@example.com", Error: `keycloak_credential: unsupported algorithm "md5"`},
+		{Line: 4, Error: "invalid json"},
+	}, result.Errors)
+}
+
+func TestService_Import_DryRun(t *testing.T) {
+	ctx := t.Context()
+	input := "email\nnew@example.com\ntaken@example.com\n"
+
+	users := mocks.NewUserRepository(t)
+	users.EXPECT().GetByEmail(mock.Anything, "tenant-1", "new@example.com").Return(nil, domainerrors.ErrUserNotFound)
+	users.EXPECT().GetByEmail(mock.Anything, "tenant-1", "taken@example.com").Return(&model.User{ID: "u1"}, nil)
+
+	svc := New(users, mocks.NewHashChecker(t), mocks.NewEventPublisher(t), zap.NewNop())
+	result, err := svc.Import(ctx, "tenant-1", model.ImportFormatCSV, strings.NewReader(input), true)
+
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Failed)
+}
+
+func TestService_Import_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    model.ImportFormat
+		input     string
+		setupMock func(users *mocks.UserRepository)
+		wantErr   string
+	}{
+		{
+			name:    "unknown format",
+			format:  "xml",
+			wantErr: "format: must be csv or ndjson",
+		},
+		{
+			name:    "empty csv",
+			format:  model.ImportFormatCSV,
+			wantErr: "csv: header row is missing",
+		},
+		{
+			name:    "unknown column",
+			format:  model.ImportFormatCSV,
+			input:   "email,password\n",
+			wantErr: `csv: unknown column "password"`,
+		},
+		{
+			name:    "no email column",
+			format:  model.ImportFormatCSV,
+			input:   "display_name\n",
+			wantErr: "csv: email column is required",
+		},
+		{
+			name:   "storage failure",
+			format: model.ImportFormatNDJSON,
+			input:  `{"email":"user@example.com"}`,
+			setupMock: func(users *mocks.UserRepository) {
+				users.EXPECT().Create(mock.Anything, mock.Anything).Return(errors.New("db down"))
+			},
+			wantErr: "line 1: create user: db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := mocks.NewUserRepository(t)
+			if tt.setupMock != nil {
+				tt.setupMock(users)
+			}
+			svc := New(users, mocks.NewHashChecker(t), mocks.NewEventPublisher(t), zap.NewNop())
+
+			result, err := svc.Import(t.Context(), "tenant-1", tt.format, strings.NewReader(tt.input), false)
+
+			require.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, result)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLineSize is the longest NDJSON line accepted.
+const maxLineSize = 1 << 20
+
+// record is a user as the import file describes it.
+type record struct {
+	line int
+
+	Email              string              `json:"email"`
+	ExternalID         string              `json:"external_id"`
+	PasswordHash       string              `json:"password_hash"`
+	EmailVerified      bool                `json:"email_verified"`
+	DisplayName        string              `json:"display_name"`
+	Locale             string              `json:"locale"`
+	Timezone           string              `json:"timezone"`
+	KeycloakCredential *keycloakCredential `json:"keycloak_credential"`
+}
+
+// keycloakCredential is a password credential from a Keycloak realm export,
+// where both fields hold JSON documents encoded as strings.
+type keycloakCredential struct {
+	SecretData     string `json:"secretData"`
+	CredentialData string `json:"credentialData"`
+}
+
+// encode converts the credential to the $pbkdf2-<digest>$i=<iterations>$
+// form the hasher verifies.
+func (c *keycloakCredential) encode() (string, error) {
+	var secret struct {
+		Value string `json:"value"`
+		Salt  string `json:"salt"`
+	}
+	var params struct {
+		HashIterations int    `json:"hashIterations"`
+		Algorithm      string `json:"algorithm"`
+	}
+	if json.Unmarshal([]byte(c.SecretData), &secret) != nil || secret.Value == "" {
+		return "", errors.New("keycloak_credential: invalid secretData")
+	}
+	if json.Unmarshal([]byte(c.CredentialData), &params) != nil || params.HashIterations < 1 {
+		return "", errors.New("keycloak_credential: invalid credentialData")
+	}
+	switch params.Algorithm {
+	case "pbkdf2", "pbkdf2-sha256", "pbkdf2-sha512":
+	default:
+		return "", fmt.Errorf("keycloak_credential: unsupported algorithm %q", params.Algorithm)
+	}
+	return fmt.Sprintf("$%s$i=%d$%s$%s", params.Algorithm, params.HashIterations, secret.Salt, secret.Value), nil
+}
+
+// lineError rejects a single line of the file; the lines after it are still
+// read.
+type lineError struct {
+	line   int
+	reason string
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.reason)
+}
+
+// recordReader reads the records of an import file one by one and returns
+// io.EOF after the last.
+type recordReader interface {
+	next() (*record, error)
+}
+
+// csvColumns are the columns a CSV file may have; email is required.
+var csvColumns = map[string]bool{
+	"email":          true,
+	"external_id":    true,
+	"password_hash":  true,
+	"email_verified": true,
+	"display_name":   true,
+	"locale":         true,
+	"timezone":       true,
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv: header row is missing")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv: %v", err) //nolint:errorlint // parse errors are reported, not inspected
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !csvColumns[name] {
+			return nil, fmt.Errorf("csv: unknown column %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("csv: duplicate column %q", name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	if !seen["email"] {
+		return nil, errors.New("csv: email column is required")
+	}
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+func (c *csvReader) next() (*record, error) {
+	fields, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if parseErr, ok := errors.AsType[*csv.ParseError](err); ok {
+		if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			return nil, &lineError{line: parseErr.StartLine, reason: "wrong number of fields"}
+		}
+		return nil, fmt.Errorf("csv: %v", err) //nolint:errorlint // parse errors are reported, not inspected
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+
+	line, _ := c.r.FieldPos(0)
+	rec := &record{line: line}
+	for i, value := range fields {
+		switch c.columns[i] {
+		case "email":
+			rec.Email = value
+		case "external_id":
+			rec.ExternalID = value
+		case "password_hash":
+			rec.PasswordHash = value
+		case "email_verified":
+			if value = strings.TrimSpace(value); value != "" {
+				if rec.EmailVerified, err = strconv.ParseBool(value); err != nil {
+					return nil, &lineError{line: line, reason: "email_verified: must be true or false"}
+				}
+			}
+		case "display_name":
+			rec.DisplayName = value
+		case "locale":
+			rec.Locale = value
+		case "timezone":
+			rec.Timezone = value
+		}
+	}
+	return rec, nil
+}
+
+type ndjsonReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &ndjsonReader{s: s}
+}
+
+func (n *ndjsonReader) next() (*record, error) {
+	for n.s.Scan() {
+		n.line++
+		data := n.s.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		rec := &record{line: n.line}
+		if err := json.Unmarshal(data, rec); err != nil {
+			return nil, &lineError{line: n.line, reason: "invalid json"}
+		}
+		return rec, nil
+	}
+	if err := n.s.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("line %d: longer than %d bytes", n.line+1, maxLineSize)
+		}
+		return nil, fmt.Errorf("read ndjson: %w", err)
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,147 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	failuresKeyPrefix = "lockout:failures:"
+	lockedKeyPrefix   = "lockout:locked:"
+)
+
+type CacheStore interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
+
+type EmailSender interface {
+	SendLockoutAlertEmail(ctx context.Context, toEmail string, lockedUntil time.Time) error
+}
+
+// Config describes the lockout policy. After DelayAfter consecutive failures
+// each further failure blocks the account for BaseDelay, doubling every time
+// up to MaxDelay. Reaching MaxFailures locks the account for LockDuration.
+// Failures are forgotten FailureWindow after the last one; until then every
+// further failure locks the account again, but the owner is alerted only for
+// the first lock.
+type Config struct {
+	MaxFailures   int
+	DelayAfter    int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	LockDuration  time.Duration
+	FailureWindow time.Duration
+}
+
+type Service struct {
+	cache CacheStore
+	email EmailSender
+	cfg   *Config
+	log   *zap.Logger
+}
+
+func New(cs CacheStore, es EmailSender, cfg *Config, log *zap.Logger) *Service {
+	return &Service{
+		cache: cs,
+		email: es,
+		cfg:   cfg,
+		log:   log,
+	}
+}
+
+func (s *Service) IsLocked(ctx context.Context, userID string) (bool, error) {
+	if _, err := s.cache.Get(ctx, lockedKeyPrefix+userID); err != nil {
+		if errors.Is(err, domainerrors.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get lockout: %w", err)
+	}
+	return true, nil
+}
+
+// RegisterFailure records a failed password attempt and applies the delay or
+// lockout it earns.
+func (s *Service) RegisterFailure(ctx context.Context, user *model.User) error {
+	failures, err := s.cache.Incr(ctx, failuresKeyPrefix+user.ID, s.cfg.FailureWindow)
+	if err != nil {
+		return fmt.Errorf("count login failure: %w", err)
+	}
+
+	if s.cfg.MaxFailures > 0 && failures >= int64(s.cfg.MaxFailures) {
+		return s.lock(ctx, user, failures)
+	}
+
+	if delay := s.delay(failures); delay > 0 {
+		if err = s.cache.Set(ctx, lockedKeyPrefix+user.ID, "delay", delay); err != nil {
+			return fmt.Errorf("save login delay: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reset forgets failed attempts and lifts any delay or lockout.
+func (s *Service) Reset(ctx context.Context, userID string) error {
+	if err := s.cache.Delete(ctx, failuresKeyPrefix+userID); err != nil {
+		return fmt.Errorf("delete login failures: %w", err)
+	}
+	if err := s.cache.Delete(ctx, lockedKeyPrefix+userID); err != nil {
+		return fmt.Errorf("delete lockout: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Unlock(ctx context.Context, userID string) error {
+	if err := s.Reset(ctx, userID); err != nil {
+		return err
+	}
+	s.log.Info("account unlocked", zap.String("user_id", userID))
+	return nil
+}
+
+func (s *Service) lock(ctx context.Context, user *model.User, failures int64) error {
+	if err := s.cache.Set(ctx, lockedKeyPrefix+user.ID, "locked", s.cfg.LockDuration); err != nil {
+		return fmt.Errorf("save lockout: %w", err)
+	}
+
+	s.log.Warn("account locked after failed logins",
+		zap.String("user_id", user.ID),
+		zap.Int64("failures", failures),
+	)
+
+	// Failures past MaxFailures relock an account already alerted about;
+	// alerting each time would let an attacker flood the owner's inbox.
+	if failures > int64(s.cfg.MaxFailures) {
+		return nil
+	}
+	if err := s.email.SendLockoutAlertEmail(ctx, user.Email, time.Now().Add(s.cfg.LockDuration)); err != nil {
+		s.log.Error("failed to send lockout alert email",
+			zap.Error(err),
+			zap.String("user_id", user.ID),
+		)
+	}
+	return nil
+}
+
+func (s *Service) delay(failures int64) time.Duration {
+	if s.cfg.BaseDelay <= 0 || failures <= int64(s.cfg.DelayAfter) {
+		return 0
+	}
+	delay := s.cfg.BaseDelay
+	for i := int64(s.cfg.DelayAfter) + 1; i < failures; i++ {
+		delay *= 2
+		if s.cfg.MaxDelay > 0 && delay >= s.cfg.MaxDelay {
+			return s.cfg.MaxDelay
+		}
+	}
+	return delay
+}
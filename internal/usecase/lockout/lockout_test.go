@@ -0,0 +1,141 @@
+package lockout
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/lockout/mocks"
+)
+
+func testConfig() *Config {
+	return &Config{
+		MaxFailures:   5,
+		DelayAfter:    2,
+		BaseDelay:     time.Second,
+		MaxDelay:      3 * time.Second,
+		LockDuration:  30 * time.Minute,
+		FailureWindow: time.Hour,
+	}
+}
+
+func TestService_IsLocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		getErr  error
+		want    bool
+		wantErr string
+	}{
+		{name: "locked", want: true},
+		{name: "not locked", getErr: domainerrors.ErrKeyNotFound},
+		{name: "store error", getErr: errors.New("redis down"), wantErr: "get lockout: redis down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := mocks.NewCacheStore(t)
+			cs.EXPECT().Get(mock.Anything, "lockout:locked:user-uuid").Return("locked", tt.getErr)
+
+			svc := New(cs, mocks.NewEmailSender(t), testConfig(), zap.NewNop())
+			got, err := svc.IsLocked(t.Context(), "user-uuid")
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestService_RegisterFailure(t *testing.T) {
+	user := &model.User{ID: "user-uuid", Email: "user@example.com"}
+
+	tests := []struct {
+		name      string
+		failures  int64
+		setupMock func(cs *mocks.CacheStore, es *mocks.EmailSender)
+		wantErr   string
+	}{
+		{
+			name:      "below delay threshold",
+			failures:  2,
+			setupMock: func(_ *mocks.CacheStore, _ *mocks.EmailSender) {},
+		},
+		{
+			name:     "first delayed failure",
+			failures: 3,
+			setupMock: func(cs *mocks.CacheStore, _ *mocks.EmailSender) {
+				cs.EXPECT().Set(mock.Anything, "lockout:locked:user-uuid", "delay", time.Second).Return(nil)
+			},
+		},
+		{
+			name:     "delay doubles up to max",
+			failures: 4,
+			setupMock: func(cs *mocks.CacheStore, _ *mocks.EmailSender) {
+				cs.EXPECT().Set(mock.Anything, "lockout:locked:user-uuid", "delay", 2*time.Second).Return(nil)
+			},
+		},
+		{
+			name:     "max failures locks and alerts",
+			failures: 5,
+			setupMock: func(cs *mocks.CacheStore, es *mocks.EmailSender) {
+				cs.EXPECT().Set(mock.Anything, "lockout:locked:user-uuid", "locked", 30*time.Minute).Return(nil)
+				es.EXPECT().SendLockoutAlertEmail(mock.Anything, "user@example.com", mock.Anything).
+					Return(errors.New("smtp down"))
+			},
+		},
+		{
+			name:     "relock after expiry does not alert again",
+			failures: 6,
+			setupMock: func(cs *mocks.CacheStore, _ *mocks.EmailSender) {
+				cs.EXPECT().Set(mock.Anything, "lockout:locked:user-uuid", "locked", 30*time.Minute).Return(nil)
+			},
+		},
+		{
+			name:     "lock store error",
+			failures: 6,
+			setupMock: func(cs *mocks.CacheStore, _ *mocks.EmailSender) {
+				cs.EXPECT().Set(mock.Anything, "lockout:locked:user-uuid", "locked", 30*time.Minute).
+					Return(errors.New("redis down"))
+			},
+			wantErr: "save lockout: redis down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := mocks.NewCacheStore(t)
+			es := mocks.NewEmailSender(t)
+			cs.EXPECT().Incr(mock.Anything, "lockout:failures:user-uuid", time.Hour).Return(tt.failures, nil)
+			tt.setupMock(cs, es)
+
+			svc := New(cs, es, testConfig(), zap.NewNop())
+			err := svc.RegisterFailure(t.Context(), user)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_Unlock(t *testing.T) {
+	cs := mocks.NewCacheStore(t)
+	cs.EXPECT().Delete(mock.Anything, "lockout:failures:user-uuid").Return(nil)
+	cs.EXPECT().Delete(mock.Anything, "lockout:locked:user-uuid").Return(nil)
+
+	svc := New(cs, mocks.NewEmailSender(t), testConfig(), zap.NewNop())
+
+	require.NoError(t, svc.Unlock(t.Context(), "user-uuid"))
+}
@@ -0,0 +1,206 @@
+package magiclink
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+const (
+	linkKeyPrefix = "magic:"
+	linkTokenLen  = 32
+)
+
+type UserRepository interface {
+	GetByEmail(ctx context.Context, tenantID, email string) (*model.User, error)
+	UpdateEmailVerified(ctx context.Context, userID string, verified bool) error
+}
+
+type CacheStore interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	GetDel(ctx context.Context, key string) (string, error)
+}
+
+type EmailSender interface {
+	// SendMagicLinkEmail emails a sign-in link below linkBase; see
+	// RequestLink.
+	SendMagicLinkEmail(ctx context.Context, toEmail, linkBase, token string) error
+}
+
+type TokenIssuer interface {
+	IssueTokenPair(ctx context.Context, userID, clientID string, scopes []string) (*model.TokenPair, error)
+}
+
+// Directories tells which accounts an external directory authenticates.
+// Only the directory knows whether such an account is still enabled, so
+// they cannot sign in with a link.
+type Directories interface {
+	Handles(tenantID, email string) bool
+}
+
+type Config struct {
+	TTL           time.Duration
+	BindToBrowser bool
+	SigningKey    string
+}
+
+type Service struct {
+	userRepo UserRepository
+	cache    CacheStore
+	email    EmailSender
+	tokenSvc TokenIssuer
+	dirs     Directories
+	cfg      *Config
+	log      *zap.Logger
+}
+
+func New(
+	ur UserRepository,
+	cs CacheStore,
+	es EmailSender,
+	ts TokenIssuer,
+	dirs Directories,
+	cfg *Config,
+	log *zap.Logger,
+) *Service {
+	return &Service{
+		userRepo: ur,
+		cache:    cs,
+		email:    es,
+		tokenSvc: ts,
+		dirs:     dirs,
+		cfg:      cfg,
+		log:      log,
+	}
+}
+
+// link is what a pending magic link resolves to. BindingHash is set when the
+// link may only be consumed by the browser that requested it.
+type link struct {
+	TenantID    string `json:"tenant_id"`
+	Email       string `json:"email"`
+	BindingHash string `json:"binding_hash,omitempty"`
+}
+
+// RequestLink emails a single-use sign-in link for the account with email in
+// the tenant. It reports success for unknown, inactive and directory accounts
+// too, so the response never reveals whether an email is registered. binding is an opaque
+// per-browser value that must be presented again when the link is consumed.
+// linkBase is where the tenant's API is served: a path below the deployment's
+// base URL, an absolute URL, or "" for the base URL itself.
+func (s *Service) RequestLink(ctx context.Context, tenantID, email, binding, linkBase string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if s.dirs.Handles(tenantID, email) {
+		s.log.Info("magic link requested for directory account", zap.String("email", email))
+		return nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, tenantID, email)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) {
+			s.log.Info("magic link requested for non-existent email", zap.String("email", email))
+			return nil
+		}
+		return fmt.Errorf("get user by email: %w", err)
+	}
+	if user.Status != model.UserStatusActive {
+		s.log.Info("magic link requested for inactive user", zap.String("user_id", user.ID))
+		return nil
+	}
+
+	raw, err := crypto.GenerateRandomToken(linkTokenLen)
+	if err != nil {
+		return fmt.Errorf("generate magic link token: %w", err)
+	}
+
+	l := link{TenantID: user.TenantID, Email: user.Email}
+	if s.cfg.BindToBrowser && binding != "" {
+		l.BindingHash = crypto.HashToken(binding)
+	}
+	payload, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("encode magic link: %w", err)
+	}
+
+	if err = s.cache.Set(ctx, linkKeyPrefix+crypto.HashToken(raw), string(payload), s.cfg.TTL); err != nil {
+		return fmt.Errorf("save magic link: %w", err)
+	}
+
+	if err = s.email.SendMagicLinkEmail(ctx, user.Email, linkBase, crypto.SignToken(s.cfg.SigningKey, raw)); err != nil {
+		s.log.Error("failed to send magic link email",
+			zap.Error(err),
+			zap.String("user_id", user.ID),
+		)
+	}
+	s.log.Info("magic link requested", zap.String("user_id", user.ID))
+	return nil
+}
+
+// ConsumeLink exchanges a magic link for a token pair. The link is deleted on
+// first use, including uses that fail the browser binding check, so a
+// forwarded link cannot be retried from the original browser either.
+func (s *Service) ConsumeLink(ctx context.Context, token, binding string) (*model.TokenPair, error) {
+	raw, ok := crypto.VerifySignedToken(s.cfg.SigningKey, token)
+	if !ok {
+		return nil, domainerrors.ErrInvalidMagicLink
+	}
+
+	payload, err := s.cache.GetDel(ctx, linkKeyPrefix+crypto.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrKeyNotFound) {
+			return nil, domainerrors.ErrInvalidMagicLink
+		}
+		return nil, fmt.Errorf("get magic link: %w", err)
+	}
+
+	var l link
+	if err = json.Unmarshal([]byte(payload), &l); err != nil {
+		return nil, fmt.Errorf("decode magic link: %w", err)
+	}
+
+	if l.BindingHash != "" &&
+		subtle.ConstantTimeCompare([]byte(l.BindingHash), []byte(crypto.HashToken(binding))) != 1 {
+		s.log.Warn("magic link consumed from a different browser", zap.String("email", l.Email))
+		return nil, domainerrors.ErrInvalidMagicLink
+	}
+	// The directory may have been configured since the link was sent.
+	if s.dirs.Handles(l.TenantID, l.Email) {
+		return nil, domainerrors.ErrInvalidMagicLink
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, l.TenantID, l.Email)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrUserNotFound) {
+			return nil, domainerrors.ErrInvalidMagicLink
+		}
+		return nil, fmt.Errorf("get user by email: %w", err)
+	}
+	if user.Status != model.UserStatusActive {
+		return nil, domainerrors.ErrInvalidCredentials
+	}
+
+	// Following the link proves control of the mailbox.
+	if !user.EmailVerified {
+		if err = s.userRepo.UpdateEmailVerified(ctx, user.ID, true); err != nil {
+			return nil, fmt.Errorf("update email verified: %w", err)
+		}
+	}
+
+	pair, err := s.tokenSvc.IssueTokenPair(ctx, user.ID, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("issue token pair: %w", err)
+	}
+
+	s.log.Info("user logged in with magic link", zap.String("user_id", user.ID))
+	return pair, nil
+}
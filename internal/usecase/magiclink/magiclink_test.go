@@ -0,0 +1,281 @@
+package magiclink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/magiclink/mocks"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+const testSigningKey = "test-signing-key"
+
+type testDeps struct {
+	ur *mocks.UserRepository
+	cs *mocks.CacheStore
+	es *mocks.EmailSender
+	ti *mocks.TokenIssuer
+}
+
+// corpDirectory authenticates the emails of corp.example.
+type corpDirectory struct{}
+
+func (corpDirectory) Handles(_, email string) bool {
+	return strings.HasSuffix(email, "@corp.example")
+}
+
+func newTestService(t *testing.T, bind bool) (*Service, *testDeps) {
+	t.Helper()
+	d := &testDeps{
+		ur: mocks.NewUserRepository(t),
+		cs: mocks.NewCacheStore(t),
+		es: mocks.NewEmailSender(t),
+		ti: mocks.NewTokenIssuer(t),
+	}
+	svc := New(d.ur, d.cs, d.es, d.ti, corpDirectory{}, &Config{
+		TTL:           15 * time.Minute,
+		BindToBrowser: bind,
+		SigningKey:    testSigningKey,
+	}, zap.NewNop())
+	return svc, d
+}
+
+func activeUser() *model.User {
+	return &model.User{
+		ID:            "user-uuid",
+		TenantID:      "tenant-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		Status:        model.UserStatusActive,
+	}
+}
+
+func TestService_RequestLink(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		name      string
+		email     string
+		bind      bool
+		setupMock func(d *testDeps)
+		wantErr   error
+	}{
+		{
+			name:  "sends bound link to existing user",
+			email: "  User@Example.com ",
+			bind:  true,
+			setupMock: func(d *testDeps) {
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(activeUser(), nil)
+				d.cs.EXPECT().Set(mock.Anything, mock.MatchedBy(func(key string) bool {
+					return len(key) > len(linkKeyPrefix) && key[:len(linkKeyPrefix)] == linkKeyPrefix
+				}), mock.MatchedBy(func(v string) bool {
+					var l link
+					return json.Unmarshal([]byte(v), &l) == nil &&
+						l.Email == "user@example.com" &&
+						l.BindingHash == crypto.HashToken("browser-nonce")
+				}), 15*time.Minute).Return(nil)
+				d.es.EXPECT().SendMagicLinkEmail(mock.Anything, "user@example.com", "", mock.MatchedBy(func(tok string) bool {
+					_, ok := crypto.VerifySignedToken(testSigningKey, tok)
+					return ok
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "binding not stored when disabled",
+			email: "user@example.com",
+			setupMock: func(d *testDeps) {
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(activeUser(), nil)
+				d.cs.EXPECT().Set(mock.Anything, mock.Anything, mock.MatchedBy(func(v string) bool {
+					var l link
+					return json.Unmarshal([]byte(v), &l) == nil && l.BindingHash == ""
+				}), mock.Anything).Return(nil)
+				d.es.EXPECT().SendMagicLinkEmail(mock.Anything, "user@example.com", "", mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:  "unknown email looks like success",
+			email: "nobody@example.com",
+			setupMock: func(d *testDeps) {
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "nobody@example.com").Return(nil, domainerrors.ErrUserNotFound)
+			},
+		},
+		{
+			name:  "blocked user looks like success",
+			email: "user@example.com",
+			setupMock: func(d *testDeps) {
+				blocked := activeUser()
+				blocked.Status = model.UserStatusBlocked
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(blocked, nil)
+			},
+		},
+		{
+			name:      "directory account gets no link",
+			email:     "jane@corp.example",
+			setupMock: func(_ *testDeps) {},
+		},
+		{
+			name:  "email send failure is not reported",
+			email: "user@example.com",
+			setupMock: func(d *testDeps) {
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(activeUser(), nil)
+				d.cs.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				d.es.EXPECT().SendMagicLinkEmail(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, d := newTestService(t, tt.bind)
+			tt.setupMock(d)
+
+			err := svc.RequestLink(ctx, "tenant-1", tt.email, "browser-nonce", "")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_ConsumeLink(t *testing.T) {
+	ctx := t.Context()
+
+	raw := "raw-link-token"
+	signed := crypto.SignToken(testSigningKey, raw)
+	key := linkKeyPrefix + crypto.HashToken(raw)
+	pair := &model.TokenPair{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 900}
+
+	boundLink := `{"tenant_id":"tenant-1","email":"user@example.com","binding_hash":"` + crypto.HashToken("browser-nonce") + `"}`
+
+	tests := []struct {
+		name      string
+		token     string
+		binding   string
+		setupMock func(d *testDeps)
+		wantErr   error
+	}{
+		{
+			name:    "bound link from same browser",
+			token:   signed,
+			binding: "browser-nonce",
+			setupMock: func(d *testDeps) {
+				d.cs.EXPECT().GetDel(mock.Anything, key).Return(boundLink, nil)
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(activeUser(), nil)
+				d.ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).Return(pair, nil)
+			},
+		},
+		{
+			name:    "bound link from another browser",
+			token:   signed,
+			binding: "other-nonce",
+			setupMock: func(d *testDeps) {
+				d.cs.EXPECT().GetDel(mock.Anything, key).Return(boundLink, nil)
+			},
+			wantErr: domainerrors.ErrInvalidMagicLink,
+		},
+		{
+			name:  "unbound link marks email verified",
+			token: signed,
+			setupMock: func(d *testDeps) {
+				unverified := activeUser()
+				unverified.EmailVerified = false
+				d.cs.EXPECT().GetDel(mock.Anything, key).Return(`{"tenant_id":"tenant-1","email":"user@example.com"}`, nil)
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(unverified, nil)
+				d.ur.EXPECT().UpdateEmailVerified(mock.Anything, "user-uuid", true).Return(nil)
+				d.ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).Return(pair, nil)
+			},
+		},
+		{
+			name:      "forged signature",
+			token:     raw + ".forged",
+			setupMock: func(_ *testDeps) {},
+			wantErr:   domainerrors.ErrInvalidMagicLink,
+		},
+		{
+			name:  "already used or expired",
+			token: signed,
+			setupMock: func(d *testDeps) {
+				d.cs.EXPECT().GetDel(mock.Anything, key).Return("", domainerrors.ErrKeyNotFound)
+			},
+			wantErr: domainerrors.ErrInvalidMagicLink,
+		},
+		{
+			name:  "directory configured since request",
+			token: signed,
+			setupMock: func(d *testDeps) {
+				d.cs.EXPECT().GetDel(mock.Anything, key).Return(`{"tenant_id":"tenant-1","email":"jane@corp.example"}`, nil)
+			},
+			wantErr: domainerrors.ErrInvalidMagicLink,
+		},
+		{
+			name:  "user blocked since request",
+			token: signed,
+			setupMock: func(d *testDeps) {
+				blocked := activeUser()
+				blocked.Status = model.UserStatusBlocked
+				d.cs.EXPECT().GetDel(mock.Anything, key).Return(`{"tenant_id":"tenant-1","email":"user@example.com"}`, nil)
+				d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(blocked, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, d := newTestService(t, true)
+			tt.setupMock(d)
+
+			got, err := svc.ConsumeLink(ctx, tt.token, tt.binding)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, pair, got)
+		})
+	}
+}
+
+func TestService_ConsumeLink_RoundTrip(t *testing.T) {
+	svc, d := newTestService(t, true)
+
+	var stored, sent string
+	d.ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").Return(activeUser(), nil)
+	d.cs.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(_ context.Context, key, value string, _ time.Duration) { stored = key + "=" + value }).
+		Return(nil)
+	d.es.EXPECT().SendMagicLinkEmail(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(_ context.Context, _, _, token string) { sent = token }).
+		Return(nil)
+
+	require.NoError(t, svc.RequestLink(t.Context(), "tenant-1", "user@example.com", "browser-nonce", ""))
+
+	raw, ok := crypto.VerifySignedToken(testSigningKey, sent)
+	require.True(t, ok)
+	key := linkKeyPrefix + crypto.HashToken(raw)
+	require.Contains(t, stored, key+"=")
+
+	d.cs.EXPECT().GetDel(mock.Anything, key).Return(stored[len(key)+1:], nil)
+	d.ti.EXPECT().IssueTokenPair(mock.Anything, "user-uuid", "", mock.Anything).
+		Return(&model.TokenPair{AccessToken: "access"}, nil)
+
+	pair, err := svc.ConsumeLink(t.Context(), sent, "browser-nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "access", pair.AccessToken)
+}
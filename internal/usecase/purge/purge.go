@@ -0,0 +1,98 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+type UserPurger interface {
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) ([]*model.User, error)
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event *model.Event) error
+}
+
+// Config controls the purge of deleted accounts. Accounts are purged once
+// GracePeriod has passed since their deletion was requested.
+type Config struct {
+	GracePeriod time.Duration
+	Interval    time.Duration
+	BatchSize   int
+}
+
+// Service hard-deletes accounts whose deletion grace period has ended.
+type Service struct {
+	users  UserPurger
+	events EventPublisher
+	cfg    *Config
+	log    *zap.Logger
+}
+
+func New(up UserPurger, ep EventPublisher, cfg *Config, log *zap.Logger) *Service {
+	return &Service{
+		users:  up,
+		events: ep,
+		cfg:    cfg,
+		log:    log,
+	}
+}
+
+// Run purges expired accounts every Interval until ctx ends.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeExpired(ctx); err != nil {
+			s.log.Error("failed to purge deleted accounts", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired purges every account past its grace period, a batch at a time,
+// and returns how many were purged. The purge drops the user's earlier
+// events; a user.deleted event is then published for each so that downstream
+// services can erase their copies.
+func (s *Service) PurgeExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.cfg.GracePeriod)
+	total := 0
+
+	for {
+		users, err := s.users.PurgeDeleted(ctx, cutoff, s.cfg.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("purge deleted users: %w", err)
+		}
+		total += len(users)
+
+		for _, u := range users {
+			if err = s.events.Publish(ctx, model.NewEvent(model.EventUserDeleted, u.TenantID, u.ID)); err != nil {
+				s.log.Error("failed to publish event",
+					zap.Error(err),
+					zap.String("type", string(model.EventUserDeleted)),
+					zap.String("user_id", u.ID),
+				)
+			}
+		}
+
+		if len(users) < s.cfg.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.log.Info("deleted accounts purged", zap.Int("count", total))
+	}
+	return total, nil
+}
@@ -0,0 +1,64 @@
+package purge
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/purge/mocks"
+)
+
+func deletedEvent(userID string) any {
+	return mock.MatchedBy(func(e *model.Event) bool {
+		return e.Type == model.EventUserDeleted && e.TenantID == "tenant-1" && e.UserID == userID
+	})
+}
+
+func purged(ids ...string) []*model.User {
+	users := make([]*model.User, len(ids))
+	for i, id := range ids {
+		users[i] = &model.User{ID: id, TenantID: "tenant-1"}
+	}
+	return users
+}
+
+func TestService_PurgeExpired(t *testing.T) {
+	ctx := t.Context()
+	grace := 30 * 24 * time.Hour
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= grace && time.Since(before) < grace+time.Minute
+	})
+
+	t.Run("purges in batches and publishes events", func(t *testing.T) {
+		up := mocks.NewUserPurger(t)
+		up.EXPECT().PurgeDeleted(mock.Anything, cutoff, 2).Return(purged("u1", "u2"), nil).Once()
+		up.EXPECT().PurgeDeleted(mock.Anything, cutoff, 2).Return(purged("u3"), nil).Once()
+		ep := mocks.NewEventPublisher(t)
+		ep.EXPECT().Publish(mock.Anything, deletedEvent("u1")).Return(nil)
+		ep.EXPECT().Publish(mock.Anything, deletedEvent("u2")).Return(errors.New("db down"))
+		ep.EXPECT().Publish(mock.Anything, deletedEvent("u3")).Return(nil)
+
+		svc := New(up, ep, &Config{GracePeriod: grace, BatchSize: 2}, zap.NewNop())
+		n, err := svc.PurgeExpired(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		up := mocks.NewUserPurger(t)
+		up.EXPECT().PurgeDeleted(mock.Anything, cutoff, 2).Return(nil, errors.New("db down"))
+
+		svc := New(up, mocks.NewEventPublisher(t), &Config{GracePeriod: grace, BatchSize: 2}, zap.NewNop())
+		n, err := svc.PurgeExpired(ctx)
+
+		require.Error(t, err)
+		assert.Zero(t, n)
+	})
+}
@@ -0,0 +1,207 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const maxDescriptionLength = 256
+
+var (
+	roleNamePattern       = regexp.MustCompile(`^[a-z0-9][a-z0-9:._-]{0,63}$`)
+	permissionNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9:._-]{0,127}$`)
+)
+
+type Repository interface {
+	CreateRole(ctx context.Context, role *model.Role) error
+	ListRoles(ctx context.Context) ([]*model.Role, error)
+	GetRole(ctx context.Context, id string) (*model.Role, error)
+	DeleteRole(ctx context.Context, id string) error
+	SetRolePermissions(ctx context.Context, roleID string, permissions []string) error
+	CreatePermission(ctx context.Context, permission *model.Permission) error
+	ListPermissions(ctx context.Context) ([]*model.Permission, error)
+	DeletePermission(ctx context.Context, id string) error
+	ListRolesByUserID(ctx context.Context, userID string) ([]*model.Role, error)
+	AssignRole(ctx context.Context, userID, roleID string) error
+	UnassignRole(ctx context.Context, userID, roleID string) error
+	HasPermission(ctx context.Context, userID, clientID, permission string) (bool, error)
+}
+
+type Service struct {
+	repo Repository
+	log  *zap.Logger
+}
+
+func New(repo Repository, log *zap.Logger) *Service {
+	return &Service{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// CreateRole stores a new role. Permissions are referenced by name and must
+// already exist.
+func (s *Service) CreateRole(ctx context.Context, role *model.Role) error {
+	role.Name = strings.TrimSpace(role.Name)
+	if !roleNamePattern.MatchString(role.Name) {
+		return errors.New("name: must be 1-64 lowercase letters, digits or :._- characters")
+	}
+	if len(role.Description) > maxDescriptionLength {
+		return fmt.Errorf("description: must not exceed %d characters", maxDescriptionLength)
+	}
+	permissions, err := normalizePermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	role.Permissions = permissions
+
+	if err = s.repo.CreateRole(ctx, role); err != nil {
+		return fmt.Errorf("create role: %w", err)
+	}
+
+	s.log.Info("role created", zap.String("role_id", role.ID), zap.String("name", role.Name))
+	return nil
+}
+
+func (s *Service) ListRoles(ctx context.Context) ([]*model.Role, error) {
+	roles, err := s.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// DeleteRole removes the role and its assignments. The global admin role
+// cannot be deleted, otherwise nobody could reach the admin API.
+func (s *Service) DeleteRole(ctx context.Context, id string) error {
+	role, err := s.repo.GetRole(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get role: %w", err)
+	}
+	if role.Name == model.RoleAdmin && role.ClientID == "" {
+		return errors.New("role: the admin role cannot be deleted")
+	}
+
+	if err = s.repo.DeleteRole(ctx, id); err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+
+	s.log.Info("role deleted", zap.String("role_id", id), zap.String("name", role.Name))
+	return nil
+}
+
+// SetRolePermissions replaces the permissions of the role.
+func (s *Service) SetRolePermissions(ctx context.Context, roleID string, permissions []string) error {
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return err
+	}
+
+	if err = s.repo.SetRolePermissions(ctx, roleID, permissions); err != nil {
+		return fmt.Errorf("set role permissions: %w", err)
+	}
+
+	s.log.Info("role permissions updated", zap.String("role_id", roleID), zap.Strings("permissions", permissions))
+	return nil
+}
+
+func (s *Service) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	permission.Name = strings.TrimSpace(permission.Name)
+	if !permissionNamePattern.MatchString(permission.Name) {
+		return errors.New("name: must be 1-128 lowercase letters, digits or :._- characters")
+	}
+	if len(permission.Description) > maxDescriptionLength {
+		return fmt.Errorf("description: must not exceed %d characters", maxDescriptionLength)
+	}
+
+	if err := s.repo.CreatePermission(ctx, permission); err != nil {
+		return fmt.Errorf("create permission: %w", err)
+	}
+
+	s.log.Info("permission created", zap.String("permission_id", permission.ID), zap.String("name", permission.Name))
+	return nil
+}
+
+func (s *Service) ListPermissions(ctx context.Context) ([]*model.Permission, error) {
+	permissions, err := s.repo.ListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// DeletePermission removes the permission from every role that has it.
+func (s *Service) DeletePermission(ctx context.Context, id string) error {
+	if err := s.repo.DeletePermission(ctx, id); err != nil {
+		return fmt.Errorf("delete permission: %w", err)
+	}
+	s.log.Info("permission deleted", zap.String("permission_id", id))
+	return nil
+}
+
+func (s *Service) ListUserRoles(ctx context.Context, userID string) ([]*model.Role, error) {
+	roles, err := s.repo.ListRolesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list user roles: %w", err)
+	}
+	return roles, nil
+}
+
+// AssignRole gives the user the role. The new role shows up in tokens
+// issued from then on.
+func (s *Service) AssignRole(ctx context.Context, userID, roleID string) error {
+	if err := s.repo.AssignRole(ctx, userID, roleID); err != nil {
+		return fmt.Errorf("assign role: %w", err)
+	}
+	s.log.Info("role assigned", zap.String("user_id", userID), zap.String("role_id", roleID))
+	return nil
+}
+
+// UnassignRole takes the role away from the user. Access tokens already
+// issued keep the role until they expire.
+func (s *Service) UnassignRole(ctx context.Context, userID, roleID string) error {
+	if err := s.repo.UnassignRole(ctx, userID, roleID); err != nil {
+		return fmt.Errorf("unassign role: %w", err)
+	}
+	s.log.Info("role unassigned", zap.String("user_id", userID), zap.String("role_id", roleID))
+	return nil
+}
+
+// CheckPermission reports whether the user currently holds permission for
+// clientID. Unlike token claims it reflects assignment changes immediately.
+func (s *Service) CheckPermission(ctx context.Context, userID, clientID, permission string) (bool, error) {
+	if userID == "" {
+		return false, errors.New("user_id: must not be empty")
+	}
+	if permission == "" {
+		return false, errors.New("permission: must not be empty")
+	}
+
+	allowed, err := s.repo.HasPermission(ctx, userID, clientID, permission)
+	if err != nil {
+		return false, fmt.Errorf("has permission: %w", err)
+	}
+	return allowed, nil
+}
+
+// normalizePermissions validates permission names and drops duplicates.
+func normalizePermissions(permissions []string) ([]string, error) {
+	normalized := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if !permissionNamePattern.MatchString(p) {
+			return nil, fmt.Errorf("permissions: invalid permission name %q", p)
+		}
+		normalized = append(normalized, p)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
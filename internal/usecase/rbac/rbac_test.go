@@ -0,0 +1,197 @@
+package rbac
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/rbac/mocks"
+)
+
+func TestService_CreateRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      *model.Role
+		mockSetup func(repo *mocks.Repository)
+		wantErr   string
+	}{
+		{
+			name: "normalizes permissions",
+			role: &model.Role{Name: " editor ", Permissions: []string{"docs:write", "docs:read", "docs:write"}},
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().CreateRole(mock.Anything, &model.Role{
+					Name:        "editor",
+					Permissions: []string{"docs:read", "docs:write"},
+				}).Return(nil)
+			},
+		},
+		{
+			name:      "invalid name",
+			role:      &model.Role{Name: "Editors!"},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "name: must be 1-64 lowercase letters, digits or :._- characters",
+		},
+		{
+			name:      "invalid permission",
+			role:      &model.Role{Name: "editor", Permissions: []string{""}},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   `permissions: invalid permission name ""`,
+		},
+		{
+			name: "duplicate",
+			role: &model.Role{Name: "editor"},
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().CreateRole(mock.Anything, mock.Anything).Return(domainerrors.ErrRoleAlreadyExists)
+			},
+			wantErr: "create role: role already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			err := New(repo, zap.NewNop()).CreateRole(t.Context(), tt.role)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_DeleteRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(repo *mocks.Repository)
+		wantErr   string
+	}{
+		{
+			name: "deletes role",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetRole(mock.Anything, "role-1").Return(&model.Role{ID: "role-1", Name: "editor"}, nil)
+				repo.EXPECT().DeleteRole(mock.Anything, "role-1").Return(nil)
+			},
+		},
+		{
+			name: "client admin role can be deleted",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetRole(mock.Anything, "role-1").
+					Return(&model.Role{ID: "role-1", Name: model.RoleAdmin, ClientID: "client-1"}, nil)
+				repo.EXPECT().DeleteRole(mock.Anything, "role-1").Return(nil)
+			},
+		},
+		{
+			name: "global admin role is protected",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetRole(mock.Anything, "role-1").Return(&model.Role{ID: "role-1", Name: model.RoleAdmin}, nil)
+			},
+			wantErr: "role: the admin role cannot be deleted",
+		},
+		{
+			name: "unknown role",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetRole(mock.Anything, "role-1").Return(nil, domainerrors.ErrRoleNotFound)
+			},
+			wantErr: "get role: role not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			err := New(repo, zap.NewNop()).DeleteRole(t.Context(), "role-1")
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_SetRolePermissions(t *testing.T) {
+	repo := mocks.NewRepository(t)
+	repo.EXPECT().SetRolePermissions(mock.Anything, "role-1", []string{}).Return(nil)
+
+	require.NoError(t, New(repo, zap.NewNop()).SetRolePermissions(t.Context(), "role-1", nil))
+}
+
+func TestService_CreatePermission(t *testing.T) {
+	t.Run("creates permission", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().CreatePermission(mock.Anything, &model.Permission{Name: "docs:write"}).Return(nil)
+
+		require.NoError(t, New(repo, zap.NewNop()).CreatePermission(t.Context(), &model.Permission{Name: "docs:write "}))
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		err := New(mocks.NewRepository(t), zap.NewNop()).CreatePermission(t.Context(), &model.Permission{Name: "Docs Write"})
+
+		require.EqualError(t, err, "name: must be 1-128 lowercase letters, digits or :._- characters")
+	})
+}
+
+func TestService_CheckPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		permission string
+		mockSetup  func(repo *mocks.Repository)
+		want       bool
+		wantErr    string
+	}{
+		{
+			name:       "allowed",
+			userID:     "user-1",
+			permission: "docs:write",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().HasPermission(mock.Anything, "user-1", "client-1", "docs:write").Return(true, nil)
+			},
+			want: true,
+		},
+		{
+			name:       "store error",
+			userID:     "user-1",
+			permission: "docs:write",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().HasPermission(mock.Anything, "user-1", "client-1", "docs:write").Return(false, errors.New("db down"))
+			},
+			wantErr: "has permission: db down",
+		},
+		{
+			name:      "missing permission",
+			userID:    "user-1",
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "permission: must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			got, err := New(repo, zap.NewNop()).CheckPermission(t.Context(), tt.userID, "client-1", tt.permission)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,474 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+const (
+	tokenLength         = 32
+	maxTokenNameLength  = 128
+	maxExternalIDLength = 512
+
+	defaultPageSize = 100
+	maxPageSize     = 200
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	ListUsers(ctx context.Context, filter model.UserFilter, offset, limit int) ([]*model.User, int, error)
+	ReplaceUser(ctx context.Context, user *model.User, unmodifiedSince time.Time) (*model.User, error)
+	UpdatePassword(ctx context.Context, userID, passwordHash string) error
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+type TokenRevoker interface {
+	RevokeByUserID(ctx context.Context, userID string) error
+}
+
+// UserStatusChanger blocks and unblocks users, revoking the sessions of
+// blocked ones.
+type UserStatusChanger interface {
+	BlockUser(ctx context.Context, userID, reason string) error
+	UnblockUser(ctx context.Context, userID string) error
+}
+
+type GroupService interface {
+	CreateGroup(ctx context.Context, group *model.Group) error
+	ListGroups(ctx context.Context, tenantID string) ([]*model.Group, error)
+	GetGroup(ctx context.Context, tenantID, id string) (*model.Group, *model.GroupMembers, error)
+	UpdateGroup(ctx context.Context, tenantID, id, name, description string) (*model.Group, error)
+	DeleteGroup(ctx context.Context, tenantID, id string) error
+	AddMember(ctx context.Context, tenantID, groupID, userID string) error
+	RemoveMember(ctx context.Context, tenantID, groupID, userID string) error
+}
+
+type TokenRepository interface {
+	CreateSCIMToken(ctx context.Context, token *model.SCIMToken) error
+	ListSCIMTokens(ctx context.Context, tenantID string) ([]*model.SCIMToken, error)
+	DeleteSCIMToken(ctx context.Context, tenantID, id string) error
+	UseSCIMToken(ctx context.Context, tokenHash string) (*model.SCIMToken, error)
+}
+
+type PasswordHasher interface {
+	Hash(ctx context.Context, password string) (string, error)
+}
+
+// PasswordPolicy decides whether a provisioned password is acceptable and
+// returns the form it is hashed in.
+type PasswordPolicy interface {
+	Normalize(password string) string
+	Check(password, email string) error
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, event *model.Event) error
+}
+
+// Service provisions the users and groups of a tenant on behalf of SCIM
+// clients such as HR systems and identity providers.
+type Service struct {
+	users  UserRepository
+	revoke TokenRevoker
+	status UserStatusChanger
+	groups GroupService
+	tokens TokenRepository
+	hasher PasswordHasher
+	policy PasswordPolicy
+	events EventPublisher
+	log    *zap.Logger
+}
+
+func New(
+	users UserRepository,
+	revoke TokenRevoker,
+	status UserStatusChanger,
+	groups GroupService,
+	tokens TokenRepository,
+	hasher PasswordHasher,
+	policy PasswordPolicy,
+	events EventPublisher,
+	log *zap.Logger,
+) *Service {
+	return &Service{
+		users:  users,
+		revoke: revoke,
+		status: status,
+		groups: groups,
+		tokens: tokens,
+		hasher: hasher,
+		policy: policy,
+		events: events,
+		log:    log,
+	}
+}
+
+// CreateToken issues a bearer token for a SCIM client of the tenant. The raw
+// token is returned only here.
+func (s *Service) CreateToken(ctx context.Context, tenantID, name string) (*model.SCIMToken, string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return nil, "", errors.New("name: must not be empty")
+	case utf8.RuneCountInString(name) > maxTokenNameLength:
+		return nil, "", fmt.Errorf("name: must not exceed %d characters", maxTokenNameLength)
+	}
+
+	raw, err := crypto.GenerateRandomToken(tokenLength)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate token: %w", err)
+	}
+
+	token := &model.SCIMToken{TenantID: tenantID, Name: name, TokenHash: crypto.HashToken(raw)}
+	if err = s.tokens.CreateSCIMToken(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("create scim token: %w", err)
+	}
+
+	s.log.Info("scim token created", zap.String("tenant_id", tenantID), zap.String("token_id", token.ID))
+	return token, raw, nil
+}
+
+func (s *Service) ListTokens(ctx context.Context, tenantID string) ([]*model.SCIMToken, error) {
+	tokens, err := s.tokens.ListSCIMTokens(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list scim tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *Service) DeleteToken(ctx context.Context, tenantID, id string) error {
+	if err := s.tokens.DeleteSCIMToken(ctx, tenantID, id); err != nil {
+		return fmt.Errorf("delete scim token: %w", err)
+	}
+	s.log.Info("scim token deleted", zap.String("tenant_id", tenantID), zap.String("token_id", id))
+	return nil
+}
+
+// Authenticate returns the SCIM token matching raw, or
+// domainerrors.ErrInvalidToken.
+func (s *Service) Authenticate(ctx context.Context, raw string) (*model.SCIMToken, error) {
+	token, err := s.tokens.UseSCIMToken(ctx, crypto.HashToken(raw))
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrSCIMTokenNotFound) {
+			return nil, domainerrors.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("use scim token: %w", err)
+	}
+	return token, nil
+}
+
+// ListUsers returns the page of the tenant's users matching filter that
+// starts at the 1-based startIndex, and the number of all matching users.
+func (s *Service) ListUsers(
+	ctx context.Context,
+	filter model.UserFilter,
+	startIndex, count int,
+) ([]*model.User, int, error) {
+	offset, limit := page(startIndex, count)
+	filter.Email = strings.ToLower(filter.Email)
+
+	users, total, err := s.users.ListUsers(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list users: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetUser returns the user if it belongs to the tenant.
+func (s *Service) GetUser(ctx context.Context, tenantID, id string) (*model.User, error) {
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+	if user.TenantID != tenantID {
+		return nil, fmt.Errorf("get user by id: %w", domainerrors.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// CreateUser provisions a user. The provisioning client vouches for the
+// email, so it is stored as verified. Without a password the user can only
+// log in through a directory backend, a magic link or a password reset.
+func (s *Service) CreateUser(ctx context.Context, user *model.User, password string) error {
+	if err := validateUser(user); err != nil {
+		return err
+	}
+	if password != "" {
+		hash, err := s.hashPassword(ctx, password, user.Email)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = hash
+	}
+	user.EmailVerified = true
+
+	if err := s.users.Create(ctx, user); err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+
+	s.publish(ctx, model.NewEvent(model.EventUserRegistered, user.TenantID, user.ID))
+	s.log.Info("user provisioned", zap.String("user_id", user.ID), zap.String("tenant_id", user.TenantID))
+	return nil
+}
+
+// ReplaceUser stores the email, external id, profile and status of user and,
+// if password is not empty, sets a new password, signing the user out
+// everywhere. version is the UpdatedAt the client last saw, or zero to skip
+// the check. Moving an active user to UserStatusBlocked revokes their
+// sessions; a user pending self-service deletion already counts as inactive.
+func (s *Service) ReplaceUser(
+	ctx context.Context,
+	user *model.User,
+	password string,
+	version time.Time,
+) (*model.User, error) {
+	current, err := s.GetUser(ctx, user.TenantID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !version.IsZero() && !current.UpdatedAt.Equal(version) {
+		return nil, domainerrors.ErrPreconditionFailed
+	}
+	if err = validateUser(user); err != nil {
+		return nil, err
+	}
+	var hash string
+	if password != "" {
+		if hash, err = s.hashPassword(ctx, password, user.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.Email != current.Email || user.ExternalID != current.ExternalID || user.Profile != current.Profile {
+		if _, err = s.users.ReplaceUser(ctx, user, current.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("replace user: %w", err)
+		}
+	}
+
+	if hash != "" {
+		if err = s.users.UpdatePassword(ctx, user.ID, hash); err != nil {
+			return nil, fmt.Errorf("update password: %w", err)
+		}
+		if err = s.revoke.RevokeByUserID(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("revoke refresh tokens: %w", err)
+		}
+	}
+
+	switch {
+	case user.Status == current.Status:
+	case user.Status == model.UserStatusBlocked && current.Status == model.UserStatusDeleted:
+	case user.Status == model.UserStatusBlocked:
+		if err = s.status.BlockUser(ctx, user.ID, "deactivated by scim client"); err != nil {
+			return nil, fmt.Errorf("block user: %w", err)
+		}
+	case user.Status == model.UserStatusActive:
+		if err = s.status.UnblockUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("unblock user: %w", err)
+		}
+	default:
+		return nil, domainerrors.ErrInvalidUserStatus
+	}
+
+	s.log.Info("user replaced by scim client", zap.String("user_id", user.ID))
+	return s.GetUser(ctx, user.TenantID, user.ID)
+}
+
+// DeleteUser deletes the user at once, without the grace period of
+// self-service deletion.
+func (s *Service) DeleteUser(ctx context.Context, tenantID, id string) error {
+	if _, err := s.GetUser(ctx, tenantID, id); err != nil {
+		return err
+	}
+	if err := s.users.DeleteUser(ctx, id); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	s.publish(ctx, model.NewEvent(model.EventUserDeleted, tenantID, id))
+	s.log.Info("user deprovisioned", zap.String("user_id", id), zap.String("tenant_id", tenantID))
+	return nil
+}
+
+// ListGroups returns the page of the tenant's groups named name, or of all
+// groups when name is empty, and the number of all matching groups.
+func (s *Service) ListGroups(
+	ctx context.Context,
+	tenantID, name string,
+	startIndex, count int,
+) ([]*model.Group, int, error) {
+	groups, err := s.groups.ListGroups(ctx, tenantID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list groups: %w", err)
+	}
+	if name != "" {
+		matching := groups[:0]
+		for _, g := range groups {
+			if strings.EqualFold(g.Name, name) {
+				matching = append(matching, g)
+			}
+		}
+		groups = matching
+	}
+
+	total := len(groups)
+	offset, limit := page(startIndex, count)
+	if offset >= total {
+		return []*model.Group{}, total, nil
+	}
+	return groups[offset:min(offset+limit, total)], total, nil
+}
+
+func (s *Service) GetGroup(ctx context.Context, tenantID, id string) (*model.Group, *model.GroupMembers, error) {
+	group, members, err := s.groups.GetGroup(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get group: %w", err)
+	}
+	return group, members, nil
+}
+
+// CreateGroup creates a group with the given user members, which must belong
+// to the group's tenant.
+func (s *Service) CreateGroup(ctx context.Context, group *model.Group, memberIDs []string) error {
+	if err := s.checkUsers(ctx, group.TenantID, memberIDs); err != nil {
+		return err
+	}
+	if err := s.groups.CreateGroup(ctx, group); err != nil {
+		return fmt.Errorf("create group: %w", err)
+	}
+	return s.updateMembers(ctx, group.TenantID, group.ID, memberIDs, nil)
+}
+
+// ReplaceGroup renames the group and makes memberIDs its exact set of user
+// members.
+func (s *Service) ReplaceGroup(ctx context.Context, tenantID, id, name string, memberIDs []string) error {
+	group, members, err := s.GetGroup(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]bool, len(memberIDs))
+	for _, userID := range memberIDs {
+		want[userID] = true
+	}
+	var add, remove []string
+	for _, userID := range members.UserIDs {
+		if !want[userID] {
+			remove = append(remove, userID)
+		}
+		delete(want, userID)
+	}
+	for _, userID := range memberIDs {
+		if want[userID] {
+			add = append(add, userID)
+			delete(want, userID)
+		}
+	}
+	if err = s.checkUsers(ctx, tenantID, add); err != nil {
+		return err
+	}
+
+	if name != group.Name {
+		if _, err = s.groups.UpdateGroup(ctx, tenantID, id, name, group.Description); err != nil {
+			return fmt.Errorf("update group: %w", err)
+		}
+	}
+	return s.updateMembers(ctx, tenantID, id, add, remove)
+}
+
+// checkUsers reports ErrUserNotFound unless all users belong to the tenant.
+func (s *Service) checkUsers(ctx context.Context, tenantID string, userIDs []string) error {
+	for _, userID := range userIDs {
+		if _, err := s.GetUser(ctx, tenantID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateMembers adds and removes user members of the group. Adding a member
+// twice or removing a non-member is not an error.
+func (s *Service) updateMembers(ctx context.Context, tenantID, id string, add, remove []string) error {
+	for _, userID := range add {
+		if err := s.groups.AddMember(ctx, tenantID, id, userID); err != nil {
+			return fmt.Errorf("add member: %w", err)
+		}
+	}
+	for _, userID := range remove {
+		if err := s.groups.RemoveMember(ctx, tenantID, id, userID); err != nil {
+			return fmt.Errorf("remove member: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) DeleteGroup(ctx context.Context, tenantID, id string) error {
+	if err := s.groups.DeleteGroup(ctx, tenantID, id); err != nil {
+		return fmt.Errorf("delete group: %w", err)
+	}
+	return nil
+}
+
+// publish records an event. The change it describes has already happened, so
+// a failure is logged rather than reported to the caller.
+func (s *Service) publish(ctx context.Context, event *model.Event) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.log.Error("failed to publish event",
+			zap.Error(err),
+			zap.String("type", string(event.Type)),
+			zap.String("user_id", event.UserID),
+		)
+	}
+}
+
+// page converts a 1-based SCIM startIndex and count to an offset and limit.
+// A negative count selects the default page size; zero asks only for the
+// total.
+func page(startIndex, count int) (int, int) {
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 {
+		count = defaultPageSize
+	}
+	return startIndex - 1, min(count, maxPageSize)
+}
+
+func (s *Service) hashPassword(ctx context.Context, password, email string) (string, error) {
+	if err := s.policy.Check(password, email); err != nil {
+		return "", fmt.Errorf("check password: %w", err)
+	}
+	hash, err := s.hasher.Hash(ctx, s.policy.Normalize(password))
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+	return hash, nil
+}
+
+func validateUser(user *model.User) error {
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	user.ExternalID = strings.TrimSpace(user.ExternalID)
+	p := &user.Profile
+	p.DisplayName = strings.TrimSpace(p.DisplayName)
+
+	if user.Email == "" {
+		return errors.New("userName: must not be empty")
+	}
+	if _, err := mail.ParseAddress(user.Email); err != nil {
+		return errors.New("userName: must be an email address")
+	}
+	if len(user.ExternalID) > maxExternalIDLength {
+		return fmt.Errorf("externalId: must not exceed %d characters", maxExternalIDLength)
+	}
+	return p.Validate() //nolint:wrapcheck // validation errors stay unwrapped
+}
@@ -0,0 +1,368 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/scim/mocks"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+type deps struct {
+	users  *mocks.UserRepository
+	revoke *mocks.TokenRevoker
+	status *mocks.UserStatusChanger
+	groups *mocks.GroupService
+	tokens *mocks.TokenRepository
+	hasher *mocks.PasswordHasher
+	policy *mocks.PasswordPolicy
+	events *mocks.EventPublisher
+}
+
+func newDeps(t *testing.T) *deps {
+	return &deps{
+		users:  mocks.NewUserRepository(t),
+		revoke: mocks.NewTokenRevoker(t),
+		status: mocks.NewUserStatusChanger(t),
+		groups: mocks.NewGroupService(t),
+		tokens: mocks.NewTokenRepository(t),
+		hasher: mocks.NewPasswordHasher(t),
+		policy: minLengthPolicy(t),
+		events: mocks.NewEventPublisher(t),
+	}
+}
+
+func (d *deps) service() *Service {
+	return New(d.users, d.revoke, d.status, d.groups, d.tokens, d.hasher, d.policy, d.events, zap.NewNop())
+}
+
+var updatedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func existingUser() *model.User {
+	return &model.User{
+		ID:        "user-1",
+		TenantID:  "tenant-1",
+		Email:     "alice@example.com",
+		Status:    model.UserStatusActive,
+		Profile:   model.Profile{DisplayName: "Alice", AvatarURL: "https://cdn.example.com/a.png"},
+		UpdatedAt: updatedAt,
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	t.Run("known token", func(t *testing.T) {
+		d := newDeps(t)
+		token := &model.SCIMToken{ID: "token-1", TenantID: "tenant-1"}
+		d.tokens.EXPECT().UseSCIMToken(mock.Anything, crypto.HashToken("raw")).Return(token, nil)
+
+		got, err := d.service().Authenticate(t.Context(), "raw")
+
+		require.NoError(t, err)
+		assert.Same(t, token, got)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		d := newDeps(t)
+		d.tokens.EXPECT().UseSCIMToken(mock.Anything, mock.Anything).Return(nil, domainerrors.ErrSCIMTokenNotFound)
+
+		_, err := d.service().Authenticate(t.Context(), "raw")
+
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidToken)
+	})
+}
+
+func TestService_CreateToken(t *testing.T) {
+	d := newDeps(t)
+	var stored *model.SCIMToken
+	d.tokens.EXPECT().CreateSCIMToken(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, token *model.SCIMToken) { stored = token }).Return(nil)
+
+	token, raw, err := d.service().CreateToken(t.Context(), "tenant-1", " Okta ")
+
+	require.NoError(t, err)
+	assert.Same(t, stored, token)
+	assert.Equal(t, "Okta", token.Name)
+	assert.Equal(t, "tenant-1", token.TenantID)
+	assert.Equal(t, crypto.HashToken(raw), token.TokenHash)
+}
+
+func TestService_CreateUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      *model.User
+		password  string
+		mockSetup func(d *deps)
+		wantErr   string
+	}{
+		{
+			name: "provisions a verified user",
+			user: &model.User{TenantID: "tenant-1", Email: " Bob@Example.com ", Status: model.UserStatusActive},
+			mockSetup: func(d *deps) {
+				d.users.EXPECT().Create(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.Email == "bob@example.com" && u.EmailVerified && u.PasswordHash == ""
+				})).Return(nil)
+				d.events.EXPECT().Publish(mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+					return e.Type == model.EventUserRegistered
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "hashes the password",
+			user:     &model.User{TenantID: "tenant-1", Email: "bob@example.com"},
+			password: "correct horse",
+			mockSetup: func(d *deps) {
+				d.hasher.EXPECT().Hash(mock.Anything, "correct horse").Return("hash", nil)
+				d.users.EXPECT().Create(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.PasswordHash == "hash"
+				})).Return(nil)
+				d.events.EXPECT().Publish(mock.Anything, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:      "short password",
+			user:      &model.User{TenantID: "tenant-1", Email: "bob@example.com"},
+			password:  "short",
+			mockSetup: func(_ *deps) {},
+			wantErr:   "check password: password: must be at least 8 characters",
+		},
+		{
+			name:      "userName is not an email",
+			user:      &model.User{TenantID: "tenant-1", Email: "bob"},
+			mockSetup: func(_ *deps) {},
+			wantErr:   "userName: must be an email address",
+		},
+		{
+			name: "unknown timezone",
+			user: &model.User{TenantID: "tenant-1", Email: "bob@example.com",
+				Profile: model.Profile{Timezone: "Mars/Olympus"}},
+			mockSetup: func(_ *deps) {},
+			wantErr:   "timezone: unknown time zone",
+		},
+		{
+			name: "duplicate email",
+			user: &model.User{TenantID: "tenant-1", Email: "bob@example.com"},
+			mockSetup: func(d *deps) {
+				d.users.EXPECT().Create(mock.Anything, mock.Anything).Return(domainerrors.ErrEmailAlreadyExists)
+			},
+			wantErr: "create user: email already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDeps(t)
+			tt.mockSetup(d)
+
+			err := d.service().CreateUser(t.Context(), tt.user, tt.password)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_ReplaceUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(u *model.User)
+		password  string
+		version   time.Time
+		current   func(u *model.User)
+		mockSetup func(d *deps)
+		wantErr   error
+	}{
+		{
+			name:      "nothing changed",
+			change:    func(_ *model.User) {},
+			mockSetup: func(_ *deps) {},
+		},
+		{
+			name:    "stores changed attributes",
+			change:  func(u *model.User) { u.Profile.DisplayName = "Alice Smith" },
+			version: updatedAt,
+			mockSetup: func(d *deps) {
+				d.users.EXPECT().ReplaceUser(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+					return u.Profile.DisplayName == "Alice Smith" && u.Profile.AvatarURL != ""
+				}), updatedAt).Return(existingUser(), nil)
+			},
+		},
+		{
+			name:      "stale version",
+			change:    func(u *model.User) { u.Profile.DisplayName = "Alice Smith" },
+			version:   updatedAt.Add(-time.Second),
+			mockSetup: func(_ *deps) {},
+			wantErr:   domainerrors.ErrPreconditionFailed,
+		},
+		{
+			name:     "new password signs the user out",
+			change:   func(_ *model.User) {},
+			password: "correct horse",
+			mockSetup: func(d *deps) {
+				d.hasher.EXPECT().Hash(mock.Anything, "correct horse").Return("hash", nil)
+				d.users.EXPECT().UpdatePassword(mock.Anything, "user-1", "hash").Return(nil)
+				d.revoke.EXPECT().RevokeByUserID(mock.Anything, "user-1").Return(nil)
+			},
+		},
+		{
+			name:   "deactivation blocks the user",
+			change: func(u *model.User) { u.Status = model.UserStatusBlocked },
+			mockSetup: func(d *deps) {
+				d.status.EXPECT().BlockUser(mock.Anything, "user-1", "deactivated by scim client").Return(nil)
+			},
+		},
+		{
+			name:    "activation unblocks the user",
+			change:  func(u *model.User) { u.Status = model.UserStatusActive },
+			current: func(u *model.User) { u.Status = model.UserStatusBlocked },
+			mockSetup: func(d *deps) {
+				d.status.EXPECT().UnblockUser(mock.Anything, "user-1").Return(nil)
+			},
+		},
+		{
+			name:      "deactivating a user pending deletion",
+			change:    func(u *model.User) { u.Status = model.UserStatusBlocked },
+			current:   func(u *model.User) { u.Status = model.UserStatusDeleted },
+			mockSetup: func(_ *deps) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDeps(t)
+			current := existingUser()
+			if tt.current != nil {
+				tt.current(current)
+			}
+			d.users.EXPECT().GetByID(mock.Anything, "user-1").Return(current, nil)
+			tt.mockSetup(d)
+
+			user := *current
+			tt.change(&user)
+			got, err := d.service().ReplaceUser(t.Context(), &user, tt.password, tt.version)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, current, got)
+		})
+	}
+}
+
+func TestService_GetUser_OtherTenant(t *testing.T) {
+	d := newDeps(t)
+	d.users.EXPECT().GetByID(mock.Anything, "user-1").Return(existingUser(), nil)
+
+	_, err := d.service().GetUser(t.Context(), "tenant-2", "user-1")
+
+	assert.ErrorIs(t, err, domainerrors.ErrUserNotFound)
+}
+
+func TestService_ReplaceGroup(t *testing.T) {
+	group := &model.Group{ID: "group-1", TenantID: "tenant-1", Name: "Engineering"}
+	members := &model.GroupMembers{UserIDs: []string{"user-1", "user-2"}}
+
+	t.Run("applies the member diff", func(t *testing.T) {
+		d := newDeps(t)
+		d.groups.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(group, members, nil)
+		d.users.EXPECT().GetByID(mock.Anything, "user-3").
+			Return(&model.User{ID: "user-3", TenantID: "tenant-1"}, nil)
+		d.groups.EXPECT().UpdateGroup(mock.Anything, "tenant-1", "group-1", "Platform", "").Return(group, nil)
+		d.groups.EXPECT().AddMember(mock.Anything, "tenant-1", "group-1", "user-3").Return(nil)
+		d.groups.EXPECT().RemoveMember(mock.Anything, "tenant-1", "group-1", "user-1").Return(nil)
+
+		err := d.service().ReplaceGroup(t.Context(), "tenant-1", "group-1", "Platform",
+			[]string{"user-2", "user-3", "user-3"})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("member of another tenant", func(t *testing.T) {
+		d := newDeps(t)
+		d.groups.EXPECT().GetGroup(mock.Anything, "tenant-1", "group-1").Return(group, members, nil)
+		d.users.EXPECT().GetByID(mock.Anything, "user-3").
+			Return(&model.User{ID: "user-3", TenantID: "tenant-2"}, nil)
+
+		err := d.service().ReplaceGroup(t.Context(), "tenant-1", "group-1", "Platform", []string{"user-3"})
+
+		assert.ErrorIs(t, err, domainerrors.ErrUserNotFound)
+	})
+}
+
+func TestService_ListGroups(t *testing.T) {
+	groups := []*model.Group{{ID: "g1", Name: "Admins"}, {ID: "g2", Name: "Engineering"}, {ID: "g3", Name: "Sales"}}
+
+	tests := []struct {
+		name       string
+		filter     string
+		startIndex int
+		count      int
+		wantIDs    []string
+		wantTotal  int
+	}{
+		{name: "all", startIndex: 1, count: -1, wantIDs: []string{"g1", "g2", "g3"}, wantTotal: 3},
+		{name: "page", startIndex: 2, count: 1, wantIDs: []string{"g2"}, wantTotal: 3},
+		{name: "past the end", startIndex: 5, count: 10, wantIDs: nil, wantTotal: 3},
+		{name: "total only", startIndex: 1, count: 0, wantIDs: nil, wantTotal: 3},
+		{name: "by name", filter: "engineering", startIndex: 1, count: -1, wantIDs: []string{"g2"}, wantTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDeps(t)
+			d.groups.EXPECT().ListGroups(mock.Anything, "tenant-1").
+				Return(append([]*model.Group(nil), groups...), nil)
+
+			got, total, err := d.service().ListGroups(t.Context(), "tenant-1", tt.filter, tt.startIndex, tt.count)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			var ids []string
+			for _, g := range got {
+				ids = append(ids, g.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestService_DeleteUser(t *testing.T) {
+	d := newDeps(t)
+	d.users.EXPECT().GetByID(mock.Anything, "user-1").Return(existingUser(), nil)
+	d.users.EXPECT().DeleteUser(mock.Anything, "user-1").Return(nil)
+	d.events.EXPECT().Publish(mock.Anything, mock.Anything).Return(errors.New("queue down"))
+
+	err := d.service().DeleteUser(t.Context(), "tenant-1", "user-1")
+
+	require.NoError(t, err)
+}
+
+// minLengthPolicy only rejects passwords shorter than 8 characters and leaves
+// passwords as typed.
+func minLengthPolicy(t *testing.T) *mocks.PasswordPolicy {
+	p := mocks.NewPasswordPolicy(t)
+	p.EXPECT().Check(mock.Anything, mock.Anything).RunAndReturn(func(password, _ string) error {
+		if utf8.RuneCountInString(password) < 8 {
+			return &domainerrors.PasswordPolicyError{Violations: []domainerrors.PasswordViolation{
+				{Code: "too_short", Message: "must be at least 8 characters"},
+			}}
+		}
+		return nil
+	}).Maybe()
+	p.EXPECT().Normalize(mock.Anything).RunAndReturn(func(password string) string { return password }).Maybe()
+	return p
+}
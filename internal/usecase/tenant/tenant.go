@@ -0,0 +1,179 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	maxNameLength        = 128
+	maxDisplayNameLength = 128
+	maxLogoURLLength     = 2048
+)
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	hostPattern  = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]{0,251}[a-z0-9])?$`)
+	colorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+)
+
+type Repository interface {
+	CreateTenant(ctx context.Context, tenant *model.Tenant) error
+	ListTenants(ctx context.Context) ([]*model.Tenant, error)
+	GetTenant(ctx context.Context, id string) (*model.Tenant, error)
+	GetTenantBySlug(ctx context.Context, slug string) (*model.Tenant, error)
+	GetTenantByHost(ctx context.Context, host string) (*model.Tenant, error)
+	UpdateTenant(ctx context.Context, tenant *model.Tenant) error
+}
+
+type Service struct {
+	repo Repository
+	log  *zap.Logger
+}
+
+func New(repo Repository, log *zap.Logger) *Service {
+	return &Service{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// CreateTenant validates and stores a new tenant. The slug becomes part of
+// the tenant's issuer URL and cannot be changed later.
+func (s *Service) CreateTenant(ctx context.Context, tenant *model.Tenant) error {
+	tenant.Slug = strings.ToLower(strings.TrimSpace(tenant.Slug))
+	if !slugPattern.MatchString(tenant.Slug) {
+		return errors.New("slug: must be 1-63 lowercase letters, digits or hyphens, not starting or ending with a hyphen")
+	}
+	if err := validateTenant(tenant); err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateTenant(ctx, tenant); err != nil {
+		return fmt.Errorf("create tenant: %w", err)
+	}
+
+	s.log.Info("tenant created", zap.String("tenant_id", tenant.ID), zap.String("slug", tenant.Slug))
+	return nil
+}
+
+func (s *Service) ListTenants(ctx context.Context) ([]*model.Tenant, error) {
+	tenants, err := s.repo.ListTenants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list tenants: %w", err)
+	}
+	return tenants, nil
+}
+
+func (s *Service) GetTenant(ctx context.Context, id string) (*model.Tenant, error) {
+	tenant, err := s.repo.GetTenant(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+// UpdateTenant replaces the name, host and branding of the tenant.
+func (s *Service) UpdateTenant(
+	ctx context.Context,
+	id, name, host string,
+	branding model.Branding,
+) (*model.Tenant, error) {
+	tenant, err := s.repo.GetTenant(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+
+	tenant.Name, tenant.Host, tenant.Branding = name, host, branding
+	if err = validateTenant(tenant); err != nil {
+		return nil, err
+	}
+	if err = s.repo.UpdateTenant(ctx, tenant); err != nil {
+		return nil, fmt.Errorf("update tenant: %w", err)
+	}
+
+	s.log.Info("tenant updated", zap.String("tenant_id", id))
+	return tenant, nil
+}
+
+// GetTenantBySlug returns the tenant named in a /t/{slug} path.
+func (s *Service) GetTenantBySlug(ctx context.Context, slug string) (*model.Tenant, error) {
+	tenant, err := s.repo.GetTenantBySlug(ctx, strings.ToLower(slug))
+	if err != nil {
+		return nil, fmt.Errorf("get tenant by slug: %w", err)
+	}
+	return tenant, nil
+}
+
+// ResolveHost returns the tenant mapped to host, or the default tenant when
+// no tenant claims the host.
+func (s *Service) ResolveHost(ctx context.Context, host string) (*model.Tenant, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	tenant, err := s.repo.GetTenantByHost(ctx, host)
+	if errors.Is(err, domainerrors.ErrTenantNotFound) {
+		tenant, err = s.repo.GetTenantBySlug(ctx, model.DefaultTenantSlug)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve tenant by host: %w", err)
+	}
+	return tenant, nil
+}
+
+func validateTenant(tenant *model.Tenant) error {
+	tenant.Name = strings.TrimSpace(tenant.Name)
+	tenant.Host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(tenant.Host)), ".")
+	b := &tenant.Branding
+	b.DisplayName = strings.TrimSpace(b.DisplayName)
+	b.LogoURL = strings.TrimSpace(b.LogoURL)
+	b.PrimaryColor = strings.ToLower(strings.TrimSpace(b.PrimaryColor))
+
+	switch {
+	case tenant.Name == "":
+		return errors.New("name: must not be empty")
+	case utf8.RuneCountInString(tenant.Name) > maxNameLength:
+		return fmt.Errorf("name: must not exceed %d characters", maxNameLength)
+	case strings.ContainsFunc(tenant.Name, unicode.IsControl):
+		return errors.New("name: must not contain control characters")
+	case tenant.Host != "" && !hostPattern.MatchString(tenant.Host):
+		return errors.New("host: must be a hostname without scheme or port")
+	case utf8.RuneCountInString(b.DisplayName) > maxDisplayNameLength:
+		return fmt.Errorf("display_name: must not exceed %d characters", maxDisplayNameLength)
+	case strings.ContainsFunc(b.DisplayName, unicode.IsControl):
+		return errors.New("display_name: must not contain control characters")
+	case b.PrimaryColor != "" && !colorPattern.MatchString(b.PrimaryColor):
+		return errors.New("primary_color: must be a hex color such as #1a73e8")
+	}
+	return validateLogoURL(b.LogoURL)
+}
+
+// validateLogoURL accepts an empty value or an absolute https URL, so login
+// pages never load mixed content.
+func validateLogoURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	if len(raw) > maxLogoURLLength {
+		return fmt.Errorf("logo_url: must not exceed %d characters", maxLogoURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("logo_url: must be an absolute https URL")
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/tenant/mocks"
+)
+
+func TestService_CreateTenant(t *testing.T) {
+	tests := []struct {
+		name      string
+		tenant    *model.Tenant
+		mockSetup func(repo *mocks.Repository)
+		wantErr   string
+	}{
+		{
+			name: "normalizes fields",
+			tenant: &model.Tenant{Slug: " Acme ", Name: " Acme Inc ", Host: "Login.Acme.Example.",
+				Branding: model.Branding{PrimaryColor: "#FF6600"}},
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().CreateTenant(mock.Anything, &model.Tenant{Slug: "acme", Name: "Acme Inc",
+					Host: "login.acme.example", Branding: model.Branding{PrimaryColor: "#ff6600"}}).Return(nil)
+			},
+		},
+		{
+			name:      "invalid slug",
+			tenant:    &model.Tenant{Slug: "-acme", Name: "Acme"},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "slug: must be 1-63 lowercase letters, digits or hyphens, not starting or ending with a hyphen",
+		},
+		{
+			name:      "empty name",
+			tenant:    &model.Tenant{Slug: "acme"},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "name: must not be empty",
+		},
+		{
+			name:      "host with port",
+			tenant:    &model.Tenant{Slug: "acme", Name: "Acme", Host: "login.acme.example:8443"},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "host: must be a hostname without scheme or port",
+		},
+		{
+			name:      "invalid color",
+			tenant:    &model.Tenant{Slug: "acme", Name: "Acme", Branding: model.Branding{PrimaryColor: "orange"}},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "primary_color: must be a hex color such as #1a73e8",
+		},
+		{
+			name: "plain http logo",
+			tenant: &model.Tenant{Slug: "acme", Name: "Acme",
+				Branding: model.Branding{LogoURL: "http://acme.example/logo.png"}},
+			mockSetup: func(_ *mocks.Repository) {},
+			wantErr:   "logo_url: must be an absolute https URL",
+		},
+		{
+			name:   "duplicate",
+			tenant: &model.Tenant{Slug: "acme", Name: "Acme"},
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().CreateTenant(mock.Anything, mock.Anything).Return(domainerrors.ErrTenantAlreadyExists)
+			},
+			wantErr: "create tenant: tenant already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			err := New(repo, zap.NewNop()).CreateTenant(t.Context(), tt.tenant)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_UpdateTenant(t *testing.T) {
+	t.Run("keeps the slug", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().GetTenant(mock.Anything, "tenant-1").
+			Return(&model.Tenant{ID: "tenant-1", Slug: "acme", Name: "Acme"}, nil)
+		repo.EXPECT().UpdateTenant(mock.Anything, mock.MatchedBy(func(tn *model.Tenant) bool {
+			return tn.Slug == "acme" && tn.Name == "Acme Inc" && tn.Branding.DisplayName == "Acme"
+		})).Return(nil)
+
+		tenant, err := New(repo, zap.NewNop()).UpdateTenant(t.Context(), "tenant-1", "Acme Inc", "",
+			model.Branding{DisplayName: "Acme"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Acme Inc", tenant.Name)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		repo := mocks.NewRepository(t)
+		repo.EXPECT().GetTenant(mock.Anything, "tenant-1").Return(nil, domainerrors.ErrTenantNotFound)
+
+		_, err := New(repo, zap.NewNop()).UpdateTenant(t.Context(), "tenant-1", "Acme", "", model.Branding{})
+
+		assert.ErrorIs(t, err, domainerrors.ErrTenantNotFound)
+	})
+}
+
+func TestService_ResolveHost(t *testing.T) {
+	acme := &model.Tenant{ID: "tenant-1", Slug: "acme"}
+	defaultTenant := &model.Tenant{ID: "tenant-0", Slug: model.DefaultTenantSlug}
+
+	tests := []struct {
+		name      string
+		host      string
+		mockSetup func(repo *mocks.Repository)
+		want      *model.Tenant
+		wantErr   string
+	}{
+		{
+			name: "mapped host with port",
+			host: "Login.Acme.Example:8443",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetTenantByHost(mock.Anything, "login.acme.example").Return(acme, nil)
+			},
+			want: acme,
+		},
+		{
+			name: "unmapped host falls back to the default tenant",
+			host: "sso.example.com",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetTenantByHost(mock.Anything, "sso.example.com").Return(nil, domainerrors.ErrTenantNotFound)
+				repo.EXPECT().GetTenantBySlug(mock.Anything, model.DefaultTenantSlug).Return(defaultTenant, nil)
+			},
+			want: defaultTenant,
+		},
+		{
+			name: "store error",
+			host: "sso.example.com",
+			mockSetup: func(repo *mocks.Repository) {
+				repo.EXPECT().GetTenantByHost(mock.Anything, "sso.example.com").Return(nil, errors.New("db down"))
+			},
+			wantErr: "resolve tenant by host: db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewRepository(t)
+			tt.mockSetup(repo)
+
+			got, err := New(repo, zap.NewNop()).ResolveHost(t.Context(), tt.host)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, tt.want, got)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package token
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
@@ -15,8 +16,11 @@ import (
 const defaultAudience = "sso"
 
 type TokenGenerator interface {
-	GenerateToken(userID, audience string) (string, error)
+	GenerateToken(userID, sessionID, audience string, authz *model.Authorization) (string, error)
 	GenerateRefreshToken() (raw, hash string, err error)
+	ValidateToken(token string) (*model.AccessTokenClaims, error)
+	Issuer(tenant string) string
+	GetJWKS(tenant string) (*model.JWKS, error)
 }
 
 type RefreshTokenRepository interface {
@@ -24,35 +28,88 @@ type RefreshTokenRepository interface {
 	GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
 	Revoke(ctx context.Context, id string) error
 	RevokeByFamilyID(ctx context.Context, familyID string) error
+	RevokeByUserID(ctx context.Context, userID string) error
+	RevokeFamiliesExcept(ctx context.Context, userID, keepFamilyID string) error
+	RotateToken(ctx context.Context, oldHash string, next *model.RefreshToken, sealedNext []byte) (*model.RefreshToken, bool, error)
+}
+
+// SessionRepository stores the login session behind each refresh token
+// family, keyed by the family ID.
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session) error
+	TouchSession(ctx context.Context, id string) error
+	GetSession(ctx context.Context, id string) (*model.Session, error)
+	ListActiveSessions(ctx context.Context, userID string) ([]*model.Session, error)
+}
+
+// AuthorizationRepository provides the roles, permissions and groups
+// embedded in access tokens.
+type AuthorizationRepository interface {
+	GetAuthorization(ctx context.Context, userID, clientID string) (*model.Authorization, error)
+	ListGroupNamesByUserID(ctx context.Context, userID string) ([]string, error)
 }
 
 type Service struct {
 	tokenGen    TokenGenerator
 	refreshRepo RefreshTokenRepository
+	authz       AuthorizationRepository
+	sessions    SessionRepository
 	accessTTL   time.Duration
 	refreshTTL  time.Duration
+	reuseGrace  time.Duration
+	groupsLimit int
 	log         *zap.Logger
 }
 
-func New(tg TokenGenerator, rr RefreshTokenRepository, accessTTL, refreshTTL time.Duration, log *zap.Logger) *Service {
+// New creates the token service. A refresh token presented again within
+// reuseGrace of its rotation gets the same successor back; zero treats every
+// reuse as replay. A user in more than groupsLimit groups gets the
+// groups_overage claim instead of the groups claim; zero disables the limit.
+func New(
+	tg TokenGenerator,
+	rr RefreshTokenRepository,
+	authz AuthorizationRepository,
+	sr SessionRepository,
+	accessTTL, refreshTTL, reuseGrace time.Duration,
+	groupsLimit int,
+	log *zap.Logger,
+) *Service {
 	return &Service{
 		tokenGen:    tg,
 		refreshRepo: rr,
+		authz:       authz,
+		sessions:    sr,
 		accessTTL:   accessTTL,
 		refreshTTL:  refreshTTL,
+		reuseGrace:  reuseGrace,
+		groupsLimit: groupsLimit,
 		log:         log,
 	}
 }
 
+// IssueTokenPair starts a new session, recording the device described by the
+// model.ClientInfo of ctx.
 func (s *Service) IssueTokenPair(ctx context.Context, userID, clientID string, scopes []string) (*model.TokenPair, error) {
-	accessToken, err := s.tokenGen.GenerateToken(userID, defaultAudience)
+	familyID, err := crypto.GenerateUUID()
 	if err != nil {
-		return nil, fmt.Errorf("generate access token: %w", err)
+		return nil, fmt.Errorf("generate family id: %w", err)
 	}
 
-	familyID, err := crypto.GenerateUUID()
+	accessToken, err := s.generateAccessToken(ctx, userID, familyID, clientID, scopes)
 	if err != nil {
-		return nil, fmt.Errorf("generate family id: %w", err)
+		return nil, err
+	}
+
+	client := model.ClientInfoFromContext(ctx)
+	err = s.sessions.CreateSession(ctx, &model.Session{
+		ID:        familyID,
+		UserID:    userID,
+		ClientID:  clientID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
 	}
 
 	refreshToken, err := s.saveRefreshToken(ctx, userID, familyID, clientID, scopes)
@@ -67,18 +124,68 @@ func (s *Service) IssueTokenPair(ctx context.Context, userID, clientID string, s
 	}, nil
 }
 
+// RefreshTokens exchanges a refresh token for a new pair in the same session.
+// The exchange is atomic, so of concurrent refreshes with one token only the
+// first rotates it. A client that lost the response may present the rotated
+// token again within the reuse grace window and gets the same successor;
+// any other reuse is treated as replay and signs out the whole session.
 func (s *Service) RefreshTokens(ctx context.Context, rawRefreshToken string) (*model.TokenPair, error) {
-	tokenHash := crypto.HashToken(rawRefreshToken)
+	newRefreshToken, newHash, err := s.tokenGen.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	sealed, err := crypto.SealWithToken(rawRefreshToken, newRefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("seal refresh token: %w", err)
+	}
 
-	stored, err := s.refreshRepo.GetByHash(ctx, tokenHash)
+	next := &model.RefreshToken{
+		TokenHash: newHash,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	stored, rotated, err := s.refreshRepo.RotateToken(ctx, crypto.HashToken(rawRefreshToken), next, sealed)
 	if err != nil {
-		return nil, fmt.Errorf("get refresh token: %w", err)
+		return nil, fmt.Errorf("rotate refresh token: %w", err)
 	}
-	if time.Now().After(stored.ExpiresAt) {
-		return nil, domainerrors.ErrTokenExpired
+	if !rotated {
+		if time.Now().After(stored.ExpiresAt) {
+			return nil, domainerrors.ErrTokenExpired
+		}
+		return s.reuseRotated(ctx, rawRefreshToken, stored)
 	}
 
-	if stored.Revoked {
+	newAccessToken, err := s.generateAccessToken(ctx, stored.UserID, stored.FamilyID, stored.ClientID, stored.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.sessions.TouchSession(ctx, stored.FamilyID); err != nil {
+		s.log.Error("failed to record session refresh",
+			zap.Error(err),
+			zap.String("family_id", stored.FamilyID),
+		)
+	}
+
+	s.log.Info("tokens refreshed",
+		zap.String("user_id", stored.UserID),
+		zap.String("family_id", stored.FamilyID),
+	)
+
+	return &model.TokenPair{
+		AccessToken:  newAccessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// reuseRotated handles a revoked refresh token presented again. Within the
+// grace window of its rotation it returns the token's successor, as long as
+// that is still active; otherwise the token family is revoked.
+func (s *Service) reuseRotated(ctx context.Context, rawRefreshToken string, stored *model.RefreshToken) (*model.TokenPair, error) {
+	successor, err := s.graceSuccessor(ctx, rawRefreshToken, stored)
+	if err != nil {
+		return nil, err
+	}
+	if successor == "" {
 		if revokeErr := s.refreshRepo.RevokeByFamilyID(ctx, stored.FamilyID); revokeErr != nil {
 			return nil, fmt.Errorf("revoke token family: %w", revokeErr)
 		}
@@ -89,32 +196,52 @@ func (s *Service) RefreshTokens(ctx context.Context, rawRefreshToken string) (*m
 		return nil, domainerrors.ErrTokenRevoked
 	}
 
-	if err = s.refreshRepo.Revoke(ctx, stored.ID); err != nil {
-		return nil, fmt.Errorf("revoke current token: %w", err)
-	}
-
-	newAccessToken, err := s.tokenGen.GenerateToken(stored.UserID, defaultAudience)
-	if err != nil {
-		return nil, fmt.Errorf("generate access token: %w", err)
-	}
-
-	newRefreshToken, err := s.saveRefreshToken(ctx, stored.UserID, stored.FamilyID, stored.ClientID, stored.Scopes)
+	accessToken, err := s.generateAccessToken(ctx, stored.UserID, stored.FamilyID, stored.ClientID, stored.Scopes)
 	if err != nil {
 		return nil, err
 	}
 
-	s.log.Info("tokens refreshed",
+	s.log.Info("rotated refresh token reused within grace window",
 		zap.String("user_id", stored.UserID),
 		zap.String("family_id", stored.FamilyID),
 	)
 
 	return &model.TokenPair{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshToken,
+		AccessToken:  accessToken,
+		RefreshToken: successor,
 		ExpiresIn:    int64(s.accessTTL.Seconds()),
 	}, nil
 }
 
+// graceSuccessor returns the raw successor of the rotated token stored, or ""
+// if the grace window has passed or the successor can no longer be used.
+func (s *Service) graceSuccessor(ctx context.Context, rawRefreshToken string, stored *model.RefreshToken) (string, error) {
+	if s.reuseGrace <= 0 || stored.RotatedAt == nil || len(stored.SealedSuccessor) == 0 ||
+		time.Since(*stored.RotatedAt) > s.reuseGrace {
+		return "", nil
+	}
+
+	successor, err := crypto.OpenWithToken(rawRefreshToken, stored.SealedSuccessor)
+	if err != nil {
+		s.log.Error("failed to open sealed refresh token successor",
+			zap.Error(err),
+			zap.String("family_id", stored.FamilyID),
+		)
+		return "", nil
+	}
+
+	// A successor that was itself rotated or revoked means the session moved
+	// on, so the reuse is not a retry.
+	next, err := s.refreshRepo.GetByHash(ctx, crypto.HashToken(successor))
+	if err != nil {
+		return "", fmt.Errorf("get refresh token successor: %w", err)
+	}
+	if next.Revoked || time.Now().After(next.ExpiresAt) {
+		return "", nil
+	}
+	return successor, nil
+}
+
 func (s *Service) RevokeToken(ctx context.Context, rawToken string) error {
 	tokenHash := crypto.HashToken(rawToken)
 
@@ -134,6 +261,136 @@ func (s *Service) RevokeToken(ctx context.Context, rawToken string) error {
 	return nil
 }
 
+// ValidateAccessToken verifies an access token's signature and expiry. It
+// does not consult storage, so a token stays valid until it expires even after
+// the user's sessions are revoked.
+func (s *Service) ValidateAccessToken(_ context.Context, accessToken string) (*model.AccessTokenClaims, error) {
+	claims, err := s.tokenGen.ValidateToken(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("validate access token: %w", err)
+	}
+	return claims, nil
+}
+
+// IntrospectRefreshToken returns the stored refresh token if it is still
+// usable. Unknown, revoked and expired tokens are all reported as
+// domainerrors.ErrInvalidToken.
+func (s *Service) IntrospectRefreshToken(ctx context.Context, rawToken string) (*model.RefreshToken, error) {
+	stored, err := s.refreshRepo.GetByHash(ctx, crypto.HashToken(rawToken))
+	if err != nil {
+		return nil, fmt.Errorf("get refresh token: %w", err)
+	}
+	if stored.Revoked || time.Now().After(stored.ExpiresAt) {
+		return nil, domainerrors.ErrInvalidToken
+	}
+	return stored, nil
+}
+
+// RevokeUserSessions revokes every refresh token of the user. Access tokens
+// already issued stay valid until they expire.
+func (s *Service) RevokeUserSessions(ctx context.Context, userID string) error {
+	if err := s.refreshRepo.RevokeByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("revoke user sessions: %w", err)
+	}
+	s.log.Info("user sessions revoked", zap.String("user_id", userID))
+	return nil
+}
+
+// ListSessions returns the user's sessions that can still refresh tokens.
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]*model.Session, error) {
+	sessions, err := s.sessions.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out one session of the user. Sessions of other users
+// are reported as domainerrors.ErrSessionNotFound.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := s.sessions.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("get session: %w", err)
+	}
+	if session.UserID != userID {
+		return domainerrors.ErrSessionNotFound
+	}
+
+	if err = s.refreshRepo.RevokeByFamilyID(ctx, sessionID); err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	s.log.Info("session revoked",
+		zap.String("user_id", userID),
+		zap.String("family_id", sessionID),
+	)
+	return nil
+}
+
+// RevokeOtherSessions signs out every session of the user except
+// keepSessionID.
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID, keepSessionID string) error {
+	if err := s.refreshRepo.RevokeFamiliesExcept(ctx, userID, keepSessionID); err != nil {
+		return fmt.Errorf("revoke other sessions: %w", err)
+	}
+	s.log.Info("other sessions revoked",
+		zap.String("user_id", userID),
+		zap.String("family_id", keepSessionID),
+	)
+	return nil
+}
+
+// Issuer returns the issuer URL of the tenant with slug tenant.
+func (s *Service) Issuer(tenant string) string {
+	return s.tokenGen.Issuer(tenant)
+}
+
+// JWKS returns the public keys that verify the tenant's access tokens.
+func (s *Service) JWKS(tenant string) (*model.JWKS, error) {
+	jwks, err := s.tokenGen.GetJWKS(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("get jwks: %w", err)
+	}
+	return jwks, nil
+}
+
+// generateAccessToken reads the user's authorization on every issue, so role
+// and group changes reach clients on their next refresh. Sessions without
+// scopes are first-party and always carry roles; clients get roles,
+// permissions and groups only for the matching scopes.
+func (s *Service) generateAccessToken(ctx context.Context, userID, sessionID, clientID string, scopes []string) (string, error) {
+	authz, err := s.authz.GetAuthorization(ctx, userID, clientID)
+	if err != nil {
+		return "", fmt.Errorf("get authorization: %w", err)
+	}
+
+	claims := &model.Authorization{Tenant: authz.Tenant}
+	if len(scopes) == 0 || slices.Contains(scopes, model.ScopeRoles) {
+		claims.Roles = authz.Roles
+	}
+	if slices.Contains(scopes, model.ScopePermissions) {
+		claims.Permissions = authz.Permissions
+	}
+	if slices.Contains(scopes, model.ScopeGroups) {
+		groups, err := s.authz.ListGroupNamesByUserID(ctx, userID)
+		if err != nil {
+			return "", fmt.Errorf("list user groups: %w", err)
+		}
+		// Past the limit the token would grow too large for headers and
+		// cookies; clients fetch the groups from the API instead.
+		if s.groupsLimit > 0 && len(groups) > s.groupsLimit {
+			claims.GroupsOverage = true
+		} else {
+			claims.Groups = groups
+		}
+	}
+
+	accessToken, err := s.tokenGen.GenerateToken(userID, sessionID, defaultAudience, claims)
+	if err != nil {
+		return "", fmt.Errorf("generate access token: %w", err)
+	}
+	return accessToken, nil
+}
+
 func (s *Service) saveRefreshToken(ctx context.Context, userID, familyID, clientID string, scopes []string) (string, error) {
 	raw, hash, err := s.tokenGen.GenerateRefreshToken()
 	if err != nil {
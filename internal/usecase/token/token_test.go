@@ -16,6 +16,23 @@ import (
 	"github.com/sanchey92/sso/pkg/crypto"
 )
 
+// noRoles is an authorization repository for users without roles.
+func noRoles(t *testing.T) *mocks.AuthorizationRepository {
+	t.Helper()
+	authz := mocks.NewAuthorizationRepository(t)
+	authz.EXPECT().GetAuthorization(mock.Anything, mock.Anything, mock.Anything).Return(&model.Authorization{}, nil).Maybe()
+	return authz
+}
+
+// noSessions is a session repository that accepts every session.
+func noSessions(t *testing.T) *mocks.SessionRepository {
+	t.Helper()
+	sessions := mocks.NewSessionRepository(t)
+	sessions.EXPECT().CreateSession(mock.Anything, mock.Anything).Return(nil).Maybe()
+	sessions.EXPECT().TouchSession(mock.Anything, mock.Anything).Return(nil).Maybe()
+	return sessions
+}
+
 func TestService_IssueTokenPair(t *testing.T) {
 	ctx := t.Context()
 
@@ -32,7 +49,7 @@ func TestService_IssueTokenPair(t *testing.T) {
 			name:   "successful issue",
 			userID: "user-1",
 			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				tg.EXPECT().GenerateToken("user-1", "sso").
+				tg.EXPECT().GenerateToken("user-1", mock.Anything, "sso", &model.Authorization{}).
 					Return("access-jwt", nil)
 				tg.EXPECT().GenerateRefreshToken().
 					Return("raw-refresh", "hash-refresh", nil)
@@ -53,7 +70,7 @@ func TestService_IssueTokenPair(t *testing.T) {
 			name:   "generate access token fails",
 			userID: "user-1",
 			setupMock: func(tg *mocks.TokenGenerator, _ *mocks.RefreshTokenRepository) {
-				tg.EXPECT().GenerateToken("user-1", "sso").
+				tg.EXPECT().GenerateToken("user-1", mock.Anything, "sso", &model.Authorization{}).
 					Return("", errors.New("sign failed"))
 			},
 			wantErr: "generate access token: sign failed",
@@ -62,7 +79,7 @@ func TestService_IssueTokenPair(t *testing.T) {
 			name:   "generate refresh token fails",
 			userID: "user-1",
 			setupMock: func(tg *mocks.TokenGenerator, _ *mocks.RefreshTokenRepository) {
-				tg.EXPECT().GenerateToken("user-1", "sso").
+				tg.EXPECT().GenerateToken("user-1", mock.Anything, "sso", &model.Authorization{}).
 					Return("access-jwt", nil)
 				tg.EXPECT().GenerateRefreshToken().
 					Return("", "", errors.New("rand failed"))
@@ -73,7 +90,7 @@ func TestService_IssueTokenPair(t *testing.T) {
 			name:   "save refresh token fails",
 			userID: "user-1",
 			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				tg.EXPECT().GenerateToken("user-1", "sso").
+				tg.EXPECT().GenerateToken("user-1", mock.Anything, "sso", &model.Authorization{}).
 					Return("access-jwt", nil)
 				tg.EXPECT().GenerateRefreshToken().
 					Return("raw", "hash", nil)
@@ -88,7 +105,7 @@ func TestService_IssueTokenPair(t *testing.T) {
 			clientID: "client-abc",
 			scopes:   []string{"openid", "profile"},
 			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				tg.EXPECT().GenerateToken("user-1", "sso").
+				tg.EXPECT().GenerateToken("user-1", mock.Anything, "sso", &model.Authorization{}).
 					Return("access-jwt", nil)
 				tg.EXPECT().GenerateRefreshToken().
 					Return("raw", "hash", nil)
@@ -112,7 +129,7 @@ func TestService_IssueTokenPair(t *testing.T) {
 			refreshRepo := mocks.NewRefreshTokenRepository(t)
 			tt.setupMock(tokenGen, refreshRepo)
 
-			svc := New(tokenGen, refreshRepo, time.Minute, time.Hour, zap.NewNop())
+			svc := New(tokenGen, refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
 
 			pair, err := svc.IssueTokenPair(ctx, tt.userID, tt.clientID, tt.scopes)
 
@@ -144,6 +161,7 @@ func TestService_RefreshTokens(t *testing.T) {
 		Revoked:   false,
 		ExpiresAt: time.Now().Add(time.Hour),
 	}
+	oldHash := crypto.HashToken("valid-refresh-token")
 
 	tests := []struct {
 		name         string
@@ -156,20 +174,16 @@ func TestService_RefreshTokens(t *testing.T) {
 			name:         "successful refresh",
 			refreshToken: "valid-refresh-token",
 			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(validRT, nil)
-				rr.EXPECT().Revoke(mock.Anything, "rt-uuid").
-					Return(nil)
-				tg.EXPECT().GenerateToken("user-uuid", "sso").
-					Return("new-access-jwt", nil)
 				tg.EXPECT().GenerateRefreshToken().
 					Return("new-raw-token", "new-hash", nil)
-				rr.EXPECT().SaveToken(mock.Anything, mock.MatchedBy(func(rt *model.RefreshToken) bool {
-					return rt.FamilyID == "family-uuid" &&
-						rt.UserID == "user-uuid" &&
-						rt.TokenHash == "new-hash" &&
-						!rt.Revoked
-				})).Return(nil)
+				rr.EXPECT().RotateToken(mock.Anything, oldHash, mock.MatchedBy(func(rt *model.RefreshToken) bool {
+					return rt.TokenHash == "new-hash" && !rt.Revoked && rt.ExpiresAt.After(time.Now())
+				}), mock.MatchedBy(func(sealed []byte) bool {
+					successor, err := crypto.OpenWithToken("valid-refresh-token", sealed)
+					return err == nil && successor == "new-raw-token"
+				})).Return(validRT, true, nil)
+				tg.EXPECT().GenerateToken("user-uuid", "family-uuid", "sso", &model.Authorization{}).
+					Return("new-access-jwt", nil)
 			},
 			check: func(t *testing.T, pair *model.TokenPair) {
 				assert.Equal(t, "new-access-jwt", pair.AccessToken)
@@ -180,56 +194,62 @@ func TestService_RefreshTokens(t *testing.T) {
 		{
 			name:         "token not found",
 			refreshToken: "unknown-token",
-			setupMock: func(_ *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("unknown-token")).
-					Return(nil, domainerrors.ErrInvalidToken)
+			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
+				tg.EXPECT().GenerateRefreshToken().
+					Return("new-raw-token", "new-hash", nil)
+				rr.EXPECT().RotateToken(mock.Anything, crypto.HashToken("unknown-token"), mock.Anything, mock.Anything).
+					Return(nil, false, domainerrors.ErrInvalidToken)
 			},
 			wantErr: domainerrors.ErrInvalidToken.Error(),
 		},
 		{
 			name:         "expired token",
 			refreshToken: "valid-refresh-token",
-			setupMock: func(_ *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
+			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
 				expired := *validRT
 				expired.ExpiresAt = time.Now().Add(-time.Hour)
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(&expired, nil)
+				tg.EXPECT().GenerateRefreshToken().
+					Return("new-raw-token", "new-hash", nil)
+				rr.EXPECT().RotateToken(mock.Anything, oldHash, mock.Anything, mock.Anything).
+					Return(&expired, false, nil)
 			},
 			wantErr: domainerrors.ErrTokenExpired.Error(),
 		},
 		{
 			name:         "revoked token triggers family revocation",
 			refreshToken: "valid-refresh-token",
-			setupMock: func(_ *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
+			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
 				revoked := *validRT
 				revoked.Revoked = true
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(&revoked, nil)
+				tg.EXPECT().GenerateRefreshToken().
+					Return("new-raw-token", "new-hash", nil)
+				rr.EXPECT().RotateToken(mock.Anything, oldHash, mock.Anything, mock.Anything).
+					Return(&revoked, false, nil)
 				rr.EXPECT().RevokeByFamilyID(mock.Anything, "family-uuid").
 					Return(nil)
 			},
 			wantErr: domainerrors.ErrTokenRevoked.Error(),
 		},
 		{
-			name:         "revoke current token fails",
+			name:         "rotation fails",
 			refreshToken: "valid-refresh-token",
-			setupMock: func(_ *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(validRT, nil)
-				rr.EXPECT().Revoke(mock.Anything, "rt-uuid").
-					Return(errors.New("db error"))
+			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
+				tg.EXPECT().GenerateRefreshToken().
+					Return("new-raw-token", "new-hash", nil)
+				rr.EXPECT().RotateToken(mock.Anything, oldHash, mock.Anything, mock.Anything).
+					Return(nil, false, errors.New("db error"))
 			},
-			wantErr: "revoke current token: db error",
+			wantErr: "rotate refresh token: db error",
 		},
 		{
 			name:         "generate access token fails",
 			refreshToken: "valid-refresh-token",
 			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(validRT, nil)
-				rr.EXPECT().Revoke(mock.Anything, "rt-uuid").
-					Return(nil)
-				tg.EXPECT().GenerateToken("user-uuid", "sso").
+				tg.EXPECT().GenerateRefreshToken().
+					Return("new-raw-token", "new-hash", nil)
+				rr.EXPECT().RotateToken(mock.Anything, oldHash, mock.Anything, mock.Anything).
+					Return(validRT, true, nil)
+				tg.EXPECT().GenerateToken("user-uuid", "family-uuid", "sso", &model.Authorization{}).
 					Return("", errors.New("sign failed"))
 			},
 			wantErr: "generate access token: sign failed",
@@ -237,43 +257,22 @@ func TestService_RefreshTokens(t *testing.T) {
 		{
 			name:         "generate refresh token fails",
 			refreshToken: "valid-refresh-token",
-			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(validRT, nil)
-				rr.EXPECT().Revoke(mock.Anything, "rt-uuid").
-					Return(nil)
-				tg.EXPECT().GenerateToken("user-uuid", "sso").
-					Return("new-access-jwt", nil)
+			setupMock: func(tg *mocks.TokenGenerator, _ *mocks.RefreshTokenRepository) {
 				tg.EXPECT().GenerateRefreshToken().
 					Return("", "", errors.New("rand failed"))
 			},
 			wantErr: "generate refresh token: rand failed",
 		},
-		{
-			name:         "save new refresh token fails",
-			refreshToken: "valid-refresh-token",
-			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(validRT, nil)
-				rr.EXPECT().Revoke(mock.Anything, "rt-uuid").
-					Return(nil)
-				tg.EXPECT().GenerateToken("user-uuid", "sso").
-					Return("new-access-jwt", nil)
-				tg.EXPECT().GenerateRefreshToken().
-					Return("new-raw", "new-hash", nil)
-				rr.EXPECT().SaveToken(mock.Anything, mock.AnythingOfType("*model.RefreshToken")).
-					Return(errors.New("insert failed"))
-			},
-			wantErr: "save refresh token: insert failed",
-		},
 		{
 			name:         "family revocation fails on replay",
 			refreshToken: "valid-refresh-token",
-			setupMock: func(_ *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
+			setupMock: func(tg *mocks.TokenGenerator, rr *mocks.RefreshTokenRepository) {
 				revoked := *validRT
 				revoked.Revoked = true
-				rr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("valid-refresh-token")).
-					Return(&revoked, nil)
+				tg.EXPECT().GenerateRefreshToken().
+					Return("new-raw-token", "new-hash", nil)
+				rr.EXPECT().RotateToken(mock.Anything, oldHash, mock.Anything, mock.Anything).
+					Return(&revoked, false, nil)
 				rr.EXPECT().RevokeByFamilyID(mock.Anything, "family-uuid").
 					Return(errors.New("db error"))
 			},
@@ -287,7 +286,7 @@ func TestService_RefreshTokens(t *testing.T) {
 			refreshRepo := mocks.NewRefreshTokenRepository(t)
 			tt.setupMock(tokenGen, refreshRepo)
 
-			svc := New(tokenGen, refreshRepo, time.Minute, time.Hour, zap.NewNop())
+			svc := New(tokenGen, refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
 
 			pair, err := svc.RefreshTokens(ctx, tt.refreshToken)
 
@@ -308,6 +307,92 @@ func TestService_RefreshTokens(t *testing.T) {
 	}
 }
 
+func TestService_RefreshTokens_ReuseGrace(t *testing.T) {
+	sealed, err := crypto.SealWithToken("old-raw", "successor-raw")
+	require.NoError(t, err)
+	rotated := func(ago time.Duration) *model.RefreshToken {
+		rotatedAt := time.Now().Add(-ago)
+		return &model.RefreshToken{
+			ID:              "rt-1",
+			UserID:          "user-1",
+			FamilyID:        "family-1",
+			Revoked:         true,
+			ExpiresAt:       time.Now().Add(time.Hour),
+			RotatedAt:       &rotatedAt,
+			SealedSuccessor: sealed,
+		}
+	}
+	activeSuccessor := &model.RefreshToken{ID: "rt-2", FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name       string
+		grace      time.Duration
+		stored     *model.RefreshToken
+		successor  *model.RefreshToken
+		wantReplay bool
+	}{
+		{
+			name:      "within grace returns same successor",
+			grace:     10 * time.Second,
+			stored:    rotated(time.Second),
+			successor: activeSuccessor,
+		},
+		{
+			name:       "after grace is replay",
+			grace:      10 * time.Second,
+			stored:     rotated(time.Minute),
+			wantReplay: true,
+		},
+		{
+			name:       "grace disabled",
+			stored:     rotated(time.Second),
+			wantReplay: true,
+		},
+		{
+			name:       "successor already rotated",
+			grace:      10 * time.Second,
+			stored:     rotated(time.Second),
+			successor:  &model.RefreshToken{ID: "rt-2", Revoked: true, ExpiresAt: time.Now().Add(time.Hour)},
+			wantReplay: true,
+		},
+		{
+			name:       "revoked without rotation",
+			grace:      10 * time.Second,
+			stored:     &model.RefreshToken{ID: "rt-1", UserID: "user-1", FamilyID: "family-1", Revoked: true, ExpiresAt: time.Now().Add(time.Hour)},
+			wantReplay: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenGen := mocks.NewTokenGenerator(t)
+			tokenGen.EXPECT().GenerateRefreshToken().Return("unused-raw", "unused-hash", nil)
+			refreshRepo := mocks.NewRefreshTokenRepository(t)
+			refreshRepo.EXPECT().RotateToken(mock.Anything, crypto.HashToken("old-raw"), mock.Anything, mock.Anything).
+				Return(tt.stored, false, nil)
+			if tt.successor != nil {
+				refreshRepo.EXPECT().GetByHash(mock.Anything, crypto.HashToken("successor-raw")).Return(tt.successor, nil)
+			}
+			if tt.wantReplay {
+				refreshRepo.EXPECT().RevokeByFamilyID(mock.Anything, "family-1").Return(nil)
+			} else {
+				tokenGen.EXPECT().GenerateToken("user-1", "family-1", "sso", mock.Anything).Return("access-jwt", nil)
+			}
+
+			svc := New(tokenGen, refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, tt.grace, 0, zap.NewNop())
+			pair, err := svc.RefreshTokens(t.Context(), "old-raw")
+
+			if tt.wantReplay {
+				require.ErrorIs(t, err, domainerrors.ErrTokenRevoked)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "access-jwt", pair.AccessToken)
+			assert.Equal(t, "successor-raw", pair.RefreshToken)
+		})
+	}
+}
+
 func TestService_RevokeToken(t *testing.T) {
 	ctx := t.Context()
 
@@ -351,7 +436,7 @@ func TestService_RevokeToken(t *testing.T) {
 			refreshRepo := mocks.NewRefreshTokenRepository(t)
 			tt.setupMock(refreshRepo)
 
-			svc := New(tokenGen, refreshRepo, time.Minute, time.Hour, zap.NewNop())
+			svc := New(tokenGen, refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
 
 			err := svc.RevokeToken(ctx, tt.rawToken)
 
@@ -364,3 +449,298 @@ func TestService_RevokeToken(t *testing.T) {
 		})
 	}
 }
+
+func TestService_IntrospectRefreshToken(t *testing.T) {
+	ctx := t.Context()
+
+	active := &model.RefreshToken{ID: "rt-1", UserID: "u1", FamilyID: "fam-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		stored  *model.RefreshToken
+		repoErr error
+		wantErr error
+	}{
+		{name: "active token", stored: active},
+		{
+			name:    "revoked token",
+			stored:  &model.RefreshToken{ID: "rt-2", Revoked: true, ExpiresAt: time.Now().Add(time.Hour)},
+			wantErr: domainerrors.ErrInvalidToken,
+		},
+		{
+			name:    "expired token",
+			stored:  &model.RefreshToken{ID: "rt-3", ExpiresAt: time.Now().Add(-time.Minute)},
+			wantErr: domainerrors.ErrInvalidToken,
+		},
+		{name: "unknown token", repoErr: domainerrors.ErrInvalidToken, wantErr: domainerrors.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshRepo := mocks.NewRefreshTokenRepository(t)
+			refreshRepo.EXPECT().GetByHash(mock.Anything, crypto.HashToken("raw-token")).Return(tt.stored, tt.repoErr)
+
+			svc := New(mocks.NewTokenGenerator(t), refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
+
+			got, err := svc.IntrospectRefreshToken(ctx, "raw-token")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.stored, got)
+		})
+	}
+}
+
+func TestService_IssueTokenPair_Authorization(t *testing.T) {
+	authz := &model.Authorization{Roles: []string{"admin", "editor"}, Permissions: []string{"docs:write"}}
+
+	tests := []struct {
+		name            string
+		clientID        string
+		scopes          []string
+		wantRoles       []string
+		wantPermissions []string
+	}{
+		{name: "first-party session gets roles", wantRoles: authz.Roles},
+		{name: "client without authorization scopes", clientID: "client-1", scopes: []string{"openid"}},
+		{name: "roles scope", clientID: "client-1", scopes: []string{"openid", "roles"}, wantRoles: authz.Roles},
+		{
+			name:            "roles and permissions scopes",
+			clientID:        "client-1",
+			scopes:          []string{"roles", "permissions"},
+			wantRoles:       authz.Roles,
+			wantPermissions: authz.Permissions,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenGen := mocks.NewTokenGenerator(t)
+			tokenGen.EXPECT().GenerateToken("user-1", mock.Anything, "sso", &model.Authorization{Roles: tt.wantRoles, Permissions: tt.wantPermissions}).Return("access-jwt", nil)
+			tokenGen.EXPECT().GenerateRefreshToken().Return("raw", "hash", nil)
+			refreshRepo := mocks.NewRefreshTokenRepository(t)
+			refreshRepo.EXPECT().SaveToken(mock.Anything, mock.Anything).Return(nil)
+			authzRepo := mocks.NewAuthorizationRepository(t)
+			authzRepo.EXPECT().GetAuthorization(mock.Anything, "user-1", tt.clientID).Return(authz, nil)
+
+			svc := New(tokenGen, refreshRepo, authzRepo, noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
+			pair, err := svc.IssueTokenPair(t.Context(), "user-1", tt.clientID, tt.scopes)
+
+			require.NoError(t, err)
+			assert.Equal(t, "access-jwt", pair.AccessToken)
+		})
+	}
+
+	t.Run("lookup fails", func(t *testing.T) {
+		authzRepo := mocks.NewAuthorizationRepository(t)
+		authzRepo.EXPECT().GetAuthorization(mock.Anything, "user-1", "").Return(nil, errors.New("db down"))
+
+		svc := New(mocks.NewTokenGenerator(t), mocks.NewRefreshTokenRepository(t), authzRepo, noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
+		_, err := svc.IssueTokenPair(t.Context(), "user-1", "", nil)
+
+		require.EqualError(t, err, "get authorization: db down")
+	})
+}
+
+func TestService_IssueTokenPair_Groups(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []string
+		want   *model.Authorization
+	}{
+		{name: "within limit", groups: []string{"eng", "ops"}, want: &model.Authorization{Groups: []string{"eng", "ops"}}},
+		{name: "overage", groups: []string{"eng", "ops", "sales"}, want: &model.Authorization{GroupsOverage: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenGen := mocks.NewTokenGenerator(t)
+			tokenGen.EXPECT().GenerateToken("user-1", mock.Anything, "sso", tt.want).Return("access-jwt", nil)
+			tokenGen.EXPECT().GenerateRefreshToken().Return("raw", "hash", nil)
+			refreshRepo := mocks.NewRefreshTokenRepository(t)
+			refreshRepo.EXPECT().SaveToken(mock.Anything, mock.Anything).Return(nil)
+			authzRepo := mocks.NewAuthorizationRepository(t)
+			authzRepo.EXPECT().GetAuthorization(mock.Anything, "user-1", "client-1").
+				Return(&model.Authorization{Roles: []string{"admin"}}, nil)
+			authzRepo.EXPECT().ListGroupNamesByUserID(mock.Anything, "user-1").Return(tt.groups, nil)
+
+			svc := New(tokenGen, refreshRepo, authzRepo, noSessions(t), time.Minute, time.Hour, 0, 2, zap.NewNop())
+			_, err := svc.IssueTokenPair(t.Context(), "user-1", "client-1", []string{"openid", "groups"})
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_ValidateAccessToken(t *testing.T) {
+	tokenGen := mocks.NewTokenGenerator(t)
+	tokenGen.EXPECT().ValidateToken("good").Return(&model.AccessTokenClaims{Subject: "u1"}, nil)
+	tokenGen.EXPECT().ValidateToken("expired").Return(nil, domainerrors.ErrTokenExpired)
+
+	svc := New(tokenGen, mocks.NewRefreshTokenRepository(t), noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
+
+	claims, err := svc.ValidateAccessToken(t.Context(), "good")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", claims.Subject)
+
+	_, err = svc.ValidateAccessToken(t.Context(), "expired")
+	require.ErrorIs(t, err, domainerrors.ErrTokenExpired)
+}
+
+func TestService_RevokeUserSessions(t *testing.T) {
+	refreshRepo := mocks.NewRefreshTokenRepository(t)
+	refreshRepo.EXPECT().RevokeByUserID(mock.Anything, "u1").Return(nil)
+	refreshRepo.EXPECT().RevokeByUserID(mock.Anything, "u2").Return(errors.New("db down"))
+
+	svc := New(mocks.NewTokenGenerator(t), refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
+
+	require.NoError(t, svc.RevokeUserSessions(t.Context(), "u1"))
+	require.EqualError(t, svc.RevokeUserSessions(t.Context(), "u2"), "revoke user sessions: db down")
+}
+
+func TestService_IssueTokenPair_RecordsSession(t *testing.T) {
+	ctx := model.WithClientInfo(t.Context(), model.ClientInfo{UserAgent: "Firefox", IP: "203.0.113.7"})
+
+	var familyID string
+	sessions := mocks.NewSessionRepository(t)
+	sessions.EXPECT().CreateSession(mock.Anything, mock.MatchedBy(func(s *model.Session) bool {
+		familyID = s.ID
+		return s.ID != "" && s.UserID == "user-1" && s.ClientID == "client-1" &&
+			s.UserAgent == "Firefox" && s.IP == "203.0.113.7"
+	})).Return(nil)
+	tokenGen := mocks.NewTokenGenerator(t)
+	tokenGen.EXPECT().GenerateToken("user-1", mock.Anything, "sso", mock.Anything).Return("access-jwt", nil)
+	tokenGen.EXPECT().GenerateRefreshToken().Return("raw", "hash", nil)
+	refreshRepo := mocks.NewRefreshTokenRepository(t)
+	refreshRepo.EXPECT().SaveToken(mock.Anything, mock.MatchedBy(func(rt *model.RefreshToken) bool {
+		return rt.FamilyID == familyID
+	})).Return(nil)
+
+	svc := New(tokenGen, refreshRepo, noRoles(t), sessions, time.Minute, time.Hour, 0, 0, zap.NewNop())
+	_, err := svc.IssueTokenPair(ctx, "user-1", "client-1", nil)
+
+	require.NoError(t, err)
+	tokenGen.AssertCalled(t, "GenerateToken", "user-1", familyID, "sso", mock.Anything)
+}
+
+func TestService_IssueTokenPair_SessionFails(t *testing.T) {
+	sessions := mocks.NewSessionRepository(t)
+	sessions.EXPECT().CreateSession(mock.Anything, mock.Anything).Return(errors.New("db down"))
+	tokenGen := mocks.NewTokenGenerator(t)
+	tokenGen.EXPECT().GenerateToken("user-1", mock.Anything, "sso", mock.Anything).Return("access-jwt", nil)
+
+	svc := New(tokenGen, mocks.NewRefreshTokenRepository(t), noRoles(t), sessions, time.Minute, time.Hour, 0, 0, zap.NewNop())
+	_, err := svc.IssueTokenPair(t.Context(), "user-1", "", nil)
+
+	require.EqualError(t, err, "create session: db down")
+}
+
+func TestService_RefreshTokens_TouchesSession(t *testing.T) {
+	refreshRepo := mocks.NewRefreshTokenRepository(t)
+	refreshRepo.EXPECT().RotateToken(mock.Anything, crypto.HashToken("raw"), mock.Anything, mock.Anything).
+		Return(&model.RefreshToken{
+			ID: "rt-1", UserID: "user-1", FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour),
+		}, true, nil)
+	tokenGen := mocks.NewTokenGenerator(t)
+	tokenGen.EXPECT().GenerateToken("user-1", "family-1", "sso", mock.Anything).Return("access-jwt", nil)
+	tokenGen.EXPECT().GenerateRefreshToken().Return("new-raw", "new-hash", nil)
+	sessions := mocks.NewSessionRepository(t)
+	sessions.EXPECT().TouchSession(mock.Anything, "family-1").Return(errors.New("db down"))
+
+	svc := New(tokenGen, refreshRepo, noRoles(t), sessions, time.Minute, time.Hour, 0, 0, zap.NewNop())
+	pair, err := svc.RefreshTokens(t.Context(), "raw")
+
+	require.NoError(t, err)
+	assert.Equal(t, "new-raw", pair.RefreshToken)
+}
+
+func TestService_ListSessions(t *testing.T) {
+	want := []*model.Session{{ID: "family-1", UserID: "user-1"}}
+	sessions := mocks.NewSessionRepository(t)
+	sessions.EXPECT().ListActiveSessions(mock.Anything, "user-1").Return(want, nil)
+	sessions.EXPECT().ListActiveSessions(mock.Anything, "user-2").Return(nil, errors.New("db down"))
+
+	svc := New(mocks.NewTokenGenerator(t), mocks.NewRefreshTokenRepository(t), noRoles(t), sessions,
+		time.Minute, time.Hour, 0, 0, zap.NewNop())
+
+	got, err := svc.ListSessions(t.Context(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	_, err = svc.ListSessions(t.Context(), "user-2")
+	require.EqualError(t, err, "list sessions: db down")
+}
+
+func TestService_RevokeSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		setupMock func(sr *mocks.SessionRepository, rr *mocks.RefreshTokenRepository)
+		wantErr   error
+		wantMsg   string
+	}{
+		{
+			name: "own session",
+			setupMock: func(sr *mocks.SessionRepository, rr *mocks.RefreshTokenRepository) {
+				sr.EXPECT().GetSession(mock.Anything, "family-1").Return(&model.Session{ID: "family-1", UserID: "user-1"}, nil)
+				rr.EXPECT().RevokeByFamilyID(mock.Anything, "family-1").Return(nil)
+			},
+		},
+		{
+			name: "session of another user",
+			setupMock: func(sr *mocks.SessionRepository, _ *mocks.RefreshTokenRepository) {
+				sr.EXPECT().GetSession(mock.Anything, "family-1").Return(&model.Session{ID: "family-1", UserID: "user-2"}, nil)
+			},
+			wantErr: domainerrors.ErrSessionNotFound,
+		},
+		{
+			name: "unknown session",
+			setupMock: func(sr *mocks.SessionRepository, _ *mocks.RefreshTokenRepository) {
+				sr.EXPECT().GetSession(mock.Anything, "family-1").Return(nil, domainerrors.ErrSessionNotFound)
+			},
+			wantErr: domainerrors.ErrSessionNotFound,
+		},
+		{
+			name: "revoke fails",
+			setupMock: func(sr *mocks.SessionRepository, rr *mocks.RefreshTokenRepository) {
+				sr.EXPECT().GetSession(mock.Anything, "family-1").Return(&model.Session{ID: "family-1", UserID: "user-1"}, nil)
+				rr.EXPECT().RevokeByFamilyID(mock.Anything, "family-1").Return(errors.New("db down"))
+			},
+			wantMsg: "revoke session: db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := mocks.NewSessionRepository(t)
+			refreshRepo := mocks.NewRefreshTokenRepository(t)
+			tt.setupMock(sessions, refreshRepo)
+
+			svc := New(mocks.NewTokenGenerator(t), refreshRepo, noRoles(t), sessions, time.Minute, time.Hour, 0, 0, zap.NewNop())
+			err := svc.RevokeSession(t.Context(), "user-1", "family-1")
+
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.wantMsg != "":
+				require.EqualError(t, err, tt.wantMsg)
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_RevokeOtherSessions(t *testing.T) {
+	refreshRepo := mocks.NewRefreshTokenRepository(t)
+	refreshRepo.EXPECT().RevokeFamiliesExcept(mock.Anything, "user-1", "family-1").Return(nil)
+	refreshRepo.EXPECT().RevokeFamiliesExcept(mock.Anything, "user-2", "family-2").Return(errors.New("db down"))
+
+	svc := New(mocks.NewTokenGenerator(t), refreshRepo, noRoles(t), noSessions(t), time.Minute, time.Hour, 0, 0, zap.NewNop())
+
+	require.NoError(t, svc.RevokeOtherSessions(t.Context(), "user-1", "family-1"))
+	require.EqualError(t, svc.RevokeOtherSessions(t.Context(), "user-2", "family-2"), "revoke other sessions: db down")
+}
@@ -0,0 +1,193 @@
+package user
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// SearchUsers returns a page of users matching filter, newest first, and the
+// cursor of the next page. The cursor is empty on the last page.
+func (s *Service) SearchUsers(
+	ctx context.Context,
+	filter model.UserFilter,
+	cursor string,
+	limit int,
+) ([]*model.User, string, error) {
+	switch filter.Status {
+	case "", model.UserStatusActive, model.UserStatusBlocked, model.UserStatusDeleted:
+	default:
+		return nil, "", errors.New("status: must be one of active, blocked, deleted")
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		return nil, "", fmt.Errorf("limit: must not exceed %d", maxSearchLimit)
+	}
+	filter.EmailPrefix = strings.ToLower(strings.TrimSpace(filter.EmailPrefix))
+
+	var after *model.UserCursor
+	if cursor != "" {
+		c, err := decodeUserCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = c
+	}
+
+	// One extra row tells whether another page follows.
+	users, err := s.userRepo.SearchUsers(ctx, filter, after, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("search users: %w", err)
+	}
+	if len(users) <= limit {
+		return users, "", nil
+	}
+	users = users[:limit]
+	last := users[limit-1]
+	return users, encodeUserCursor(&model.UserCursor{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
+// BlockUser stops an active user from logging in and revokes their refresh
+// tokens. Blocking a blocked user only revokes the tokens again.
+func (s *Service) BlockUser(ctx context.Context, userID, reason string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+
+	switch user.Status {
+	case model.UserStatusBlocked:
+	case model.UserStatusActive:
+		if err = s.userRepo.UpdateStatus(ctx, userID, model.UserStatusActive, model.UserStatusBlocked); err != nil {
+			return fmt.Errorf("block user: %w", err)
+		}
+		event := model.NewEvent(model.EventUserBlocked, user.TenantID, userID)
+		if reason != "" {
+			event.Data["reason"] = reason
+		}
+		s.publishEvent(ctx, event)
+	default:
+		return domainerrors.ErrInvalidUserStatus
+	}
+
+	if err = s.tokenRevoker.RevokeByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("revoke refresh tokens: %w", err)
+	}
+
+	s.log.Info("user blocked", zap.String("user_id", userID), zap.String("reason", reason))
+	return nil
+}
+
+// UnblockUser lets a blocked user log in again. Unblocking an active user is
+// a no-op.
+func (s *Service) UnblockUser(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+
+	switch user.Status {
+	case model.UserStatusActive:
+		return nil
+	case model.UserStatusBlocked:
+		if err = s.userRepo.UpdateStatus(ctx, userID, model.UserStatusBlocked, model.UserStatusActive); err != nil {
+			return fmt.Errorf("unblock user: %w", err)
+		}
+	default:
+		return domainerrors.ErrInvalidUserStatus
+	}
+
+	s.log.Info("user unblocked", zap.String("user_id", userID))
+	return nil
+}
+
+// ForceVerifyEmail marks the user's email verified without a token, for
+// owners support has verified out of band.
+func (s *Service) ForceVerifyEmail(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err = s.userRepo.UpdateEmailVerified(ctx, userID, true); err != nil {
+		return fmt.Errorf("update email verified: %w", err)
+	}
+
+	s.publish(ctx, model.EventEmailVerified, user)
+	s.log.Info("email verified by admin", zap.String("user_id", userID))
+	return nil
+}
+
+// ResetMFA removes the user's second factor so they can enroll again.
+func (s *Service) ResetMFA(ctx context.Context, userID string) error {
+	if err := s.userRepo.ResetMFA(ctx, userID); err != nil {
+		return fmt.Errorf("reset mfa: %w", err)
+	}
+	s.log.Info("mfa reset by admin", zap.String("user_id", userID))
+	return nil
+}
+
+// SendPasswordReset emails the user a password reset link.
+func (s *Service) SendPasswordReset(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	return s.sendPasswordReset(ctx, user)
+}
+
+// LogoutEverywhere revokes every refresh token of the user. Access tokens
+// already issued stay valid until they expire.
+func (s *Service) LogoutEverywhere(ctx context.Context, userID string) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if err := s.tokenRevoker.RevokeByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("revoke refresh tokens: %w", err)
+	}
+	s.log.Info("user logged out everywhere by admin", zap.String("user_id", userID))
+	return nil
+}
+
+func encodeUserCursor(c *model.UserCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeUserCursor(cursor string) (*model.UserCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domainerrors.ErrInvalidCursor
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok || !uuidPattern.MatchString(id) {
+		return nil, domainerrors.ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, domainerrors.ErrInvalidCursor
+	}
+	return &model.UserCursor{CreatedAt: time.UnixMicro(n), ID: id}, nil
+}
@@ -0,0 +1,226 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/user/mocks"
+)
+
+type adminMocks struct {
+	userRepo *mocks.UserRepository
+	cache    *mocks.CacheStore
+	email    *mocks.EmailSender
+	revoker  *mocks.TokenRevoker
+	events   *mocks.EventPublisher
+}
+
+func newAdminService(t *testing.T) (*Service, adminMocks) {
+	t.Helper()
+	m := adminMocks{
+		userRepo: mocks.NewUserRepository(t),
+		cache:    mocks.NewCacheStore(t),
+		email:    mocks.NewEmailSender(t),
+		revoker:  mocks.NewTokenRevoker(t),
+		events:   mocks.NewEventPublisher(t),
+	}
+	svc := New(m.userRepo, mocks.NewPasswordHasher(t), minLengthPolicy(t), noBreaches(t), noHistory(t), m.cache, m.email, m.revoker,
+		mocks.NewLockoutResetter(t), m.events, &Config{}, zap.NewNop())
+	return svc, m
+}
+
+func TestService_SearchUsers(t *testing.T) {
+	ctx := t.Context()
+	created := time.UnixMicro(1760868000123456)
+	page := []*model.User{
+		{ID: "00000000-0000-0000-0000-000000000003", CreatedAt: created},
+		{ID: "00000000-0000-0000-0000-000000000002", CreatedAt: created},
+		{ID: "00000000-0000-0000-0000-000000000001", CreatedAt: created},
+	}
+
+	t.Run("returns next cursor when more users follow", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().SearchUsers(mock.Anything, model.UserFilter{EmailPrefix: "ann"}, (*model.UserCursor)(nil), 3).
+			Return(page, nil)
+
+		users, next, err := svc.SearchUsers(ctx, model.UserFilter{EmailPrefix: " Ann "}, "", 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, page[:2], users)
+		require.NotEmpty(t, next)
+
+		after, err := decodeUserCursor(next)
+		require.NoError(t, err)
+		assert.Equal(t, page[1].ID, after.ID)
+		assert.True(t, created.Equal(after.CreatedAt))
+	})
+
+	t.Run("continues from cursor", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		cursor := encodeUserCursor(&model.UserCursor{CreatedAt: created, ID: page[1].ID})
+		m.userRepo.EXPECT().SearchUsers(mock.Anything, model.UserFilter{}, mock.MatchedBy(func(c *model.UserCursor) bool {
+			return c.ID == page[1].ID && c.CreatedAt.Equal(created)
+		}), defaultSearchLimit+1).Return(page[2:], nil)
+
+		users, next, err := svc.SearchUsers(ctx, model.UserFilter{}, cursor, 0)
+
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Empty(t, next)
+	})
+
+	invalid := []struct {
+		name    string
+		filter  model.UserFilter
+		cursor  string
+		limit   int
+		wantErr string
+	}{
+		{name: "unknown status", filter: model.UserFilter{Status: "frozen"}, wantErr: "status: must be one of active, blocked, deleted"},
+		{name: "limit too large", limit: maxSearchLimit + 1, wantErr: "limit: must not exceed 200"},
+		{name: "garbage cursor", cursor: "%%%", wantErr: domainerrors.ErrInvalidCursor.Error()},
+		{name: "cursor without uuid", cursor: "MTIzOmFiYw", wantErr: domainerrors.ErrInvalidCursor.Error()},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newAdminService(t)
+
+			_, _, err := svc.SearchUsers(ctx, tt.filter, tt.cursor, tt.limit)
+
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestService_BlockUser(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("blocks active user", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").
+			Return(&model.User{ID: "user-1", Status: model.UserStatusActive}, nil)
+		m.userRepo.EXPECT().UpdateStatus(mock.Anything, "user-1", model.UserStatusActive, model.UserStatusBlocked).Return(nil)
+		m.events.EXPECT().Publish(mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+			return e.Type == model.EventUserBlocked && e.UserID == "user-1" && e.Data["reason"] == "abuse"
+		})).Return(nil)
+		m.revoker.EXPECT().RevokeByUserID(mock.Anything, "user-1").Return(nil)
+
+		require.NoError(t, svc.BlockUser(ctx, "user-1", "abuse"))
+	})
+
+	t.Run("already blocked only revokes tokens", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").
+			Return(&model.User{ID: "user-1", Status: model.UserStatusBlocked}, nil)
+		m.revoker.EXPECT().RevokeByUserID(mock.Anything, "user-1").Return(nil)
+
+		require.NoError(t, svc.BlockUser(ctx, "user-1", ""))
+	})
+
+	t.Run("pending deletion", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").
+			Return(&model.User{ID: "user-1", Status: model.UserStatusDeleted}, nil)
+
+		assert.ErrorIs(t, svc.BlockUser(ctx, "user-1", ""), domainerrors.ErrInvalidUserStatus)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").Return(nil, domainerrors.ErrUserNotFound)
+
+		assert.ErrorIs(t, svc.BlockUser(ctx, "user-1", ""), domainerrors.ErrUserNotFound)
+	})
+}
+
+func TestService_UnblockUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    model.UserStatus
+		mockSetup func(m adminMocks)
+		wantErr   error
+	}{
+		{
+			name:   "blocked",
+			status: model.UserStatusBlocked,
+			mockSetup: func(m adminMocks) {
+				m.userRepo.EXPECT().UpdateStatus(mock.Anything, "user-1", model.UserStatusBlocked, model.UserStatusActive).Return(nil)
+			},
+		},
+		{name: "already active", status: model.UserStatusActive},
+		{name: "pending deletion", status: model.UserStatusDeleted, wantErr: domainerrors.ErrInvalidUserStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newAdminService(t)
+			m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1", Status: tt.status}, nil)
+			if tt.mockSetup != nil {
+				tt.mockSetup(m)
+			}
+
+			err := svc.UnblockUser(t.Context(), "user-1")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_ForceVerifyEmail(t *testing.T) {
+	t.Run("verifies and publishes event", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+		m.userRepo.EXPECT().UpdateEmailVerified(mock.Anything, "user-1", true).Return(nil)
+		expectEvent(m.events, model.EventEmailVerified)
+
+		require.NoError(t, svc.ForceVerifyEmail(t.Context(), "user-1"))
+	})
+
+	t.Run("already verified", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1", EmailVerified: true}, nil)
+
+		require.NoError(t, svc.ForceVerifyEmail(t.Context(), "user-1"))
+	})
+}
+
+func TestService_SendPasswordReset(t *testing.T) {
+	svc, m := newAdminService(t)
+	m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").
+		Return(&model.User{ID: "user-1", Email: "user@example.com"}, nil)
+	m.cache.EXPECT().Set(mock.Anything, mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, resetKeyPrefix)
+	}), "user-1", resetTTL).Return(nil)
+	m.email.EXPECT().SendPasswordResetEmail(mock.Anything, "user@example.com", mock.Anything).Return(nil)
+
+	require.NoError(t, svc.SendPasswordReset(t.Context(), "user-1"))
+}
+
+func TestService_LogoutEverywhere(t *testing.T) {
+	t.Run("revokes refresh tokens", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+		m.revoker.EXPECT().RevokeByUserID(mock.Anything, "user-1").Return(nil)
+
+		require.NoError(t, svc.LogoutEverywhere(t.Context(), "user-1"))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		svc, m := newAdminService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-1").Return(nil, domainerrors.ErrUserNotFound)
+
+		assert.ErrorIs(t, svc.LogoutEverywhere(t.Context(), "user-1"), domainerrors.ErrUserNotFound)
+	})
+}
@@ -0,0 +1,219 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/pkg/crypto"
+)
+
+const (
+	emailChangeKeyPrefix = "email_change:"
+	emailChangeTTL       = 24 * time.Hour
+	// emailPendingKeyPrefix maps a user to the token of their one pending
+	// change, so that a newer request or a revert voids every older link.
+	emailPendingKeyPrefix  = "email_change_pending:"
+	emailRevertKeyPrefix   = "email_revert:"
+	emailRevertTTL         = 7 * 24 * time.Hour
+	emailCooldownKeyPrefix = "email_change_cooldown:"
+	emailChangeTokenLen    = 32
+)
+
+type emailChange struct {
+	UserID   string `json:"user_id"`
+	NewEmail string `json:"new_email"`
+}
+
+type emailRevert struct {
+	UserID   string `json:"user_id"`
+	OldEmail string `json:"old_email"`
+}
+
+// RequestEmailChange starts moving the user to newEmail. The address only
+// changes once the link sent to newEmail is followed; the old address gets a
+// notice with a link that cancels or undoes the change. A user has at most
+// one pending change: a new request voids the links of earlier ones.
+func (s *Service) RequestEmailChange(ctx context.Context, userID, newEmail string) error {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if err := validateEmail(newEmail); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if user.Email == newEmail {
+		return fmt.Errorf("email: must differ from the current email")
+	}
+
+	if err = s.checkEmailChangeCooldown(ctx, userID); err != nil {
+		return err
+	}
+
+	changeToken, err := s.saveToken(ctx, emailChangeKeyPrefix, emailChangeTTL, &emailChange{
+		UserID:   userID,
+		NewEmail: newEmail,
+	})
+	if err != nil {
+		return fmt.Errorf("save email change token: %w", err)
+	}
+	if err = s.cache.Set(ctx, emailPendingKeyPrefix+userID, changeToken, emailChangeTTL); err != nil {
+		return fmt.Errorf("save pending email change: %w", err)
+	}
+	revertToken, err := s.saveToken(ctx, emailRevertKeyPrefix, emailRevertTTL, &emailRevert{
+		UserID:   userID,
+		OldEmail: user.Email,
+	})
+	if err != nil {
+		return fmt.Errorf("save email revert token: %w", err)
+	}
+
+	if err = s.email.SendEmailChangeVerificationEmail(ctx, newEmail, changeToken); err != nil {
+		return fmt.Errorf("send email change verification: %w", err)
+	}
+	if err = s.email.SendEmailChangeNoticeEmail(ctx, user.Email, newEmail, revertToken); err != nil {
+		s.log.Error("failed to send email change notice",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+
+	s.log.Info("email change requested", zap.String("user_id", userID))
+	return nil
+}
+
+// ConfirmEmailChange switches the user to the address the token was sent to.
+// Only the user's latest request can be confirmed, and none while a revert
+// cooldown is running. The address may have been taken since the change was
+// requested, in which case domainerrors.ErrEmailAlreadyExists is returned.
+func (s *Service) ConfirmEmailChange(ctx context.Context, token string) error {
+	key := emailChangeKeyPrefix + token
+	var change emailChange
+	if err := s.loadToken(ctx, key, &change); err != nil {
+		if errors.Is(err, domainerrors.ErrKeyNotFound) {
+			return domainerrors.ErrInvalidEmailChangeToken
+		}
+		return fmt.Errorf("get email change token: %w", err)
+	}
+
+	pendingKey := emailPendingKeyPrefix + change.UserID
+	pending, err := s.cache.Get(ctx, pendingKey)
+	switch {
+	case errors.Is(err, domainerrors.ErrKeyNotFound):
+		return domainerrors.ErrInvalidEmailChangeToken
+	case err != nil:
+		return fmt.Errorf("get pending email change: %w", err)
+	case pending != token:
+		return domainerrors.ErrInvalidEmailChangeToken
+	}
+	if err = s.checkEmailChangeCooldown(ctx, change.UserID); err != nil {
+		return err
+	}
+
+	if err = s.userRepo.UpdateEmail(ctx, change.UserID, change.NewEmail); err != nil {
+		return fmt.Errorf("update email: %w", err)
+	}
+
+	for _, k := range []string{pendingKey, key} {
+		if err = s.cache.Delete(ctx, k); err != nil {
+			s.log.Error("failed to delete email change token",
+				zap.Error(err),
+				zap.String("user_id", change.UserID),
+			)
+		}
+	}
+	s.log.Info("email changed", zap.String("user_id", change.UserID))
+	return nil
+}
+
+// RevertEmailChange handles the "this wasn't me" link sent to the old
+// address. It cancels a pending change or restores the old address, signs out
+// every session and blocks further email changes for the cooldown.
+func (s *Service) RevertEmailChange(ctx context.Context, token string) error {
+	key := emailRevertKeyPrefix + token
+	var revert emailRevert
+	if err := s.loadToken(ctx, key, &revert); err != nil {
+		if errors.Is(err, domainerrors.ErrKeyNotFound) {
+			return domainerrors.ErrInvalidEmailChangeToken
+		}
+		return fmt.Errorf("get email revert token: %w", err)
+	}
+
+	if err := s.cache.Set(ctx, emailCooldownKeyPrefix+revert.UserID, "1", s.cfg.EmailChangeCooldown); err != nil {
+		return fmt.Errorf("set email change cooldown: %w", err)
+	}
+	if err := s.cache.Delete(ctx, emailPendingKeyPrefix+revert.UserID); err != nil {
+		return fmt.Errorf("delete pending email change: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, revert.UserID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if user.Email != revert.OldEmail {
+		if err = s.userRepo.UpdateEmail(ctx, revert.UserID, revert.OldEmail); err != nil {
+			return fmt.Errorf("restore email: %w", err)
+		}
+	}
+
+	if err = s.tokenRevoker.RevokeByUserID(ctx, revert.UserID); err != nil {
+		s.log.Error("failed to revoke refresh tokens after email change revert",
+			zap.Error(err),
+			zap.String("user_id", revert.UserID),
+		)
+	}
+
+	if err = s.cache.Delete(ctx, key); err != nil {
+		s.log.Error("failed to delete email revert token",
+			zap.Error(err),
+			zap.String("user_id", revert.UserID),
+		)
+	}
+	s.log.Warn("email change reverted", zap.String("user_id", revert.UserID))
+	return nil
+}
+
+func (s *Service) checkEmailChangeCooldown(ctx context.Context, userID string) error {
+	_, err := s.cache.Get(ctx, emailCooldownKeyPrefix+userID)
+	switch {
+	case err == nil:
+		return domainerrors.ErrEmailChangeCooldown
+	case !errors.Is(err, domainerrors.ErrKeyNotFound):
+		return fmt.Errorf("get email change cooldown: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) saveToken(ctx context.Context, prefix string, ttl time.Duration, value any) (string, error) {
+	token, err := crypto.GenerateRandomToken(emailChangeTokenLen)
+	if err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("marshal token data: %w", err)
+	}
+	if err = s.cache.Set(ctx, prefix+token, string(data), ttl); err != nil {
+		return "", fmt.Errorf("save token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *Service) loadToken(ctx context.Context, key string, dst any) error {
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return err //nolint:wrapcheck // callers wrap with token context
+	}
+	if err = json.Unmarshal([]byte(data), dst); err != nil {
+		return fmt.Errorf("unmarshal token data: %w", err)
+	}
+	return nil
+}
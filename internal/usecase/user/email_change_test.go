@@ -0,0 +1,296 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/user/mocks"
+)
+
+type emailChangeMocks struct {
+	userRepo *mocks.UserRepository
+	cache    *mocks.CacheStore
+	email    *mocks.EmailSender
+	revoker  *mocks.TokenRevoker
+}
+
+func newEmailChangeService(t *testing.T) (*Service, emailChangeMocks) {
+	t.Helper()
+	m := emailChangeMocks{
+		userRepo: mocks.NewUserRepository(t),
+		cache:    mocks.NewCacheStore(t),
+		email:    mocks.NewEmailSender(t),
+		revoker:  mocks.NewTokenRevoker(t),
+	}
+	svc := New(m.userRepo, mocks.NewPasswordHasher(t), minLengthPolicy(t), noBreaches(t), noHistory(t), m.cache, m.email, m.revoker,
+		mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{EmailChangeCooldown: time.Hour}, zap.NewNop())
+	return svc, m
+}
+
+func TestService_RequestEmailChange(t *testing.T) {
+	ctx := t.Context()
+	current := &model.User{ID: "user-123", Email: "old@example.com"}
+
+	t.Run("sends confirmation and revert links", func(t *testing.T) {
+		svc, m := newEmailChangeService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").Return(current, nil)
+		m.cache.EXPECT().Get(mock.Anything, "email_change_cooldown:user-123").Return("", domainerrors.ErrKeyNotFound)
+
+		var changeToken string
+		m.cache.EXPECT().Set(mock.Anything, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, emailChangeKeyPrefix)
+		}), `{"user_id":"user-123","new_email":"new@example.com"}`, emailChangeTTL).
+			RunAndReturn(func(_ context.Context, key, _ string, _ time.Duration) error {
+				changeToken = key[len(emailChangeKeyPrefix):]
+				return nil
+			})
+		m.cache.EXPECT().Set(mock.Anything, "email_change_pending:user-123", mock.MatchedBy(func(value string) bool {
+			return value == changeToken
+		}), emailChangeTTL).Return(nil)
+		m.cache.EXPECT().Set(mock.Anything, mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, emailRevertKeyPrefix)
+		}), `{"user_id":"user-123","old_email":"old@example.com"}`, emailRevertTTL).Return(nil)
+		m.email.EXPECT().SendEmailChangeVerificationEmail(mock.Anything, "new@example.com", mock.Anything).Return(nil)
+		m.email.EXPECT().SendEmailChangeNoticeEmail(mock.Anything, "old@example.com", "new@example.com", mock.Anything).
+			Return(errors.New("smtp down"))
+
+		require.NoError(t, svc.RequestEmailChange(ctx, "user-123", " New@Example.com "))
+	})
+
+	t.Run("blocked during cooldown", func(t *testing.T) {
+		svc, m := newEmailChangeService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").Return(current, nil)
+		m.cache.EXPECT().Get(mock.Anything, "email_change_cooldown:user-123").Return("1", nil)
+
+		err := svc.RequestEmailChange(ctx, "user-123", "new@example.com")
+
+		assert.ErrorIs(t, err, domainerrors.ErrEmailChangeCooldown)
+	})
+
+	t.Run("same email", func(t *testing.T) {
+		svc, m := newEmailChangeService(t)
+		m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").Return(current, nil)
+
+		err := svc.RequestEmailChange(ctx, "user-123", "OLD@example.com")
+
+		require.Error(t, err)
+		assert.Equal(t, "email: must differ from the current email", err.Error())
+	})
+
+	t.Run("invalid email", func(t *testing.T) {
+		svc, _ := newEmailChangeService(t)
+
+		err := svc.RequestEmailChange(ctx, "user-123", "not-an-email")
+
+		require.Error(t, err)
+		assert.Equal(t, "email: invalid format", err.Error())
+	})
+}
+
+func TestService_ConfirmEmailChange(t *testing.T) {
+	ctx := t.Context()
+	const stored = `{"user_id":"user-123","new_email":"new@example.com"}`
+
+	tests := []struct {
+		name      string
+		setupMock func(m emailChangeMocks)
+		wantErr   error
+	}{
+		{
+			name: "success",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_change:tok").Return(stored, nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_pending:user-123").Return("tok", nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_cooldown:user-123").Return("", domainerrors.ErrKeyNotFound)
+				m.userRepo.EXPECT().UpdateEmail(mock.Anything, "user-123", "new@example.com").Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_change_pending:user-123").Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_change:tok").Return(nil)
+			},
+		},
+		{
+			name: "superseded by a newer request",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_change:tok").Return(stored, nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_pending:user-123").Return("newer-tok", nil)
+			},
+			wantErr: domainerrors.ErrInvalidEmailChangeToken,
+		},
+		{
+			name: "cancelled by a revert",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_change:tok").Return(stored, nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_pending:user-123").Return("", domainerrors.ErrKeyNotFound)
+			},
+			wantErr: domainerrors.ErrInvalidEmailChangeToken,
+		},
+		{
+			name: "blocked during cooldown",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_change:tok").Return(stored, nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_pending:user-123").Return("tok", nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_cooldown:user-123").Return("1", nil)
+			},
+			wantErr: domainerrors.ErrEmailChangeCooldown,
+		},
+		{
+			name: "unknown token",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_change:tok").Return("", domainerrors.ErrKeyNotFound)
+			},
+			wantErr: domainerrors.ErrInvalidEmailChangeToken,
+		},
+		{
+			name: "address taken since request",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_change:tok").Return(stored, nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_pending:user-123").Return("tok", nil)
+				m.cache.EXPECT().Get(mock.Anything, "email_change_cooldown:user-123").Return("", domainerrors.ErrKeyNotFound)
+				m.userRepo.EXPECT().UpdateEmail(mock.Anything, "user-123", "new@example.com").
+					Return(domainerrors.ErrEmailAlreadyExists)
+			},
+			wantErr: domainerrors.ErrEmailAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newEmailChangeService(t)
+			tt.setupMock(m)
+
+			err := svc.ConfirmEmailChange(ctx, "tok")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_RevertEmailChange(t *testing.T) {
+	ctx := t.Context()
+	const stored = `{"user_id":"user-123","old_email":"old@example.com"}`
+
+	tests := []struct {
+		name      string
+		setupMock func(m emailChangeMocks)
+		wantErr   error
+	}{
+		{
+			name: "restores confirmed change",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_revert:tok").Return(stored, nil)
+				m.cache.EXPECT().Set(mock.Anything, "email_change_cooldown:user-123", "1", time.Hour).Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_change_pending:user-123").Return(nil)
+				m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").
+					Return(&model.User{ID: "user-123", Email: "new@example.com"}, nil)
+				m.userRepo.EXPECT().UpdateEmail(mock.Anything, "user-123", "old@example.com").Return(nil)
+				m.revoker.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_revert:tok").Return(nil)
+			},
+		},
+		{
+			name: "cancels pending change",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_revert:tok").Return(stored, nil)
+				m.cache.EXPECT().Set(mock.Anything, "email_change_cooldown:user-123", "1", time.Hour).Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_change_pending:user-123").Return(nil)
+				m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").
+					Return(&model.User{ID: "user-123", Email: "old@example.com"}, nil)
+				m.revoker.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_revert:tok").Return(nil)
+			},
+		},
+		{
+			name: "unknown token",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_revert:tok").Return("", domainerrors.ErrKeyNotFound)
+			},
+			wantErr: domainerrors.ErrInvalidEmailChangeToken,
+		},
+		{
+			name: "old address taken",
+			setupMock: func(m emailChangeMocks) {
+				m.cache.EXPECT().Get(mock.Anything, "email_revert:tok").Return(stored, nil)
+				m.cache.EXPECT().Set(mock.Anything, "email_change_cooldown:user-123", "1", time.Hour).Return(nil)
+				m.cache.EXPECT().Delete(mock.Anything, "email_change_pending:user-123").Return(nil)
+				m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").
+					Return(&model.User{ID: "user-123", Email: "new@example.com"}, nil)
+				m.userRepo.EXPECT().UpdateEmail(mock.Anything, "user-123", "old@example.com").
+					Return(domainerrors.ErrEmailAlreadyExists)
+			},
+			wantErr: domainerrors.ErrEmailAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, m := newEmailChangeService(t)
+			tt.setupMock(m)
+
+			err := svc.RevertEmailChange(ctx, "tok")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_EmailChange_TwoPendingRequests(t *testing.T) {
+	ctx := t.Context()
+	svc, m := newEmailChangeService(t)
+
+	// The cache mock keeps what it is given, so that both requests and the
+	// confirmations see each other's writes.
+	store := map[string]string{}
+	m.cache.EXPECT().Get(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, key string) (string, error) {
+		v, ok := store[key]
+		if !ok {
+			return "", domainerrors.ErrKeyNotFound
+		}
+		return v, nil
+	})
+	m.cache.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, key, value string, _ time.Duration) error {
+			store[key] = value
+			return nil
+		})
+	m.cache.EXPECT().Delete(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, key string) error {
+		delete(store, key)
+		return nil
+	})
+	m.userRepo.EXPECT().GetByID(mock.Anything, "user-123").
+		Return(&model.User{ID: "user-123", Email: "old@example.com"}, nil)
+
+	var tokens []string
+	m.email.EXPECT().SendEmailChangeVerificationEmail(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, _, token string) error {
+			tokens = append(tokens, token)
+			return nil
+		})
+	m.email.EXPECT().SendEmailChangeNoticeEmail(mock.Anything, "old@example.com", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, svc.RequestEmailChange(ctx, "user-123", "first@example.com"))
+	require.NoError(t, svc.RequestEmailChange(ctx, "user-123", "second@example.com"))
+	require.Len(t, tokens, 2)
+
+	err := svc.ConfirmEmailChange(ctx, tokens[0])
+	require.ErrorIs(t, err, domainerrors.ErrInvalidEmailChangeToken)
+
+	m.userRepo.EXPECT().UpdateEmail(mock.Anything, "user-123", "second@example.com").Return(nil)
+	require.NoError(t, svc.ConfirmEmailChange(ctx, tokens[1]))
+}
@@ -0,0 +1,45 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+)
+
+// UpdateProfile applies update to the user's profile. version is the
+// UpdatedAt the caller last saw; if the user has changed since,
+// domainerrors.ErrPreconditionFailed is returned and nothing is written.
+func (s *Service) UpdateProfile(
+	ctx context.Context,
+	userID string,
+	update model.ProfileUpdate,
+	version time.Time,
+) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
+	if !user.UpdatedAt.Equal(version) {
+		return nil, domainerrors.ErrPreconditionFailed
+	}
+
+	profile := update.Apply(user.Profile)
+	profile.DisplayName = strings.TrimSpace(profile.DisplayName)
+	if err = profile.Validate(); err != nil {
+		return nil, err //nolint:wrapcheck // answered as a validation error
+	}
+
+	updated, err := s.userRepo.UpdateProfile(ctx, userID, profile, version)
+	if err != nil {
+		return nil, fmt.Errorf("update profile: %w", err)
+	}
+
+	s.log.Info("profile updated", zap.String("user_id", userID))
+	return updated, nil
+}
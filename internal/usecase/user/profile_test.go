@@ -0,0 +1,148 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
+	"github.com/sanchey92/sso/internal/domain/model"
+	"github.com/sanchey92/sso/internal/usecase/user/mocks"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestService_UpdateProfile(t *testing.T) {
+	ctx := t.Context()
+	version := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	current := func() *model.User {
+		return &model.User{
+			ID:        "user-123",
+			Profile:   model.Profile{DisplayName: "Ann", Locale: "en"},
+			UpdatedAt: version,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		update    model.ProfileUpdate
+		version   time.Time
+		setupMock func(ur *mocks.UserRepository)
+		wantErr   error
+		wantMsg   string
+	}{
+		{
+			name:    "merges fields and trims display name",
+			update:  model.ProfileUpdate{DisplayName: ptr("  Ann Lee "), Timezone: ptr("Europe/Berlin"), Locale: ptr("")},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+				ur.EXPECT().UpdateProfile(mock.Anything, "user-123", model.Profile{
+					DisplayName: "Ann Lee",
+					Timezone:    "Europe/Berlin",
+				}, version).Return(&model.User{ID: "user-123"}, nil)
+			},
+		},
+		{
+			name:    "stale version",
+			update:  model.ProfileUpdate{DisplayName: ptr("Bob")},
+			version: version.Add(-time.Second),
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+			},
+			wantErr: domainerrors.ErrPreconditionFailed,
+		},
+		{
+			name:    "concurrent write",
+			update:  model.ProfileUpdate{DisplayName: ptr("Bob")},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+				ur.EXPECT().UpdateProfile(mock.Anything, "user-123", mock.Anything, version).
+					Return(nil, domainerrors.ErrPreconditionFailed)
+			},
+			wantErr: domainerrors.ErrPreconditionFailed,
+		},
+		{
+			name:    "user not found",
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(nil, domainerrors.ErrUserNotFound)
+			},
+			wantErr: domainerrors.ErrUserNotFound,
+		},
+		{
+			name:    "display name too long",
+			update:  model.ProfileUpdate{DisplayName: ptr(strings.Repeat("я", model.MaxDisplayNameLen+1))},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+			},
+			wantMsg: "display_name: must be at most 100 characters",
+		},
+		{
+			name:    "control characters in display name",
+			update:  model.ProfileUpdate{DisplayName: ptr("Bob\nSmith")},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+			},
+			wantMsg: "display_name: must not contain control characters",
+		},
+		{
+			name:    "invalid locale",
+			update:  model.ProfileUpdate{Locale: ptr("english please")},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+			},
+			wantMsg: "locale: invalid format",
+		},
+		{
+			name:    "unknown timezone",
+			update:  model.ProfileUpdate{Timezone: ptr("Mars/Olympus")},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+			},
+			wantMsg: "timezone: unknown time zone",
+		},
+		{
+			name:    "avatar must be https",
+			update:  model.ProfileUpdate{AvatarURL: ptr("http://cdn.example.com/a.png")},
+			version: version,
+			setupMock: func(ur *mocks.UserRepository) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(current(), nil)
+			},
+			wantMsg: "avatar_url: must be an absolute https URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewUserRepository(t)
+			tt.setupMock(userRepo)
+
+			svc := New(userRepo, mocks.NewPasswordHasher(t), minLengthPolicy(t), noBreaches(t), noHistory(t), mocks.NewCacheStore(t), mocks.NewEmailSender(t),
+				mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{}, zap.NewNop())
+
+			user, err := svc.UpdateProfile(ctx, "user-123", tt.update, tt.version)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantMsg != "":
+				require.Error(t, err)
+				assert.Equal(t, tt.wantMsg, err.Error())
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, "user-123", user.ID)
+			}
+		})
+	}
+}
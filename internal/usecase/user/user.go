@@ -16,7 +16,6 @@ import (
 )
 
 const (
-	minPasswordLen  = 8
 	verifyKeyPrefix = "verify:"
 	verificationTTL = 24 * time.Hour
 	verifyTokenLen  = 32
@@ -28,17 +27,59 @@ const (
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
-	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByEmail(ctx context.Context, tenantID, email string) (*model.User, error)
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*model.User, error)
 	UpdateEmailVerified(ctx context.Context, userID string, verified bool) error
 	UpdatePassword(ctx context.Context, userID, passwordHash string) error
+	UpdateEmail(ctx context.Context, userID, email string) error
+	MarkDeleted(ctx context.Context, userID string) error
+	SearchUsers(ctx context.Context, filter model.UserFilter, after *model.UserCursor, limit int) ([]*model.User, error)
+	UpdateStatus(ctx context.Context, userID string, from, to model.UserStatus) error
+	ResetMFA(ctx context.Context, userID string) error
+	UpdateProfile(ctx context.Context, userID string, profile model.Profile, unmodifiedSince time.Time) (*model.User, error)
 }
 
 type TokenRevoker interface {
+	GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
 	RevokeByUserID(ctx context.Context, userID string) error
+	RevokeFamiliesExcept(ctx context.Context, userID, keepFamilyID string) error
+}
+
+// LockoutResetter lifts login lockouts once the owner proves control of the
+// account.
+type LockoutResetter interface {
+	Reset(ctx context.Context, userID string) error
+}
+
+// EventPublisher records identity events for downstream consumers.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *model.Event) error
 }
 
 type PasswordHasher interface {
-	Hash(password string) (string, error)
+	Hash(ctx context.Context, password string) (string, error)
+	Verify(ctx context.Context, password, encodedHash string) (bool, error)
+}
+
+// PasswordPolicy decides whether a new password is acceptable. Passwords are
+// normalized before they are hashed, so that the same password typed on a
+// different keyboard or input method still matches.
+type PasswordPolicy interface {
+	Normalize(password string) string
+	Check(password, email string) error
+}
+
+// BreachChecker reports whether a password appears in known data breaches.
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// PasswordHistory holds the hashes of replaced passwords, which the storage
+// records whenever a password is updated.
+type PasswordHistory interface {
+	RecentPasswordHashes(ctx context.Context, userID string, limit int, since time.Time) ([]string, error)
+	PrunePasswordHistory(ctx context.Context, userID string, keep int, before time.Time) error
 }
 
 type CacheStore interface {
@@ -50,80 +91,181 @@ type CacheStore interface {
 type EmailSender interface {
 	SendVerificationEmail(_ context.Context, toEmail, token string) error
 	SendPasswordResetEmail(_ context.Context, toEmail, token string) error
+	SendAccountExistsEmail(_ context.Context, toEmail string) error
+	SendPasswordChangedEmail(_ context.Context, toEmail string) error
+	SendEmailChangeVerificationEmail(_ context.Context, toEmail, token string) error
+	SendEmailChangeNoticeEmail(_ context.Context, toEmail, newEmail, revertToken string) error
+}
+
+type Config struct {
+	// EnumerationSafe hides whether an email is already registered. Register
+	// then reports the same outcome for new and taken emails, and the owner of
+	// a taken email is notified instead.
+	EnumerationSafe bool
+	// EmailChangeCooldown blocks further email changes after the owner
+	// reverts one.
+	EmailChangeCooldown time.Duration
+	// PasswordHistorySize is how many recent passwords, the current one
+	// included, a new password must differ from; zero disables the check.
+	PasswordHistorySize int
+	// PasswordHistoryRetention is how long replaced passwords are
+	// remembered; zero keeps them until newer ones push them out.
+	PasswordHistoryRetention time.Duration
 }
 
 type Service struct {
 	userRepo     UserRepository
 	hasher       PasswordHasher
+	policy       PasswordPolicy
+	breaches     BreachChecker
+	history      PasswordHistory
 	cache        CacheStore
 	email        EmailSender
 	tokenRevoker TokenRevoker
+	lockout      LockoutResetter
+	events       EventPublisher
+	cfg          *Config
 	log          *zap.Logger
 }
 
 func New(
 	ur UserRepository,
 	h PasswordHasher,
+	pp PasswordPolicy,
+	bc BreachChecker,
+	ph PasswordHistory,
 	cs CacheStore,
 	es EmailSender,
 	tr TokenRevoker,
+	lr LockoutResetter,
+	ep EventPublisher,
+	cfg *Config,
 	log *zap.Logger,
 ) *Service {
 	return &Service{
 		userRepo:     ur,
 		hasher:       h,
+		policy:       pp,
+		breaches:     bc,
+		history:      ph,
 		cache:        cs,
 		email:        es,
 		tokenRevoker: tr,
+		lockout:      lr,
+		events:       ep,
+		cfg:          cfg,
 		log:          log,
 	}
 }
 
-func (s *Service) Register(ctx context.Context, email, password string) (*model.User, error) {
+// Register creates an unverified user in the tenant and emails a verification
+// link. In enumeration-safe mode it returns a nil user on success, whether or
+// not the email was already taken in the tenant.
+func (s *Service) Register(ctx context.Context, tenantID, email, password string) (*model.User, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
 	if err := validateEmail(email); err != nil {
 		return nil, err
 	}
-	if err := validatePassword(password); err != nil {
+	if err := s.checkPassword(ctx, password, email); err != nil {
 		return nil, err
 	}
 
-	hash, err := s.hasher.Hash(password)
+	hash, err := s.hashPassword(ctx, password)
 	if err != nil {
-		return nil, fmt.Errorf("hash password: %w", err)
+		return nil, err
 	}
 
-	user := model.NewUser(email, hash)
+	user := model.NewUser(tenantID, email, hash)
 
 	if err = s.userRepo.Create(ctx, user); err != nil {
+		if s.cfg.EnumerationSafe && errors.Is(err, domainerrors.ErrEmailAlreadyExists) {
+			s.notifyExistingOwner(ctx, email)
+			return nil, nil
+		}
 		return nil, fmt.Errorf("create user: %w", err)
 	}
 
+	s.sendVerification(ctx, user)
+	s.publish(ctx, model.EventUserRegistered, user)
+
+	s.log.Info("user registered", zap.String("user_id", user.ID))
+
+	if s.cfg.EnumerationSafe {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// sendVerification emails a verification link. Failures are only logged: the
+// user can ask for a new link later.
+func (s *Service) sendVerification(ctx context.Context, user *model.User) {
 	token, err := crypto.GenerateRandomToken(verifyTokenLen)
 	if err != nil {
 		s.log.Error("failed to generate verification token", zap.Error(err))
-		return user, nil
+		return
 	}
 
 	key := verifyKeyPrefix + token
-	if err := s.cache.Set(ctx, key, user.ID, verificationTTL); err != nil {
+	if err = s.cache.Set(ctx, key, user.ID, verificationTTL); err != nil {
 		s.log.Error("failed to save verification token", zap.Error(err))
-		return user, nil
+		return
 	}
 
-	if err := s.email.SendVerificationEmail(ctx, user.Email, token); err != nil {
+	if err = s.email.SendVerificationEmail(ctx, user.Email, token); err != nil {
 		s.log.Error("failed to send verification email",
 			zap.Error(err),
 			zap.String("user_id", user.ID),
 		)
 	}
+}
 
-	s.log.Info("user registered", zap.String("user_id", user.ID))
+// publish records an event. The change it describes has already happened, so
+// a failure is logged rather than reported to the caller.
+func (s *Service) publish(ctx context.Context, t model.EventType, user *model.User) {
+	s.publishEvent(ctx, model.NewEvent(t, user.TenantID, user.ID))
+}
+
+func (s *Service) publishEvent(ctx context.Context, event *model.Event) {
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.log.Error("failed to publish event",
+			zap.Error(err),
+			zap.String("type", string(event.Type)),
+			zap.String("user_id", event.UserID),
+		)
+	}
+}
 
+// notifyExistingOwner tells the owner of an already registered email that
+// someone tried to sign up with it.
+func (s *Service) notifyExistingOwner(ctx context.Context, email string) {
+	if err := s.email.SendAccountExistsEmail(ctx, email); err != nil {
+		s.log.Error("failed to send account exists email", zap.Error(err))
+	}
+	s.log.Info("registration attempted for existing email", zap.String("email", email))
+}
+
+func (s *Service) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id: %w", err)
+	}
 	return user, nil
 }
 
+// GetUsersByIDs returns the users that exist among ids, in no particular
+// order. Unknown ids are skipped.
+func (s *Service) GetUsersByIDs(ctx context.Context, ids []string) ([]*model.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get users by ids: %w", err)
+	}
+	return users, nil
+}
+
 func (s *Service) VerifyEmail(ctx context.Context, token string) error {
 	key := verifyKeyPrefix + token
 
@@ -135,7 +277,11 @@ func (s *Service) VerifyEmail(ctx context.Context, token string) error {
 		return fmt.Errorf("get verification token: %w", err)
 	}
 
-	if err := s.userRepo.UpdateEmailVerified(ctx, userID, true); err != nil {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if err = s.userRepo.UpdateEmailVerified(ctx, userID, true); err != nil {
 		return fmt.Errorf("update email verified: %w", err)
 	}
 
@@ -145,14 +291,15 @@ func (s *Service) VerifyEmail(ctx context.Context, token string) error {
 			zap.String("user_id", userID),
 		)
 	}
+	s.publish(ctx, model.EventEmailVerified, user)
 	s.log.Info("email verified", zap.String("user_id", userID))
 	return nil
 }
 
-func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+func (s *Service) RequestPasswordReset(ctx context.Context, tenantID, email string) error {
 	email = strings.ToLower(strings.TrimSpace(email))
 
-	user, err := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.userRepo.GetByEmail(ctx, tenantID, email)
 	if err != nil {
 		if errors.Is(err, domainerrors.ErrUserNotFound) {
 			s.log.Info("password reset requested for non-existent email",
@@ -162,7 +309,10 @@ func (s *Service) RequestPasswordReset(ctx context.Context, email string) error
 		}
 		return fmt.Errorf("get user by email: %w", err)
 	}
+	return s.sendPasswordReset(ctx, user)
+}
 
+func (s *Service) sendPasswordReset(ctx context.Context, user *model.User) error {
 	token, err := crypto.GenerateRandomToken(resetTokenLen)
 	if err != nil {
 		return fmt.Errorf("generate reset token: %w", err)
@@ -184,10 +334,6 @@ func (s *Service) RequestPasswordReset(ctx context.Context, email string) error
 }
 
 func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
-	if err := validatePassword(newPassword); err != nil {
-		return err
-	}
-
 	key := resetKeyPrefix + token
 	userID, err := s.cache.Get(ctx, key)
 	if err != nil {
@@ -197,14 +343,25 @@ func (s *Service) ResetPassword(ctx context.Context, token, newPassword string)
 		return fmt.Errorf("get reset token: %w", err)
 	}
 
-	hash, err := s.hasher.Hash(newPassword)
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if err = s.checkPassword(ctx, newPassword, user.Email); err != nil {
+		return err
+	}
+	if err = s.checkReuse(ctx, userID, newPassword, user.PasswordHash); err != nil {
+		return err
+	}
+	hash, err := s.hashPassword(ctx, newPassword)
 	if err != nil {
-		return fmt.Errorf("hash password: %w", err)
+		return err
 	}
 
 	if err = s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
 		return fmt.Errorf("update password: %w", err)
 	}
+	s.prunePasswordHistory(ctx, userID)
 
 	if err = s.tokenRevoker.RevokeByUserID(ctx, userID); err != nil {
 		s.log.Error("failed to revoke refresh tokens after password reset",
@@ -213,16 +370,146 @@ func (s *Service) ResetPassword(ctx context.Context, token, newPassword string)
 		)
 	}
 
+	if err = s.lockout.Reset(ctx, userID); err != nil {
+		s.log.Error("failed to reset lockout after password reset",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+
 	if err = s.cache.Delete(ctx, key); err != nil {
 		s.log.Error("failed to delete reset token",
 			zap.Error(err),
 			zap.String("user_id", userID),
 		)
 	}
+	s.publish(ctx, model.EventPasswordReset, user)
 	s.log.Info("password reset completed", zap.String("user_id", userID))
 	return nil
 }
 
+// ChangePassword replaces the password of a signed-in user after checking the
+// current one. Every other session is signed out; the session of
+// keepRefreshToken, if given, stays signed in.
+func (s *Service) ChangePassword(ctx context.Context, userID, currentPassword, newPassword, keepRefreshToken string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if user.PasswordHash == "" {
+		return domainerrors.ErrInvalidCredentials
+	}
+
+	// The current password is checked first, so that policy and breach
+	// answers about a new password are only given to the account owner.
+	match, err := s.verifyPassword(ctx, currentPassword, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return domainerrors.ErrInvalidCredentials
+	}
+	if err = s.checkPassword(ctx, newPassword, user.Email); err != nil {
+		return err
+	}
+	if s.policy.Normalize(newPassword) == s.policy.Normalize(currentPassword) {
+		return fmt.Errorf("new_password: must differ from the current password")
+	}
+	// The current password was just compared, so only replaced ones are left.
+	if err = s.checkReuse(ctx, userID, newPassword, ""); err != nil {
+		return err
+	}
+
+	hash, err := s.hashPassword(ctx, newPassword)
+	if err != nil {
+		return err
+	}
+	if err = s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	s.prunePasswordHistory(ctx, userID)
+
+	if family := s.activeFamily(ctx, userID, keepRefreshToken); family != "" {
+		err = s.tokenRevoker.RevokeFamiliesExcept(ctx, userID, family)
+	} else {
+		err = s.tokenRevoker.RevokeByUserID(ctx, userID)
+	}
+	if err != nil {
+		s.log.Error("failed to revoke refresh tokens after password change",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+
+	if err = s.email.SendPasswordChangedEmail(ctx, user.Email); err != nil {
+		s.log.Error("failed to send password changed email",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+
+	s.log.Info("password changed", zap.String("user_id", userID))
+	return nil
+}
+
+// activeFamily returns the token family of the user's refresh token raw, or
+// "" if raw is empty, unknown, revoked, expired or someone else's.
+func (s *Service) activeFamily(ctx context.Context, userID, raw string) string {
+	if raw == "" {
+		return ""
+	}
+	rt, err := s.tokenRevoker.GetByHash(ctx, crypto.HashToken(raw))
+	if err != nil {
+		if !errors.Is(err, domainerrors.ErrInvalidToken) {
+			s.log.Error("failed to look up refresh token to keep",
+				zap.Error(err),
+				zap.String("user_id", userID),
+			)
+		}
+		return ""
+	}
+	if rt.UserID != userID || rt.Revoked || time.Now().After(rt.ExpiresAt) {
+		return ""
+	}
+	return rt.FamilyID
+}
+
+// DeleteAccount schedules the user's account for deletion after checking the
+// password. Every session is signed out; logging in again during the grace
+// period restores the account. Accounts without a local password have to set
+// one through the reset flow first.
+func (s *Service) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id: %w", err)
+	}
+	if user.PasswordHash == "" {
+		return domainerrors.ErrInvalidCredentials
+	}
+
+	match, err := s.verifyPassword(ctx, password, user.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !match {
+		return domainerrors.ErrInvalidCredentials
+	}
+
+	if err = s.userRepo.MarkDeleted(ctx, userID); err != nil {
+		return fmt.Errorf("mark user deleted: %w", err)
+	}
+
+	if err = s.tokenRevoker.RevokeByUserID(ctx, userID); err != nil {
+		s.log.Error("failed to revoke refresh tokens after account deletion",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+
+	s.log.Info("account deletion requested", zap.String("user_id", userID))
+	return nil
+}
+
 func validateEmail(email string) error {
 	if email == "" {
 		return fmt.Errorf("email: cannot be empty")
@@ -233,9 +520,106 @@ func validateEmail(email string) error {
 	return nil
 }
 
-func validatePassword(password string) error {
-	if len(password) < minPasswordLen {
-		return fmt.Errorf("password: must be at least %d characters", minPasswordLen)
+// checkPassword checks a new password against the policy for the account of
+// email and the breach corpus. The corpus only adds to the policy, so when it
+// cannot be read the password is accepted and the failure logged.
+func (s *Service) checkPassword(ctx context.Context, password, email string) error {
+	if err := s.policy.Check(password, email); err != nil {
+		return fmt.Errorf("check password: %w", err)
+	}
+
+	breached, err := s.breaches.Breached(ctx, password)
+	if err != nil {
+		s.log.Error("failed to check password against breach corpus", zap.Error(err))
+		return nil
+	}
+	if breached {
+		return fmt.Errorf("check password: %w", &domainerrors.PasswordPolicyError{
+			Violations: []domainerrors.PasswordViolation{
+				{Code: "breached", Message: "appears in a known data breach"},
+			},
+		})
+	}
+	return nil
+}
+
+// checkReuse rejects password if it matches currentHash, if given, or one of
+// the user's replaced passwords within the configured history.
+func (s *Service) checkReuse(ctx context.Context, userID, password, currentHash string) error {
+	size := s.cfg.PasswordHistorySize
+	if size <= 0 {
+		return nil
+	}
+
+	var hashes []string
+	if currentHash != "" {
+		hashes = append(hashes, currentHash)
+	}
+	if size > 1 {
+		recent, err := s.history.RecentPasswordHashes(ctx, userID, size-1, s.historyCutoff())
+		if err != nil {
+			return fmt.Errorf("get password history: %w", err)
+		}
+		hashes = append(hashes, recent...)
+	}
+
+	for _, hash := range hashes {
+		match, err := s.verifyPassword(ctx, password, hash)
+		if err != nil {
+			return err
+		}
+		if match {
+			return fmt.Errorf("check password: %w", &domainerrors.PasswordPolicyError{
+				Violations: []domainerrors.PasswordViolation{
+					{Code: "reused", Message: fmt.Sprintf("must differ from your last %d passwords", size)},
+				},
+			})
+		}
 	}
 	return nil
 }
+
+// prunePasswordHistory drops replaced passwords that no longer count towards
+// the history. A failure only keeps them longer, so it is logged.
+func (s *Service) prunePasswordHistory(ctx context.Context, userID string) {
+	keep := max(s.cfg.PasswordHistorySize-1, 0)
+	if err := s.history.PrunePasswordHistory(ctx, userID, keep, s.historyCutoff()); err != nil {
+		s.log.Error("failed to prune password history",
+			zap.Error(err),
+			zap.String("user_id", userID),
+		)
+	}
+}
+
+// historyCutoff returns the time before which replaced passwords are
+// forgotten, or the zero time if they are kept regardless of age.
+func (s *Service) historyCutoff() time.Time {
+	if s.cfg.PasswordHistoryRetention <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-s.cfg.PasswordHistoryRetention)
+}
+
+// hashPassword hashes the normalized form of password.
+func (s *Service) hashPassword(ctx context.Context, password string) (string, error) {
+	hash, err := s.hasher.Hash(ctx, s.policy.Normalize(password))
+	if err != nil {
+		return "", fmt.Errorf("hash password: %w", err)
+	}
+	return hash, nil
+}
+
+// verifyPassword checks password against encodedHash. Hashes stored before
+// passwords were normalized may hold the password as typed, which is tried
+// when it differs from the normalized form.
+func (s *Service) verifyPassword(ctx context.Context, password, encodedHash string) (bool, error) {
+	normalized := s.policy.Normalize(password)
+	match, err := s.hasher.Verify(ctx, normalized, encodedHash)
+	if err == nil && !match && normalized != password {
+		match, err = s.hasher.Verify(ctx, password, encodedHash)
+	}
+	if err != nil {
+		return false, fmt.Errorf("verify password: %w", err)
+	}
+	return match, nil
+}
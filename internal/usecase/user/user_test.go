@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,8 +17,15 @@ import (
 	domainerrors "github.com/sanchey92/sso/internal/domain/errors"
 	"github.com/sanchey92/sso/internal/domain/model"
 	"github.com/sanchey92/sso/internal/usecase/user/mocks"
+	"github.com/sanchey92/sso/pkg/crypto"
 )
 
+func expectEvent(ep *mocks.EventPublisher, eventType model.EventType) {
+	ep.EXPECT().Publish(mock.Anything, mock.MatchedBy(func(e *model.Event) bool {
+		return e.Type == eventType
+	})).Return(nil)
+}
+
 func TestService_Register(t *testing.T) {
 	ctx := t.Context()
 
@@ -34,7 +42,7 @@ func TestService_Register(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("hashed_password", nil)
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed_password", nil)
 				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
 					Run(func(_ context.Context, u *model.User) {
 						u.ID = "generated-uuid"
@@ -45,6 +53,7 @@ func TestService_Register(t *testing.T) {
 			},
 			check: func(t *testing.T, user *model.User) {
 				assert.Equal(t, "generated-uuid", user.ID)
+				assert.Equal(t, "tenant-1", user.TenantID)
 				assert.Equal(t, "user@example.com", user.Email)
 				assert.Equal(t, "hashed_password", user.PasswordHash)
 				assert.Equal(t, model.UserStatusActive, user.Status)
@@ -57,7 +66,7 @@ func TestService_Register(t *testing.T) {
 			email:    "  User@Example.COM  ",
 			password: "securepassword",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("hashed_password", nil)
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed_password", nil)
 				ur.EXPECT().Create(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
 					return u.Email == "user@example.com"
 				})).Return(nil)
@@ -101,7 +110,7 @@ func TestService_Register(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(h *mocks.PasswordHasher, _ *mocks.UserRepository, _ *mocks.CacheStore, _ *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("", errors.New("hash failed"))
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("", errors.New("hash failed"))
 			},
 			wantErr: "hash password: hash failed",
 		},
@@ -110,7 +119,7 @@ func TestService_Register(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, _ *mocks.CacheStore, _ *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("hashed_password", nil)
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed_password", nil)
 				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
 					Return(domainerrors.ErrEmailAlreadyExists)
 			},
@@ -121,7 +130,7 @@ func TestService_Register(t *testing.T) {
 			email:    "user@example.com",
 			password: "securepassword",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, _ *mocks.CacheStore, _ *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("hashed_password", nil)
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed_password", nil)
 				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
 					Return(errors.New("db connection lost"))
 			},
@@ -132,7 +141,7 @@ func TestService_Register(t *testing.T) {
 			email:    "user@example.com",
 			password: "12345678",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
-				h.EXPECT().Hash("12345678").Return("hashed_password", nil)
+				h.EXPECT().Hash(mock.Anything, "12345678").Return("hashed_password", nil)
 				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
 				cs.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 				es.EXPECT().SendVerificationEmail(mock.Anything, "user@example.com", mock.Anything).Return(nil)
@@ -157,10 +166,14 @@ func TestService_Register(t *testing.T) {
 			cache := mocks.NewCacheStore(t)
 			emailSender := mocks.NewEmailSender(t)
 			tt.setupMock(hasher, userRepo, cache, emailSender)
+			ep := mocks.NewEventPublisher(t)
+			if tt.wantErr == "" {
+				expectEvent(ep, model.EventUserRegistered)
+			}
 
-			svc := New(userRepo, hasher, cache, emailSender, mocks.NewTokenRevoker(t), zap.NewNop())
+			svc := New(userRepo, hasher, minLengthPolicy(t), noBreaches(t), noHistory(t), cache, emailSender, mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), ep, &Config{}, zap.NewNop())
 
-			user, err := svc.Register(ctx, tt.email, tt.password)
+			user, err := svc.Register(ctx, "tenant-1", tt.email, tt.password)
 
 			if tt.wantErr != "" {
 				require.Error(t, err)
@@ -179,6 +192,70 @@ func TestService_Register(t *testing.T) {
 	}
 }
 
+func TestService_Register_EnumerationSafe(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		name      string
+		setupMock func(ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender)
+		wantEvent bool
+		wantErr   string
+	}{
+		{
+			name:      "new email hides created user",
+			wantEvent: true,
+			setupMock: func(ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
+				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).Return(nil)
+				cs.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+				es.EXPECT().SendVerificationEmail(mock.Anything, "user@example.com", mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "taken email notifies owner",
+			setupMock: func(ur *mocks.UserRepository, _ *mocks.CacheStore, es *mocks.EmailSender) {
+				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
+					Return(domainerrors.ErrEmailAlreadyExists)
+				es.EXPECT().SendAccountExistsEmail(mock.Anything, "user@example.com").Return(errors.New("smtp down"))
+			},
+		},
+		{
+			name: "repository error still reported",
+			setupMock: func(ur *mocks.UserRepository, _ *mocks.CacheStore, _ *mocks.EmailSender) {
+				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
+					Return(errors.New("db connection lost"))
+			},
+			wantErr: "create user: db connection lost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := mocks.NewPasswordHasher(t)
+			hasher.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed_password", nil)
+			userRepo := mocks.NewUserRepository(t)
+			cache := mocks.NewCacheStore(t)
+			emailSender := mocks.NewEmailSender(t)
+			tt.setupMock(userRepo, cache, emailSender)
+			ep := mocks.NewEventPublisher(t)
+			if tt.wantEvent {
+				expectEvent(ep, model.EventUserRegistered)
+			}
+
+			svc := New(userRepo, hasher, minLengthPolicy(t), noBreaches(t), noHistory(t), cache, emailSender, mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), ep,
+				&Config{EnumerationSafe: true}, zap.NewNop())
+
+			user, err := svc.Register(ctx, "tenant-1", "user@example.com", "securepassword")
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Nil(t, user)
+		})
+	}
+}
+
 func TestService_VerifyEmail(t *testing.T) {
 	ctx := t.Context()
 
@@ -193,6 +270,7 @@ func TestService_VerifyEmail(t *testing.T) {
 			token: "valid-token",
 			setupMock: func(cs *mocks.CacheStore, ur *mocks.UserRepository) {
 				cs.EXPECT().Get(mock.Anything, "verify:valid-token").Return("user-123", nil)
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123", TenantID: "tenant-1"}, nil)
 				ur.EXPECT().UpdateEmailVerified(mock.Anything, "user-123", true).Return(nil)
 				cs.EXPECT().Delete(mock.Anything, "verify:valid-token").Return(nil)
 			},
@@ -220,8 +298,7 @@ func TestService_VerifyEmail(t *testing.T) {
 			token: "orphan-token",
 			setupMock: func(cs *mocks.CacheStore, ur *mocks.UserRepository) {
 				cs.EXPECT().Get(mock.Anything, "verify:orphan-token").Return("missing-user", nil)
-				ur.EXPECT().UpdateEmailVerified(mock.Anything, "missing-user", true).
-					Return(domainerrors.ErrUserNotFound)
+				ur.EXPECT().GetByID(mock.Anything, "missing-user").Return(nil, domainerrors.ErrUserNotFound)
 			},
 			wantErr: domainerrors.ErrUserNotFound,
 		},
@@ -230,6 +307,7 @@ func TestService_VerifyEmail(t *testing.T) {
 			token: "valid-token",
 			setupMock: func(cs *mocks.CacheStore, ur *mocks.UserRepository) {
 				cs.EXPECT().Get(mock.Anything, "verify:valid-token").Return("user-456", nil)
+				ur.EXPECT().GetByID(mock.Anything, "user-456").Return(&model.User{ID: "user-456", TenantID: "tenant-1"}, nil)
 				ur.EXPECT().UpdateEmailVerified(mock.Anything, "user-456", true).Return(nil)
 				cs.EXPECT().Delete(mock.Anything, "verify:valid-token").
 					Return(errors.New("redis del failed"))
@@ -243,8 +321,12 @@ func TestService_VerifyEmail(t *testing.T) {
 			cache := mocks.NewCacheStore(t)
 			userRepo := mocks.NewUserRepository(t)
 			tt.setupMock(cache, userRepo)
+			ep := mocks.NewEventPublisher(t)
+			if tt.wantErr == nil && tt.name != "cache get unexpected error" {
+				expectEvent(ep, model.EventEmailVerified)
+			}
 
-			svc := New(userRepo, mocks.NewPasswordHasher(t), cache, mocks.NewEmailSender(t), mocks.NewTokenRevoker(t), zap.NewNop())
+			svc := New(userRepo, mocks.NewPasswordHasher(t), minLengthPolicy(t), noBreaches(t), noHistory(t), cache, mocks.NewEmailSender(t), mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), ep, &Config{}, zap.NewNop())
 
 			err := svc.VerifyEmail(ctx, tt.token)
 
@@ -275,7 +357,7 @@ func TestService_Register_VerificationEmailFlow(t *testing.T) {
 		{
 			name: "cache set fails — user still returned",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, cs *mocks.CacheStore, _ *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("hashed", nil)
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed", nil)
 				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
 					Run(func(_ context.Context, u *model.User) { u.ID = "user-1" }).
 					Return(nil)
@@ -286,7 +368,7 @@ func TestService_Register_VerificationEmailFlow(t *testing.T) {
 		{
 			name: "email send fails — user still returned",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
-				h.EXPECT().Hash("securepassword").Return("hashed", nil)
+				h.EXPECT().Hash(mock.Anything, "securepassword").Return("hashed", nil)
 				ur.EXPECT().Create(mock.Anything, mock.AnythingOfType("*model.User")).
 					Run(func(_ context.Context, u *model.User) { u.ID = "user-2" }).
 					Return(nil)
@@ -304,10 +386,12 @@ func TestService_Register_VerificationEmailFlow(t *testing.T) {
 			cache := mocks.NewCacheStore(t)
 			emailSender := mocks.NewEmailSender(t)
 			tt.setupMock(hasher, userRepo, cache, emailSender)
+			ep := mocks.NewEventPublisher(t)
+			expectEvent(ep, model.EventUserRegistered)
 
-			svc := New(userRepo, hasher, cache, emailSender, mocks.NewTokenRevoker(t), zap.NewNop())
+			svc := New(userRepo, hasher, minLengthPolicy(t), noBreaches(t), noHistory(t), cache, emailSender, mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), ep, &Config{}, zap.NewNop())
 
-			user, err := svc.Register(ctx, "test@example.com", "securepassword")
+			user, err := svc.Register(ctx, "tenant-1", "test@example.com", "securepassword")
 
 			require.NoError(t, err)
 			require.NotNil(t, user)
@@ -328,7 +412,7 @@ func TestService_RequestPasswordReset(t *testing.T) {
 			name:  "successful reset request",
 			email: "user@example.com",
 			setupMock: func(ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
-				ur.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
 				cs.EXPECT().Set(mock.Anything, mock.MatchedBy(func(key string) bool {
 					return strings.HasPrefix(key, "reset:")
@@ -341,7 +425,7 @@ func TestService_RequestPasswordReset(t *testing.T) {
 			name:  "non-existent email returns nil (anti-enumeration)",
 			email: "nobody@example.com",
 			setupMock: func(ur *mocks.UserRepository, _ *mocks.CacheStore, _ *mocks.EmailSender) {
-				ur.EXPECT().GetByEmail(mock.Anything, "nobody@example.com").
+				ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "nobody@example.com").
 					Return(nil, domainerrors.ErrUserNotFound)
 			},
 		},
@@ -349,7 +433,7 @@ func TestService_RequestPasswordReset(t *testing.T) {
 			name:  "email send failure does not return error",
 			email: "user@example.com",
 			setupMock: func(ur *mocks.UserRepository, cs *mocks.CacheStore, es *mocks.EmailSender) {
-				ur.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
 				cs.EXPECT().Set(mock.Anything, mock.Anything, "user-123", 1*time.Hour).Return(nil)
 				es.EXPECT().SendPasswordResetEmail(mock.Anything, mock.Anything, mock.Anything).
@@ -360,7 +444,7 @@ func TestService_RequestPasswordReset(t *testing.T) {
 			name:  "cache error returns error",
 			email: "user@example.com",
 			setupMock: func(ur *mocks.UserRepository, cs *mocks.CacheStore, _ *mocks.EmailSender) {
-				ur.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
 				cs.EXPECT().Set(mock.Anything, mock.Anything, "user-123", 1*time.Hour).
 					Return(fmt.Errorf("redis error"))
@@ -371,7 +455,7 @@ func TestService_RequestPasswordReset(t *testing.T) {
 			name:  "db error returns error",
 			email: "user@example.com",
 			setupMock: func(ur *mocks.UserRepository, _ *mocks.CacheStore, _ *mocks.EmailSender) {
-				ur.EXPECT().GetByEmail(mock.Anything, "user@example.com").
+				ur.EXPECT().GetByEmail(mock.Anything, "tenant-1", "user@example.com").
 					Return(nil, fmt.Errorf("db connection: %w", errors.New("timeout")))
 			},
 			wantErr: true,
@@ -385,8 +469,8 @@ func TestService_RequestPasswordReset(t *testing.T) {
 			es := mocks.NewEmailSender(t)
 			tt.setupMock(ur, cs, es)
 
-			svc := New(ur, mocks.NewPasswordHasher(t), cs, es, mocks.NewTokenRevoker(t), zap.NewNop())
-			err := svc.RequestPasswordReset(ctx, tt.email)
+			svc := New(ur, mocks.NewPasswordHasher(t), minLengthPolicy(t), noBreaches(t), noHistory(t), cs, es, mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{}, zap.NewNop())
+			err := svc.RequestPasswordReset(ctx, "tenant-1", tt.email)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -413,7 +497,8 @@ func TestService_ResetPassword(t *testing.T) {
 			password: "newpassword123",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, tr *mocks.TokenRevoker, cs *mocks.CacheStore) {
 				cs.EXPECT().Get(mock.Anything, "reset:valid-token").Return("user-123", nil)
-				h.EXPECT().Hash("newpassword123").Return("new-hash", nil)
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword123").Return("new-hash", nil)
 				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new-hash").Return(nil)
 				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
 				cs.EXPECT().Delete(mock.Anything, "reset:valid-token").Return(nil)
@@ -433,8 +518,9 @@ func TestService_ResetPassword(t *testing.T) {
 			name:     "password too short",
 			token:    "valid-token",
 			password: "short",
-			setupMock: func(_ *mocks.PasswordHasher, _ *mocks.UserRepository, _ *mocks.TokenRevoker, _ *mocks.CacheStore) {
-				// validatePassword fails before any external calls
+			setupMock: func(_ *mocks.PasswordHasher, ur *mocks.UserRepository, _ *mocks.TokenRevoker, cs *mocks.CacheStore) {
+				cs.EXPECT().Get(mock.Anything, "reset:valid-token").Return("user-123", nil)
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
 			},
 			wantErr: "password: must be at least 8 characters",
 		},
@@ -444,7 +530,8 @@ func TestService_ResetPassword(t *testing.T) {
 			password: "newpassword123",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, tr *mocks.TokenRevoker, cs *mocks.CacheStore) {
 				cs.EXPECT().Get(mock.Anything, "reset:valid-token").Return("user-123", nil)
-				h.EXPECT().Hash("newpassword123").Return("new-hash", nil)
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword123").Return("new-hash", nil)
 				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new-hash").Return(nil)
 				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").
 					Return(fmt.Errorf("db error"))
@@ -458,7 +545,8 @@ func TestService_ResetPassword(t *testing.T) {
 			password: "newpassword123",
 			setupMock: func(h *mocks.PasswordHasher, ur *mocks.UserRepository, tr *mocks.TokenRevoker, cs *mocks.CacheStore) {
 				cs.EXPECT().Get(mock.Anything, "reset:valid-token").Return("user-123", nil)
-				h.EXPECT().Hash("newpassword123").Return("new-hash", nil)
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword123").Return("new-hash", nil)
 				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new-hash").Return(nil)
 				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
 				cs.EXPECT().Delete(mock.Anything, "reset:valid-token").
@@ -473,9 +561,15 @@ func TestService_ResetPassword(t *testing.T) {
 			ur := mocks.NewUserRepository(t)
 			tr := mocks.NewTokenRevoker(t)
 			cs := mocks.NewCacheStore(t)
+			lr := mocks.NewLockoutResetter(t)
 			tt.setupMock(h, ur, tr, cs)
+			ep := mocks.NewEventPublisher(t)
+			if tt.wantErr == "" {
+				lr.EXPECT().Reset(mock.Anything, "user-123").Return(nil)
+				expectEvent(ep, model.EventPasswordReset)
+			}
 
-			svc := New(ur, h, cs, mocks.NewEmailSender(t), tr, zap.NewNop())
+			svc := New(ur, h, minLengthPolicy(t), noBreaches(t), noHistory(t), cs, mocks.NewEmailSender(t), tr, lr, ep, &Config{}, zap.NewNop())
 			err := svc.ResetPassword(ctx, tt.token, tt.password)
 
 			if tt.wantErr != "" {
@@ -487,3 +581,452 @@ func TestService_ResetPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestService_GetUsersByIDs(t *testing.T) {
+	ctx := t.Context()
+
+	ur := mocks.NewUserRepository(t)
+	ur.EXPECT().GetByIDs(mock.Anything, []string{"u1", "u2"}).Return([]*model.User{{ID: "u1"}}, nil)
+
+	svc := New(ur, mocks.NewPasswordHasher(t), minLengthPolicy(t), noBreaches(t), noHistory(t), mocks.NewCacheStore(t), mocks.NewEmailSender(t),
+		mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{}, zap.NewNop())
+
+	users, err := svc.GetUsersByIDs(ctx, []string{"u1", "u2"})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "u1", users[0].ID)
+
+	users, err = svc.GetUsersByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	ctx := t.Context()
+	stored := &model.User{ID: "user-123", Email: "user@example.com", PasswordHash: "old_hash"}
+
+	tests := []struct {
+		name         string
+		current      string
+		newPassword  string
+		refreshToken string
+		setupMock    func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker, es *mocks.EmailSender)
+		wantErr      error
+		wantMsg      string
+	}{
+		{
+			name:         "keeps current session",
+			current:      "oldpassword",
+			newPassword:  "newpassword",
+			refreshToken: "raw-refresh",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker, es *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword").Return("new_hash", nil)
+				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new_hash").Return(nil)
+				tr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("raw-refresh")).Return(&model.RefreshToken{
+					UserID: "user-123", FamilyID: "family-1", ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				tr.EXPECT().RevokeFamiliesExcept(mock.Anything, "user-123", "family-1").Return(nil)
+				es.EXPECT().SendPasswordChangedEmail(mock.Anything, "user@example.com").Return(nil)
+			},
+		},
+		{
+			name:        "revokes all sessions without refresh token",
+			current:     "oldpassword",
+			newPassword: "newpassword",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker, es *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword").Return("new_hash", nil)
+				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new_hash").Return(nil)
+				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(errors.New("db down"))
+				es.EXPECT().SendPasswordChangedEmail(mock.Anything, "user@example.com").Return(errors.New("smtp down"))
+			},
+		},
+		{
+			name:         "revoked refresh token keeps no session",
+			current:      "oldpassword",
+			newPassword:  "newpassword",
+			refreshToken: "raw-refresh",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker, es *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword").Return("new_hash", nil)
+				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new_hash").Return(nil)
+				tr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("raw-refresh")).Return(&model.RefreshToken{
+					UserID: "user-123", FamilyID: "family-1", Revoked: true, ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
+				es.EXPECT().SendPasswordChangedEmail(mock.Anything, "user@example.com").Return(nil)
+			},
+		},
+		{
+			name:         "another user's refresh token keeps no session",
+			current:      "oldpassword",
+			newPassword:  "newpassword",
+			refreshToken: "raw-refresh",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker, es *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword").Return("new_hash", nil)
+				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new_hash").Return(nil)
+				tr.EXPECT().GetByHash(mock.Anything, crypto.HashToken("raw-refresh")).Return(&model.RefreshToken{
+					UserID: "user-456", FamilyID: "family-2", ExpiresAt: time.Now().Add(time.Hour),
+				}, nil)
+				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
+				es.EXPECT().SendPasswordChangedEmail(mock.Anything, "user@example.com").Return(nil)
+			},
+		},
+		{
+			name:        "wrong current password",
+			current:     "wrongpassword",
+			newPassword: "newpassword",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, _ *mocks.TokenRevoker, _ *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "wrongpassword", "old_hash").Return(false, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials,
+		},
+		{
+			name:        "account without local password",
+			current:     "oldpassword",
+			newPassword: "newpassword",
+			setupMock: func(ur *mocks.UserRepository, _ *mocks.PasswordHasher, _ *mocks.TokenRevoker, _ *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123"}, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials,
+		},
+		{
+			name:        "same as current",
+			current:     "oldpassword",
+			newPassword: "oldpassword",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, _ *mocks.TokenRevoker, _ *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+			},
+			wantMsg: "new_password: must differ from the current password",
+		},
+		{
+			name:        "new password too short",
+			current:     "oldpassword",
+			newPassword: "short",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, _ *mocks.TokenRevoker, _ *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+			},
+			wantMsg: "check password: password: must be at least 8 characters",
+		},
+		{
+			name:        "wrong current password hides policy result",
+			current:     "wrongpassword",
+			newPassword: "short",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, _ *mocks.TokenRevoker, _ *mocks.EmailSender) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "wrongpassword", "old_hash").Return(false, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewUserRepository(t)
+			hasher := mocks.NewPasswordHasher(t)
+			revoker := mocks.NewTokenRevoker(t)
+			emailSender := mocks.NewEmailSender(t)
+			tt.setupMock(userRepo, hasher, revoker, emailSender)
+
+			svc := New(userRepo, hasher, minLengthPolicy(t), noBreaches(t), noHistory(t), mocks.NewCacheStore(t), emailSender, revoker,
+				mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{}, zap.NewNop())
+
+			err := svc.ChangePassword(ctx, "user-123", tt.current, tt.newPassword, tt.refreshToken)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantMsg != "":
+				require.Error(t, err)
+				assert.Equal(t, tt.wantMsg, err.Error())
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestService_DeleteAccount(t *testing.T) {
+	ctx := t.Context()
+	stored := &model.User{ID: "user-123", PasswordHash: "hash", Status: model.UserStatusActive}
+
+	tests := []struct {
+		name      string
+		password  string
+		setupMock func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker)
+		wantErr   error
+	}{
+		{
+			name:     "marks deleted and revokes sessions",
+			password: "securepassword",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, tr *mocks.TokenRevoker) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "securepassword", "hash").Return(true, nil)
+				ur.EXPECT().MarkDeleted(mock.Anything, "user-123").Return(nil)
+				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
+			},
+		},
+		{
+			name:     "wrong password",
+			password: "wrong",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, _ *mocks.TokenRevoker) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "wrong", "hash").Return(false, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials,
+		},
+		{
+			name:     "account without local password",
+			password: "securepassword",
+			setupMock: func(ur *mocks.UserRepository, _ *mocks.PasswordHasher, _ *mocks.TokenRevoker) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123"}, nil)
+			},
+			wantErr: domainerrors.ErrInvalidCredentials,
+		},
+		{
+			name:     "already deleted",
+			password: "securepassword",
+			setupMock: func(ur *mocks.UserRepository, h *mocks.PasswordHasher, _ *mocks.TokenRevoker) {
+				ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+				h.EXPECT().Verify(mock.Anything, "securepassword", "hash").Return(true, nil)
+				ur.EXPECT().MarkDeleted(mock.Anything, "user-123").Return(domainerrors.ErrUserNotFound)
+			},
+			wantErr: domainerrors.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := mocks.NewUserRepository(t)
+			hasher := mocks.NewPasswordHasher(t)
+			revoker := mocks.NewTokenRevoker(t)
+			tt.setupMock(userRepo, hasher, revoker)
+
+			svc := New(userRepo, hasher, minLengthPolicy(t), noBreaches(t), noHistory(t), mocks.NewCacheStore(t), mocks.NewEmailSender(t), revoker,
+				mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{}, zap.NewNop())
+
+			err := svc.DeleteAccount(ctx, "user-123", tt.password)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// minLengthPolicy only rejects passwords shorter than 8 characters and leaves
+// passwords as typed.
+func minLengthPolicy(t *testing.T) *mocks.PasswordPolicy {
+	p := mocks.NewPasswordPolicy(t)
+	p.EXPECT().Check(mock.Anything, mock.Anything).RunAndReturn(func(password, _ string) error {
+		if utf8.RuneCountInString(password) < 8 {
+			return &domainerrors.PasswordPolicyError{Violations: []domainerrors.PasswordViolation{
+				{Code: "too_short", Message: "must be at least 8 characters"},
+			}}
+		}
+		return nil
+	}).Maybe()
+	p.EXPECT().Normalize(mock.Anything).RunAndReturn(func(password string) string { return password }).Maybe()
+	return p
+}
+
+func TestService_Register_PasswordPolicy(t *testing.T) {
+	ctx := t.Context()
+	policyErr := &domainerrors.PasswordPolicyError{Violations: []domainerrors.PasswordViolation{
+		{Code: "contains_email", Message: "must not contain your email address"},
+	}}
+
+	t.Run("rejected", func(t *testing.T) {
+		policy := mocks.NewPasswordPolicy(t)
+		policy.EXPECT().Check("jane-secret-1", "jane@example.com").Return(policyErr)
+		svc := New(mocks.NewUserRepository(t), mocks.NewPasswordHasher(t), policy, noBreaches(t), noHistory(t), mocks.NewCacheStore(t),
+			mocks.NewEmailSender(t), mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t),
+			&Config{}, zap.NewNop())
+
+		user, err := svc.Register(ctx, "tenant-1", "Jane@Example.com", "jane-secret-1")
+
+		require.ErrorIs(t, err, domainerrors.ErrWeakPassword)
+		assert.Nil(t, user)
+	})
+
+	t.Run("normalized form hashed", func(t *testing.T) {
+		policy := mocks.NewPasswordPolicy(t)
+		policy.EXPECT().Check("ｓｅｃｕｒｅ-pass", "jane@example.com").Return(nil)
+		policy.EXPECT().Normalize("ｓｅｃｕｒｅ-pass").Return("secure-pass")
+		hasher := mocks.NewPasswordHasher(t)
+		hasher.EXPECT().Hash(mock.Anything, "secure-pass").Return("hashed", nil)
+		userRepo := mocks.NewUserRepository(t)
+		userRepo.EXPECT().Create(mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+			return u.PasswordHash == "hashed"
+		})).Return(nil)
+		cache := mocks.NewCacheStore(t)
+		cache.EXPECT().Set(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		emailSender := mocks.NewEmailSender(t)
+		emailSender.EXPECT().SendVerificationEmail(mock.Anything, "jane@example.com", mock.Anything).Return(nil)
+		ep := mocks.NewEventPublisher(t)
+		expectEvent(ep, model.EventUserRegistered)
+		svc := New(userRepo, hasher, policy, noBreaches(t), noHistory(t), cache, emailSender, mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), ep,
+			&Config{}, zap.NewNop())
+
+		_, err := svc.Register(ctx, "tenant-1", "jane@example.com", "ｓｅｃｕｒｅ-pass")
+
+		require.NoError(t, err)
+	})
+}
+
+// noBreaches finds no password in the breach corpus.
+func noBreaches(t *testing.T) *mocks.BreachChecker {
+	b := mocks.NewBreachChecker(t)
+	b.EXPECT().Breached(mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	return b
+}
+
+func TestService_ResetPassword_Breached(t *testing.T) {
+	ctx := t.Context()
+
+	cache := mocks.NewCacheStore(t)
+	cache.EXPECT().Get(mock.Anything, "reset:valid-token").Return("user-123", nil)
+	userRepo := mocks.NewUserRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, "user-123").Return(&model.User{ID: "user-123", Email: "user@example.com"}, nil)
+	breaches := mocks.NewBreachChecker(t)
+	breaches.EXPECT().Breached(mock.Anything, "correcthorse").Return(true, nil)
+
+	svc := New(userRepo, mocks.NewPasswordHasher(t), minLengthPolicy(t), breaches, noHistory(t), cache, mocks.NewEmailSender(t),
+		mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{}, zap.NewNop())
+
+	err := svc.ResetPassword(ctx, "valid-token", "correcthorse")
+
+	require.ErrorIs(t, err, domainerrors.ErrWeakPassword)
+	policyErr, ok := errors.AsType[*domainerrors.PasswordPolicyError](err)
+	require.True(t, ok)
+	assert.Equal(t, "breached", policyErr.Violations[0].Code)
+}
+
+func noHistory(t *testing.T) *mocks.PasswordHistory {
+	h := mocks.NewPasswordHistory(t)
+	h.EXPECT().RecentPasswordHashes(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	h.EXPECT().PrunePasswordHistory(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	return h
+}
+
+func TestService_ResetPassword_History(t *testing.T) {
+	ctx := t.Context()
+	stored := &model.User{ID: "user-123", Email: "user@example.com", PasswordHash: "current-hash"}
+
+	tests := []struct {
+		name      string
+		cfg       *Config
+		setupMock func(h *mocks.PasswordHasher, ph *mocks.PasswordHistory)
+		wantReuse bool
+	}{
+		{
+			name: "matches current password",
+			cfg:  &Config{PasswordHistorySize: 3},
+			setupMock: func(h *mocks.PasswordHasher, ph *mocks.PasswordHistory) {
+				ph.EXPECT().RecentPasswordHashes(mock.Anything, "user-123", 2, time.Time{}).Return([]string{"old-hash-1"}, nil)
+				h.EXPECT().Verify(mock.Anything, "newpassword123", "current-hash").Return(true, nil)
+			},
+			wantReuse: true,
+		},
+		{
+			name: "matches replaced password",
+			cfg:  &Config{PasswordHistorySize: 3},
+			setupMock: func(h *mocks.PasswordHasher, ph *mocks.PasswordHistory) {
+				ph.EXPECT().RecentPasswordHashes(mock.Anything, "user-123", 2, time.Time{}).
+					Return([]string{"old-hash-1", "old-hash-2"}, nil)
+				h.EXPECT().Verify(mock.Anything, "newpassword123", "current-hash").Return(false, nil)
+				h.EXPECT().Verify(mock.Anything, "newpassword123", "old-hash-1").Return(false, nil)
+				h.EXPECT().Verify(mock.Anything, "newpassword123", "old-hash-2").Return(true, nil)
+			},
+			wantReuse: true,
+		},
+		{
+			name: "differs from history within retention",
+			cfg:  &Config{PasswordHistorySize: 2, PasswordHistoryRetention: 24 * time.Hour},
+			setupMock: func(h *mocks.PasswordHasher, ph *mocks.PasswordHistory) {
+				ph.EXPECT().RecentPasswordHashes(mock.Anything, "user-123", 1, mock.MatchedBy(func(since time.Time) bool {
+					return time.Since(since) > 23*time.Hour && time.Since(since) < 25*time.Hour
+				})).Return([]string{"old-hash-1"}, nil)
+				h.EXPECT().Verify(mock.Anything, "newpassword123", "current-hash").Return(false, nil)
+				h.EXPECT().Verify(mock.Anything, "newpassword123", "old-hash-1").Return(false, nil)
+				h.EXPECT().Hash(mock.Anything, "newpassword123").Return("new-hash", nil)
+				ph.EXPECT().PrunePasswordHistory(mock.Anything, "user-123", 1, mock.AnythingOfType("time.Time")).
+					Return(errors.New("db down"))
+			},
+		},
+		{
+			name: "disabled",
+			cfg:  &Config{},
+			setupMock: func(h *mocks.PasswordHasher, ph *mocks.PasswordHistory) {
+				h.EXPECT().Hash(mock.Anything, "newpassword123").Return("new-hash", nil)
+				ph.EXPECT().PrunePasswordHistory(mock.Anything, "user-123", 0, time.Time{}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := mocks.NewCacheStore(t)
+			cs.EXPECT().Get(mock.Anything, "reset:valid-token").Return("user-123", nil)
+			ur := mocks.NewUserRepository(t)
+			ur.EXPECT().GetByID(mock.Anything, "user-123").Return(stored, nil)
+			h := mocks.NewPasswordHasher(t)
+			ph := mocks.NewPasswordHistory(t)
+			tr := mocks.NewTokenRevoker(t)
+			lr := mocks.NewLockoutResetter(t)
+			ep := mocks.NewEventPublisher(t)
+			tt.setupMock(h, ph)
+			if !tt.wantReuse {
+				ur.EXPECT().UpdatePassword(mock.Anything, "user-123", "new-hash").Return(nil)
+				tr.EXPECT().RevokeByUserID(mock.Anything, "user-123").Return(nil)
+				lr.EXPECT().Reset(mock.Anything, "user-123").Return(nil)
+				cs.EXPECT().Delete(mock.Anything, "reset:valid-token").Return(nil)
+				expectEvent(ep, model.EventPasswordReset)
+			}
+
+			svc := New(ur, h, minLengthPolicy(t), noBreaches(t), ph, cs, mocks.NewEmailSender(t), tr, lr, ep, tt.cfg, zap.NewNop())
+			err := svc.ResetPassword(ctx, "valid-token", "newpassword123")
+
+			if !tt.wantReuse {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, domainerrors.ErrWeakPassword)
+			policyErr, ok := errors.AsType[*domainerrors.PasswordPolicyError](err)
+			require.True(t, ok)
+			assert.Equal(t, "reused", policyErr.Violations[0].Code)
+		})
+	}
+}
+
+func TestService_ChangePassword_ReusedPassword(t *testing.T) {
+	ctx := t.Context()
+
+	userRepo := mocks.NewUserRepository(t)
+	userRepo.EXPECT().GetByID(mock.Anything, "user-123").
+		Return(&model.User{ID: "user-123", Email: "user@example.com", PasswordHash: "old_hash"}, nil)
+	hasher := mocks.NewPasswordHasher(t)
+	hasher.EXPECT().Verify(mock.Anything, "oldpassword", "old_hash").Return(true, nil)
+	hasher.EXPECT().Verify(mock.Anything, "newpassword", "older_hash").Return(true, nil)
+	history := mocks.NewPasswordHistory(t)
+	history.EXPECT().RecentPasswordHashes(mock.Anything, "user-123", 3, time.Time{}).Return([]string{"older_hash"}, nil)
+
+	svc := New(userRepo, hasher, minLengthPolicy(t), noBreaches(t), history, mocks.NewCacheStore(t), mocks.NewEmailSender(t),
+		mocks.NewTokenRevoker(t), mocks.NewLockoutResetter(t), mocks.NewEventPublisher(t), &Config{PasswordHistorySize: 4}, zap.NewNop())
+
+	err := svc.ChangePassword(ctx, "user-123", "oldpassword", "newpassword", "")
+
+	require.ErrorIs(t, err, domainerrors.ErrWeakPassword)
+	assert.EqualError(t, err, "check password: password: must differ from your last 4 passwords")
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_identities
+(
+    id           UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    user_id      UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider     VARCHAR(64)  NOT NULL,
+    subject      TEXT         NOT NULL,
+    email        VARCHAR(255) NOT NULL,
+    display_name TEXT,
+    groups       TEXT[]       NOT NULL DEFAULT '{}',
+    attributes   JSONB        NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementEnd
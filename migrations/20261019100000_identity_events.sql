@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS identity_events
+(
+    id         BIGSERIAL PRIMARY KEY,
+    type       VARCHAR(64) NOT NULL,
+    user_id    UUID        NOT NULL,
+    data       JSONB       NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_identity_events_type_id ON identity_events (type, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS identity_events;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- One row per purged account. Nothing here identifies the user.
+CREATE TABLE IF NOT EXISTS account_deletions
+(
+    id           BIGSERIAL PRIMARY KEY,
+    requested_at TIMESTAMPTZ NOT NULL,
+    purged_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS account_deletions;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS data_exports
+(
+    id           UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    user_id      UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status       VARCHAR(20) NOT NULL DEFAULT 'pending',
+    requested_by VARCHAR(20) NOT NULL,
+    archive      BYTEA,
+    token_hash   TEXT UNIQUE,
+    expires_at   TIMESTAMPTZ,
+    claimed_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ
+);
+
+-- At most one export per user is in flight.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_exports_in_flight
+    ON data_exports (user_id) WHERE status IN ('pending', 'processing');
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_expires_at ON data_exports (expires_at);
+
+CREATE INDEX IF NOT EXISTS idx_identity_events_user_id ON identity_events (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_identity_events_user_id;
+DROP TABLE IF EXISTS data_exports;
+-- +goose StatementEnd
@@ -0,0 +1,85 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS roles
+(
+    id          UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    name        VARCHAR(64) NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    -- NULL makes the role global; otherwise it only applies to tokens issued
+    -- for this client.
+    client_id   UUID REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_roles_global_name ON roles (name) WHERE client_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_roles_client_name ON roles (client_id, name) WHERE client_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS permissions
+(
+    id          UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    name        VARCHAR(128) NOT NULL UNIQUE,
+    description TEXT         NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role_id       UUID NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_permissions_permission_id ON role_permissions (permission_id);
+
+-- Move user_roles from role names to role ids. Existing names become global
+-- roles.
+INSERT INTO roles (name, description)
+VALUES ('admin', 'Access to the admin API')
+ON CONFLICT (name) WHERE client_id IS NULL DO NOTHING;
+
+INSERT INTO roles (name)
+SELECT DISTINCT role FROM user_roles
+ON CONFLICT (name) WHERE client_id IS NULL DO NOTHING;
+
+ALTER TABLE user_roles
+    ADD COLUMN role_id UUID REFERENCES roles (id) ON DELETE CASCADE;
+
+UPDATE user_roles ur
+SET role_id = r.id
+FROM roles r
+WHERE r.name = ur.role AND r.client_id IS NULL;
+
+ALTER TABLE user_roles DROP CONSTRAINT user_roles_pkey;
+ALTER TABLE user_roles DROP COLUMN role;
+ALTER TABLE user_roles ALTER COLUMN role_id SET NOT NULL;
+ALTER TABLE user_roles ADD PRIMARY KEY (user_id, role_id);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles (role_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_roles_role_id;
+
+ALTER TABLE user_roles
+    ADD COLUMN role VARCHAR(64);
+
+-- Client-scoped assignments have no name-only equivalent and are dropped.
+DELETE FROM user_roles ur
+USING roles r
+WHERE r.id = ur.role_id AND r.client_id IS NOT NULL;
+
+UPDATE user_roles ur
+SET role = r.name
+FROM roles r
+WHERE r.id = ur.role_id;
+
+ALTER TABLE user_roles DROP CONSTRAINT user_roles_pkey;
+ALTER TABLE user_roles DROP COLUMN role_id;
+ALTER TABLE user_roles ALTER COLUMN role SET NOT NULL;
+ALTER TABLE user_roles ADD PRIMARY KEY (user_id, role);
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
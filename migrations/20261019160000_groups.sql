@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS groups
+(
+    id          UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    name        VARCHAR(128) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    -- source and external_id are set for groups synced from a directory, e.g.
+    -- the LDAP backend name and the group DN.
+    source      VARCHAR(64),
+    external_id VARCHAR(512),
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    CHECK ((source IS NULL) = (external_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_groups_local_name ON groups (name) WHERE source IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_groups_external ON groups (source, external_id) WHERE source IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS group_members
+(
+    group_id   UUID        NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_members_user_id ON group_members (user_id);
+
+CREATE TABLE IF NOT EXISTS group_subgroups
+(
+    parent_id  UUID        NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    child_id   UUID        NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (parent_id, child_id),
+    CHECK (parent_id <> child_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_subgroups_child_id ON group_subgroups (child_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS group_subgroups;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tenants
+(
+    id            UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    slug          VARCHAR(63)  NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9]([a-z0-9-]*[a-z0-9])?$'),
+    name          VARCHAR(128) NOT NULL,
+    -- host maps a dedicated hostname to the tenant, e.g. login.acme.example.
+    host          VARCHAR(253) UNIQUE CHECK (host = lower(host)),
+    display_name  TEXT         NOT NULL DEFAULT '',
+    logo_url      TEXT         NOT NULL DEFAULT '',
+    primary_color VARCHAR(7)   NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+-- Existing accounts, clients and groups move to the default tenant.
+INSERT INTO tenants (slug, name)
+VALUES ('default', 'Default')
+ON CONFLICT (slug) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN tenant_id UUID REFERENCES tenants (id) ON DELETE RESTRICT;
+UPDATE users SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT uq_users_tenant_email UNIQUE (tenant_id, email);
+
+ALTER TABLE oauth_clients ADD COLUMN tenant_id UUID REFERENCES tenants (id) ON DELETE RESTRICT;
+UPDATE oauth_clients SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+ALTER TABLE oauth_clients ALTER COLUMN tenant_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_tenant_id ON oauth_clients (tenant_id);
+
+ALTER TABLE groups ADD COLUMN tenant_id UUID REFERENCES tenants (id) ON DELETE RESTRICT;
+UPDATE groups SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+ALTER TABLE groups ALTER COLUMN tenant_id SET NOT NULL;
+DROP INDEX IF EXISTS uq_groups_local_name;
+DROP INDEX IF EXISTS uq_groups_external;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_groups_local_name ON groups (tenant_id, name) WHERE source IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_groups_external ON groups (tenant_id, source, external_id) WHERE source IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS uq_groups_local_name;
+DROP INDEX IF EXISTS uq_groups_external;
+ALTER TABLE groups DROP COLUMN IF EXISTS tenant_id;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_groups_local_name ON groups (name) WHERE source IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_groups_external ON groups (source, external_id) WHERE source IS NOT NULL;
+
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS uq_users_tenant_email;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+DROP TABLE IF EXISTS tenants;
+-- +goose StatementEnd
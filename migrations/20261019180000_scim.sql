@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS scim_tokens
+(
+    id           UUID PRIMARY KEY      DEFAULT gen_random_uuid(),
+    tenant_id    UUID         NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    name         VARCHAR(128) NOT NULL,
+    token_hash   VARCHAR(64)  NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_scim_tokens_tenant_id ON scim_tokens (tenant_id);
+
+-- external_id is the id a SCIM client assigned to the user; clients look users
+-- up by it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id VARCHAR(512);
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_external_id ON users (tenant_id, external_id)
+    WHERE external_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_tenant_external_id;
+ALTER TABLE users DROP COLUMN IF EXISTS external_id;
+DROP TABLE IF EXISTS scim_tokens;
+-- +goose StatementEnd
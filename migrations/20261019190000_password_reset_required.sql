@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- password_reset_required blocks password logins until the password is reset,
+-- e.g. once it turns up in a breach corpus.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required;
+-- +goose StatementEnd
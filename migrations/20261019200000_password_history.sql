@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- password_history keeps the hashes of replaced passwords so that recent
+-- passwords cannot be reused.
+CREATE TABLE IF NOT EXISTS password_history
+(
+    id            UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    user_id       UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash TEXT        NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id_created_at ON password_history (user_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_history;
+-- +goose StatementEnd
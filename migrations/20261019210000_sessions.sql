@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sessions describes the login session behind each refresh token family; id
+-- is the family_id of its refresh tokens.
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id                UUID PRIMARY KEY,
+    user_id           UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    client_id         UUID REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_agent        TEXT        NOT NULL DEFAULT '',
+    ip                TEXT        NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_refreshed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- Families issued before sessions were recorded keep their timestamps but
+-- have no device information.
+INSERT INTO sessions (id, user_id, client_id, created_at, last_refreshed_at)
+SELECT family_id, user_id, client_id, min(created_at), max(created_at)
+FROM refresh_tokens
+GROUP BY family_id, user_id, client_id
+ON CONFLICT (id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- rotated_at and sealed_successor let a client that re-presents a
+-- just-rotated refresh token get the same successor back instead of tripping
+-- replay detection. The successor is sealed with the raw rotated token, which
+-- the database never sees.
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS sealed_successor BYTEA;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS sealed_successor;
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS rotated_at;
+-- +goose StatementEnd
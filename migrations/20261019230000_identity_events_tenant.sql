@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Events carry the tenant of their user, so that the stream can be limited
+-- to one tenant. Events of users purged before this migration cannot be
+-- attributed and keep a NULL tenant; only all-tenant consumers see them.
+ALTER TABLE identity_events ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants (id) ON DELETE RESTRICT;
+UPDATE identity_events e SET tenant_id = u.tenant_id FROM users u WHERE u.id = e.user_id;
+CREATE INDEX IF NOT EXISTS idx_identity_events_tenant_id ON identity_events (tenant_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_identity_events_tenant_id;
+ALTER TABLE identity_events DROP COLUMN IF EXISTS tenant_id;
+-- +goose StatementEnd
@@ -0,0 +1,312 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: sso/v1/events.proto
+
+package ssov1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED     EventType = 0
+	EventType_EVENT_TYPE_USER_REGISTERED EventType = 1
+	EventType_EVENT_TYPE_EMAIL_VERIFIED  EventType = 2
+	EventType_EVENT_TYPE_PASSWORD_RESET  EventType = 3
+	EventType_EVENT_TYPE_USER_BLOCKED    EventType = 4
+	// The account has been purged; consumers should erase their copies of the
+	// user's data.
+	EventType_EVENT_TYPE_USER_DELETED EventType = 5
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_USER_REGISTERED",
+		2: "EVENT_TYPE_EMAIL_VERIFIED",
+		3: "EVENT_TYPE_PASSWORD_RESET",
+		4: "EVENT_TYPE_USER_BLOCKED",
+		5: "EVENT_TYPE_USER_DELETED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED":     0,
+		"EVENT_TYPE_USER_REGISTERED": 1,
+		"EVENT_TYPE_EMAIL_VERIFIED":  2,
+		"EVENT_TYPE_PASSWORD_RESET":  3,
+		"EVENT_TYPE_USER_BLOCKED":    4,
+		"EVENT_TYPE_USER_DELETED":    5,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_sso_v1_events_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_sso_v1_events_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_sso_v1_events_proto_rawDescGZIP(), []int{0}
+}
+
+type WatchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// cursor is the cursor of the last event already processed. When empty the
+	// stream starts with the next event published.
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// types limits the stream to the given event types. Empty means all.
+	Types         []EventType `protobuf:"varint,2,rep,packed,name=types,proto3,enum=sso.v1.EventType" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEventsRequest) Reset() {
+	*x = WatchEventsRequest{}
+	mi := &file_sso_v1_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEventsRequest) ProtoMessage() {}
+
+func (x *WatchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_v1_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_sso_v1_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchEventsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *WatchEventsRequest) GetTypes() []EventType {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type Event struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Cursor     string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Type       EventType              `protobuf:"varint,2,opt,name=type,proto3,enum=sso.v1.EventType" json:"type,omitempty"`
+	UserId     string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Data       map[string]string      `protobuf:"bytes,4,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	// tenant_id is empty for events recorded before events carried a tenant.
+	TenantId      string `protobuf:"bytes,6,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_sso_v1_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_sso_v1_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_sso_v1_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Event) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *Event) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Event) GetData() map[string]string {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Event) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+func (x *Event) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+var File_sso_v1_events_proto protoreflect.FileDescriptor
+
+const file_sso_v1_events_proto_rawDesc = "" +
+	"\n" +
+	"\x13sso/v1/events.proto\x12\x06sso.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"U\n" +
+	"\x12WatchEventsRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12'\n" +
+	"\x05types\x18\x02 \x03(\x0e2\x11.sso.v1.EventTypeR\x05types\"\x9f\x02\n" +
+	"\x05Event\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12%\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x11.sso.v1.EventTypeR\x04type\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12+\n" +
+	"\x04data\x18\x04 \x03(\v2\x17.sso.v1.Event.DataEntryR\x04data\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x12\x1b\n" +
+	"\ttenant_id\x18\x06 \x01(\tR\btenantId\x1a7\n" +
+	"\tDataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*\xbf\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aEVENT_TYPE_USER_REGISTERED\x10\x01\x12\x1d\n" +
+	"\x19EVENT_TYPE_EMAIL_VERIFIED\x10\x02\x12\x1d\n" +
+	"\x19EVENT_TYPE_PASSWORD_RESET\x10\x03\x12\x1b\n" +
+	"\x17EVENT_TYPE_USER_BLOCKED\x10\x04\x12\x1b\n" +
+	"\x17EVENT_TYPE_USER_DELETED\x10\x052J\n" +
+	"\fEventService\x12:\n" +
+	"\vWatchEvents\x12\x1a.sso.v1.WatchEventsRequest\x1a\r.sso.v1.Event0\x01B/Z-github.com/sanchey92/sso/pkg/api/sso/v1;ssov1b\x06proto3"
+
+var (
+	file_sso_v1_events_proto_rawDescOnce sync.Once
+	file_sso_v1_events_proto_rawDescData []byte
+)
+
+func file_sso_v1_events_proto_rawDescGZIP() []byte {
+	file_sso_v1_events_proto_rawDescOnce.Do(func() {
+		file_sso_v1_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sso_v1_events_proto_rawDesc), len(file_sso_v1_events_proto_rawDesc)))
+	})
+	return file_sso_v1_events_proto_rawDescData
+}
+
+var file_sso_v1_events_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_sso_v1_events_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_sso_v1_events_proto_goTypes = []any{
+	(EventType)(0),                // 0: sso.v1.EventType
+	(*WatchEventsRequest)(nil),    // 1: sso.v1.WatchEventsRequest
+	(*Event)(nil),                 // 2: sso.v1.Event
+	nil,                           // 3: sso.v1.Event.DataEntry
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_sso_v1_events_proto_depIdxs = []int32{
+	0, // 0: sso.v1.WatchEventsRequest.types:type_name -> sso.v1.EventType
+	0, // 1: sso.v1.Event.type:type_name -> sso.v1.EventType
+	3, // 2: sso.v1.Event.data:type_name -> sso.v1.Event.DataEntry
+	4, // 3: sso.v1.Event.occurred_at:type_name -> google.protobuf.Timestamp
+	1, // 4: sso.v1.EventService.WatchEvents:input_type -> sso.v1.WatchEventsRequest
+	2, // 5: sso.v1.EventService.WatchEvents:output_type -> sso.v1.Event
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_sso_v1_events_proto_init() }
+func file_sso_v1_events_proto_init() {
+	if File_sso_v1_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sso_v1_events_proto_rawDesc), len(file_sso_v1_events_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sso_v1_events_proto_goTypes,
+		DependencyIndexes: file_sso_v1_events_proto_depIdxs,
+		EnumInfos:         file_sso_v1_events_proto_enumTypes,
+		MessageInfos:      file_sso_v1_events_proto_msgTypes,
+	}.Build()
+	File_sso_v1_events_proto = out.File
+	file_sso_v1_events_proto_goTypes = nil
+	file_sso_v1_events_proto_depIdxs = nil
+}
@@ -1,11 +1,16 @@
 package crypto
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -30,3 +35,79 @@ func GenerateUUID() (string, error) {
 	}
 	return id.String(), nil
 }
+
+// SignToken appends an HMAC-SHA256 signature of token under secret, so that
+// forged tokens can be rejected without a storage lookup.
+func SignToken(secret, token string) string {
+	return token + "." + tokenMAC(secret, token)
+}
+
+// VerifySignedToken checks a value produced by SignToken and returns the
+// original token.
+func VerifySignedToken(secret, signed string) (string, bool) {
+	token, sig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", false
+	}
+	if !hmac.Equal([]byte(sig), []byte(tokenMAC(secret, token))) {
+		return "", false
+	}
+	return token, true
+}
+
+func tokenMAC(secret, token string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sealLabel separates the keys SealWithToken derives from a token from any
+// other use of the token.
+const sealLabel = "sso seal"
+
+// SealWithToken encrypts plaintext under a key derived from token, so that
+// only a holder of token can read it back with OpenWithToken. The key cannot
+// be derived from HashToken(token).
+func SealWithToken(token, plaintext string) ([]byte, error) {
+	aead, err := tokenAEAD(token)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("read random bytes: %w", err)
+	}
+	return aead.Seal(nonce, nonce, []byte(plaintext), nil), nil
+}
+
+// OpenWithToken decrypts a value produced by SealWithToken with the same
+// token.
+func OpenWithToken(token string, sealed []byte) (string, error) {
+	aead, err := tokenAEAD(token)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("sealed value too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("open sealed value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func tokenAEAD(token string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(sealLabel))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return aead, nil
+}